@@ -0,0 +1,27 @@
+package main
+
+import (
+	"github.com/Dcarbon/go-shared/libs/utils"
+	"github.com/Dcarbon/iott-cloud/internal/consumer"
+	"github.com/Dcarbon/iott-cloud/internal/repo"
+	"github.com/Dcarbon/iott-cloud/internal/rss"
+)
+
+// Consume commands from other services (run with `iott-cloud consumer` for
+// consumer only mode, or CONSUMER_ENABLE=1 to run beside http server)
+func runConsumer() {
+	rss.SetUrl(config.DBUrl, config.RedisUrl)
+
+	domains, err := repo.NewSignDomainRepo(config.CarbonDomain())
+	utils.PanicError("Create sign domain repo", err)
+
+	iot, err := repo.NewIOTRepo(domains)
+	utils.PanicError("Create iot repo", err)
+
+	project, err := repo.NewProjectRepo()
+	utils.PanicError("Create project repo", err)
+
+	var c = consumer.NewConsumer(rss.GetRabbitMQ())
+	consumer.RegisterHandlers(c, iot, project)
+	c.Run()
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Dcarbon/go-shared/libs/utils"
+	"github.com/Dcarbon/iott-cloud/internal/domain"
+	"github.com/Dcarbon/iott-cloud/internal/repo"
+	"github.com/Dcarbon/iott-cloud/internal/rss"
+)
+
+// Manage sign domains:
+//
+//	iott-cloud domain list
+//	iott-cloud domain move -domain 2 -project 10 [-force]
+//	iott-cloud domain move -domain 2 -iots 1,2,3 [-force]
+func runDomain(args []string) {
+	if len(args) == 0 {
+		fmt.Println("Usage: iott-cloud domain list|move [flags]")
+		os.Exit(2)
+	}
+
+	rss.SetUrl(config.DBUrl, config.RedisUrl)
+
+	domains, err := repo.NewSignDomainRepo(config.CarbonDomain())
+	utils.PanicError("Create sign domain repo", err)
+
+	switch args[0] {
+	case "list":
+		sds, err := domains.GetList()
+		utils.PanicError("Get sign domains", err)
+		for _, sd := range sds {
+			fmt.Printf("%d\t%s\t%s v%s\tchain=%d\t%s\tdefault=%v\n",
+				sd.ID, sd.Label, sd.Name, sd.Version, sd.ChainId,
+				sd.VerifyingContract, sd.IsDefault,
+			)
+		}
+	case "move":
+		var fs = flag.NewFlagSet("domain move", flag.ExitOnError)
+		var domainId = fs.Int64("domain", 0, "Target domain id")
+		var projectId = fs.Int64("project", 0, "Move all IoTs of project")
+		var iots = fs.String("iots", "", "Comma separated iot ids")
+		var force = fs.Bool("force", false, "Move IoT has pending signature")
+		fs.Parse(args[1:])
+
+		var req = &domain.RIotMoveDomain{
+			DomainId:  *domainId,
+			ProjectId: *projectId,
+			Force:     *force,
+		}
+		for _, s := range strings.Split(*iots, ",") {
+			if s = strings.TrimSpace(s); s == "" {
+				continue
+			}
+			id, err := strconv.ParseInt(s, 10, 64)
+			utils.PanicError("Invalid iot id "+s, err)
+			req.IotIds = append(req.IotIds, id)
+		}
+
+		iot, err := repo.NewIOTRepo(domains)
+		utils.PanicError("Create iot repo", err)
+
+		rs, err := iot.MoveDomain(req)
+		utils.PanicError("Move iot domain", err)
+
+		fmt.Println("Moved: ", rs.Moved)
+		for id, reason := range rs.Skipped {
+			fmt.Printf("Skipped %d: %s\n", id, reason)
+		}
+	default:
+		fmt.Println("Unknown domain command: ", args[0])
+		os.Exit(2)
+	}
+}
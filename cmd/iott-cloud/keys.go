@@ -0,0 +1,102 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/go-shared/libs/utils"
+	"github.com/Dcarbon/iott-cloud/internal/signer"
+)
+
+// Manage encrypted keystore of server keys (oracle, ...):
+//
+//	iott-cloud keys list -dir ./keys [-address 0x...]
+//	iott-cloud keys new -dir ./keys -passfile ./pass
+//	iott-cloud keys import -dir ./keys -passfile ./pass -keyfile ./key.hex
+//	iott-cloud keys rotate -dir ./keys -passfile ./pass [-retire]
+func runKeys(args []string) {
+	if len(args) == 0 {
+		fmt.Println("Usage: iott-cloud keys list|new|import|rotate [flags]")
+		os.Exit(2)
+	}
+
+	var fs = flag.NewFlagSet("keys "+args[0], flag.ExitOnError)
+	var dir = fs.String("dir", utils.StringEnv("ORACLE_KEYSTORE", ""), "Keystore dir")
+	var passfile = fs.String("passfile", utils.StringEnv("ORACLE_PASSPHRASE_FILE", ""), "Passphrase file")
+	var keyfile = fs.String("keyfile", "", "File of private key (hex) to import")
+	var retire = fs.Bool("retire", false, "Move old keys to retired dir")
+	var address = fs.String("address", utils.StringEnv("ORACLE_ADDRESS", ""), "Pinned address (empty: newest key)")
+	fs.Parse(args[1:])
+
+	ks, err := signer.NewKeystore(*dir)
+	utils.PanicError("Open keystore", err)
+
+	var passphrase = func() []byte {
+		if *passfile == "" {
+			fmt.Println("Passphrase file is required (-passfile)")
+			os.Exit(2)
+		}
+		pass, err := signer.ReadPassphrase(*passfile)
+		utils.PanicError("Read passphrase", err)
+		return pass
+	}
+
+	switch args[0] {
+	case "list":
+		keys, err := ks.List()
+		utils.PanicError("List keys", err)
+
+		// Same key as signer (FromEnv) unlocks
+		var activeId = ""
+		if active, err := ks.Find(dmodels.EthAddress(*address)); nil == err {
+			activeId = active.Id
+		} else if *address != "" {
+			fmt.Printf("Pinned address %s is not in keystore\n", *address)
+		}
+		for _, key := range keys {
+			var active = ""
+			if key.Id == activeId {
+				active = "(active)"
+			}
+			fmt.Printf("%s\t%s\t%s %s\n", key.Address, key.Id, key.File, active)
+		}
+	case "new":
+		var pass = passphrase()
+		info, err := ks.Create(pass)
+		zero(pass)
+		utils.PanicError("Create key", err)
+		fmt.Println("Created key: ", info.Address)
+	case "import":
+		raw, err := os.ReadFile(*keyfile)
+		utils.PanicError("Read key file", err)
+
+		var pass = passphrase()
+		info, err := ks.Import(raw, pass)
+		zero(pass)
+		zero(raw)
+		utils.PanicError("Import key", err)
+		fmt.Println("Imported key: ", info.Address)
+	case "rotate":
+		var pass = passphrase()
+		info, retired, err := ks.Rotate(pass, *retire)
+		zero(pass)
+		utils.PanicError("Rotate key", err)
+
+		fmt.Println("New active key: ", info.Address)
+		for _, old := range retired {
+			fmt.Println("Retired key: ", old.Address)
+		}
+		fmt.Println("Grant new key on contract before restart service (if address is not pinned)")
+	default:
+		fmt.Println("Unknown keys command: ", args[0])
+		os.Exit(2)
+	}
+}
+
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
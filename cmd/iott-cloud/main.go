@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"log"
+	"os"
 
 	"github.com/Dcarbon/go-shared/libs/utils"
 	"github.com/Dcarbon/iott-cloud/internal/api/routers"
@@ -34,6 +35,30 @@ var config = routers.Config{
 // @host      localhost:8081
 // @BasePath  /api/v1
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "consumer":
+			runConsumer()
+			return
+		case "domain":
+			runDomain(os.Args[2:])
+			return
+		case "keys":
+			runKeys(os.Args[2:])
+			return
+		case "report":
+			runReport(os.Args[2:])
+			return
+		case "verify":
+			runVerify(os.Args[2:])
+			return
+		}
+	}
+
+	if utils.IntEnv("CONSUMER_ENABLE", 0) == 1 {
+		go runConsumer()
+	}
+
 	// docs.SwaggerInfo.Title = "Internet of trusted thing cloud"
 	// docs.SwaggerInfo.Version = "1.0.0"
 	// docs.SwaggerInfo.Description = "Internet of trusted thing cloud"
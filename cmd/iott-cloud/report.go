@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Dcarbon/go-shared/libs/utils"
+	"github.com/Dcarbon/iott-cloud/internal/domain"
+	"github.com/Dcarbon/iott-cloud/internal/repo"
+	"github.com/Dcarbon/iott-cloud/internal/report"
+	"github.com/Dcarbon/iott-cloud/internal/rss"
+)
+
+// Generate audit report of project:
+//
+//	iott-cloud report -project 1 -from 2024-01-01 -to 2024-02-01 -format csv -out report.zip
+func runReport(args []string) {
+	var fs = flag.NewFlagSet("report", flag.ExitOnError)
+	var projectId = fs.Int64("project", 0, "Project id")
+	var from = fs.String("from", "", "From date (YYYY-MM-DD, UTC, inclusive)")
+	var to = fs.String("to", "", "To date (YYYY-MM-DD, UTC, exclusive)")
+	var format = fs.String("format", "json", "json, csv (zip), pdf (summary)")
+	var out = fs.String("out", "", "Output file (default: stdout)")
+	fs.Parse(args)
+
+	fromT, err := time.Parse("2006-01-02", *from)
+	utils.PanicError("Invalid from date", err)
+
+	toT, err := time.Parse("2006-01-02", *to)
+	utils.PanicError("Invalid to date", err)
+
+	rss.SetUrl(config.DBUrl, config.RedisUrl)
+
+	reportRepo, err := repo.NewReportRepo()
+	utils.PanicError("Create report repo", err)
+
+	var req = &domain.RReport{
+		ProjectId: *projectId,
+		From:      fromT.Unix(),
+		To:        toT.Unix(),
+		Format:    report.Format(*format),
+	}
+	rp, err := reportRepo.Generate(req)
+	utils.PanicError("Generate report", err)
+
+	var w = os.Stdout
+	if *out != "" {
+		w, err = os.Create(*out)
+		utils.PanicError("Create output file", err)
+		defer w.Close()
+	}
+
+	err = report.Write(w, rp, req.Format)
+	utils.PanicError("Write report", err)
+
+	if *out != "" {
+		fmt.Fprintf(os.Stderr, "Report of project %d was written to %s\n", *projectId, *out)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Dcarbon/go-shared/libs/utils"
+	"github.com/Dcarbon/iott-cloud/internal/report"
+)
+
+// Verify exported report (json or csv zip) offline, exit 1 if any check
+// failed:
+//
+//	iott-cloud verify -in report.zip
+func runVerify(args []string) {
+	var fs = flag.NewFlagSet("verify", flag.ExitOnError)
+	var in = fs.String("in", "", "Exported report (json or zip of csv)")
+	var asJSON = fs.Bool("json", false, "Print result as json")
+	var failed = fs.Bool("failed", false, "Print failed checks only")
+	fs.Parse(args)
+
+	rp, err := report.ReadFile(*in)
+	utils.PanicError("Read report", err)
+
+	var rs = report.Verify(rp)
+	if *asJSON {
+		var enc = json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(rs)
+		utils.PanicError("Write result", err)
+	} else {
+		for _, c := range rs.Checks {
+			if *failed && c.Status != report.CheckFail {
+				continue
+			}
+			fmt.Printf("%s %-9s %-36s iot %-6d %s\n", c.Status, c.Kind, c.Id, c.IotId, strings.Join(c.Reasons, "; "))
+		}
+		fmt.Printf("\n%d passed, %d failed, %d skipped\n", rs.Passed, rs.Failed, rs.Skipped)
+	}
+
+	if !rs.OK() {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,193 @@
+package ctrls
+
+import (
+	"strconv"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/iott-cloud/internal/domain"
+	"github.com/Dcarbon/iott-cloud/internal/models"
+	"github.com/Dcarbon/iott-cloud/internal/notify"
+	"github.com/Dcarbon/iott-cloud/internal/repo"
+	"github.com/Dcarbon/iott-cloud/internal/rss"
+	"github.com/gin-gonic/gin"
+)
+
+type AlertCtrl struct {
+	alert domain.IAlert
+}
+
+func NewAlertCtrl(iot domain.IIot) (*AlertCtrl, error) {
+	var notifiers = map[string]domain.INotifier{
+		models.AlertChannelWebhook: notify.NewWebhook(),
+		models.AlertChannelEmail:   notify.NewEmail(),
+		models.AlertChannelEvent:   notify.NewEvent(rss.GetRabbitPusher()),
+	}
+
+	alert, err := repo.NewAlertRepo(iot, notifiers)
+	if nil != err {
+		return nil, err
+	}
+
+	var ctrl = &AlertCtrl{
+		alert: alert,
+	}
+	return ctrl, nil
+}
+
+func (ctrl *AlertCtrl) GetAlertRepo() domain.IAlert {
+	return ctrl.alert
+}
+
+// CreateRule godoc
+// @Summary      CreateRule
+// @Description  Create alert rule
+// @Tags         Alerts
+// @Accept       json
+// @Produce      json
+// @Param        rule				body		domain.RAlertRuleCreate		true	"Rule"
+// @Param        Authorization		header		string						true	"Authorization token (`Bearer $token`)"
+// @Success      200				{object}	models.AlertRule
+// @Failure      400				{object}	Error
+// @Failure      500				{object}	Error
+// @Router       /alerts/rules		[post]
+func (ctrl *AlertCtrl) CreateRule(r *gin.Context) {
+	var payload = &domain.RAlertRuleCreate{}
+	var err = r.Bind(payload)
+	if nil != err {
+		r.JSON(400, dmodels.ErrBadRequest(err.Error()))
+		return
+	}
+
+	rule, err := ctrl.alert.CreateRule(payload)
+	if nil != err {
+		r.JSON(500, err)
+		return
+	}
+	r.JSON(200, rule)
+}
+
+// UpdateRule godoc
+// @Summary      UpdateRule
+// @Description  Update alert rule
+// @Tags         Alerts
+// @Accept       json
+// @Produce      json
+// @Param        id					path		int							true	"Rule id"
+// @Param        rule				body		domain.RAlertRuleUpdate		true	"Rule"
+// @Param        Authorization		header		string						true	"Authorization token (`Bearer $token`)"
+// @Success      200				{object}	models.AlertRule
+// @Failure      400				{object}	Error
+// @Failure      404				{object}	Error
+// @Failure      500				{object}	Error
+// @Router       /alerts/rules/{id}	[put]
+func (ctrl *AlertCtrl) UpdateRule(r *gin.Context) {
+	id, err := strconv.ParseInt(r.Param("id"), 10, 64)
+	if nil != err {
+		r.JSON(400, dmodels.ErrBadRequest("Invalid rule id (Must be integer)"))
+		return
+	}
+
+	var payload = &domain.RAlertRuleUpdate{}
+	err = r.Bind(payload)
+	if nil != err {
+		r.JSON(400, dmodels.ErrBadRequest(err.Error()))
+		return
+	}
+	payload.Id = id
+
+	rule, err := ctrl.alert.UpdateRule(payload)
+	if nil != err {
+		r.JSON(500, err)
+		return
+	}
+	r.JSON(200, rule)
+}
+
+// DeleteRule godoc
+// @Summary      DeleteRule
+// @Description  Delete alert rule
+// @Tags         Alerts
+// @Produce      json
+// @Param        id					path		int							true	"Rule id"
+// @Param        Authorization		header		string						true	"Authorization token (`Bearer $token`)"
+// @Success      200				{object}	Empty
+// @Failure      400				{object}	Error
+// @Failure      500				{object}	Error
+// @Router       /alerts/rules/{id}	[delete]
+func (ctrl *AlertCtrl) DeleteRule(r *gin.Context) {
+	id, err := strconv.ParseInt(r.Param("id"), 10, 64)
+	if nil != err {
+		r.JSON(400, dmodels.ErrBadRequest("Invalid rule id (Must be integer)"))
+		return
+	}
+
+	err = ctrl.alert.DeleteRule(id)
+	if nil != err {
+		r.JSON(500, err)
+		return
+	}
+	r.JSON(200, Empty{})
+}
+
+// GetRules godoc
+// @Summary      GetRules
+// @Description  Get list alert rule
+// @Tags         Alerts
+// @Produce      json
+// @Param        projectId			query		int							false	"Project id"
+// @Param        iotId				query		int							false	"Iot id"
+// @Param        skip				query		int							false	"Skip"
+// @Param        limit				query		int							false	"Limit (max: 50)"
+// @Param        Authorization		header		string						true	"Authorization token (`Bearer $token`)"
+// @Success      200				{array}		models.AlertRule
+// @Failure      400				{object}	Error
+// @Failure      500				{object}	Error
+// @Router       /alerts/rules		[get]
+func (ctrl *AlertCtrl) GetRules(r *gin.Context) {
+	var payload = &domain.RAlertRuleGetList{}
+	var err = r.Bind(payload)
+	if nil != err {
+		r.JSON(400, dmodels.ErrBadRequest(err.Error()))
+		return
+	}
+
+	rules, err := ctrl.alert.GetRules(payload)
+	if nil != err {
+		r.JSON(500, err)
+		return
+	}
+	r.JSON(200, rules)
+}
+
+// GetAlerts godoc
+// @Summary      GetAlerts
+// @Description  Get history of fired alert (latest first)
+// @Tags         Alerts
+// @Produce      json
+// @Param        ruleId				query		int							false	"Rule id"
+// @Param        projectId			query		int							false	"Project id"
+// @Param        iotId				query		int							false	"Iot id"
+// @Param        from				query		int							false	"Timestamp start"
+// @Param        to					query		int							false	"Timestamp end"
+// @Param        skip				query		int							false	"Skip"
+// @Param        limit				query		int							false	"Limit (max: 50)"
+// @Param        Authorization		header		string						true	"Authorization token (`Bearer $token`)"
+// @Success      200				{array}		models.Alert
+// @Failure      400				{object}	Error
+// @Failure      500				{object}	Error
+// @Router       /alerts/			[get]
+func (ctrl *AlertCtrl) GetAlerts(r *gin.Context) {
+	var payload = &domain.RAlertGetList{}
+	var err = r.Bind(payload)
+	if nil != err {
+		r.JSON(400, dmodels.ErrBadRequest(err.Error()))
+		return
+	}
+
+	alerts, err := ctrl.alert.GetAlerts(payload)
+	if nil != err {
+		r.JSON(500, err)
+		return
+	}
+	r.JSON(200, alerts)
+}
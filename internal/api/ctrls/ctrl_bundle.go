@@ -0,0 +1,84 @@
+package ctrls
+
+import (
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/go-shared/libs/utils"
+	"github.com/Dcarbon/iott-cloud/internal/domain"
+	"github.com/Dcarbon/iott-cloud/internal/repo"
+	"github.com/gin-gonic/gin"
+)
+
+type BundleCtrl struct {
+	bundle domain.IBundle
+}
+
+// Builder is started unless BUNDLE_ENABLE=0
+func NewBundleCtrl() (*BundleCtrl, error) {
+	bundle, err := repo.NewBundleRepo()
+	if nil != err {
+		return nil, err
+	}
+
+	if utils.IntEnv("BUNDLE_ENABLE", 1) == 1 {
+		go bundle.Run()
+	}
+
+	var ctrl = &BundleCtrl{
+		bundle: bundle,
+	}
+	return ctrl, nil
+}
+
+// GetBundles godoc
+// @Summary      GetBundles
+// @Description  Merkle bundles of signed metrics of iot (latest first)
+// @Tags         Bundles
+// @Produce      json
+// @Param        iotId				query		int							true	"IoT id"
+// @Param        mintSignId			query		int							false	"Anchored mint signature id"
+// @Param        skip				query		int							false	"Skip"
+// @Param        limit				query		int							false	"Limit (max: 50)"
+// @Success      200				{array}		models.MetricBundle
+// @Failure      400				{object}	Error
+// @Failure      500				{object}	Error
+// @Router       /bundles/			[get]
+func (ctrl *BundleCtrl) GetBundles(r *gin.Context) {
+	var payload = &domain.RBundleGetList{}
+	var err = r.Bind(payload)
+	if nil != err {
+		r.JSON(400, dmodels.ErrBadRequest(err.Error()))
+		return
+	}
+
+	bundles, err := ctrl.bundle.GetBundles(payload)
+	if nil != err {
+		r.JSON(500, err)
+		return
+	}
+	r.JSON(200, bundles)
+}
+
+// GetProof godoc
+// @Summary      GetProof
+// @Description  Inclusion proof of metric in merkle bundle (verify offline by leaf, proof & root)
+// @Tags         Bundles
+// @Produce      json
+// @Param        metricId			query		string						true	"Sensor metric id (or metric signature id)"
+// @Success      200				{object}	domain.RsMetricProof
+// @Failure      400				{object}	Error
+// @Failure      500				{object}	Error
+// @Router       /bundles/proof		[get]
+func (ctrl *BundleCtrl) GetProof(r *gin.Context) {
+	var metricId = r.Query("metricId")
+	if metricId == "" {
+		r.JSON(400, dmodels.ErrBadRequest("metricId is required"))
+		return
+	}
+
+	proof, err := ctrl.bundle.GetProof(metricId)
+	if nil != err {
+		r.JSON(500, err)
+		return
+	}
+	r.JSON(200, proof)
+}
@@ -0,0 +1,155 @@
+package ctrls
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/go-shared/libs/utils"
+	"github.com/Dcarbon/iott-cloud/internal/chain"
+	"github.com/Dcarbon/iott-cloud/internal/domain"
+	"github.com/Dcarbon/iott-cloud/internal/repo"
+	"github.com/gin-gonic/gin"
+)
+
+type ChainCtrl struct {
+	chain domain.IChain
+}
+
+// Start indexer for each sign domain has rpc url: CHAIN_RPC_URL_<chain id>
+// (CHAIN_RPC_URL for chain of default domain)
+func NewChainCtrl(iot domain.IIot, domains domain.ISignDomain) (*ChainCtrl, error) {
+	chainRepo, err := repo.NewChainRepo()
+	if nil != err {
+		return nil, err
+	}
+
+	sds, err := domains.GetList()
+	if nil != err {
+		return nil, err
+	}
+
+	def, err := domains.GetById(0)
+	if nil != err {
+		return nil, err
+	}
+
+	var clients = make(map[int64]chain.IClient)
+	for _, sd := range sds {
+		var rpcUrl = utils.StringEnv(fmt.Sprintf("CHAIN_RPC_URL_%d", sd.ChainId), "")
+		if rpcUrl == "" && sd.ChainId == def.ChainId {
+			rpcUrl = utils.StringEnv("CHAIN_RPC_URL", "")
+		}
+		if rpcUrl == "" {
+			log.Printf("Rpc url of chain %d is empty, indexer of domain %s is disabled\n", sd.ChainId, sd.Label)
+			continue
+		}
+
+		client, ok := clients[sd.ChainId]
+		if !ok {
+			client, err = chain.Dial(rpcUrl)
+			if nil != err {
+				return nil, err
+			}
+			clients[sd.ChainId] = client
+		}
+
+		indexer, err := repo.NewChainIndexer(client, sd, iot)
+		if nil != err {
+			return nil, err
+		}
+		go indexer.Run()
+	}
+
+	var ctrl = &ChainCtrl{
+		chain: chainRepo,
+	}
+	return ctrl, nil
+}
+
+// GetMints godoc
+// @Summary      GetMints
+// @Description  Mint events of carbon contract (latest first)
+// @Tags         Chain
+// @Produce      json
+// @Param        domainId			query		int							false	"Sign domain id"
+// @Param        iot				query		string						false	"IoT address"
+// @Param        skip				query		int							false	"Skip"
+// @Param        limit				query		int							false	"Limit (max: 50)"
+// @Success      200				{array}		models.ChainMint
+// @Failure      400				{object}	Error
+// @Failure      500				{object}	Error
+// @Router       /chain/mints		[get]
+func (ctrl *ChainCtrl) GetMints(r *gin.Context) {
+	var payload = &domain.RChainMintGetList{}
+	var err = r.Bind(payload)
+	if nil != err {
+		r.JSON(400, dmodels.ErrBadRequest(err.Error()))
+		return
+	}
+
+	mints, err := ctrl.chain.GetMints(payload)
+	if nil != err {
+		r.JSON(500, err)
+		return
+	}
+	r.JSON(200, mints)
+}
+
+// GetDiscrepancies godoc
+// @Summary      GetDiscrepancies
+// @Description  Difference between mint events on-chain and mint signatures
+// @Tags         Chain
+// @Produce      json
+// @Param        kind					query		string					false	"Kind (missing_sign, amount_mismatch, tx_mismatch, minted_mismatch)"
+// @Param        iot					query		string					false	"IoT address"
+// @Param        resolved				query		bool					false	"Include resolved"
+// @Param        skip					query		int						false	"Skip"
+// @Param        limit					query		int						false	"Limit (max: 50)"
+// @Param        Authorization			header		string					true	"Authorization token (`Bearer $token`)"
+// @Success      200					{array}		models.MintDiscrepancy
+// @Failure      400					{object}	Error
+// @Failure      500					{object}	Error
+// @Router       /chain/discrepancies	[get]
+func (ctrl *ChainCtrl) GetDiscrepancies(r *gin.Context) {
+	var payload = &domain.RDiscrepancyGetList{}
+	var err = r.Bind(payload)
+	if nil != err {
+		r.JSON(400, dmodels.ErrBadRequest(err.Error()))
+		return
+	}
+
+	data, err := ctrl.chain.GetDiscrepancies(payload)
+	if nil != err {
+		r.JSON(500, err)
+		return
+	}
+	r.JSON(200, data)
+}
+
+// ResolveDiscrepancy godoc
+// @Summary      ResolveDiscrepancy
+// @Description  Mark discrepancy as resolved
+// @Tags         Chain
+// @Produce      json
+// @Param        id								path		int					true	"Discrepancy id"
+// @Param        Authorization					header		string				true	"Authorization token (`Bearer $token`)"
+// @Success      200							{object}	Empty
+// @Failure      400							{object}	Error
+// @Failure      500							{object}	Error
+// @Router       /chain/discrepancies/{id}/resolve	[put]
+func (ctrl *ChainCtrl) ResolveDiscrepancy(r *gin.Context) {
+	id, err := strconv.ParseInt(r.Param("id"), 10, 64)
+	if nil != err {
+		r.JSON(400, dmodels.ErrBadRequest("Invalid discrepancy id (Must be integer)"))
+		return
+	}
+
+	err = ctrl.chain.ResolveDiscrepancy(id)
+	if nil != err {
+		r.JSON(500, err)
+		return
+	}
+	r.JSON(200, Empty{})
+}
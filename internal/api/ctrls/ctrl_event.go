@@ -0,0 +1,44 @@
+package ctrls
+
+import (
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/iott-cloud/internal/events"
+	"github.com/gin-gonic/gin"
+)
+
+type EventCtrl struct {
+}
+
+func NewEventCtrl() (*EventCtrl, error) {
+	return &EventCtrl{}, nil
+}
+
+// GetCatalogue godoc
+// @Summary      GetCatalogue
+// @Description  Catalogue of events was published to rabbitmq (routing key is event name) and webhooks
+// @Tags         Events
+// @Produce      json
+// @Success      200				{array}		events.Definition
+// @Failure      500				{object}	Error
+// @Router       /events/			[get]
+func (ctrl *EventCtrl) GetCatalogue(r *gin.Context) {
+	r.JSON(200, events.Catalogue())
+}
+
+// GetSchema godoc
+// @Summary      GetSchema
+// @Description  Json schema of payload (current version) of event
+// @Tags         Events
+// @Produce      json
+// @Param        name				path		string				true	"Event name (e.g. iot.created)"
+// @Success      200				{object}	events.Definition
+// @Failure      404				{object}	Error
+// @Router       /events/{name}		[get]
+func (ctrl *EventCtrl) GetSchema(r *gin.Context) {
+	def, ok := events.Get(r.Param("name"))
+	if !ok {
+		r.JSON(404, dmodels.ErrNotFound("Event"))
+		return
+	}
+	r.JSON(200, def)
+}
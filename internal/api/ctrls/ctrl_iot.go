@@ -6,50 +6,30 @@ import (
 	"time"
 
 	"github.com/Dcarbon/go-shared/dmodels"
-	"github.com/Dcarbon/go-shared/edef"
-	"github.com/Dcarbon/go-shared/libs/esign"
-	"github.com/Dcarbon/go-shared/libs/utils"
 	"github.com/Dcarbon/iott-cloud/internal/domain"
 	"github.com/Dcarbon/iott-cloud/internal/models"
 	"github.com/Dcarbon/iott-cloud/internal/repo"
-	"github.com/Dcarbon/iott-cloud/internal/rss"
 	"github.com/gin-gonic/gin"
 	"github.com/paulmach/orb"
 	"github.com/paulmach/orb/geojson"
 )
 
 type IotCtrl struct {
-	separator *esign.TypedDataDomain // Domain seperator
-	iot       domain.IIot
-	sensor    domain.ISensor
-	pusher    *edef.IOTEvent
+	domains domain.ISignDomain // Domain seperator of mint signature
+	iot     domain.IIot
+	sensor  domain.ISensor
 }
 
-func NewIotCtrl(typedDomain *esign.TypedDataDomain,
+func NewIotCtrl(domains domain.ISignDomain,
 ) (*IotCtrl, error) {
-	var dMinter, err = esign.NewERC712(
-		typedDomain,
-		esign.MustNewTypedDataField(
-			"Mint",
-			esign.TypedDataStruct,
-			esign.MustNewTypedDataField("iot", esign.TypedDataAddress),
-			esign.MustNewTypedDataField("amount", esign.TypedDataUint256),
-			esign.MustNewTypedDataField("nonce", esign.TypedDataUint256),
-		),
-	)
-	if nil != err {
-		return nil, err
-	}
-	utils.Dump("Type domain config ", typedDomain)
-	irepo, err := repo.NewIOTRepo(dMinter)
+	irepo, err := repo.NewIOTRepo(domains)
 	if nil != err {
 		return nil, err
 	}
 
 	var ctrl = &IotCtrl{
-		iot:       irepo,
-		separator: typedDomain,
-		pusher:    edef.NewIOTEvent(rss.GetRabbitPusher()),
+		iot:     irepo,
+		domains: domains,
 	}
 	return ctrl, nil
 }
@@ -86,16 +66,6 @@ func (ctrl *IotCtrl) Create(r *gin.Context) {
 	}
 
 	r.JSON(200, iot)
-	log.Println("Publish iot created")
-	ctrl.pusher.PushIOTCreate(&edef.EventIOTCreate{
-		ID:      iot.ID,
-		Status:  dmodels.DeviceStatus(iot.Status),
-		Address: string(iot.Address),
-		Location: &edef.GPS{
-			Lng: iot.Position.Lng,
-			Lat: iot.Position.Lat,
-		},
-	})
 }
 
 // Create godoc
@@ -159,14 +129,28 @@ func (ctrl *IotCtrl) GetIot(r *gin.Context) {
 }
 
 // Create godoc
-// @Summary      GetIot
-// @Description  Get iot by id
+// @Summary      GetIots
+// @Description  Get list of iot by filter. Value of set is comma separated (type=20,21)
 // @Tags         Iots
 // @Accept       json
 // @Produce      json
-// @Param        projectId			query  		int 					true	"IOT id"
-// @Param        status				query  		dmodels.DeviceStatus	true	"Status"
+// @Param        projectId			query  		int 					false	"Project id"
+// @Param        project			query  		string 					false	"Set of project id"
+// @Param        type				query  		string 					false	"Set of iot type"
+// @Param        status				query  		string					false	"Set of device status"
+// @Param        opStatus			query  		string					false	"Set of operator status (-1: inactived, 1: warning, 10: actived)"
+// @Param        owner				query  		string					false	"Owner of project"
+// @Param        createdFrom		query  		int 					false	"Created from (unix second, inclusive)"
+// @Param        createdTo			query  		int 					false	"Created to (unix second, exclusive)"
+// @Param        hasSensors			query  		bool 					false	"Iot has (not) any sensor"
+// @Param        address			query  		string 					false	"Address contains"
+// @Param        sort				query  		string 					false	"id, createdAt, address, type, status (prefix -: desc)"
+// @Param        limit				query  		int 					false	"Limit (max: 50)"
+// @Param        cursor				query  		string 					false	"Cursor (X-Next-Cursor or X-Prev-Cursor of previous page)"
 // @Success      200				{array}		IOTDevice
+// @Header       200				{integer}	X-Total-Count			"Total of iot"
+// @Header       200				{string}	X-Next-Cursor			"Cursor of next page"
+// @Header       200				{string}	X-Prev-Cursor			"Cursor of previous page"
 // @Failure      400				{object}	Error
 // @Failure      404				{object}	Error
 // @Failure      500				{object}	Error
@@ -176,14 +160,15 @@ func (ctrl *IotCtrl) GetIots(r *gin.Context) {
 	var err = r.Bind(payload)
 	if nil != err {
 		log.Println("Error: ", err)
-		r.JSON(400, dmodels.ErrBadRequest("Invalid iot id (Must be integer)"))
+		r.JSON(400, dmodels.ErrBadRequest(err.Error()))
 		return
 	}
 
-	iots, err := ctrl.iot.GetIots(payload)
+	iots, page, err := ctrl.iot.GetIots(payload)
 	if nil != err {
 		r.JSON(500, err)
 	} else {
+		setPageHeader(r, page)
 		r.JSON(200, iots)
 	}
 }
@@ -201,7 +186,7 @@ func (ctrl *IotCtrl) GetIots(r *gin.Context) {
 // @Router       /iots/geojson		[get]
 func (ctrl *IotCtrl) GetIotPosition(r *gin.Context) {
 	locs, err := ctrl.iot.GetIotPositions(&domain.RIotGetList{
-		Status: dmodels.DeviceStatusSuccess,
+		IotFilter: domain.IotFilter{StatusIn: []dmodels.DeviceStatus{dmodels.DeviceStatusSuccess}},
 	})
 	if nil != err {
 		r.JSON(500, err)
@@ -254,7 +239,7 @@ func (ctrl *IotCtrl) ChangeStatus(r *gin.Context) {
 	}
 
 	if ctrl.sensor != nil {
-		sensors, err := ctrl.sensor.GetSensors(&domain.RGetSensors{})
+		sensors, _, err := ctrl.sensor.GetSensors(&domain.RGetSensors{})
 		if nil != err {
 			log.Println("Get list sensor error: ", err)
 		} else {
@@ -272,10 +257,6 @@ func (ctrl *IotCtrl) ChangeStatus(r *gin.Context) {
 	}
 
 	r.JSON(200, iot)
-	ctrl.pusher.PushIOTChangeStatus(&edef.EventIOTChangeStatus{
-		ID:     iot.ID,
-		Status: dmodels.DeviceStatus(iot.Status),
-	})
 }
 
 // GetRawMetric		godoc
@@ -317,7 +298,13 @@ func (ctrl *IotCtrl) CreateMint(r *gin.Context) {
 // @Param			from					query		number				true	"Duration start"
 // @Param			to						query		number				false	"Duration end"
 // @Param			sort					query		number				false	"Sort by created at"
+// @Param			state					query		number				false	"Redemption state (-1: failed 0: pending 1: submitted 2: confirmed)"
+// @Param			limit					query		number				false	"Limit"
+// @Param			cursor					query		string				false	"Cursor (X-Next-Cursor or X-Prev-Cursor of previous page)"
 // @Success			200						{array}		models.MintSign
+// @Header			200						{integer}	X-Total-Count		"Total of mint signature"
+// @Header			200						{string}	X-Next-Cursor		"Cursor of next page"
+// @Header			200						{string}	X-Prev-Cursor		"Cursor of previous page"
 // @Failure			400						{object}	Error
 // @Failure			404						{object}	Error
 // @Failure			500						{object}	Error
@@ -339,14 +326,74 @@ func (ctrl *IotCtrl) GetMintSigns(r *gin.Context) {
 	}
 
 	var signeds []*models.MintSign
-	signeds, err = ctrl.iot.GetMintSigns(payload)
+	var page *domain.PageInfo
+	signeds, page, err = ctrl.iot.GetMintSigns(payload)
 	if nil != err {
 		r.JSON(500, err)
 	} else {
+		setPageHeader(r, page)
 		r.JSON(200, signeds)
 	}
 }
 
+// ReportMintTx		godoc
+// @Summary			Report tx of mint signature
+// @Description		Relayer report state of tx was sent to carbon contract
+// @Tags			Iots
+// @Accept			json
+// @Produce			json
+// @Param			iotAddr			path		string				true	"IOT address"
+// @Param			payload			body		domain.RMintTxReport	true	"Tx report"
+// @Param			Authorization	header		string				true	"Authorization token (`Bearer $token`)"
+// @Success			200				{object}	models.MintSign
+// @Failure			400				{object}	Error
+// @Failure			404				{object}	Error
+// @Failure			500				{object}	Error
+// @Router			/iots/{iotAddr}/mint-sign/report	[post]
+func (ctrl *IotCtrl) ReportMintTx(r *gin.Context) {
+	var payload = &domain.RMintTxReport{}
+	var err = r.BindJSON(payload)
+	if nil != err {
+		r.JSON(400, dmodels.ErrBadRequest("Payload must be json: "+err.Error()))
+		return
+	}
+	payload.Iot = dmodels.EthAddress(r.Param("iotAddr"))
+
+	sign, err := ctrl.iot.ReportMintTx(payload)
+	if nil != err {
+		r.JSON(500, err)
+	} else {
+		r.JSON(200, sign)
+	}
+}
+
+// GetMintUnclaimed	godoc
+// @Summary			Get unclaimed carbon of iot
+// @Description		Carbon was signed but not confirmed on-chain
+// @Tags			Iots
+// @Accept			json
+// @Produce			json
+// @Param			iotId					path		number				true	"Iot id"
+// @Success			200						{object}	domain.RsMintUnclaimed
+// @Failure			400						{object}	Error
+// @Failure			404						{object}	Error
+// @Failure			500						{object}	Error
+// @Router			/iots/{iotId}/mint-sign/unclaimed 	[get]
+func (ctrl *IotCtrl) GetMintUnclaimed(r *gin.Context) {
+	iotId, err := strconv.ParseInt(r.Param("iotId"), 10, 64)
+	if nil != err {
+		r.JSON(400, dmodels.ErrBadRequest("Iot id is invalid: "+err.Error()))
+		return
+	}
+
+	rs, err := ctrl.iot.GetMintUnclaimed(iotId)
+	if nil != err {
+		r.JSON(500, err)
+	} else {
+		r.JSON(200, rs)
+	}
+}
+
 // GetRawMetric		godoc
 // @Summary			Get mint signature of iot
 // @Description		Get mint signature of iot
@@ -375,7 +422,7 @@ func (ctrl *IotCtrl) GetMintSignsLatest(r *gin.Context) {
 	}
 
 	var signeds []*models.MintSign
-	signeds, err = ctrl.iot.GetMintSigns(payload)
+	signeds, _, err = ctrl.iot.GetMintSigns(payload)
 	if nil != err {
 		r.JSON(500, err)
 	} else {
@@ -396,8 +443,17 @@ func (ctrl *IotCtrl) GetMintSignsLatest(r *gin.Context) {
 // @Param			iotId					path		number				true	"Iot id"
 // @Param			from					query		number				true	"Duration start"
 // @Param			to						query		number				true	"Duration end"
-// @Param			interval				query		number				false	"Interval: 1:day 2:month"
+// @Param			interval				query		number				false	"Deprecated (use bucket). Interval: 1:day 2:month"
+// @Param			bucket					query		string				false	"Bucket: hour, day, week, month, quarter, year"
+// @Param			func					query		string				false	"Aggregate function: sum, avg, min, max, count, last (default: sum)"
+// @Param			tz						query		string				false	"IANA timezone (default: Asia/Ho_Chi_Minh)"
+// @Param			fill					query		string				false	"Fill empty bucket: zero"
+// @Param			limit					query		number				false	"Limit (max: 50, raw minted only)"
+// @Param			cursor					query		string				false	"Cursor (raw minted only)"
 // @Success			200						{array}		models.Minted
+// @Header			200						{integer}	X-Total-Count		"Total of minted (raw minted only)"
+// @Header			200						{string}	X-Next-Cursor		"Cursor of next page"
+// @Header			200						{string}	X-Prev-Cursor		"Cursor of previous page"
 // @Failure			400						{object}	Error
 // @Failure			404						{object}	Error
 // @Failure			500						{object}	Error
@@ -412,14 +468,50 @@ func (ctrl *IotCtrl) GetMinted(r *gin.Context) {
 	}
 	// utils.Dump("payload", payload)
 
-	signeds, err := ctrl.iot.GetMinted(payload)
+	signeds, page, err := ctrl.iot.GetMinted(payload)
 	if nil != err {
 		r.JSON(500, err)
 	} else {
+		setPageHeader(r, page)
 		r.JSON(200, signeds)
 	}
 }
 
+// GetMintedSeries	godoc
+// @Summary			Get minted series of iot
+// @Description		Aggregate minted of iot by time bucket
+// @Tags			Iots
+// @Accept			json
+// @Produce			json
+// @Param			iotId						path		number				true	"Iot id"
+// @Param			from						query		number				true	"Duration start"
+// @Param			to							query		number				true	"Duration end"
+// @Param			bucket						query		string				false	"Bucket: hour, day, week, month, quarter, year (default: day)"
+// @Param			func						query		string				false	"Aggregate function: sum, avg, min, max, count, last (default: sum)"
+// @Param			tz							query		string				false	"IANA timezone (default: Asia/Ho_Chi_Minh)"
+// @Param			fill						query		string				false	"Fill empty bucket: null, zero"
+// @Success			200							{object}	AggSeries
+// @Failure			400							{object}	Error
+// @Failure			404							{object}	Error
+// @Failure			500							{object}	Error
+// @Router			/iots/{iotId}/minted/series [get]
+func (ctrl *IotCtrl) GetMintedSeries(r *gin.Context) {
+	var payload = &domain.RIotGetMintedList{}
+	payload.IotId, _ = strconv.ParseInt(r.Param("iotId"), 10, 64)
+	var err = r.Bind(payload)
+	if nil != err {
+		r.JSON(400, dmodels.ErrBadRequest("Payload error "+err.Error()))
+		return
+	}
+
+	series, err := ctrl.iot.GetMintedSeries(payload)
+	if nil != err {
+		r.JSON(500, err)
+	} else {
+		r.JSON(200, series)
+	}
+}
+
 // IsActived		godoc
 // @Summary			IsActived
 // @Description		Check is iot is actived in range [from:to)
@@ -453,25 +545,99 @@ func (ctrl *IotCtrl) IsActived(r *gin.Context) {
 
 // GetDomainSeperator		godoc
 // @Summary			GetDomainSeperator
-// @Description		Get domain separator
+// @Description		Get domain separator of iot (default domain if iot is empty)
 // @Tags			Iots
 // @Accept			json
 // @Produce			json
+// @Param			iotId			query		number				false	"Iot id"
+// @Param			address			query		string				false	"Iot address"
 // @Success			200				{object}	esign.TypedDataDomain
 // @Failure			400				{object}	Error
 // @Failure			404				{object}	Error
 // @Failure			500				{object}	Error
 // @Router			/iots/seperator [get]
 func (ctrl *IotCtrl) GetDomainSeperator(r *gin.Context) {
-	r.JSON(200, ctrl.separator)
+	var domainId int64
+	if r.Query("iotId") != "" || r.Query("address") != "" {
+		var iot *models.IOTDevice
+		var err error
+		if r.Query("iotId") != "" {
+			iotId, e1 := strconv.ParseInt(r.Query("iotId"), 10, 64)
+			if nil != e1 {
+				r.JSON(400, dmodels.ErrBadRequest("Iot id is invalid: "+e1.Error()))
+				return
+			}
+			iot, err = ctrl.iot.GetIot(iotId)
+		} else {
+			iot, err = ctrl.iot.GetIotByAddress(dmodels.EthAddress(r.Query("address")))
+		}
+		if nil != err {
+			r.JSON(404, err)
+			return
+		}
+		domainId = iot.DomainId
+	}
+
+	sd, err := ctrl.domains.GetById(domainId)
+	if nil != err {
+		r.JSON(500, err)
+		return
+	}
+	r.JSON(200, sd.TypedDomain())
+}
+
+// MoveDomain		godoc
+// @Summary			MoveDomain
+// @Description		Move iots (by ids or all iots of project) to sign domain
+// @Tags			Iots
+// @Accept			json
+// @Produce			json
+// @Param			id				path		number					true	"Sign domain id"
+// @Param			payload			body		domain.RIotMoveDomain	true	"Iots"
+// @Param			Authorization	header		string					true	"Authorization token (`Bearer $token`)"
+// @Success			200				{object}	domain.RsIotMoveDomain
+// @Failure			400				{object}	Error
+// @Failure			404				{object}	Error
+// @Failure			500				{object}	Error
+// @Router			/domains/{id}/iots [put]
+func (ctrl *IotCtrl) MoveDomain(r *gin.Context) {
+	var payload = &domain.RIotMoveDomain{}
+	var err = r.BindJSON(payload)
+	if nil != err {
+		r.JSON(400, dmodels.ErrBadRequest("Payload must be json: "+err.Error()))
+		return
+	}
+
+	payload.DomainId, err = strconv.ParseInt(r.Param("id"), 10, 64)
+	if nil != err {
+		r.JSON(400, dmodels.ErrBadRequest("Invalid domain id (Must be integer)"))
+		return
+	}
+
+	rs, err := ctrl.iot.MoveDomain(payload)
+	if nil != err {
+		r.JSON(500, err)
+		return
+	}
+	r.JSON(200, rs)
 }
 
 // GetDomainSeperator		godoc
 // @Summary			CountIot
-// @Description		Num of iot
+// @Description		Num of iot matched filter (same filter of /iots/list, default status: 10)
 // @Tags			Iots
 // @Accept			json
 // @Produce			json
+// @Param			projectId		query		int			false	"Project id"
+// @Param			project			query		string		false	"Set of project id"
+// @Param			type			query		string		false	"Set of iot type"
+// @Param			status			query		string		false	"Set of device status (default: 10)"
+// @Param			opStatus		query		string		false	"Set of operator status"
+// @Param			owner			query		string		false	"Owner of project"
+// @Param			createdFrom		query		int			false	"Created from (unix second, inclusive)"
+// @Param			createdTo		query		int			false	"Created to (unix second, exclusive)"
+// @Param			hasSensors		query		bool		false	"Iot has (not) any sensor"
+// @Param			address			query		string		false	"Address contains"
 // @Success			200				{object}	Count
 // @Failure			400				{object}	Error
 // @Failure			404				{object}	Error
@@ -479,7 +645,13 @@ func (ctrl *IotCtrl) GetDomainSeperator(r *gin.Context) {
 // @Router			/iots/count [get]
 func (ctrl *IotCtrl) Count(r *gin.Context) {
 	var payload = &domain.RIotCount{}
-	var count, err = ctrl.iot.CountIot(payload)
+	var err = r.Bind(payload)
+	if nil != err {
+		r.JSON(400, dmodels.ErrBadRequest(err.Error()))
+		return
+	}
+
+	count, err := ctrl.iot.CountIot(payload)
 	if nil != err {
 		r.JSON(500, err)
 		return
@@ -1,6 +1,7 @@
 package ctrls
 
 import (
+	"log"
 	"strconv"
 
 	"github.com/Dcarbon/go-shared/dmodels"
@@ -18,11 +19,17 @@ type OperatorCtrl struct {
 
 func NewOperatorCtrl(iot domain.IIot, sensor domain.ISensor,
 ) (*OperatorCtrl, error) {
-	var op, err = repo.NewOperatorRepo()
+	var op, err = repo.NewOperatorRepo(iot)
 	if nil != err {
 		return nil, err
 	}
 	sensor.SetOperatorCache(op)
+	go repo.NewOpWatchdog(op).Run()
+	go func() {
+		if err := op.Reindex(); nil != err {
+			log.Println("Reindex operator status error: ", err)
+		}
+	}()
 
 	var ctrl = &OperatorCtrl{
 		iot:      iot,
@@ -87,5 +94,59 @@ func (ctrl *OperatorCtrl) GetMetrics(r *gin.Context) {
 	r.JSON(200, metric)
 }
 
+// Create godoc
+// @Summary      GetHealth
+// @Description  Fleet health summary (num of iot by operator status)
+// @Tags         Operator
+// @Accept       json
+// @Produce      json
+// @Success      200					{object}	domain.RsOpHealth
+// @Failure      400					{object}	Error
+// @Failure      500					{object}	Error
+// @Router       /op/health				[get]
+func (ctrl *OperatorCtrl) GetHealth(r *gin.Context) {
+	health, err := ctrl.operator.GetHealth()
+	if nil != err {
+		r.JSON(500, err)
+		return
+	}
+	r.JSON(200, health)
+}
+
+// Create godoc
+// @Summary      GetOverview
+// @Description  Fleet overview: num of iot by status, project, type and page of iot (latest first)
+// @Tags         Operator
+// @Accept       json
+// @Produce      json
+// @Param        status					query		int					false	"Operator status (-1: inactived, 1: warning, 10: actived)"
+// @Param        projectId				query		int					false	"Project id"
+// @Param        type					query		int					false	"Iot type"
+// @Param        skip					query		int					false	"Skip"
+// @Param        limit					query		int					false	"Limit (max: 100)"
+// @Success      200					{object}	domain.RsOpOverview
+// @Failure      400					{object}	Error
+// @Failure      500					{object}	Error
+// @Router       /op/overview			[get]
+func (ctrl *OperatorCtrl) GetOverview(r *gin.Context) {
+	var payload = &domain.ROpOverview{}
+	var err = r.Bind(payload)
+	if nil != err {
+		r.JSON(400, dmodels.ErrBadRequest(err.Error()))
+		return
+	}
+
+	overview, err := ctrl.operator.GetOverview(payload)
+	if nil != err {
+		r.JSON(500, err)
+		return
+	}
+	r.JSON(200, overview)
+}
+
+func (ctrl *OperatorCtrl) GetOperatorRepo() domain.IOperator {
+	return ctrl.operator
+}
+
 type Empty struct {
 }
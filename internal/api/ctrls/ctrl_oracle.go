@@ -0,0 +1,142 @@
+package ctrls
+
+import (
+	"log"
+	"strconv"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/iott-cloud/internal/domain"
+	"github.com/Dcarbon/iott-cloud/internal/repo"
+	"github.com/Dcarbon/iott-cloud/internal/signer"
+	"github.com/gin-gonic/gin"
+)
+
+type OracleCtrl struct {
+	oracle domain.IOracle
+}
+
+// Oracle key is loaded by ORACLE_* env (see signer.FromEnv)
+func NewOracleCtrl(domains domain.ISignDomain) (*OracleCtrl, error) {
+	s, err := signer.FromEnv("ORACLE")
+	if nil != err {
+		return nil, err
+	}
+
+	oracle, err := repo.NewOracleRepo(domains, s)
+	if nil != err {
+		return nil, err
+	}
+
+	if s != nil {
+		log.Println("Oracle signer address: ", s.Address())
+		go oracle.Run()
+	} else {
+		log.Println("Oracle signer is not configured, oracle mint is disabled")
+	}
+
+	var ctrl = &OracleCtrl{
+		oracle: oracle,
+	}
+	return ctrl, nil
+}
+
+// SetEnable godoc
+// @Summary      SetEnable
+// @Description  Enable/disable oracle mode of iot (mint is signed by server)
+// @Tags         Iots
+// @Accept       json
+// @Produce      json
+// @Param        iotId				path		int							true	"IoT id"
+// @Param        payload			body		domain.RIotOracleEnable		true	"Enable"
+// @Param        Authorization		header		string						true	"Authorization token (`Bearer $token`)"
+// @Success      200				{object}	models.IOTDevice
+// @Failure      400				{object}	Error
+// @Failure      500				{object}	Error
+// @Router       /iots/{iotId}/oracle	[put]
+func (ctrl *OracleCtrl) SetEnable(r *gin.Context) {
+	iotId, err := strconv.ParseInt(r.Param("iotId"), 10, 64)
+	if nil != err {
+		r.JSON(400, dmodels.ErrBadRequest("Invalid iot id (Must be integer)"))
+		return
+	}
+
+	var payload = &domain.RIotOracleEnable{}
+	err = r.Bind(payload)
+	if nil != err {
+		r.JSON(400, dmodels.ErrBadRequest(err.Error()))
+		return
+	}
+	payload.IotId = iotId
+
+	iot, err := ctrl.oracle.SetEnable(payload)
+	if nil != err {
+		r.JSON(500, err)
+		return
+	}
+	r.JSON(200, iot)
+}
+
+// Mint godoc
+// @Summary      Mint
+// @Description  Sign next mint of oracle iot now (empty if there is no carbon increment)
+// @Tags         Iots
+// @Produce      json
+// @Param        iotId				path		int							true	"IoT id"
+// @Param        Authorization		header		string						true	"Authorization token (`Bearer $token`)"
+// @Success      200				{object}	models.MintAudit
+// @Failure      400				{object}	Error
+// @Failure      500				{object}	Error
+// @Router       /iots/{iotId}/oracle/mint	[post]
+func (ctrl *OracleCtrl) Mint(r *gin.Context) {
+	iotId, err := strconv.ParseInt(r.Param("iotId"), 10, 64)
+	if nil != err {
+		r.JSON(400, dmodels.ErrBadRequest("Invalid iot id (Must be integer)"))
+		return
+	}
+
+	audit, err := ctrl.oracle.Mint(iotId)
+	if nil != err {
+		r.JSON(500, err)
+		return
+	}
+	if nil == audit {
+		r.JSON(200, Empty{})
+		return
+	}
+	r.JSON(200, audit)
+}
+
+// GetAudits godoc
+// @Summary      GetAudits
+// @Description  Inputs of oracle mint signatures of iot (latest first)
+// @Tags         Iots
+// @Produce      json
+// @Param        iotId				path		int							true	"IoT id"
+// @Param        skip				query		int							false	"Skip"
+// @Param        limit				query		int							false	"Limit (max: 50)"
+// @Success      200				{array}		models.MintAudit
+// @Failure      400				{object}	Error
+// @Failure      500				{object}	Error
+// @Router       /iots/{iotId}/oracle/audits	[get]
+func (ctrl *OracleCtrl) GetAudits(r *gin.Context) {
+	iotId, err := strconv.ParseInt(r.Param("iotId"), 10, 64)
+	if nil != err {
+		r.JSON(400, dmodels.ErrBadRequest("Invalid iot id (Must be integer)"))
+		return
+	}
+
+	var payload = &domain.RMintAuditGetList{}
+	err = r.Bind(payload)
+	if nil != err {
+		r.JSON(400, dmodels.ErrBadRequest(err.Error()))
+		return
+	}
+	payload.IotId = iotId
+
+	audits, err := ctrl.oracle.GetAudits(payload)
+	if nil != err {
+		r.JSON(500, err)
+		return
+	}
+	r.JSON(200, audits)
+}
@@ -0,0 +1,81 @@
+package ctrls
+
+import (
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/go-shared/libs/utils"
+	"github.com/Dcarbon/iott-cloud/internal/domain"
+	"github.com/Dcarbon/iott-cloud/internal/repo"
+	"github.com/gin-gonic/gin"
+)
+
+type PlatformCtrl struct {
+	platform domain.IPlatform
+}
+
+// Summaries are refreshed unless PLATFORM_REFRESH_ENABLE=0
+func NewPlatformCtrl() (*PlatformCtrl, error) {
+	platform, err := repo.NewPlatformRepo()
+	if nil != err {
+		return nil, err
+	}
+
+	if utils.IntEnv("PLATFORM_REFRESH_ENABLE", 1) == 1 {
+		go platform.Run()
+	}
+
+	var ctrl = &PlatformCtrl{
+		platform: platform,
+	}
+	return ctrl, nil
+}
+
+// GetStats godoc
+// @Summary      GetStats
+// @Description  Platform-wide stats (refreshed periodically)
+// @Tags         Platform
+// @Produce      json
+// @Success      200				{object}	domain.RsPlatformStats
+// @Failure      500				{object}	Error
+// @Router       /platform/stats	[get]
+func (ctrl *PlatformCtrl) GetStats(r *gin.Context) {
+	stats, err := ctrl.platform.GetStats()
+	if nil != err {
+		r.JSON(500, err)
+		return
+	}
+	r.JSON(200, stats)
+}
+
+// GetLeaderboard godoc
+// @Summary      GetLeaderboard
+// @Description  Top projects / iots by minted carbon in period (refreshed periodically)
+// @Tags         Platform
+// @Produce      json
+// @Param        kind					query		string						false	"project (default), iot"
+// @Param        period					query		string						false	"1d (today UTC), 7d, 30d (default), 90d, 365d, all (UTC days, today included)"
+// @Param        limit					query		int							false	"Limit (default: 10, max: 100)"
+// @Success      200					{object}	domain.RsLeaderboard
+// @Failure      400					{object}	Error
+// @Failure      500					{object}	Error
+// @Router       /platform/leaderboard	[get]
+func (ctrl *PlatformCtrl) GetLeaderboard(r *gin.Context) {
+	var payload = &domain.RLeaderboard{}
+	var err = r.Bind(payload)
+	if nil != err {
+		r.JSON(400, dmodels.ErrBadRequest(err.Error()))
+		return
+	}
+
+	err = payload.Normalize()
+	if nil != err {
+		r.JSON(400, err)
+		return
+	}
+
+	board, err := ctrl.platform.GetLeaderboard(payload)
+	if nil != err {
+		r.JSON(500, err)
+		return
+	}
+	r.JSON(200, board)
+}
@@ -2,27 +2,32 @@ package ctrls
 
 import (
 	"errors"
-	"log"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strconv"
 
 	"github.com/Dcarbon/go-shared/dmodels"
-	"github.com/Dcarbon/go-shared/libs/sclient"
+	"github.com/Dcarbon/go-shared/libs/utils"
 	"github.com/Dcarbon/iott-cloud/internal/api/mids"
 	"github.com/Dcarbon/iott-cloud/internal/domain"
 	"github.com/Dcarbon/iott-cloud/internal/env"
+	"github.com/Dcarbon/iott-cloud/internal/imaging"
 	"github.com/Dcarbon/iott-cloud/internal/repo"
+	"github.com/Dcarbon/iott-cloud/internal/signer"
+	"github.com/Dcarbon/iott-cloud/internal/storage"
 	"github.com/gin-gonic/gin"
-	uuid "github.com/satori/go.uuid"
 )
 
 type ProjectCtrl struct {
-	dstTmp     string
-	serverHost string
-	repo       domain.IProject
-	storage    sclient.IStorage
+	serverHost   string
+	repo         domain.IProject
+	stats        domain.IProjectStats
+	storage      domain.IStorage
+	docs         domain.IProjectDoc
+	imgLimits    *imaging.Limits // Dimensions of uploaded image
+	imgSlots     chan struct{}   // Limit images are processed at the same time (memory)
+	maxImageSize int64           // Bytes of uploaded image
+	maxDocSize   int64           // Bytes of uploaded document
+	privateDocs  bool            // Storage has private area for documents (local driver)
 }
 
 func NewProjectCtrl(dbUrl, storageHost, isvToken string) (*ProjectCtrl, error) {
@@ -31,16 +36,49 @@ func NewProjectCtrl(dbUrl, storageHost, isvToken string) (*ProjectCtrl, error) {
 		return nil, err
 	}
 
-	storage, err := sclient.NewStorage(storageHost, isvToken)
+	statsRepo, err := repo.NewProjectStatsRepo()
+	if nil != err {
+		return nil, err
+	}
+
+	// Versions of document are anchored (signed) if DOC_* signer is
+	// configured (see signer.FromEnv)
+	docSigner, err := signer.FromEnv("DOC")
+	if nil != err {
+		return nil, err
+	}
+
+	docRepo, err := repo.NewProjectDocRepo(docSigner)
+	if nil != err {
+		return nil, err
+	}
+
+	var serverHost = env.ServerScheme + "://" + env.ServerHost
+	store, err := storage.New(
+		env.StorageDriver, storageHost, isvToken,
+		env.StorageLocalDir, env.StoragePrivateDir, serverHost,
+	)
 	if nil != err {
 		return nil, err
 	}
 
 	var ctrl = &ProjectCtrl{
-		dstTmp:     "./static",
-		serverHost: env.ServerScheme + "://" + env.ServerHost,
+		serverHost: serverHost,
 		repo:       projectRepo,
-		storage:    storage,
+		stats:      statsRepo,
+		docs:       docRepo,
+		storage:    store,
+		imgLimits: &imaging.Limits{
+			MinWidth:  utils.IntEnv("PROJECT_IMAGE_MIN_EDGE", 200),
+			MinHeight: utils.IntEnv("PROJECT_IMAGE_MIN_EDGE", 200),
+			MaxWidth:  utils.IntEnv("PROJECT_IMAGE_MAX_EDGE", 4096),
+			MaxHeight: utils.IntEnv("PROJECT_IMAGE_MAX_EDGE", 4096),
+			MaxPixels: utils.IntEnv("PROJECT_IMAGE_MAX_PIXELS", 12000000),
+		},
+		imgSlots:     make(chan struct{}, utils.IntEnv("PROJECT_IMAGE_CONCURRENCY", 2)),
+		maxImageSize: utils.Int64Env("PROJECT_IMAGE_MAX_SIZE", 10<<20),
+		maxDocSize:   utils.Int64Env("PROJECT_DOC_MAX_SIZE", 50<<20),
+		privateDocs:  env.StorageDriver == storage.DriverLocal,
 	}
 	return ctrl, nil
 }
@@ -149,14 +187,15 @@ func (ctrl *ProjectCtrl) UpdateSpecs(r *gin.Context) {
 
 // Create godoc
 // @Summary      Add image
-// @Description  Add image for project
+// @Description  Add image for project. Image is validated (jpeg, png), EXIF is stripped and thumbnails (sm, md, lg) are generated
 // @Tags         Project
 // @Accept       mpfd
 // @Produce      json
 // @Param        projectId				formData	int64			true	"Project id"
 // @Param        image					formData	file			true	"Project image (*.png, *.jpg)"
+// @Param        cover					formData	bool			false	"Set as cover (first image of project is cover)"
 // @Param        Authorization			header		string			true	"Authorization token (`Bearer $token`)"
-// @Success      200					{array}		Project
+// @Success      200					{object}	ProjectImage
 // @Failure      400					{object}	Error
 // @Failure      404					{object}	Error
 // @Failure      500					{object}	Error
@@ -174,40 +213,27 @@ func (ctrl *ProjectCtrl) AddImage(r *gin.Context) {
 		return
 	}
 
-	img, err := r.FormFile("image")
-	if nil != err {
-		r.JSON(400, dmodels.ErrBadRequest("Missing image"))
-		return
-	}
-	os.MkdirAll(ctrl.dstTmp, 0777)
-
-	fileName := filepath.Base(img.Filename)
-	ext := filepath.Ext(fileName)
-	fileName = ctrl.dstTmp + "/" + uuid.NewV4().String() + ext
-	log.Println("Save file ")
-
-	err = r.SaveUploadedFile(img, fileName)
+	raw, _, err := readFormFile(r, "image", ctrl.maxImageSize)
 	if nil != err {
-		r.JSON(400, dmodels.ErrInternal(errors.New("missing image")))
+		r.JSON(400, err)
 		return
 	}
-	defer os.Remove(fileName)
 
-	path, err := ctrl.storage.UploadToProject(fileName, projectId)
+	req, err := ctrl.processImage(r.Request.Context(), projectId, raw)
 	if nil != err {
 		r.JSON(400, err)
 		return
 	}
+	req.Cover, _ = strconv.ParseBool(r.PostForm("cover"))
 
-	pimg, err := ctrl.repo.AddImage(&domain.RProjectAddImage{
-		ProjectID: projectId,
-		ImgPath:   path,
-	})
+	pimg, err := ctrl.repo.AddImage(req)
 	if nil != err {
+		ctrl.removeImageFiles(req.ImgPath, req.Thumbs)
 		r.JSON(500, err)
 		return
 	}
 
+	ctrl.imageUrls(pimg)
 	r.JSON(200, pimg)
 }
 
@@ -218,6 +244,8 @@ func (ctrl *ProjectCtrl) AddImage(r *gin.Context) {
 // @Accept       json
 // @Produce      json
 // @Param        projectId					path  		string		true	"Project id"
+// @Param        lang						query  		string		false	"Language of description (default: by Accept-Language)"
+// @Param        Accept-Language			header  	string		false	"Languages (fallback: en, vi)"
 // @Success      200						{array}		Project
 // @Failure      400						{object}	Error
 // @Failure      404  						{object}	Error
@@ -230,16 +258,100 @@ func (ctrl *ProjectCtrl) GetByID(r *gin.Context) {
 		return
 	}
 
-	data, err := ctrl.repo.GetById(id, "vi")
+	var langs = domain.LanguageChain(r.Query("lang"), r.GetHeader("Accept-Language"))
+	data, err := ctrl.repo.GetById(id, langs...)
+	if nil != err {
+		r.JSON(500, err)
+		return
+	}
+
+	ctrl.imageUrls(data.Images...)
+	if len(data.Descs) > 0 {
+		r.Header("Content-Language", data.Descs[0].Language)
+	}
+
+	r.JSON(200, data)
+}
+
+// GetStats godoc
+// @Summary      GetStats
+// @Description  Dashboard statistics of project: devices, minted, active days, latest metrics, minted series
+// @Tags         Project
+// @Accept       json
+// @Produce      json
+// @Param        projectId					path  		int			true	"Project id"
+// @Param        from						query  		int			false	"From (unix second, inclusive. Default: to - 30 days)"
+// @Param        to							query  		int			false	"To (unix second, exclusive. Default: next hour)"
+// @Param        bucket						query		string		false	"Bucket of minted series: hour, day, week, month, quarter, year (default: day)"
+// @Param        func						query		string		false	"Aggregate function: sum, avg, min, max, count, last (default: sum)"
+// @Param        tz							query		string		false	"IANA timezone (default: Asia/Ho_Chi_Minh)"
+// @Param        fill						query		string		false	"Fill empty bucket: null, zero"
+// @Success      200						{object}	RsProjectStats
+// @Failure      400						{object}	Error
+// @Failure      404  						{object}	Error
+// @Failure      500  						{object}	Error
+// @Router       /projects/{projectId}/stats [get]
+func (ctrl *ProjectCtrl) GetStats(r *gin.Context) {
+	id, err := strconv.ParseInt(r.Param("projectId"), 10, 64)
+	if nil != err {
+		r.JSON(400, dmodels.ErrBadRequest("projectId must be int64"))
+		return
+	}
+
+	var payload = &domain.RProjectStats{}
+	err = r.Bind(payload)
+	if nil != err {
+		r.JSON(400, dmodels.ErrBadRequest(err.Error()))
+		return
+	}
+	payload.ProjectId = id
+
+	data, err := ctrl.stats.GetStats(payload)
 	if nil != err {
 		r.JSON(500, err)
 		return
 	}
+	r.JSON(200, data)
+}
+
+// Search godoc
+// @Summary      Search
+// @Description  Full-text search of project by name, description and location name
+// @Tags         Project
+// @Accept       json
+// @Produce      json
+// @Param        q							query  		string		true	"Text search"
+// @Param        lang						query  		string		false	"Language (default: by Accept-Language)"
+// @Param        skip						query  		integer		false	"Deprecated (use cursor)"
+// @Param        limit						query  		integer		false	"Limit (default: 20, max: 50)"
+// @Param        cursor						query  		string		false	"Cursor (X-Next-Cursor or X-Prev-Cursor of previous page)"
+// @Param        Accept-Language			header  	string		false	"Languages (fallback: en, vi)"
+// @Success      200						{array}		ProjectHit
+// @Header       200						{integer}	X-Total-Count		"Total of hit"
+// @Header       200						{string}	X-Next-Cursor		"Cursor of next page"
+// @Header       200						{string}	X-Prev-Cursor		"Cursor of previous page"
+// @Failure      400						{object}	Error
+// @Failure      500  						{object}	Error
+// @Router       /projects/search 			[get]
+func (ctrl *ProjectCtrl) Search(r *gin.Context) {
+	var payload = &domain.RProjectSearch{}
+	var err = r.Bind(payload)
+	if nil != err {
+		r.JSON(400, dmodels.ErrBadRequest(err.Error()))
+		return
+	}
+	payload.Langs = domain.LanguageChain(payload.Lang, r.GetHeader("Accept-Language"))
 
-	for _, v := range data.Images {
-		v.Image = ctrl.serverHost + v.Image
+	data, page, err := ctrl.repo.Search(payload)
+	if nil != err {
+		r.JSON(500, err)
+		return
 	}
 
+	for _, hit := range data {
+		ctrl.imageUrls(hit.Images...)
+	}
+	setPageHeader(r, page)
 	r.JSON(200, data)
 }
 
@@ -249,9 +361,14 @@ func (ctrl *ProjectCtrl) GetByID(r *gin.Context) {
 // @Tags         Project
 // @Accept       json
 // @Produce      json
-// @Param        skip		query		integer				true		"Skip"
-// @Param        limit		query		integer				true		"Limit"
+// @Param        skip		query		integer				false		"Deprecated (use cursor)"
+// @Param        limit		query		integer				false		"Limit (default: 20, max: 50)"
+// @Param        cursor		query		string				false		"Cursor (X-Next-Cursor or X-Prev-Cursor of previous page)"
+// @Param        owner		query		string				false		"Owner address"
 // @Success      200		{array}		Project
+// @Header       200		{integer}	X-Total-Count		"Total of project"
+// @Header       200		{string}	X-Next-Cursor		"Cursor of next page"
+// @Header       200		{string}	X-Prev-Cursor		"Cursor of previous page"
 // @Failure      400		{object}	Error
 // @Failure      404		{object}	Error
 // @Failure      500		{object}	Error
@@ -263,21 +380,26 @@ func (ctrl *ProjectCtrl) GetList(r *gin.Context) {
 		return
 	}
 
-	limit, err := strconv.ParseInt(r.DefaultQuery("skip", "0"), 10, 64)
+	limit, err := strconv.ParseInt(r.DefaultQuery("limit", "20"), 10, 64)
 	if nil != err {
 		r.JSON(400, dmodels.ErrBadRequest("limit must be int64"))
 		return
 	}
+	if limit <= 0 || limit > 50 {
+		limit = 20
+	}
 
 	owner := r.Query("owner")
-	data, err := ctrl.repo.GetList(&domain.RProjectFilter{
-		Skip:  int(skip),
-		Limit: int(limit),
-		Owner: owner,
+	data, page, err := ctrl.repo.GetList(&domain.RProjectFilter{
+		Cursor: r.Query("cursor"),
+		Skip:   int(skip),
+		Limit:  int(limit),
+		Owner:  owner,
 	})
 	if nil != err {
 		r.JSON(500, err)
 	} else {
+		setPageHeader(r, page)
 		r.JSON(200, data)
 	}
 
@@ -299,6 +421,10 @@ func (ctrl *ProjectCtrl) GetList(r *gin.Context) {
 // func (ctrl *ProjectCtrl) ChangeStatus(r *gin.Context) {
 // }
 
+func (ctrl *ProjectCtrl) GetProjectRepo() domain.IProject {
+	return ctrl.repo
+}
+
 func (ctrl *ProjectCtrl) isProjectOwner(r *gin.Context, projectId int64,
 ) error {
 	user, err := mids.GetAuth(r.Request.Context())
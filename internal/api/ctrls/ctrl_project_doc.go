@@ -0,0 +1,515 @@
+package ctrls
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/iott-cloud/internal/api/mids"
+	"github.com/Dcarbon/iott-cloud/internal/domain"
+	"github.com/Dcarbon/iott-cloud/internal/models"
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+)
+
+var errNoPrivateDocs = dmodels.ErrBadRequest(
+	"Storage has no private area for private document (STORAGE_DRIVER must be local)",
+)
+
+// Content type (detected) of document
+var docMimes = map[string]bool{
+	"application/pdf":           true,
+	"application/zip":           true, // docx, xlsx, odt, ...
+	"image/png":                 true,
+	"image/jpeg":                true,
+	"text/plain; charset=utf-8": true, // txt, csv
+}
+
+// CreateDoc godoc
+// @Summary      CreateDoc
+// @Description  Upload new document (first version) of project. Uploader: owner of project or verifier
+// @Tags         Project documents
+// @Accept       mpfd
+// @Produce      json
+// @Param        projectId						path		int			true	"Project id"
+// @Param        file							formData	file		true	"Document (pdf, office, png, jpeg, txt)"
+// @Param        type							formData	string		true	"pdd, monitoring_plan, monitoring_report, validation_report, verification_report, other"
+// @Param        title							formData	string		true	"Title"
+// @Param        access							formData	string		false	"public, private (default)"
+// @Param        note							formData	string		false	"Note of version"
+// @Param        Authorization					header		string		true	"Authorization token (`Bearer $token`)"
+// @Success      200							{object}	ProjectDoc
+// @Failure      400							{object}	Error
+// @Failure      403							{object}	Error
+// @Failure      500							{object}	Error
+// @Router       /projects/{projectId}/docs		[post]
+func (ctrl *ProjectCtrl) CreateDoc(r *gin.Context) {
+	projectId, err := strconv.ParseInt(r.Param("projectId"), 10, 64)
+	if nil != err {
+		r.JSON(400, dmodels.ErrBadRequest("projectId must be int64"))
+		return
+	}
+
+	var payload = &domain.RProjectDocCreate{}
+	err = r.Bind(payload)
+	if nil != err {
+		r.JSON(400, dmodels.ErrBadRequest(err.Error()))
+		return
+	}
+	payload.ProjectId = projectId
+
+	err = payload.Normalize()
+	if nil != err {
+		r.JSON(400, err)
+		return
+	}
+
+	if !ctrl.canAccessDocs(r, projectId, mids.PermProjectDocWrite) {
+		r.JSON(http.StatusForbidden, dmodels.ErrorPermissionDenied)
+		return
+	}
+
+	file, err := ctrl.saveDocFile(r, projectId, payload.Access)
+	if nil != err {
+		r.JSON(400, err)
+		return
+	}
+
+	doc, err := ctrl.docs.Create(payload, file)
+	if nil != err {
+		ctrl.removeDocFile(file)
+		r.JSON(500, err)
+		return
+	}
+	r.JSON(200, doc)
+}
+
+// AddDocVersion godoc
+// @Summary      AddDocVersion
+// @Description  Upload new version of document. Uploader: owner of project or verifier
+// @Tags         Project documents
+// @Accept       mpfd
+// @Produce      json
+// @Param        projectId										path		int			true	"Project id"
+// @Param        docId											path		int			true	"Document id"
+// @Param        file											formData	file		true	"Document (pdf, office, png, jpeg, txt)"
+// @Param        note											formData	string		false	"Note of version"
+// @Param        Authorization									header		string		true	"Authorization token (`Bearer $token`)"
+// @Success      200											{object}	ProjectDoc
+// @Failure      400											{object}	Error
+// @Failure      403											{object}	Error
+// @Failure      404											{object}	Error
+// @Failure      500											{object}	Error
+// @Router       /projects/{projectId}/docs/{docId}/versions	[post]
+func (ctrl *ProjectCtrl) AddDocVersion(r *gin.Context) {
+	projectId, docId, err := parseDocPath(r)
+	if nil != err {
+		r.JSON(400, err)
+		return
+	}
+
+	var payload = &domain.RProjectDocVersion{}
+	err = r.Bind(payload)
+	if nil != err {
+		r.JSON(400, dmodels.ErrBadRequest(err.Error()))
+		return
+	}
+	payload.ProjectId = projectId
+	payload.DocId = docId
+
+	if !ctrl.canAccessDocs(r, projectId, mids.PermProjectDocWrite) {
+		r.JSON(http.StatusForbidden, dmodels.ErrorPermissionDenied)
+		return
+	}
+
+	// File is stored by access of document
+	doc, _, err := ctrl.docs.GetVersion(projectId, docId, 0)
+	if nil != err {
+		r.JSON(500, err)
+		return
+	}
+
+	file, err := ctrl.saveDocFile(r, projectId, doc.Access)
+	if nil != err {
+		r.JSON(400, err)
+		return
+	}
+
+	doc, err = ctrl.docs.AddVersion(payload, file)
+	if nil != err {
+		ctrl.removeDocFile(file)
+		r.JSON(500, err)
+		return
+	}
+	r.JSON(200, doc)
+}
+
+// UpdateDoc godoc
+// @Summary      UpdateDoc
+// @Description  Change title or access of document (owner of project)
+// @Tags         Project documents
+// @Accept       json
+// @Produce      json
+// @Param        projectId							path		int					true	"Project id"
+// @Param        docId								path		int					true	"Document id"
+// @Param        payload							body		RProjectDocUpdate	true	"Title, access (empty: not changed)"
+// @Param        Authorization						header		string				true	"Authorization token (`Bearer $token`)"
+// @Success      200								{object}	ProjectDoc
+// @Failure      400								{object}	Error
+// @Failure      404								{object}	Error
+// @Failure      500								{object}	Error
+// @Router       /projects/{projectId}/docs/{docId}	[put]
+func (ctrl *ProjectCtrl) UpdateDoc(r *gin.Context) {
+	projectId, docId, err := parseDocPath(r)
+	if nil != err {
+		r.JSON(400, err)
+		return
+	}
+
+	var payload = &domain.RProjectDocUpdate{}
+	err = r.Bind(payload)
+	if nil != err {
+		r.JSON(400, dmodels.ErrBadRequest(err.Error()))
+		return
+	}
+	payload.ProjectId = projectId
+	payload.DocId = docId
+
+	err = ctrl.isProjectOwner(r, projectId)
+	if nil != err {
+		r.JSON(400, err)
+		return
+	}
+
+	// Files of public document are in public storage (no private area)
+	if payload.Access == models.ProjectDocPrivate && !ctrl.privateDocs {
+		r.JSON(400, errNoPrivateDocs)
+		return
+	}
+
+	doc, err := ctrl.docs.Update(payload)
+	if nil != err {
+		r.JSON(500, err)
+		return
+	}
+	r.JSON(200, doc)
+}
+
+// GetDocs godoc
+// @Summary      GetDocs
+// @Description  Documents of project (with latest version). Private documents are listed for owner of project and verifier
+// @Tags         Project documents
+// @Produce      json
+// @Param        projectId						path		int			true	"Project id"
+// @Param        type							query		string		false	"Document type"
+// @Param        Authorization					header		string		false	"Authorization token (`Bearer $token`)"
+// @Success      200							{array}		ProjectDoc
+// @Failure      400							{object}	Error
+// @Failure      500							{object}	Error
+// @Router       /projects/{projectId}/docs		[get]
+func (ctrl *ProjectCtrl) GetDocs(r *gin.Context) {
+	projectId, err := strconv.ParseInt(r.Param("projectId"), 10, 64)
+	if nil != err {
+		r.JSON(400, dmodels.ErrBadRequest("projectId must be int64"))
+		return
+	}
+
+	var payload = &domain.RProjectDocGetList{}
+	err = r.Bind(payload)
+	if nil != err {
+		r.JSON(400, dmodels.ErrBadRequest(err.Error()))
+		return
+	}
+	payload.ProjectId = projectId
+	payload.Private = ctrl.canAccessDocs(r, projectId, mids.PermProjectDocRead)
+
+	docs, err := ctrl.docs.GetList(payload)
+	if nil != err {
+		r.JSON(500, err)
+		return
+	}
+	r.JSON(200, docs)
+}
+
+// GetDoc godoc
+// @Summary      GetDoc
+// @Description  Document with all versions (newest first)
+// @Tags         Project documents
+// @Produce      json
+// @Param        projectId							path		int			true	"Project id"
+// @Param        docId								path		int			true	"Document id"
+// @Param        Authorization						header		string		false	"Authorization token (`Bearer $token`)"
+// @Success      200								{object}	ProjectDoc
+// @Failure      400								{object}	Error
+// @Failure      403								{object}	Error
+// @Failure      404								{object}	Error
+// @Failure      500								{object}	Error
+// @Router       /projects/{projectId}/docs/{docId}	[get]
+func (ctrl *ProjectCtrl) GetDoc(r *gin.Context) {
+	projectId, docId, err := parseDocPath(r)
+	if nil != err {
+		r.JSON(400, err)
+		return
+	}
+
+	doc, err := ctrl.docs.GetById(projectId, docId)
+	if nil != err {
+		r.JSON(500, err)
+		return
+	}
+
+	if !ctrl.canReadDoc(r, doc) {
+		r.JSON(http.StatusForbidden, dmodels.ErrorPermissionDenied)
+		return
+	}
+	r.JSON(200, doc)
+}
+
+// DownloadDoc godoc
+// @Summary      DownloadDoc
+// @Description  Download content of document version. Header X-Content-SHA256 is recorded hash of content
+// @Tags         Project documents
+// @Produce      octet-stream
+// @Param        projectId									path		int			true	"Project id"
+// @Param        docId										path		int			true	"Document id"
+// @Param        version									query		int			false	"Version (default: latest)"
+// @Param        token										query		string		false	"Authorization token (private document, instead of header)"
+// @Param        Authorization								header		string		false	"Authorization token (`Bearer $token`)"
+// @Success      200										{file}		file
+// @Failure      400										{object}	Error
+// @Failure      403										{object}	Error
+// @Failure      404										{object}	Error
+// @Failure      500										{object}	Error
+// @Router       /projects/{projectId}/docs/{docId}/download [get]
+func (ctrl *ProjectCtrl) DownloadDoc(r *gin.Context) {
+	projectId, docId, err := parseDocPath(r)
+	if nil != err {
+		r.JSON(400, err)
+		return
+	}
+
+	var version = 0
+	if v := r.Query("version"); v != "" {
+		version, err = strconv.Atoi(v)
+		if nil != err {
+			r.JSON(400, dmodels.ErrBadRequest("version must be int"))
+			return
+		}
+	}
+
+	doc, v, err := ctrl.docs.GetVersion(projectId, docId, version)
+	if nil != err {
+		r.JSON(500, err)
+		return
+	}
+
+	if !ctrl.canReadDoc(r, doc) {
+		r.JSON(http.StatusForbidden, dmodels.ErrorPermissionDenied)
+		return
+	}
+
+	file, err := ctrl.storage.Open(v.Path)
+	if nil != err {
+		r.JSON(500, dmodels.ErrInternal(err))
+		return
+	}
+	defer file.Close()
+
+	r.DataFromReader(200, v.Size, v.Mime, file, map[string]string{
+		"Content-Disposition": mime.FormatMediaType("attachment", map[string]string{"filename": v.FileName}),
+		"X-Content-SHA256":    v.Sha256,
+		"ETag":                `"` + v.Sha256 + `"`,
+	})
+}
+
+// VerifyDoc godoc
+// @Summary      VerifyDoc
+// @Description  Verify hash chain and signatures of versions (and content of storage if content=true)
+// @Tags         Project documents
+// @Produce      json
+// @Param        projectId									path		int			true	"Project id"
+// @Param        docId										path		int			true	"Document id"
+// @Param        content									query		bool		false	"Hash content of storage"
+// @Param        Authorization								header		string		false	"Authorization token (`Bearer $token`)"
+// @Success      200										{object}	RsDocVerify
+// @Failure      400										{object}	Error
+// @Failure      403										{object}	Error
+// @Failure      404										{object}	Error
+// @Failure      500										{object}	Error
+// @Router       /projects/{projectId}/docs/{docId}/verify	[get]
+func (ctrl *ProjectCtrl) VerifyDoc(r *gin.Context) {
+	projectId, docId, err := parseDocPath(r)
+	if nil != err {
+		r.JSON(400, err)
+		return
+	}
+
+	doc, err := ctrl.docs.GetById(projectId, docId)
+	if nil != err {
+		r.JSON(500, err)
+		return
+	}
+
+	if !ctrl.canReadDoc(r, doc) {
+		r.JSON(http.StatusForbidden, dmodels.ErrorPermissionDenied)
+		return
+	}
+
+	var rs = domain.VerifyDocVersions(doc)
+	if content, _ := strconv.ParseBool(r.Query("content")); content {
+		var byVersion = make(map[int]*models.ProjectDocVersion, len(doc.Versions))
+		for _, v := range doc.Versions {
+			byVersion[v.Version] = v
+		}
+
+		for _, check := range rs.Versions {
+			var valid = ctrl.hashDocFile(byVersion[check.Version].Path) == byVersion[check.Version].Sha256
+			check.ContentValid = &valid
+			rs.Valid = rs.Valid && valid
+		}
+	}
+	r.JSON(200, rs)
+}
+
+// Read uploaded file (form field "file"), check size & type and save it to
+// storage (private storage if storage has it, else only public document is
+// saved as project file)
+func (ctrl *ProjectCtrl) saveDocFile(r *gin.Context, projectId int64,
+	access models.ProjectDocAccess,
+) (*domain.DocFile, error) {
+	if access != models.ProjectDocPublic && !ctrl.privateDocs {
+		return nil, errNoPrivateDocs
+	}
+
+	user, err := mids.GetAuth(r.Request.Context())
+	if nil != err {
+		return nil, err
+	}
+
+	raw, header, err := readFormFile(r, "file", ctrl.maxDocSize)
+	if nil != err {
+		return nil, err
+	}
+
+	var fileName = filepath.Base(header.Filename)
+	var ext = strings.ToLower(filepath.Ext(fileName))
+	var mimeType = http.DetectContentType(raw)
+	if !docMimes[mimeType] {
+		return nil, dmodels.ErrBadRequest("Document type is not supported: " + mimeType)
+	}
+	if byExt := mime.TypeByExtension(ext); mimeType == "application/zip" && byExt != "" {
+		mimeType = byExt
+	}
+
+	var sum = sha256.Sum256(raw)
+	var file = &domain.DocFile{
+		FileName: fileName,
+		Mime:     mimeType,
+		Size:     int64(len(raw)),
+		Sha256:   hex.EncodeToString(sum[:]),
+		Uploader: dmodels.EthAddress(user.EthAddress),
+	}
+	// Private storage keeps public document too (access can be changed later)
+	var name = uuid.NewV4().String() + ext
+	if ctrl.privateDocs {
+		file.Path, err = ctrl.storage.PutPrivateFile(projectId, name, raw)
+	} else {
+		file.Path, err = ctrl.storage.PutProjectFile(projectId, name, raw)
+	}
+	if nil != err {
+		return nil, dmodels.ErrInternal(err)
+	}
+	return file, nil
+}
+
+// Remove file of version that was not saved (storage may not delete it)
+func (ctrl *ProjectCtrl) removeDocFile(file *domain.DocFile) {
+	if !ctrl.removeFiles(file.Path) {
+		log.Println("Keep file of unsaved document version: ", file.Path)
+	}
+}
+
+// Sha256 (hex) of file in storage (empty if file can't be read)
+func (ctrl *ProjectCtrl) hashDocFile(path string) string {
+	file, err := ctrl.storage.Open(path)
+	if nil != err {
+		return ""
+	}
+	defer file.Close()
+
+	var h = sha256.New()
+	_, err = io.Copy(h, file)
+	if nil != err {
+		return ""
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (ctrl *ProjectCtrl) canReadDoc(r *gin.Context, doc *models.ProjectDoc) bool {
+	return doc.Access == models.ProjectDocPublic ||
+		ctrl.canAccessDocs(r, doc.ProjectID, mids.PermProjectDocRead)
+}
+
+// Owner of project or user has perm (verifier, super-admin)
+func (ctrl *ProjectCtrl) canAccessDocs(r *gin.Context, projectId int64, perm string) bool {
+	user, err := mids.GetAuth(r.Request.Context())
+	if nil != err {
+		return false
+	}
+	if user.HasPerm(perm) {
+		return true
+	}
+
+	owner, err := ctrl.repo.GetOwner(projectId)
+	return nil == err && owner != "" &&
+		dmodels.EthAddress(user.EthAddress) == dmodels.EthAddress(owner)
+}
+
+func parseDocPath(r *gin.Context) (int64, int64, error) {
+	projectId, err := strconv.ParseInt(r.Param("projectId"), 10, 64)
+	if nil != err {
+		return 0, 0, dmodels.ErrBadRequest("projectId must be int64")
+	}
+
+	docId, err := strconv.ParseInt(r.Param("docId"), 10, 64)
+	if nil != err {
+		return 0, 0, dmodels.ErrBadRequest("docId must be int64")
+	}
+	return projectId, docId, nil
+}
+
+// Content of multipart file (max: bytes)
+func readFormFile(r *gin.Context, field string, maxSize int64,
+) ([]byte, *multipart.FileHeader, error) {
+	header, err := r.FormFile(field)
+	if nil != err {
+		return nil, nil, dmodels.ErrBadRequest("Missing " + field)
+	}
+	if header.Size > maxSize {
+		return nil, nil, dmodels.ErrBadRequest(fmt.Sprintf("File is too large (max: %d bytes)", maxSize))
+	}
+
+	file, err := header.Open()
+	if nil != err {
+		return nil, nil, dmodels.ErrBadRequest("Missing " + field)
+	}
+	defer file.Close()
+
+	raw, err := io.ReadAll(io.LimitReader(file, maxSize+1))
+	if nil != err {
+		return nil, nil, dmodels.ErrBadRequest("Read " + field + " error: " + err.Error())
+	}
+	if int64(len(raw)) > maxSize {
+		return nil, nil, dmodels.ErrBadRequest(fmt.Sprintf("File is too large (max: %d bytes)", maxSize))
+	}
+	return raw, header, nil
+}
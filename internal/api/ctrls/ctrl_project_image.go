@@ -0,0 +1,263 @@
+package ctrls
+
+import (
+	"context"
+	"log"
+	"strconv"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/iott-cloud/internal/domain"
+	"github.com/Dcarbon/iott-cloud/internal/imaging"
+	"github.com/Dcarbon/iott-cloud/internal/models"
+	"github.com/Dcarbon/iott-cloud/internal/storage"
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+)
+
+// GetImages godoc
+// @Summary      GetImages
+// @Description  Images of project (by position)
+// @Tags         Project
+// @Produce      json
+// @Param        projectId						path		int				true	"Project id"
+// @Success      200							{array}		ProjectImage
+// @Failure      400							{object}	Error
+// @Failure      500							{object}	Error
+// @Router       /projects/{projectId}/images	[get]
+func (ctrl *ProjectCtrl) GetImages(r *gin.Context) {
+	projectId, err := strconv.ParseInt(r.Param("projectId"), 10, 64)
+	if nil != err {
+		r.JSON(400, dmodels.ErrBadRequest("projectId must be int64"))
+		return
+	}
+
+	imgs, err := ctrl.repo.GetImages(projectId)
+	if nil != err {
+		r.JSON(500, err)
+		return
+	}
+
+	ctrl.imageUrls(imgs...)
+	r.JSON(200, imgs)
+}
+
+// ReorderImages godoc
+// @Summary      ReorderImages
+// @Description  Change order of images (ids must be all images of project)
+// @Tags         Project
+// @Accept       json
+// @Produce      json
+// @Param        projectId						path		int						true	"Project id"
+// @Param        payload						body		RProjectReorderImages	true	"Image ids in new order"
+// @Param        Authorization					header		string					true	"Authorization token (`Bearer $token`)"
+// @Success      200							{array}		ProjectImage
+// @Failure      400							{object}	Error
+// @Failure      404							{object}	Error
+// @Failure      500							{object}	Error
+// @Router       /projects/{projectId}/images	[put]
+func (ctrl *ProjectCtrl) ReorderImages(r *gin.Context) {
+	projectId, err := strconv.ParseInt(r.Param("projectId"), 10, 64)
+	if nil != err {
+		r.JSON(400, dmodels.ErrBadRequest("projectId must be int64"))
+		return
+	}
+
+	var payload = &domain.RProjectReorderImages{}
+	err = r.Bind(payload)
+	if nil != err {
+		r.JSON(400, dmodels.ErrBadRequest(err.Error()))
+		return
+	}
+	payload.ProjectId = projectId
+
+	err = ctrl.isProjectOwner(r, projectId)
+	if nil != err {
+		r.JSON(400, err)
+		return
+	}
+
+	imgs, err := ctrl.repo.ReorderImages(payload)
+	if nil != err {
+		r.JSON(500, err)
+		return
+	}
+
+	ctrl.imageUrls(imgs...)
+	r.JSON(200, imgs)
+}
+
+// SetCoverImage godoc
+// @Summary      SetCoverImage
+// @Description  Set cover image of project
+// @Tags         Project
+// @Produce      json
+// @Param        projectId									path		int			true	"Project id"
+// @Param        imageId									path		int			true	"Image id"
+// @Param        Authorization								header		string		true	"Authorization token (`Bearer $token`)"
+// @Success      200										{object}	ProjectImage
+// @Failure      400										{object}	Error
+// @Failure      404										{object}	Error
+// @Failure      500										{object}	Error
+// @Router       /projects/{projectId}/images/{imageId}/cover	[put]
+func (ctrl *ProjectCtrl) SetCoverImage(r *gin.Context) {
+	projectId, imageId, err := parseImagePath(r)
+	if nil != err {
+		r.JSON(400, err)
+		return
+	}
+
+	err = ctrl.isProjectOwner(r, projectId)
+	if nil != err {
+		r.JSON(400, err)
+		return
+	}
+
+	img, err := ctrl.repo.SetCoverImage(projectId, imageId)
+	if nil != err {
+		r.JSON(500, err)
+		return
+	}
+
+	ctrl.imageUrls(img)
+	r.JSON(200, img)
+}
+
+// DeleteImage godoc
+// @Summary      DeleteImage
+// @Description  Delete image (and thumbnails) of project. Next image becomes cover if image is cover.
+// @Description  filesKept: storage can't delete files (remote), image is removed from project only
+// @Tags         Project
+// @Produce      json
+// @Param        projectId								path		int			true	"Project id"
+// @Param        imageId								path		int			true	"Image id"
+// @Param        Authorization							header		string		true	"Authorization token (`Bearer $token`)"
+// @Success      200									{object}	ProjectImage
+// @Failure      400									{object}	Error
+// @Failure      404									{object}	Error
+// @Failure      500									{object}	Error
+// @Router       /projects/{projectId}/images/{imageId}	[delete]
+func (ctrl *ProjectCtrl) DeleteImage(r *gin.Context) {
+	projectId, imageId, err := parseImagePath(r)
+	if nil != err {
+		r.JSON(400, err)
+		return
+	}
+
+	err = ctrl.isProjectOwner(r, projectId)
+	if nil != err {
+		r.JSON(400, err)
+		return
+	}
+
+	img, err := ctrl.repo.DeleteImage(projectId, imageId)
+	if nil != err {
+		r.JSON(500, err)
+		return
+	}
+
+	img.FilesKept = !ctrl.removeImageFiles(img.Image, img.Thumbs)
+	ctrl.imageUrls(img)
+	r.JSON(200, img)
+}
+
+// Validate image, strip metadata (re-encode), generate thumbnails and save
+// all of them to storage. Wait for free slot (decoded image is large)
+func (ctrl *ProjectCtrl) processImage(ctx context.Context, projectId int64, raw []byte,
+) (*domain.RProjectAddImage, error) {
+	select {
+	case ctrl.imgSlots <- struct{}{}:
+		defer func() { <-ctrl.imgSlots }()
+	case <-ctx.Done():
+		return nil, dmodels.ErrBadRequest("Request was canceled")
+	}
+
+	img, err := imaging.Decode(raw, ctrl.imgLimits)
+	if nil != err {
+		return nil, dmodels.ErrBadRequest(err.Error())
+	}
+
+	data, err := img.Encode()
+	if nil != err {
+		return nil, dmodels.ErrInternal(err)
+	}
+
+	var name = uuid.NewV4().String()
+	var req = &domain.RProjectAddImage{
+		ProjectID: projectId,
+		Thumbs:    make(map[string]string, len(domain.ProjectThumbSizes)),
+		Mime:      img.Mime(),
+		Width:     img.Width,
+		Height:    img.Height,
+		Size:      int64(len(data)),
+	}
+	req.ImgPath, err = ctrl.storage.PutProjectFile(projectId, name+img.Ext(), data)
+	if nil != err {
+		return nil, dmodels.ErrInternal(err)
+	}
+
+	for size, edge := range domain.ProjectThumbSizes {
+		data, err = img.Thumbnail(edge).Encode()
+		if nil == err {
+			req.Thumbs[size], err = ctrl.storage.PutProjectFile(
+				projectId, name+"_"+size+img.Ext(), data,
+			)
+		}
+		if nil != err {
+			ctrl.removeImageFiles(req.ImgPath, req.Thumbs)
+			return nil, dmodels.ErrInternal(err)
+		}
+	}
+	return req, nil
+}
+
+// Remove files of image (and thumbnails) from storage. Return false if any
+// file is kept
+func (ctrl *ProjectCtrl) removeImageFiles(path string, thumbs map[string]string) bool {
+	var paths = []string{path}
+	for _, p := range thumbs {
+		paths = append(paths, p)
+	}
+	return ctrl.removeFiles(paths...)
+}
+
+// Remove files from storage (errors are logged). Return false if any file is
+// kept (error or storage can't delete)
+func (ctrl *ProjectCtrl) removeFiles(paths ...string) bool {
+	var removed = true
+	for _, p := range paths {
+		if p == "" {
+			continue
+		}
+		var err = ctrl.storage.Delete(p)
+		if nil != err {
+			removed = false
+			if err != storage.ErrNotSupported {
+				log.Println("Delete file ", p, " error: ", err)
+			}
+		}
+	}
+	return removed
+}
+
+// Path of image (and thumbnails) => url
+func (ctrl *ProjectCtrl) imageUrls(imgs ...*models.ProjectImage) {
+	for _, img := range imgs {
+		img.Image = ctrl.serverHost + img.Image
+		for size, p := range img.Thumbs {
+			img.Thumbs[size] = ctrl.serverHost + p
+		}
+	}
+}
+
+func parseImagePath(r *gin.Context) (int64, int64, error) {
+	projectId, err := strconv.ParseInt(r.Param("projectId"), 10, 64)
+	if nil != err {
+		return 0, 0, dmodels.ErrBadRequest("projectId must be int64")
+	}
+
+	imageId, err := strconv.ParseInt(r.Param("imageId"), 10, 64)
+	if nil != err {
+		return 0, 0, dmodels.ErrBadRequest("imageId must be int64")
+	}
+	return projectId, imageId, nil
+}
@@ -0,0 +1,80 @@
+package ctrls
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/iott-cloud/internal/domain"
+	"github.com/Dcarbon/iott-cloud/internal/repo"
+	"github.com/Dcarbon/iott-cloud/internal/report"
+	"github.com/gin-gonic/gin"
+)
+
+type ReportCtrl struct {
+	report domain.IReport
+}
+
+func NewReportCtrl() (*ReportCtrl, error) {
+	reportRepo, err := repo.NewReportRepo()
+	if nil != err {
+		return nil, err
+	}
+
+	var ctrl = &ReportCtrl{
+		report: reportRepo,
+	}
+	return ctrl, nil
+}
+
+// GetReport godoc
+// @Summary      GetReport
+// @Description  Audit report of project (iots, sensors, raw signed metrics, mint signatures, minted) in period
+// @Tags         Project
+// @Produce      json
+// @Produce      application/zip
+// @Produce      application/pdf
+// @Param        projectId			path		int							true	"Project id"
+// @Param        from				query		int							true	"From (unix second, inclusive)"
+// @Param        to					query		int							true	"To (unix second, exclusive)"
+// @Param        format				query		string						false	"json (default), csv (zip), pdf (summary)"
+// @Param        Authorization		header		string						true	"Authorization token (`Bearer $token`)"
+// @Success      200				{object}	report.Report
+// @Failure      400				{object}	Error
+// @Failure      500				{object}	Error
+// @Router       /projects/{projectId}/report	[get]
+func (ctrl *ReportCtrl) GetReport(r *gin.Context) {
+	projectId, err := strconv.ParseInt(r.Param("projectId"), 10, 64)
+	if nil != err {
+		r.JSON(400, dmodels.ErrBadRequest("Invalid project id (Must be integer)"))
+		return
+	}
+
+	var payload = &domain.RReport{}
+	err = r.Bind(payload)
+	if nil != err {
+		r.JSON(400, dmodels.ErrBadRequest(err.Error()))
+		return
+	}
+	payload.ProjectId = projectId
+
+	rp, err := ctrl.report.Generate(payload)
+	if nil != err {
+		r.JSON(500, err)
+		return
+	}
+
+	// Written directly to response (status can't be changed after error)
+	r.Header("Content-Disposition", fmt.Sprintf(
+		`attachment; filename="project-%d-%d-%d.%s"`,
+		projectId, payload.From, payload.To, payload.Format.Ext(),
+	))
+	r.Header("Content-Type", payload.Format.ContentType())
+	r.Status(200)
+	err = report.Write(r.Writer, rp, payload.Format)
+	if nil != err {
+		log.Printf("Write report of project %d error: %s\n", projectId, err)
+		r.Abort()
+	}
+}
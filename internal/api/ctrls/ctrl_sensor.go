@@ -1,17 +1,24 @@
 package ctrls
 
 import (
+	"compress/gzip"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/Dcarbon/go-shared/dmodels"
 	"github.com/Dcarbon/go-shared/ecodes"
 	"github.com/Dcarbon/iott-cloud/internal/domain"
+	"github.com/Dcarbon/iott-cloud/internal/export"
 	"github.com/Dcarbon/iott-cloud/internal/repo"
 	"github.com/gin-gonic/gin"
 )
 
+const exportFlushRows = 1000 // Flush response of export every n rows
+
 type SensorCtrl struct {
 	iotRepo    domain.IIot
 	sensorRepo domain.ISensor
@@ -129,11 +136,15 @@ func (ctrl *SensorCtrl) GetSensor(r *gin.Context) {
 // @Tags         Sensors
 // @Accept       json
 // @Produce      json
-// @Param        skip				query		int					false	"Skip"
+// @Param        skip				query		int					false	"Deprecated (use cursor)"
 // @Param        limit				query		int					false	"Limit"
+// @Param        cursor				query		string				false	"Cursor (X-Next-Cursor or X-Prev-Cursor of previous page)"
 // @Param        iot_id				query		int					false	"IOT id, only use iot_id or iot_address"
 // @Param        iot_address		query		string				false	"IOT address, only use iot_id or iot_address"
 // @Success      200				{array}		Sensor
+// @Header       200				{integer}	X-Total-Count		"Total of sensor"
+// @Header       200				{string}	X-Next-Cursor		"Cursor of next page"
+// @Header       200				{string}	X-Prev-Cursor		"Cursor of previous page"
 // @Failure      400				{object}	Error
 // @Failure      404				{object}	Error
 // @Failure      500				{object}	Error
@@ -157,14 +168,16 @@ func (ctrl *SensorCtrl) GetSensors(r *gin.Context) {
 		iotId = iot.ID
 	}
 
-	sensors, err := ctrl.sensorRepo.GetSensors(&domain.RGetSensors{
-		Skip:  int(skip),
-		Limit: int(limit),
-		IotId: iotId,
+	sensors, page, err := ctrl.sensorRepo.GetSensors(&domain.RGetSensors{
+		Cursor: r.Query("cursor"),
+		Skip:   int(skip),
+		Limit:  int(limit),
+		IotId:  iotId,
 	})
 	if nil != err {
 		r.JSON(500, err)
 	} else {
+		setPageHeader(r, page)
 		r.JSON(http.StatusOK, sensors)
 	}
 }
@@ -268,11 +281,15 @@ func (ctrl *SensorCtrl) CreateSMBySign(r *gin.Context) {
 // @Param        from				query		int  			true	"From unix (second)"
 // @Param        to					query		int  			true	"To unix (second)"
 // @Param        iotId				query		int  			true	"Iot id"
-// @Param        skip				query		int  			false	"Skip"
+// @Param        skip				query		int  			false	"Deprecated (use cursor)"
 // @Param        limit				query		int  			true	"Limit (max: 50)"
+// @Param        cursor				query		string 			false	"Cursor (X-Next-Cursor or X-Prev-Cursor of previous page)"
 // @Param        sensorId			query		int  			false	"Sensor id"
 // @Param        sort				query		int  			false	"Sort (0: asc, 1: desc)"
 // @Success      200				{object}	SensorMetrics
+// @Header       200				{integer}	X-Total-Count	"Total of metric"
+// @Header       200				{string}	X-Next-Cursor	"Cursor of next page"
+// @Header       200				{string}	X-Prev-Cursor	"Cursor of previous page"
 // @Failure      400				{object}	Error
 // @Failure      404				{object}	Error
 // @Failure      500				{object}	Error
@@ -285,14 +302,103 @@ func (ctrl *SensorCtrl) GetMetrics(r *gin.Context) {
 		return
 	}
 
-	metrics, err := ctrl.sensorRepo.GetMetrics(payload)
+	metrics, page, err := ctrl.sensorRepo.GetMetrics(payload)
 	if nil != err {
 		r.JSON(500, err)
 	} else {
+		setPageHeader(r, page)
 		r.JSON(http.StatusOK, &SensorMetrics{Metrics: metrics})
 	}
 }
 
+// ExportMetrics godoc
+// @Summary      ExportSensorMetrics
+// @Description  Stream all metrics of iot in period (csv, ndjson or parquet)
+// @Tags         Sensors
+// @Produce      text/csv
+// @Produce      application/x-ndjson
+// @Produce      application/vnd.apache.parquet
+// @Param        from				query		int  			true	"From unix (second, inclusive)"
+// @Param        to					query		int  			true	"To unix (second, exclusive)"
+// @Param        iotId				query		int  			true	"Iot id"
+// @Param        sensorId			query		int  			false	"Sensor id"
+// @Param        format				query		string 			false	"csv (default), ndjson, parquet"
+// @Param        withSign			query		bool 			false	"Include raw data & signature"
+// @Param        gzip				query		bool 			false	"Gzip response (also by Accept-Encoding)"
+// @Param        Authorization		header		string			true	"Authorization token (`Bearer $token`)"
+// @Success      200				{array}		ExportMetric
+// @Failure      400				{object}	Error
+// @Failure      429				{object}	Error
+// @Failure      500				{object}	Error
+// @Router       /sensors/sm/export		[get]
+func (ctrl *SensorCtrl) ExportMetrics(r *gin.Context) {
+	var payload = &domain.RExportSM{}
+	var err = r.Bind(payload)
+	if nil != err {
+		r.JSON(400, dmodels.ErrBadRequest(err.Error()))
+		return
+	}
+
+	var gz *gzip.Writer
+	var ew export.Writer
+	var rows = 0
+	var start = func() error {
+		var name = fmt.Sprintf("iot-%d-%d-%d.%s", payload.IotId, payload.From, payload.To, payload.Format)
+		var out io.Writer = r.Writer
+		if payload.Gzip || strings.Contains(r.GetHeader("Accept-Encoding"), "gzip") {
+			r.Header("Content-Encoding", "gzip")
+			gz = gzip.NewWriter(r.Writer)
+			out = gz
+		}
+		r.Header("Content-Type", payload.Format.ContentType())
+		r.Header("Content-Disposition", `attachment; filename="`+name+`"`)
+		r.Status(http.StatusOK)
+
+		var err error
+		ew, err = export.NewWriter(out, payload.Format, payload.WithSign)
+		return err
+	}
+
+	// Response is started by first row: error of request is still json
+	err = ctrl.sensorRepo.ExportMetrics(payload, func(m *export.Metric) error {
+		if nil == ew {
+			if err := start(); nil != err {
+				return err
+			}
+		}
+
+		rows++
+		if rows%exportFlushRows == 0 {
+			if nil != gz {
+				gz.Flush()
+			}
+			r.Writer.Flush()
+		}
+		return ew.Write(m)
+	})
+	if nil != err && nil == ew {
+		r.JSON(500, err)
+		return
+	}
+	if nil != err {
+		log.Println("Export metrics error: ", payload.IotId, rows, err)
+		return
+	}
+
+	if nil == ew {
+		err = start()
+	}
+	if nil == err {
+		err = ew.Close()
+	}
+	if nil == err && nil != gz {
+		err = gz.Close()
+	}
+	if nil != err {
+		log.Println("Export metrics error: ", payload.IotId, rows, err)
+	}
+}
+
 // Create godoc
 // @Summary			GetSensorAggregatedMetrics
 // @Description		Get sensor aggregated metrics (by day or month)
@@ -302,8 +408,12 @@ func (ctrl *SensorCtrl) GetMetrics(r *gin.Context) {
 // @Param			from						query		int				true	"From unix (second)"
 // @Param			to							query		int				true	"To unix (second)"
 // @Param			iotId						query		int				true	"Iot id"
-// @Param			sensorId					query		int				false	"Sensor id"
-// @Param			interval					query		number			false	"Interval: 1 : day 2: month"
+// @Param			sensorId					query		int				true	"Sensor id"
+// @Param			interval					query		number			false	"Deprecated (use bucket). Interval: 1 : day 2: month"
+// @Param			bucket						query		string			false	"Bucket: hour, day, week, month, quarter, year"
+// @Param			func						query		string			false	"Aggregate function: sum, avg, min, max, count, last (default: sum)"
+// @Param			tz							query		string			false	"IANA timezone (default: UTC)"
+// @Param			fill						query		string			false	"Fill empty bucket: null, zero"
 // @Success			200							{array}		TimeValue
 // @Failure			400							{object}	Error
 // @Failure			404							{object}	Error
@@ -325,6 +435,42 @@ func (ctrl *SensorCtrl) GetAggregatedMetrics(r *gin.Context) {
 	}
 }
 
+// Create godoc
+// @Summary			AggregateMetrics
+// @Description		Aggregate metrics of multiple sensors by time bucket
+// @Tags			Sensors
+// @Accept			json
+// @Produce			json
+// @Param			from							query		int				true	"From unix (second)"
+// @Param			to								query		int				true	"To unix (second)"
+// @Param			iotId							query		int				true	"Iot id"
+// @Param			sensorId						query		int				false	"Sensor id"
+// @Param			sensorIds						query		[]int			false	"Sensor ids"
+// @Param			bucket							query		string			false	"Bucket: hour, day, week, month, quarter, year (default: day)"
+// @Param			func							query		string			false	"Aggregate function: sum, avg, min, max, count, last (default: sum)"
+// @Param			tz								query		string			false	"IANA timezone (default: UTC)"
+// @Param			fill							query		string			false	"Fill empty bucket: null, zero"
+// @Success			200								{array}		AggSeries
+// @Failure			400								{object}	Error
+// @Failure			404								{object}	Error
+// @Failure			500								{object}	Error
+// @Router			/sensors/sm/aggregate/series	[get]
+func (ctrl *SensorCtrl) AggregateMetrics(r *gin.Context) {
+	var payload = &domain.RSMAggregate{}
+	var err = r.Bind(payload)
+	if nil != err {
+		r.JSON(400, dmodels.ErrBadRequest(err.Error()))
+		return
+	}
+
+	series, err := ctrl.sensorRepo.AggregateMetrics(payload)
+	if nil != err {
+		r.JSON(500, err)
+	} else {
+		r.JSON(http.StatusOK, series)
+	}
+}
+
 type SensorMetrics struct {
 	Metrics []*domain.Metric `json:"metrics"`
 }
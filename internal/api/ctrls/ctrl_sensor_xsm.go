@@ -64,6 +64,9 @@ func (ctrl *XSMCtrl) Create(r *gin.Context) {
 // @Produce      json
 // @Param        sensor				body		RXSMGetList	true	"Payload"
 // @Success      200				{array}		models.XSMetric
+// @Header       200				{integer}	X-Total-Count	"Total of metric"
+// @Header       200				{string}	X-Next-Cursor	"Cursor of next page"
+// @Header       200				{string}	X-Prev-Cursor	"Cursor of previous page"
 // @Failure      400				{object}	Error
 // @Failure      404				{object}	Error
 // @Failure      500				{object}	Error
@@ -77,11 +80,13 @@ func (ctrl *XSMCtrl) GetList(r *gin.Context) {
 	}
 
 	var resp []*models.XSMetric
-	resp, err = ctrl.ixsm.GetList(payload)
+	var page *domain.PageInfo
+	resp, page, err = ctrl.ixsm.GetList(payload)
 	if nil != err {
 		r.JSON(500, dmodels.ErrBadRequest(err.Error()))
 		return
 	}
 
+	setPageHeader(r, page)
 	r.JSON(http.StatusOK, resp)
 }
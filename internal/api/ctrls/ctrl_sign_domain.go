@@ -0,0 +1,103 @@
+package ctrls
+
+import (
+	"strconv"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/go-shared/libs/esign"
+	"github.com/Dcarbon/iott-cloud/internal/domain"
+	"github.com/Dcarbon/iott-cloud/internal/repo"
+	"github.com/gin-gonic/gin"
+)
+
+type SignDomainCtrl struct {
+	domains domain.ISignDomain
+}
+
+// config: domain from env (registered & default when there is no domain)
+func NewSignDomainCtrl(config *esign.TypedDataDomain) (*SignDomainCtrl, error) {
+	domains, err := repo.NewSignDomainRepo(config)
+	if nil != err {
+		return nil, err
+	}
+
+	var ctrl = &SignDomainCtrl{
+		domains: domains,
+	}
+	return ctrl, nil
+}
+
+func (ctrl *SignDomainCtrl) GetSignDomainRepo() domain.ISignDomain {
+	return ctrl.domains
+}
+
+// Create godoc
+// @Summary      Create
+// @Description  Register EIP712 domain (chain & version of carbon contract)
+// @Tags         Domains
+// @Accept       json
+// @Produce      json
+// @Param        payload			body		domain.RSignDomainCreate	true	"Domain"
+// @Param        Authorization		header		string						true	"Authorization token (`Bearer $token`)"
+// @Success      200				{object}	models.SignDomain
+// @Failure      400				{object}	Error
+// @Failure      500				{object}	Error
+// @Router       /domains/			[post]
+func (ctrl *SignDomainCtrl) Create(r *gin.Context) {
+	var payload = &domain.RSignDomainCreate{}
+	var err = r.Bind(payload)
+	if nil != err {
+		r.JSON(400, dmodels.ErrBadRequest(err.Error()))
+		return
+	}
+
+	sd, err := ctrl.domains.Create(payload)
+	if nil != err {
+		r.JSON(500, err)
+		return
+	}
+	r.JSON(200, sd)
+}
+
+// GetList godoc
+// @Summary      GetList
+// @Description  Get list sign domain
+// @Tags         Domains
+// @Produce      json
+// @Success      200				{array}		models.SignDomain
+// @Failure      500				{object}	Error
+// @Router       /domains/			[get]
+func (ctrl *SignDomainCtrl) GetList(r *gin.Context) {
+	data, err := ctrl.domains.GetList()
+	if nil != err {
+		r.JSON(500, err)
+		return
+	}
+	r.JSON(200, data)
+}
+
+// SetDefault godoc
+// @Summary      SetDefault
+// @Description  Set default domain (for new iot of project has no domain)
+// @Tags         Domains
+// @Produce      json
+// @Param        id						path		int					true	"Domain id"
+// @Param        Authorization			header		string				true	"Authorization token (`Bearer $token`)"
+// @Success      200					{object}	Empty
+// @Failure      400					{object}	Error
+// @Failure      500					{object}	Error
+// @Router       /domains/{id}/default	[put]
+func (ctrl *SignDomainCtrl) SetDefault(r *gin.Context) {
+	id, err := strconv.ParseInt(r.Param("id"), 10, 64)
+	if nil != err {
+		r.JSON(400, dmodels.ErrBadRequest("Invalid domain id (Must be integer)"))
+		return
+	}
+
+	err = ctrl.domains.SetDefault(id)
+	if nil != err {
+		r.JSON(500, err)
+		return
+	}
+	r.JSON(200, Empty{})
+}
@@ -0,0 +1,280 @@
+package ctrls
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/iott-cloud/internal/api/mids"
+	"github.com/Dcarbon/iott-cloud/internal/domain"
+	"github.com/Dcarbon/iott-cloud/internal/env"
+	"github.com/Dcarbon/iott-cloud/internal/models"
+	"github.com/Dcarbon/iott-cloud/internal/repo"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	streamMaxIots      = 500              // Max iot per subscription
+	streamPingInterval = 30 * time.Second // Websocket ping & sse heartbeat
+	streamWriteTimeout = 10 * time.Second //
+)
+
+type StreamCtrl struct {
+	iot      domain.IIot
+	project  domain.IProject
+	stream   domain.IOpStream
+	origins  map[string]bool // Allowed origin (lower case) of browser client
+	upgrader websocket.Upgrader
+}
+
+func NewStreamCtrl(iot domain.IIot, project domain.IProject) (*StreamCtrl, error) {
+	stream, err := repo.NewOpStream()
+	if nil != err {
+		return nil, err
+	}
+
+	var ctrl = &StreamCtrl{
+		iot:     iot,
+		project: project,
+		stream:  stream,
+		origins: make(map[string]bool),
+	}
+	for _, origin := range strings.Split(env.StreamOrigins, ",") {
+		origin = strings.TrimSpace(origin)
+		if origin != "" {
+			ctrl.origins[strings.ToLower(strings.TrimSuffix(origin, "/"))] = true
+		}
+	}
+	ctrl.upgrader = websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 4096,
+		CheckOrigin:     ctrl.checkOrigin,
+	}
+	return ctrl, nil
+}
+
+// Stream godoc
+// @Summary      Stream
+// @Description  Stream operator status and sensor metric of iots. Use websocket if request is upgrade request, otherwise server-sent events
+// @Tags         Operator
+// @Produce      json
+// @Param        iotIds			query		string			false	"Iot ids (comma separated)"
+// @Param        projectId		query		int				false	"Project id (subscribe all iot of project)"
+// @Param        token			query		string			false	"Authorization token (for client can't set header)"
+// @Success      200			{object}	models.OpEvent
+// @Failure      400			{object}	Error
+// @Failure      403			{object}	Error
+// @Failure      500			{object}	Error
+// @Router       /op/stream		[get]
+func (ctrl *StreamCtrl) Stream(r *gin.Context) {
+	if !ctrl.checkOrigin(r.Request) {
+		r.JSON(http.StatusForbidden, dmodels.ErrorPermissionDenied)
+		return
+	}
+
+	user, err := mids.GetAuth(r.Request.Context())
+	if nil != err {
+		r.JSON(401, err)
+		return
+	}
+
+	iotIds, err := ctrl.getIotIds(r, user)
+	if nil != err {
+		if err == dmodels.ErrorPermissionDenied {
+			r.JSON(http.StatusForbidden, err)
+		} else {
+			r.JSON(400, err)
+		}
+		return
+	}
+
+	events, cancel := ctrl.stream.Subscribe(iotIds)
+	defer cancel()
+
+	if websocket.IsWebSocketUpgrade(r.Request) {
+		ctrl.serveWS(r, events)
+	} else {
+		ctrl.serveSSE(r, events)
+	}
+}
+
+func (ctrl *StreamCtrl) serveWS(r *gin.Context, events <-chan *models.OpEvent) {
+	conn, err := ctrl.upgrader.Upgrade(r.Writer, r.Request, nil)
+	if nil != err {
+		log.Println("Upgrade websocket error: ", err)
+		return
+	}
+	defer conn.Close()
+
+	// Client don't send anything, read only for detect closed
+	var closed = make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); nil != err {
+				return
+			}
+		}
+	}()
+
+	var ticker = time.NewTicker(streamPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case evt, ok := <-events:
+			if !ok {
+				conn.WriteControl(
+					websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "slow consumer"),
+					time.Now().Add(streamWriteTimeout),
+				)
+				return
+			}
+			conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+			if err := conn.WriteJSON(evt); nil != err {
+				return
+			}
+		case <-ticker.C:
+			err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamWriteTimeout))
+			if nil != err {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
+
+func (ctrl *StreamCtrl) serveSSE(r *gin.Context, events <-chan *models.OpEvent) {
+	r.Header("Content-Type", "text/event-stream")
+	r.Header("Cache-Control", "no-cache")
+	r.Header("Connection", "keep-alive")
+	r.Header("X-Accel-Buffering", "no")
+	r.Status(http.StatusOK)
+	r.Writer.Flush()
+
+	var ticker = time.NewTicker(streamPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case evt, ok := <-events:
+			if !ok {
+				r.SSEvent("close", "slow consumer")
+				r.Writer.Flush()
+				return
+			}
+			r.SSEvent(string(evt.Type), evt)
+			r.Writer.Flush()
+		case <-ticker.C:
+			r.Writer.WriteString(": ping\n\n")
+			r.Writer.Flush()
+		case <-r.Request.Context().Done():
+			return
+		}
+	}
+}
+
+// Iots of query. User without PermOpStream can stream iots of own projects only
+func (ctrl *StreamCtrl) getIotIds(r *gin.Context, user *mids.ClaimModel) ([]int64, error) {
+	var owner = dmodels.EthAddress("")
+	if !user.HasPerm(mids.PermOpStream) {
+		owner = dmodels.EthAddress(user.EthAddress)
+	}
+
+	var iotIds = make([]int64, 0)
+	var seen = make(map[int64]bool)
+	for _, str := range strings.Split(r.Query("iotIds"), ",") {
+		str = strings.TrimSpace(str)
+		if str == "" {
+			continue
+		}
+
+		id, err := strconv.ParseInt(str, 10, 64)
+		if nil != err {
+			return nil, dmodels.ErrBadRequest("Invalid iot id (Must be integer)")
+		}
+		if !seen[id] {
+			seen[id] = true
+			iotIds = append(iotIds, id)
+		}
+	}
+
+	if len(iotIds) > streamMaxIots {
+		return nil, dmodels.ErrBadRequest("Too many iot (max: " + strconv.Itoa(streamMaxIots) + ")")
+	}
+
+	if len(iotIds) > 0 && owner != "" {
+		owned, err := ctrl.iot.GetIotPositions(&domain.RIotGetList{
+			IotFilter: domain.IotFilter{IdIn: iotIds, Owner: owner},
+		})
+		if nil != err {
+			return nil, err
+		}
+		if len(owned) != len(iotIds) {
+			return nil, dmodels.ErrorPermissionDenied
+		}
+	}
+
+	if r.Query("projectId") != "" {
+		projectId, err := strconv.ParseInt(r.Query("projectId"), 10, 64)
+		if nil != err {
+			return nil, dmodels.ErrBadRequest("Invalid project id (Must be integer)")
+		}
+
+		if owner != "" {
+			projectOwner, err := ctrl.project.GetOwner(projectId)
+			if nil != err {
+				return nil, err
+			}
+			if dmodels.EthAddress(projectOwner) != owner {
+				return nil, dmodels.ErrorPermissionDenied
+			}
+		}
+
+		iots, err := ctrl.iot.GetIotPositions(&domain.RIotGetList{
+			IotFilter: domain.IotFilter{ProjectId: projectId},
+		})
+		if nil != err {
+			return nil, err
+		}
+		for _, iot := range iots {
+			if !seen[iot.Id] {
+				seen[iot.Id] = true
+				iotIds = append(iotIds, iot.Id)
+			}
+		}
+	}
+
+	if len(iotIds) == 0 {
+		return nil, dmodels.ErrBadRequest("Missing iotIds or projectId")
+	}
+
+	if len(iotIds) > streamMaxIots {
+		return nil, dmodels.ErrBadRequest("Too many iot (max: " + strconv.Itoa(streamMaxIots) + ")")
+	}
+	return iotIds, nil
+}
+
+// Request without Origin (not browser) or from server host or allowed origin
+func (ctrl *StreamCtrl) checkOrigin(req *http.Request) bool {
+	var origin = req.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if nil != err {
+		return false
+	}
+	if strings.EqualFold(u.Host, req.Host) {
+		return true
+	}
+	return ctrl.origins[strings.ToLower(origin)]
+}
@@ -0,0 +1,231 @@
+package ctrls
+
+import (
+	"strconv"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/iott-cloud/internal/api/mids"
+	"github.com/Dcarbon/iott-cloud/internal/domain"
+	"github.com/Dcarbon/iott-cloud/internal/repo"
+	"github.com/Dcarbon/iott-cloud/internal/rss"
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookCtrl struct {
+	webhook domain.IWebhook
+}
+
+func NewWebhookCtrl() (*WebhookCtrl, error) {
+	webhook, err := repo.NewWebhookRepo()
+	if nil != err {
+		return nil, err
+	}
+	go repo.NewWebhookWorker(webhook).Run()
+
+	relay, err := repo.NewOutboxRelay(rss.GetRabbitPusher(), webhook)
+	if nil != err {
+		return nil, err
+	}
+	go relay.Run()
+
+	var ctrl = &WebhookCtrl{
+		webhook: webhook,
+	}
+	return ctrl, nil
+}
+
+func (ctrl *WebhookCtrl) GetWebhookRepo() domain.IWebhook {
+	return ctrl.webhook
+}
+
+// Create godoc
+// @Summary      Create
+// @Description  Create webhook subscription. Secret (for verify X-DCarbon-Signature) is only returned here
+// @Tags         Webhooks
+// @Accept       json
+// @Produce      json
+// @Param        payload			body		domain.RWebhookCreate		true	"Subscription"
+// @Param        Authorization		header		string						true	"Authorization token (`Bearer $token`)"
+// @Success      200				{object}	models.WebhookSub
+// @Failure      400				{object}	Error
+// @Failure      500				{object}	Error
+// @Router       /webhooks/			[post]
+func (ctrl *WebhookCtrl) Create(r *gin.Context) {
+	var payload = &domain.RWebhookCreate{}
+	var err = r.Bind(payload)
+	if nil != err {
+		r.JSON(400, dmodels.ErrBadRequest(err.Error()))
+		return
+	}
+
+	user, err := mids.GetAuth(r.Request.Context())
+	if nil != err {
+		r.JSON(401, err)
+		return
+	}
+	payload.CreatedBy = user.ID
+
+	sub, err := ctrl.webhook.CreateSub(payload)
+	if nil != err {
+		r.JSON(500, err)
+		return
+	}
+	r.JSON(200, sub)
+}
+
+// Update godoc
+// @Summary      Update
+// @Description  Update webhook subscription
+// @Tags         Webhooks
+// @Accept       json
+// @Produce      json
+// @Param        id					path		int							true	"Subscription id"
+// @Param        payload			body		domain.RWebhookUpdate		true	"Subscription"
+// @Param        Authorization		header		string						true	"Authorization token (`Bearer $token`)"
+// @Success      200				{object}	models.WebhookSub
+// @Failure      400				{object}	Error
+// @Failure      404				{object}	Error
+// @Failure      500				{object}	Error
+// @Router       /webhooks/{id}		[put]
+func (ctrl *WebhookCtrl) Update(r *gin.Context) {
+	id, err := strconv.ParseInt(r.Param("id"), 10, 64)
+	if nil != err {
+		r.JSON(400, dmodels.ErrBadRequest("Invalid webhook id (Must be integer)"))
+		return
+	}
+
+	var payload = &domain.RWebhookUpdate{}
+	err = r.Bind(payload)
+	if nil != err {
+		r.JSON(400, dmodels.ErrBadRequest(err.Error()))
+		return
+	}
+	payload.Id = id
+
+	sub, err := ctrl.webhook.UpdateSub(payload)
+	if nil != err {
+		r.JSON(500, err)
+		return
+	}
+	r.JSON(200, sub)
+}
+
+// Delete godoc
+// @Summary      Delete
+// @Description  Delete webhook subscription
+// @Tags         Webhooks
+// @Produce      json
+// @Param        id					path		int							true	"Subscription id"
+// @Param        Authorization		header		string						true	"Authorization token (`Bearer $token`)"
+// @Success      200				{object}	Empty
+// @Failure      400				{object}	Error
+// @Failure      500				{object}	Error
+// @Router       /webhooks/{id}		[delete]
+func (ctrl *WebhookCtrl) Delete(r *gin.Context) {
+	id, err := strconv.ParseInt(r.Param("id"), 10, 64)
+	if nil != err {
+		r.JSON(400, dmodels.ErrBadRequest("Invalid webhook id (Must be integer)"))
+		return
+	}
+
+	err = ctrl.webhook.DeleteSub(id)
+	if nil != err {
+		r.JSON(500, err)
+		return
+	}
+	r.JSON(200, Empty{})
+}
+
+// GetList godoc
+// @Summary      GetList
+// @Description  Get list webhook subscription
+// @Tags         Webhooks
+// @Produce      json
+// @Param        projectId			query		int							false	"Project id"
+// @Param        skip				query		int							false	"Skip"
+// @Param        limit				query		int							false	"Limit (max: 50)"
+// @Param        Authorization		header		string						true	"Authorization token (`Bearer $token`)"
+// @Success      200				{array}		models.WebhookSub
+// @Failure      400				{object}	Error
+// @Failure      500				{object}	Error
+// @Router       /webhooks/			[get]
+func (ctrl *WebhookCtrl) GetList(r *gin.Context) {
+	var payload = &domain.RWebhookGetList{}
+	var err = r.Bind(payload)
+	if nil != err {
+		r.JSON(400, dmodels.ErrBadRequest(err.Error()))
+		return
+	}
+
+	subs, err := ctrl.webhook.GetSubs(payload)
+	if nil != err {
+		r.JSON(500, err)
+		return
+	}
+	r.JSON(200, subs)
+}
+
+// GetDeliveries godoc
+// @Summary      GetDeliveries
+// @Description  Delivery log of webhook subscription (latest first)
+// @Tags         Webhooks
+// @Produce      json
+// @Param        id						path		int						true	"Subscription id"
+// @Param        status					query		string					false	"Status (pending, success, dead)"
+// @Param        event					query		string					false	"Event type"
+// @Param        skip					query		int						false	"Skip"
+// @Param        limit					query		int						false	"Limit (max: 50)"
+// @Param        Authorization			header		string					true	"Authorization token (`Bearer $token`)"
+// @Success      200					{array}		models.WebhookDelivery
+// @Failure      400					{object}	Error
+// @Failure      500					{object}	Error
+// @Router       /webhooks/{id}/deliveries	[get]
+func (ctrl *WebhookCtrl) GetDeliveries(r *gin.Context) {
+	id, err := strconv.ParseInt(r.Param("id"), 10, 64)
+	if nil != err {
+		r.JSON(400, dmodels.ErrBadRequest("Invalid webhook id (Must be integer)"))
+		return
+	}
+
+	var payload = &domain.RWebhookDeliveryGetList{}
+	err = r.Bind(payload)
+	if nil != err {
+		r.JSON(400, dmodels.ErrBadRequest(err.Error()))
+		return
+	}
+	payload.SubId = id
+
+	deliveries, err := ctrl.webhook.GetDeliveries(payload)
+	if nil != err {
+		r.JSON(500, err)
+		return
+	}
+	r.JSON(200, deliveries)
+}
+
+// Redeliver godoc
+// @Summary      Redeliver
+// @Description  Requeue delivery (e.g. from dead letter) for new attempt
+// @Tags         Webhooks
+// @Produce      json
+// @Param        id								path		int					true	"Subscription id"
+// @Param        deliveryId						path		string				true	"Delivery id"
+// @Param        Authorization					header		string				true	"Authorization token (`Bearer $token`)"
+// @Success      200							{object}	models.WebhookDelivery
+// @Failure      400							{object}	Error
+// @Failure      500							{object}	Error
+// @Router       /webhooks/{id}/deliveries/{deliveryId}/redeliver	[post]
+func (ctrl *WebhookCtrl) Redeliver(r *gin.Context) {
+	id, err := strconv.ParseInt(r.Param("id"), 10, 64)
+	if nil != err {
+		r.JSON(400, dmodels.ErrBadRequest("Invalid webhook id (Must be integer)"))
+		return
+	}
+
+	delivery, err := ctrl.webhook.Redeliver(id, r.Param("deliveryId"))
+	if nil != err {
+		r.JSON(500, err)
+		return
+	}
+	r.JSON(200, delivery)
+}
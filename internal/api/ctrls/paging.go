@@ -0,0 +1,30 @@
+package ctrls
+
+import (
+	"strconv"
+
+	"github.com/Dcarbon/iott-cloud/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+// Page info of list endpoints is returned by headers (body still is array)
+const (
+	HeaderTotalCount = "X-Total-Count"
+	HeaderNextCursor = "X-Next-Cursor"
+	HeaderPrevCursor = "X-Prev-Cursor"
+)
+
+func setPageHeader(r *gin.Context, page *domain.PageInfo) {
+	if page == nil {
+		return
+	}
+
+	r.Header("Access-Control-Expose-Headers", HeaderTotalCount+", "+HeaderNextCursor+", "+HeaderPrevCursor)
+	r.Header(HeaderTotalCount, strconv.FormatInt(page.Total, 10))
+	if page.Next != "" {
+		r.Header(HeaderNextCursor, page.Next)
+	}
+	if page.Prev != "" {
+		r.Header(HeaderPrevCursor, page.Prev)
+	}
+}
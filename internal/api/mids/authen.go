@@ -18,10 +18,24 @@ var ctxKey = new(int)
 
 var rolesTable = map[string]map[string]bool{
 	"admin": {
-		"": true,
+		"":           true,
+		PermOpStream: true,
+	},
+	"verifier": {
+		PermProjectDocRead:  true,
+		PermProjectDocWrite: true,
 	},
 }
 
+// Stream operator status of any iot (owner of project can stream own iots)
+const PermOpStream = "op-stream"
+
+// Permissions of project documents (owner of project has them on own project)
+const (
+	PermProjectDocRead  = "project-doc-read"  // Read private document of any project
+	PermProjectDocWrite = "project-doc-write" // Upload document to any project
+)
+
 type customClaim struct {
 	jwt.StandardClaims
 	*ClaimModel
@@ -36,8 +50,10 @@ type ClaimModel struct {
 
 // Check authen and permission
 type A2M struct {
-	jwtKey string
-	perm   string
+	jwtKey     string
+	perm       string
+	allowQuery bool // Accept token from query (?token=). For websocket, event source
+	optional   bool // Request without token is passed (anonymous)
 }
 
 func NewA2(jwtKey string, perm string) *A2M {
@@ -48,8 +64,31 @@ func NewA2(jwtKey string, perm string) *A2M {
 	return a2
 }
 
+// Same as NewA2 but token can be passed by query param "token"
+// (for client can't set header: websocket, event source)
+func NewA2Query(jwtKey string, perm string) *A2M {
+	var a2 = NewA2(jwtKey, perm)
+	a2.allowQuery = true
+	return a2
+}
+
+// Same as NewA2 but request without token is passed (handler checks
+// GetAuth). Token is accepted from query param "token" (download link)
+func NewA2Optional(jwtKey string) *A2M {
+	var a2 = NewA2Query(jwtKey, "")
+	a2.optional = true
+	return a2
+}
+
 func (a2 *A2M) HandlerFunc(r *gin.Context) {
 	var authToken = r.GetHeader("Authorization")
+	if authToken == "" && a2.allowQuery && r.Query("token") != "" {
+		authToken = "Bearer " + r.Query("token")
+	}
+	if authToken == "" && a2.optional {
+		return
+	}
+
 	var idx = strings.Index(authToken, "Bearer ")
 	if idx != 0 && len(authToken) < 10 {
 		r.AbortWithError(http.StatusUnauthorized, dmodels.ErrorUnauthorized)
@@ -113,13 +152,17 @@ func EncodeJWT(key string, user *models.User, duration int64) (string, error) {
 }
 
 func GetAuth(ctx context.Context) (*ClaimModel, error) {
-	var user = ctx.Value(ctxKey).(*ClaimModel)
+	var user, _ = ctx.Value(ctxKey).(*ClaimModel)
 	if nil == user {
 		return nil, dmodels.ErrorUnauthorized
 	}
 	return user, nil
 }
 
+func (c *ClaimModel) HasPerm(perm string) bool {
+	return hasPerm(c.Role, perm) == nil
+}
+
 func hasPerm(role string, perm string) error {
 	if perm == "" {
 		return nil
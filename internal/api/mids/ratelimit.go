@@ -0,0 +1,73 @@
+package mids
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/go-shared/ecodes"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+)
+
+const keyRateLimit = "ratelimit:%s:%s:%d" // name, user (or ip), window
+
+// Fixed window rate limit by user (or client ip if request has no auth).
+// Must be after A2 to limit by user
+type RateLimit struct {
+	redis  *redis.Client
+	name   string
+	limit  int64 // Requests per window. <= 0: no limit
+	window time.Duration
+}
+
+func NewRateLimit(client *redis.Client, name string, limit int64, window time.Duration,
+) *RateLimit {
+	if window < time.Second {
+		window = time.Second
+	}
+	return &RateLimit{
+		redis:  client,
+		name:   name,
+		limit:  limit,
+		window: window,
+	}
+}
+
+func (rl *RateLimit) HandlerFunc(r *gin.Context) {
+	if rl.limit <= 0 {
+		return
+	}
+
+	var who = "ip-" + r.ClientIP()
+	if user, ok := r.Request.Context().Value(ctxKey).(*ClaimModel); ok && nil != user {
+		who = "user-" + strconv.FormatInt(user.ID, 10)
+	}
+
+	var seconds = int64(rl.window / time.Second)
+	var window = time.Now().Unix() / seconds
+	var key = fmt.Sprintf(keyRateLimit, rl.name, who, window)
+
+	var ctx = r.Request.Context()
+	var pipe = rl.redis.TxPipeline()
+	var count = pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, rl.window)
+	_, err := pipe.Exec(ctx)
+	if nil != err {
+		// Redis is down: allow request
+		log.Println("Rate limit error: ", rl.name, err)
+		return
+	}
+
+	if count.Val() > rl.limit {
+		var retry = (window+1)*seconds - time.Now().Unix()
+		r.Header("Retry-After", strconv.FormatInt(retry, 10))
+		r.AbortWithStatusJSON(
+			http.StatusTooManyRequests,
+			dmodels.NewError(ecodes.PermissionDenied, "Too many requests, retry after "+strconv.FormatInt(retry, 10)+"s"),
+		)
+	}
+}
@@ -2,12 +2,16 @@ package routers
 
 import (
 	"log"
+	"time"
 
 	"github.com/Dcarbon/go-shared/libs/esign"
+	"github.com/Dcarbon/go-shared/libs/utils"
 	"github.com/Dcarbon/iott-cloud/internal/api/ctrls"
 	"github.com/Dcarbon/iott-cloud/internal/api/mids"
+	"github.com/Dcarbon/iott-cloud/internal/env"
 	"github.com/Dcarbon/iott-cloud/internal/models"
 	"github.com/Dcarbon/iott-cloud/internal/rss"
+	"github.com/Dcarbon/iott-cloud/internal/storage"
 	"github.com/gin-gonic/gin"
 )
 
@@ -24,16 +28,36 @@ type Config struct {
 	StorageHost string
 }
 
+// EIP712 domain of carbon contract (mint signature)
+func (config Config) CarbonDomain() *esign.TypedDataDomain {
+	return &esign.TypedDataDomain{
+		Name:              "CARBON",
+		ChainId:           config.ChainID,
+		Version:           config.CarbonVersion,
+		VerifyingContract: config.CarbonAddress,
+	}
+}
+
 type Router struct {
 	*gin.Engine
 	config       Config
 	auth         *mids.A2M
+	domainCtrl   *ctrls.SignDomainCtrl
 	iotCtrl      *ctrls.IotCtrl
+	oracleCtrl   *ctrls.OracleCtrl
 	projectCtrl  *ctrls.ProjectCtrl
 	userCtrl     *ctrls.UserCtrl
 	sensorCtrl   *ctrls.SensorCtrl
 	xsmCtrl      *ctrls.XSMCtrl
 	operatorCtrl *ctrls.OperatorCtrl
+	streamCtrl   *ctrls.StreamCtrl
+	alertCtrl    *ctrls.AlertCtrl
+	webhookCtrl  *ctrls.WebhookCtrl
+	eventCtrl    *ctrls.EventCtrl
+	chainCtrl    *ctrls.ChainCtrl
+	bundleCtrl   *ctrls.BundleCtrl
+	reportCtrl   *ctrls.ReportCtrl
+	platformCtrl *ctrls.PlatformCtrl
 	versionCtrl  *ctrls.VersionCtrl
 }
 
@@ -61,14 +85,17 @@ func NewRouter(config Config,
 	// 	return nil, err
 	// }
 
-	iotCtrl, err := ctrls.NewIotCtrl(
-		&esign.TypedDataDomain{
-			Name:              "CARBON",
-			ChainId:           config.ChainID,
-			Version:           config.CarbonVersion,
-			VerifyingContract: config.CarbonAddress,
-		},
-	)
+	domainCtrl, err := ctrls.NewSignDomainCtrl(config.CarbonDomain())
+	if nil != err {
+		return nil, err
+	}
+
+	iotCtrl, err := ctrls.NewIotCtrl(domainCtrl.GetSignDomainRepo())
+	if nil != err {
+		return nil, err
+	}
+
+	oracleCtrl, err := ctrls.NewOracleCtrl(domainCtrl.GetSignDomainRepo())
 	if nil != err {
 		return nil, err
 	}
@@ -93,6 +120,50 @@ func NewRouter(config Config,
 	if nil != err {
 		return nil, err
 	}
+	iotCtrl.GetIOTRepo().SetOperator(opCtrl.GetOperatorRepo())
+
+	streamCtrl, err := ctrls.NewStreamCtrl(iotCtrl.GetIOTRepo(), projectCtrl.GetProjectRepo())
+	if nil != err {
+		return nil, err
+	}
+
+	alertCtrl, err := ctrls.NewAlertCtrl(iotCtrl.GetIOTRepo())
+	if nil != err {
+		return nil, err
+	}
+	iotCtrl.GetIOTRepo().SetAlerter(alertCtrl.GetAlertRepo())
+	sensorCtrl.GetSensorRepo().SetAlerter(alertCtrl.GetAlertRepo())
+	opCtrl.GetOperatorRepo().SetAlerter(alertCtrl.GetAlertRepo())
+
+	webhookCtrl, err := ctrls.NewWebhookCtrl()
+	if nil != err {
+		return nil, err
+	}
+
+	eventCtrl, err := ctrls.NewEventCtrl()
+	if nil != err {
+		return nil, err
+	}
+
+	chainCtrl, err := ctrls.NewChainCtrl(iotCtrl.GetIOTRepo(), domainCtrl.GetSignDomainRepo())
+	if nil != err {
+		return nil, err
+	}
+
+	bundleCtrl, err := ctrls.NewBundleCtrl()
+	if nil != err {
+		return nil, err
+	}
+
+	reportCtrl, err := ctrls.NewReportCtrl()
+	if nil != err {
+		return nil, err
+	}
+
+	platformCtrl, err := ctrls.NewPlatformCtrl()
+	if nil != err {
+		return nil, err
+	}
 
 	// signVerifier := mids.NewSignedAuth()
 
@@ -100,17 +171,30 @@ func NewRouter(config Config,
 		Engine:       gin.Default(),
 		auth:         &mids.A2M{},
 		config:       config,
+		domainCtrl:   domainCtrl,
 		iotCtrl:      iotCtrl,
+		oracleCtrl:   oracleCtrl,
 		projectCtrl:  projectCtrl,
 		userCtrl:     userCtrl,
 		sensorCtrl:   sensorCtrl,
 		xsmCtrl:      xsmCtrl,
 		operatorCtrl: opCtrl,
+		streamCtrl:   streamCtrl,
+		alertCtrl:    alertCtrl,
+		webhookCtrl:  webhookCtrl,
+		eventCtrl:    eventCtrl,
+		chainCtrl:    chainCtrl,
+		bundleCtrl:   bundleCtrl,
+		reportCtrl:   reportCtrl,
+		platformCtrl: platformCtrl,
 		versionCtrl:  verCtrl,
 	}
 
 	r.Engine.MaxMultipartMemory = 25 << 20
 	r.Use(mids.GetCORS())
+	if env.StorageDriver == storage.DriverLocal {
+		r.Static(storage.LocalPrefix, env.StorageLocalDir)
+	}
 
 	var v1 = r.Group("/api/v1")
 	v1.GET("/ping", func(c *gin.Context) {
@@ -132,9 +216,22 @@ func NewRouter(config Config,
 
 		iotRoute.GET("/:iotId", iotCtrl.GetIot)
 		iotRoute.GET("/:iotId/minted", iotCtrl.GetMinted)
+		iotRoute.GET("/:iotId/minted/series", iotCtrl.GetMintedSeries)
 		iotRoute.GET("/:iotId/mint-sign", iotCtrl.GetMintSigns)
 		iotRoute.GET("/:iotId/is-actived", iotCtrl.IsActived)
 		iotRoute.GET("/:iotId/mint-sign/latest", iotCtrl.GetMintSignsLatest)
+		iotRoute.GET("/:iotId/mint-sign/unclaimed", iotCtrl.GetMintUnclaimed)
+		iotRoute.GET("/:iotId/oracle/audits", oracleCtrl.GetAudits)
+		iotRoute.PUT(
+			"/:iotId/oracle",
+			mids.NewA2(config.JwtKey, "iot-oracle").HandlerFunc,
+			oracleCtrl.SetEnable,
+		)
+		iotRoute.POST(
+			"/:iotId/oracle/mint",
+			mids.NewA2(config.JwtKey, "iot-oracle").HandlerFunc,
+			oracleCtrl.Mint,
+		)
 
 		iotRoute.GET("/seperator", iotCtrl.GetDomainSeperator)
 		iotRoute.GET("/geojson", iotCtrl.GetIotPosition)
@@ -143,6 +240,11 @@ func NewRouter(config Config,
 		iotRoute.GET("/list", iotCtrl.GetIots)
 
 		iotRoute.POST("/:iotAddr/mint-sign", iotCtrl.CreateMint)
+		iotRoute.POST(
+			"/:iotAddr/mint-sign/report",
+			mids.NewA2(config.JwtKey, "mint-report").HandlerFunc,
+			iotCtrl.ReportMintTx,
+		)
 
 		// iotRoute.GET("/by-bb", iotCtrl.GetByBB)
 		// iotRoute.POST("/:iotAddr/metrics", iotCtrl.CreateMetric)
@@ -169,7 +271,17 @@ func NewRouter(config Config,
 		sensorRoute.POST("/sm/create-sign", sensorCtrl.CreateSMBySign)
 
 		sensorRoute.GET("/sm", sensorCtrl.GetMetrics)
+		sensorRoute.GET("/sm/export",
+			mids.NewA2(config.JwtKey, "sensor-export").HandlerFunc,
+			mids.NewRateLimit(
+				rss.GetRedis(), "sensor-export",
+				utils.Int64Env("EXPORT_RATE_LIMIT", 10),
+				time.Duration(utils.Int64Env("EXPORT_RATE_WINDOW", 3600))*time.Second,
+			).HandlerFunc,
+			sensorCtrl.ExportMetrics,
+		)
 		sensorRoute.GET("/sm/aggregate", sensorCtrl.GetAggregatedMetrics)
+		sensorRoute.GET("/sm/aggregate/series", sensorCtrl.AggregateMetrics)
 
 		sensorRoute.POST("/xsm", xsmCtrl.Create)
 		sensorRoute.GET("/xsm", xsmCtrl.GetList)
@@ -179,6 +291,73 @@ func NewRouter(config Config,
 	{
 		opRoute.GET("/status/:iotId", opCtrl.GetStatus)
 		opRoute.GET("/metrics/:iotId", opCtrl.GetMetrics)
+		opRoute.GET("/health", opCtrl.GetHealth)
+		opRoute.GET("/overview", opCtrl.GetOverview)
+		opRoute.GET(
+			"/stream",
+			mids.NewA2Query(config.JwtKey, "").HandlerFunc,
+			streamCtrl.Stream,
+		)
+	}
+
+	var alertRoute = v1.Group("/alerts")
+	{
+		var alertAuth = mids.NewA2(config.JwtKey, "alert-manage").HandlerFunc
+
+		alertRoute.GET("/", alertAuth, alertCtrl.GetAlerts)
+		alertRoute.GET("/rules", alertAuth, alertCtrl.GetRules)
+		alertRoute.POST("/rules", alertAuth, alertCtrl.CreateRule)
+		alertRoute.PUT("/rules/:id", alertAuth, alertCtrl.UpdateRule)
+		alertRoute.DELETE("/rules/:id", alertAuth, alertCtrl.DeleteRule)
+	}
+
+	var webhookRoute = v1.Group("/webhooks")
+	{
+		var webhookAuth = mids.NewA2(config.JwtKey, "webhook-manage").HandlerFunc
+
+		webhookRoute.POST("/", webhookAuth, webhookCtrl.Create)
+		webhookRoute.GET("/", webhookAuth, webhookCtrl.GetList)
+		webhookRoute.PUT("/:id", webhookAuth, webhookCtrl.Update)
+		webhookRoute.DELETE("/:id", webhookAuth, webhookCtrl.Delete)
+		webhookRoute.GET("/:id/deliveries", webhookAuth, webhookCtrl.GetDeliveries)
+		webhookRoute.POST("/:id/deliveries/:deliveryId/redeliver", webhookAuth, webhookCtrl.Redeliver)
+	}
+
+	var eventRoute = v1.Group("/events")
+	{
+		eventRoute.GET("/", eventCtrl.GetCatalogue)
+		eventRoute.GET("/:name", eventCtrl.GetSchema)
+	}
+
+	var domainRoute = v1.Group("/domains")
+	{
+		var domainAuth = mids.NewA2(config.JwtKey, "domain-manage").HandlerFunc
+
+		domainRoute.GET("/", domainCtrl.GetList)
+		domainRoute.POST("/", domainAuth, domainCtrl.Create)
+		domainRoute.PUT("/:id/default", domainAuth, domainCtrl.SetDefault)
+		domainRoute.PUT("/:id/iots", domainAuth, iotCtrl.MoveDomain)
+	}
+
+	var chainRoute = v1.Group("/chain")
+	{
+		var chainAuth = mids.NewA2(config.JwtKey, "chain-manage").HandlerFunc
+
+		chainRoute.GET("/mints", chainCtrl.GetMints)
+		chainRoute.GET("/discrepancies", chainAuth, chainCtrl.GetDiscrepancies)
+		chainRoute.PUT("/discrepancies/:id/resolve", chainAuth, chainCtrl.ResolveDiscrepancy)
+	}
+
+	var bundleRoute = v1.Group("/bundles")
+	{
+		bundleRoute.GET("/", bundleCtrl.GetBundles)
+		bundleRoute.GET("/proof", bundleCtrl.GetProof)
+	}
+
+	var platformRoute = v1.Group("/platform")
+	{
+		platformRoute.GET("/stats", platformCtrl.GetStats)
+		platformRoute.GET("/leaderboard", platformCtrl.GetLeaderboard)
 	}
 
 	var projectRoute = v1.Group("/projects")
@@ -207,7 +386,40 @@ func NewRouter(config Config,
 		)
 
 		projectRoute.GET("/", projectCtrl.GetList)
+		projectRoute.GET("/search", projectCtrl.Search)
 		projectRoute.GET("/:projectId", projectCtrl.GetByID)
+		projectRoute.GET("/:projectId/stats", projectCtrl.GetStats)
+		projectRoute.GET("/:projectId/images", projectCtrl.GetImages)
+		projectRoute.PUT(
+			"/:projectId/images",
+			mids.NewA2(config.JwtKey, "").HandlerFunc,
+			projectCtrl.ReorderImages,
+		)
+		projectRoute.PUT(
+			"/:projectId/images/:imageId/cover",
+			mids.NewA2(config.JwtKey, "").HandlerFunc,
+			projectCtrl.SetCoverImage,
+		)
+		projectRoute.DELETE(
+			"/:projectId/images/:imageId",
+			mids.NewA2(config.JwtKey, "").HandlerFunc,
+			projectCtrl.DeleteImage,
+		)
+
+		var docAuth = mids.NewA2(config.JwtKey, "").HandlerFunc
+		var docRead = mids.NewA2Optional(config.JwtKey).HandlerFunc
+		projectRoute.POST("/:projectId/docs", docAuth, projectCtrl.CreateDoc)
+		projectRoute.GET("/:projectId/docs", docRead, projectCtrl.GetDocs)
+		projectRoute.GET("/:projectId/docs/:docId", docRead, projectCtrl.GetDoc)
+		projectRoute.PUT("/:projectId/docs/:docId", docAuth, projectCtrl.UpdateDoc)
+		projectRoute.POST("/:projectId/docs/:docId/versions", docAuth, projectCtrl.AddDocVersion)
+		projectRoute.GET("/:projectId/docs/:docId/download", docRead, projectCtrl.DownloadDoc)
+		projectRoute.GET("/:projectId/docs/:docId/verify", docRead, projectCtrl.VerifyDoc)
+		projectRoute.GET(
+			"/:projectId/report",
+			mids.NewA2(config.JwtKey, "project-report").HandlerFunc,
+			reportCtrl.GetReport,
+		)
 
 		// projectRoute.GET("/by-bb", projectCtrl.GetByBB)
 		// projectRoute.PUT("/:projectId/change-status", projectCtrl.ChangeStatus)
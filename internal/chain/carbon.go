@@ -0,0 +1,113 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Mint event of carbon contract: Mint(address indexed iot, uint256 nonce, uint256 amount)
+const carbonABI = `[{
+	"anonymous": false,
+	"name": "Mint",
+	"type": "event",
+	"inputs": [
+		{ "indexed": true, "name": "iot", "type": "address" },
+		{ "indexed": false, "name": "nonce", "type": "uint256" },
+		{ "indexed": false, "name": "amount", "type": "uint256" }
+	]
+}]`
+
+var carbon = mustParseABI(carbonABI)
+
+var ErrNotMintLog = errors.New("log is not mint event")
+
+type MintLog struct {
+	Iot         common.Address
+	Nonce       *big.Int
+	Amount      *big.Int
+	BlockNumber uint64
+	BlockHash   common.Hash
+	TxHash      common.Hash
+	LogIndex    uint
+}
+
+// Log of contract can't be parsed as mint event
+type InvalidLog struct {
+	Log types.Log
+	Err error
+}
+
+// Topic (event id) of mint event
+func MintTopic() common.Hash {
+	return carbon.Events["Mint"].ID
+}
+
+func ParseMintLog(log *types.Log) (*MintLog, error) {
+	if len(log.Topics) != 2 || log.Topics[0] != MintTopic() {
+		return nil, ErrNotMintLog
+	}
+
+	values, err := carbon.Unpack("Mint", log.Data)
+	if nil != err {
+		return nil, err
+	}
+
+	var mint = &MintLog{
+		Iot:         common.BytesToAddress(log.Topics[1].Bytes()),
+		BlockNumber: log.BlockNumber,
+		BlockHash:   log.BlockHash,
+		TxHash:      log.TxHash,
+		LogIndex:    log.Index,
+	}
+	mint.Nonce, _ = values[0].(*big.Int)
+	mint.Amount, _ = values[1].(*big.Int)
+	if nil == mint.Nonce || nil == mint.Amount {
+		return nil, ErrNotMintLog
+	}
+	return mint, nil
+}
+
+// Mint events of contract in block range [from, to]. Log can't be parsed is
+// returned in invalid (it doesn't fail the range)
+func FetchMints(ctx context.Context, client IClient, contract common.Address, from, to uint64,
+) ([]*MintLog, []*InvalidLog, error) {
+	logs, err := client.FilterLogs(ctx, ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(from),
+		ToBlock:   new(big.Int).SetUint64(to),
+		Addresses: []common.Address{contract},
+		Topics:    [][]common.Hash{{MintTopic()}},
+	})
+	if nil != err {
+		return nil, nil, err
+	}
+
+	var mints = make([]*MintLog, 0, len(logs))
+	var invalid = make([]*InvalidLog, 0)
+	for i := range logs {
+		if logs[i].Removed {
+			continue
+		}
+		mint, err := ParseMintLog(&logs[i])
+		if nil != err {
+			invalid = append(invalid, &InvalidLog{Log: logs[i], Err: err})
+			continue
+		}
+		mints = append(mints, mint)
+	}
+	return mints, invalid, nil
+}
+
+func mustParseABI(raw string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(raw))
+	if nil != err {
+		panic("Parse carbon abi error: " + err.Error())
+	}
+	return parsed
+}
@@ -0,0 +1,62 @@
+package chain_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Dcarbon/iott-cloud/internal/chain"
+	"github.com/Dcarbon/iott-cloud/internal/chain/chaintest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestFetchMints(t *testing.T) {
+	sim, err := chaintest.NewSim()
+	if nil != err {
+		t.Fatalf("Create simulated chain error: %s", err)
+	}
+	defer sim.Close()
+
+	var iot = common.HexToAddress("0xe445517abb524002bb04c96f96abb87b8b19b53d")
+	if err = sim.Mint(iot, 1, 9e9); nil != err {
+		t.Fatalf("Mint error: %s", err)
+	}
+	if err = sim.Mint(iot, 2, 12e9); nil != err {
+		t.Fatalf("Mint error: %s", err)
+	}
+
+	var ctx = context.Background()
+	head, err := sim.Client.BlockNumber(ctx)
+	if nil != err {
+		t.Fatalf("Get block number error: %s", err)
+	}
+
+	mints, invalid, err := chain.FetchMints(ctx, sim.Client, sim.Contract, 0, head)
+	if nil != err || len(invalid) != 0 {
+		t.Fatalf("Fetch mints error: %v (invalid: %d)", err, len(invalid))
+	}
+	if len(mints) != 2 {
+		t.Fatalf("Expect 2 mints, got %d", len(mints))
+	}
+	if mints[0].Iot != iot || mints[0].Nonce.Int64() != 1 || mints[0].Amount.Int64() != 9e9 {
+		t.Fatalf("Invalid mint: %+v", mints[0])
+	}
+	if mints[1].Nonce.Int64() != 2 || mints[1].BlockNumber <= mints[0].BlockNumber {
+		t.Fatalf("Invalid mint: %+v", mints[1])
+	}
+
+	// Other contract
+	mints, _, err = chain.FetchMints(ctx, sim.Client, common.HexToAddress("0x01"), 0, head)
+	if nil != err || len(mints) != 0 {
+		t.Fatalf("Expect no mint of other contract, got %d (%v)", len(mints), err)
+	}
+}
+
+func TestParseMintLogInvalid(t *testing.T) {
+	var _, err = chain.ParseMintLog(&types.Log{
+		Topics: []common.Hash{common.HexToHash("0x01"), common.HexToHash("0x02")},
+	})
+	if err != chain.ErrNotMintLog {
+		t.Fatalf("Expect ErrNotMintLog, got %v", err)
+	}
+}
@@ -0,0 +1,112 @@
+// Simulated chain with contract emit mint event of carbon contract (for test)
+package chaintest
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+
+	"github.com/Dcarbon/iott-cloud/internal/chain"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+)
+
+type Sim struct {
+	*simulated.Backend
+	Client   simulated.Client
+	Contract common.Address // Mint emitter
+
+	key  *ecdsa.PrivateKey
+	from common.Address
+}
+
+func NewSim() (*Sim, error) {
+	key, err := crypto.GenerateKey()
+	if nil != err {
+		return nil, err
+	}
+
+	var from = crypto.PubkeyToAddress(key.PublicKey)
+	var backend = simulated.NewBackend(types.GenesisAlloc{
+		from: {Balance: new(big.Int).Mul(big.NewInt(1e18), big.NewInt(100))},
+	})
+
+	var sim = &Sim{
+		Backend: backend,
+		Client:  backend.Client(),
+		key:     key,
+		from:    from,
+	}
+	sim.Contract, err = sim.send(nil, mintEmitter())
+	if nil != err {
+		backend.Close()
+		return nil, err
+	}
+	return sim, nil
+}
+
+// Emit mint event in new block
+func (sim *Sim) Mint(iot common.Address, nonce, amount int64) error {
+	var data = common.LeftPadBytes(iot.Bytes(), 32)
+	data = append(data, common.LeftPadBytes(big.NewInt(nonce).Bytes(), 32)...)
+	data = append(data, common.LeftPadBytes(big.NewInt(amount).Bytes(), 32)...)
+
+	var _, err = sim.send(&sim.Contract, data)
+	return err
+}
+
+// Send tx & commit block. Return address of contract was created by tx
+func (sim *Sim) send(to *common.Address, data []byte) (common.Address, error) {
+	var ctx = context.Background()
+	chainId, err := sim.Client.ChainID(ctx)
+	if nil != err {
+		return common.Address{}, err
+	}
+
+	nonce, err := sim.Client.PendingNonceAt(ctx, sim.from)
+	if nil != err {
+		return common.Address{}, err
+	}
+
+	tx, err := types.SignNewTx(sim.key, types.LatestSignerForChainID(chainId), &types.DynamicFeeTx{
+		ChainID:   chainId,
+		Nonce:     nonce,
+		GasTipCap: big.NewInt(1e9),
+		GasFeeCap: big.NewInt(1e11),
+		Gas:       200000,
+		To:        to,
+		Data:      data,
+	})
+	if nil != err {
+		return common.Address{}, err
+	}
+
+	err = sim.Client.SendTransaction(ctx, tx)
+	if nil != err {
+		return common.Address{}, err
+	}
+	sim.Commit()
+	return crypto.CreateAddress(sim.from, nonce), nil
+}
+
+// Contract emit Mint(calldata[0:32] as iot, calldata[32:64], calldata[64:96])
+// for any call. Init code copy runtime (at offset 11) to memory & return it
+func mintEmitter() []byte {
+	var runtime = append(hexutil.MustDecode("0x366000600037600035"), 0x7f)
+	runtime = append(runtime, chain.MintTopic().Bytes()...)
+	runtime = append(runtime, hexutil.MustDecode("0x60406020a200")...)
+
+	var code = []byte{
+		0x60, byte(len(runtime)), // PUSH1 len
+		0x80,       // DUP1
+		0x60, 0x0b, // PUSH1 11 (len of init code)
+		0x60, 0x00, // PUSH1 0
+		0x39,       // CODECOPY
+		0x60, 0x00, // PUSH1 0
+		0xf3, // RETURN
+	}
+	return append(code, runtime...)
+}
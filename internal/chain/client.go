@@ -0,0 +1,23 @@
+// Read state of carbon contract from chain
+package chain
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// Json-rpc methods was used by indexer. Implemented by ethclient.Client and
+// simulated.Client (for test)
+type IClient interface {
+	BlockNumber(ctx context.Context) (uint64, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error)
+}
+
+func Dial(url string) (IClient, error) {
+	return ethclient.Dial(url)
+}
@@ -0,0 +1,241 @@
+// Consume commands from other services over rabbitmq. Command is routed by
+// routing key (see domain.Cmd*) to handler. Failed message is republished to
+// retry queue (delayed by ttl) and moved to dead queue after max retry
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/go-shared/ecodes"
+	"github.com/Dcarbon/go-shared/libs/rabbit"
+	"github.com/Dcarbon/go-shared/libs/utils"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+const (
+	HeaderRetry      = "x-retry"       // Num of failed attempts
+	HeaderError      = "x-error"       // Last error
+	HeaderRoutingKey = "x-routing-key" // Original key of dead message
+)
+
+// Handler of command (body is json of command)
+type Handler func(body []byte) error
+
+// Error can't be fixed by retry (invalid payload, unknown command...)
+type dropError struct {
+	err error
+}
+
+func (e *dropError) Error() string { return e.err.Error() }
+func (e *dropError) Unwrap() error { return e.err }
+
+// Mark err as permanent, message is moved to dead queue without retry
+func Drop(err error) error {
+	return &dropError{err: err}
+}
+
+// Permanent error: dropped or rejected by domain (except internal error)
+func IsDrop(err error) bool {
+	var drop *dropError
+	if errors.As(err, &drop) {
+		return true
+	}
+
+	var derr *dmodels.Error
+	if errors.As(err, &derr) {
+		return derr.Code != ecodes.Internal
+	}
+	return false
+}
+
+type Consumer struct {
+	conn          rabbit.IConnection
+	exchange      string // Command exchange (direct)
+	queue         string //
+	retryExchange string // Fanout to retry queue
+	retryQueue    string // Message ttl is retry delay, dead-letter back to exchange
+	deadQueue     string //
+	retryDelay    time.Duration
+	maxRetry      int
+	prefetch      int
+	handlers      map[string]Handler
+}
+
+func NewConsumer(conn rabbit.IConnection) *Consumer {
+	var exchange = utils.StringEnv("CONSUMER_EXCHANGE", "iott.commands")
+	var queue = utils.StringEnv("CONSUMER_QUEUE", "iott-cloud.commands")
+
+	return &Consumer{
+		conn:          conn,
+		exchange:      exchange,
+		queue:         queue,
+		retryExchange: exchange + ".retry",
+		retryQueue:    queue + ".retry",
+		deadQueue:     queue + ".dead",
+		retryDelay:    time.Duration(utils.Int64Env("CONSUMER_RETRY_DELAY", 30)) * time.Second,
+		maxRetry:      int(utils.Int64Env("CONSUMER_MAX_RETRY", 5)),
+		prefetch:      int(utils.Int64Env("CONSUMER_PREFETCH", 10)),
+		handlers:      make(map[string]Handler),
+	}
+}
+
+// Register handler of command. Must be called before Run
+func (c *Consumer) Handle(key string, handler Handler) {
+	c.handlers[key] = handler
+}
+
+// Consume until process exit (reconnect channel when it was closed)
+func (c *Consumer) Run() {
+	for {
+		var err = c.consume()
+		log.Println("Consumer stopped: ", err)
+		time.Sleep(5 * time.Second)
+	}
+}
+
+func (c *Consumer) consume() error {
+	ch, err := c.conn.Channel()
+	if nil != err {
+		return err
+	}
+	defer ch.Close()
+
+	err = c.declare(ch)
+	if nil != err {
+		return err
+	}
+
+	err = ch.Qos(c.prefetch, 0, false)
+	if nil != err {
+		return err
+	}
+
+	deliveries, err := ch.Consume(c.queue, "", false, false, false, false, nil)
+	if nil != err {
+		return err
+	}
+
+	log.Printf("Consumer is listening on %s (%d handlers)\n", c.queue, len(c.handlers))
+	for d := range deliveries {
+		c.handle(ch, d)
+	}
+	return errors.New("delivery channel was closed")
+}
+
+func (c *Consumer) declare(ch *amqp.Channel) error {
+	var err = ch.ExchangeDeclare(c.exchange, amqp.ExchangeDirect, true, false, false, false, nil)
+	if nil != err {
+		return err
+	}
+
+	err = ch.ExchangeDeclare(c.retryExchange, amqp.ExchangeFanout, true, false, false, false, nil)
+	if nil != err {
+		return err
+	}
+
+	_, err = ch.QueueDeclare(c.queue, true, false, false, false, nil)
+	if nil != err {
+		return err
+	}
+
+	for key := range c.handlers {
+		err = ch.QueueBind(c.queue, key, c.exchange, false, nil)
+		if nil != err {
+			return err
+		}
+	}
+
+	// Dead-lettered message keep original routing key
+	_, err = ch.QueueDeclare(c.retryQueue, true, false, false, false, amqp.Table{
+		"x-message-ttl":          c.retryDelay.Milliseconds(),
+		"x-dead-letter-exchange": c.exchange,
+	})
+	if nil != err {
+		return err
+	}
+
+	err = ch.QueueBind(c.retryQueue, "", c.retryExchange, false, nil)
+	if nil != err {
+		return err
+	}
+
+	_, err = ch.QueueDeclare(c.deadQueue, true, false, false, false, nil)
+	return err
+}
+
+func (c *Consumer) handle(ch *amqp.Channel, d amqp.Delivery) {
+	var err = c.dispatch(d.RoutingKey, d.Body)
+	if nil == err {
+		d.Ack(false)
+		return
+	}
+
+	var attempts = retryCount(d.Headers) + 1
+	var exchange, key = c.next(err, attempts, d.RoutingKey)
+	log.Printf(
+		"Handle command %s (%s) attempt %d error: %s. Move to %s\n",
+		d.RoutingKey, d.MessageId, attempts, err, c.target(exchange, key),
+	)
+
+	var ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err = ch.PublishWithContext(ctx, exchange, key, false, false, amqp.Publishing{
+		ContentType:  d.ContentType,
+		MessageId:    d.MessageId,
+		DeliveryMode: amqp.Persistent,
+		Body:         d.Body,
+		Headers: amqp.Table{
+			HeaderRetry:      int32(attempts),
+			HeaderError:      err.Error(),
+			HeaderRoutingKey: d.RoutingKey,
+		},
+	})
+	if nil != err {
+		// Can't move message, let broker redeliver it
+		log.Println("Republish command error: ", err)
+		d.Nack(false, true)
+		return
+	}
+	d.Ack(false)
+}
+
+func (c *Consumer) dispatch(key string, body []byte) error {
+	handler, ok := c.handlers[key]
+	if !ok {
+		return Drop(fmt.Errorf("unknown command: %s", key))
+	}
+	return handler(body)
+}
+
+// Exchange & routing key for failed message
+func (c *Consumer) next(err error, attempts int, key string) (string, string) {
+	if IsDrop(err) || attempts > c.maxRetry {
+		return "", c.deadQueue // Default exchange routes to queue by name
+	}
+	return c.retryExchange, key
+}
+
+func (c *Consumer) target(exchange, key string) string {
+	if exchange == "" {
+		return key
+	}
+	return exchange
+}
+
+func retryCount(headers amqp.Table) int {
+	switch v := headers[HeaderRetry].(type) {
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case int:
+		return v
+	}
+	return 0
+}
@@ -0,0 +1,72 @@
+package consumer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/iott-cloud/internal/domain"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+func TestIsDrop(t *testing.T) {
+	if !IsDrop(Drop(errors.New("invalid"))) {
+		t.Fatalf("Dropped error must be permanent")
+	}
+	if !IsDrop(dmodels.ErrNotFound("Mint sign")) {
+		t.Fatalf("Domain error must be permanent")
+	}
+	if IsDrop(dmodels.ErrInternal(errors.New("connection refused"))) {
+		t.Fatalf("Internal error must be retried")
+	}
+	if IsDrop(errors.New("timeout")) {
+		t.Fatalf("Unknown error must be retried")
+	}
+}
+
+func TestDispatch(t *testing.T) {
+	var c = &Consumer{handlers: make(map[string]Handler)}
+	c.Handle(domain.CmdMintRedeemed, func(body []byte) error {
+		return decode(body, &domain.RMintRedeemed{})
+	})
+
+	var err = c.dispatch("unknown", []byte("{}"))
+	if !IsDrop(err) {
+		t.Fatalf("Unknown command must be dropped: %v", err)
+	}
+
+	err = c.dispatch(domain.CmdMintRedeemed, []byte(`{"iot":"0x01","nonce":1}`))
+	if !IsDrop(err) {
+		t.Fatalf("Invalid command must be dropped: %v", err)
+	}
+
+	err = c.dispatch(
+		domain.CmdMintRedeemed,
+		[]byte(`{"iot":"0x01","nonce":1,"txHash":"0x02","redeemedAt":1700000000}`),
+	)
+	if nil != err {
+		t.Fatalf("Dispatch valid command error: %v", err)
+	}
+}
+
+func TestNext(t *testing.T) {
+	var c = &Consumer{
+		retryExchange: "cmd.retry",
+		deadQueue:     "cmd.dead",
+		maxRetry:      2,
+	}
+	var transient = errors.New("timeout")
+
+	if ex, _ := c.next(transient, 1, "mint.redeemed"); ex != "cmd.retry" {
+		t.Fatalf("Transient error must be retried, got %s", ex)
+	}
+	if _, key := c.next(transient, 3, "mint.redeemed"); key != "cmd.dead" {
+		t.Fatalf("Message must be dead after max retry, got %s", key)
+	}
+	if _, key := c.next(Drop(transient), 1, "mint.redeemed"); key != "cmd.dead" {
+		t.Fatalf("Dropped message must be dead, got %s", key)
+	}
+	if n := retryCount(amqp.Table{HeaderRetry: int32(2)}); n != 2 {
+		t.Fatalf("Retry count must be 2, got %d", n)
+	}
+}
@@ -0,0 +1,55 @@
+package consumer
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/Dcarbon/iott-cloud/internal/domain"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// Register handlers of commands from other services
+func RegisterHandlers(c *Consumer, iot domain.IIot, project domain.IProject) {
+	c.Handle(domain.CmdMintRedeemed, func(body []byte) error {
+		var req = &domain.RMintRedeemed{}
+		var err = decode(body, req)
+		if nil != err {
+			return err
+		}
+		_, err = iot.ReportMintTx(req.ToReport())
+		return err
+	})
+
+	c.Handle(domain.CmdIotChangeStatus, func(body []byte) error {
+		var req = &domain.RIotChangeStatus{}
+		var err = decode(body, req)
+		if nil != err {
+			return err
+		}
+		_, err = iot.ChangeStatus(req)
+		return err
+	})
+
+	c.Handle(domain.CmdProjectChangeStatus, func(body []byte) error {
+		var req = &domain.RProjectChangeStatus{}
+		var err = decode(body, req)
+		if nil != err {
+			return err
+		}
+		return project.ChangeStatus(strconv.FormatInt(req.ProjectId, 10), *req.Status)
+	})
+}
+
+// Unmarshal & validate (binding tag) command. Invalid command is dropped
+func decode(body []byte, req interface{}) error {
+	var err = json.Unmarshal(body, req)
+	if nil != err {
+		return Drop(err)
+	}
+
+	err = binding.Validator.ValidateStruct(req)
+	if nil != err {
+		return Drop(err)
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+)
+
+// Size of aggregation bucket
+type AggBucket string
+
+const (
+	AggBucketHour    AggBucket = "hour"
+	AggBucketDay     AggBucket = "day"
+	AggBucketWeek    AggBucket = "week" // ISO week, start on monday
+	AggBucketMonth   AggBucket = "month"
+	AggBucketQuarter AggBucket = "quarter"
+	AggBucketYear    AggBucket = "year"
+)
+
+// Aggregate function apply for each bucket
+type AggFunc string
+
+const (
+	AggFuncSum   AggFunc = "sum"
+	AggFuncAvg   AggFunc = "avg"
+	AggFuncMin   AggFunc = "min"
+	AggFuncMax   AggFunc = "max"
+	AggFuncCount AggFunc = "count"
+	AggFuncLast  AggFunc = "last"
+)
+
+// How to fill bucket has no data
+type AggFill string
+
+const (
+	AggFillNone AggFill = ""     // Bucket has no data is omitted
+	AggFillNull AggFill = "null" // Bucket has no data has null value
+	AggFillZero AggFill = "zero" // Bucket has no data has 0 value
+)
+
+const (
+	AggDefaultTimezone = "UTC"
+	AggMaxPoints       = 10000 // Max number of bucket per series
+)
+
+type AggOption struct {
+	Bucket   AggBucket `json:"bucket" form:"bucket"` // hour, day, week, month, quarter, year
+	Func     AggFunc   `json:"func"   form:"func"`   // sum, avg, min, max, count, last
+	Timezone string    `json:"tz"     form:"tz"`     // IANA timezone. Ex: Asia/Ho_Chi_Minh
+	Fill     AggFill   `json:"fill"   form:"fill"`   // "", null, zero
+} //@name AggOption
+
+type AggPoint struct {
+	Time time.Time `json:"time"`
+	Val  *float64  `json:"value"` // Null when bucket has no data and fill is null
+} // @name AggPoint
+
+type AggSeries struct {
+	Id     int64       `json:"id"` // Sensor id or iot id
+	Points []*AggPoint `json:"points"`
+} // @name AggSeries
+
+// Set default value and validate option.
+// Return location of option timezone
+func (opt *AggOption) Normalize(defaultTz string) (*time.Location, error) {
+	if opt.Bucket == "" {
+		opt.Bucket = AggBucketDay
+	}
+	if opt.Func == "" {
+		opt.Func = AggFuncSum
+	}
+	if opt.Timezone == "" {
+		opt.Timezone = defaultTz
+	}
+
+	switch opt.Bucket {
+	case AggBucketHour, AggBucketDay, AggBucketWeek, AggBucketMonth, AggBucketQuarter, AggBucketYear:
+	default:
+		return nil, dmodels.ErrBadRequest("Invalid bucket: " + string(opt.Bucket))
+	}
+
+	switch opt.Func {
+	case AggFuncSum, AggFuncAvg, AggFuncMin, AggFuncMax, AggFuncCount, AggFuncLast:
+	default:
+		return nil, dmodels.ErrBadRequest("Invalid aggregate function: " + string(opt.Func))
+	}
+
+	switch opt.Fill {
+	case AggFillNone, AggFillNull, AggFillZero:
+	default:
+		return nil, dmodels.ErrBadRequest("Invalid fill: " + string(opt.Fill))
+	}
+
+	loc, err := time.LoadLocation(opt.Timezone)
+	if nil != err {
+		return nil, dmodels.ErrBadRequest("Invalid timezone: " + opt.Timezone)
+	}
+	return loc, nil
+}
+
+// Start of bucket contain t (in loc)
+func (b AggBucket) Truncate(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	switch b {
+	case AggBucketHour:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc)
+	case AggBucketWeek:
+		var offset = (int(t.Weekday()) + 6) % 7
+		return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, loc)
+	case AggBucketMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+	case AggBucketQuarter:
+		var month = time.Month((int(t.Month())-1)/3*3 + 1)
+		return time.Date(t.Year(), month, 1, 0, 0, 0, 0, loc)
+	case AggBucketYear:
+		return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, loc)
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
+
+// Start of next bucket (t must be start of bucket)
+func (b AggBucket) Next(t time.Time) time.Time {
+	switch b {
+	case AggBucketHour:
+		return t.Add(time.Hour)
+	case AggBucketWeek:
+		return t.AddDate(0, 0, 7)
+	case AggBucketMonth:
+		return t.AddDate(0, 1, 0)
+	case AggBucketQuarter:
+		return t.AddDate(0, 3, 0)
+	case AggBucketYear:
+		return t.AddDate(1, 0, 0)
+	}
+	return t.AddDate(0, 0, 1)
+}
+
+// Fill buckets in range [from, to) has no data.
+// Points must be sorted by time
+func (opt *AggOption) FillPoints(points []*AggPoint, from, to time.Time, loc *time.Location,
+) ([]*AggPoint, error) {
+	if opt.Fill == AggFillNone {
+		return points, nil
+	}
+
+	var existed = make(map[int64]*AggPoint, len(points))
+	for _, p := range points {
+		existed[p.Time.Unix()] = p
+	}
+
+	var rs = make([]*AggPoint, 0, len(points))
+	for t := opt.Bucket.Truncate(from, loc); t.Before(to); t = opt.Bucket.Next(t) {
+		if len(rs) >= AggMaxPoints {
+			return nil, dmodels.ErrBadRequest("Too many bucket. Reduce time range or increase bucket size")
+		}
+
+		if p, ok := existed[t.Unix()]; ok {
+			rs = append(rs, p)
+			continue
+		}
+
+		var p = &AggPoint{Time: t}
+		if opt.Fill == AggFillZero {
+			var zero = float64(0)
+			p.Val = &zero
+		}
+		rs = append(rs, p)
+	}
+	return rs, nil
+}
+
+// Map deprecated interval (1: day, 2: month) to bucket
+func (opt *AggOption) SetInterval(interval int) {
+	if opt.Bucket != "" {
+		return
+	}
+
+	switch interval {
+	case 1:
+		opt.Bucket = AggBucketDay
+	case 2:
+		opt.Bucket = AggBucketMonth
+	}
+}
@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAggBucketTruncate(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Ho_Chi_Minh")
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	// 2024-05-15 (wednesday) 20:30 UTC => 2024-05-16 03:30 +07
+	var ts = time.Date(2024, 5, 15, 20, 30, 0, 0, time.UTC)
+	var cases = map[AggBucket]time.Time{
+		AggBucketHour:    time.Date(2024, 5, 16, 3, 0, 0, 0, loc),
+		AggBucketDay:     time.Date(2024, 5, 16, 0, 0, 0, 0, loc),
+		AggBucketWeek:    time.Date(2024, 5, 13, 0, 0, 0, 0, loc),
+		AggBucketMonth:   time.Date(2024, 5, 1, 0, 0, 0, 0, loc),
+		AggBucketQuarter: time.Date(2024, 4, 1, 0, 0, 0, 0, loc),
+		AggBucketYear:    time.Date(2024, 1, 1, 0, 0, 0, 0, loc),
+	}
+	for bucket, expected := range cases {
+		var rs = bucket.Truncate(ts, loc)
+		if !rs.Equal(expected) {
+			t.Fatalf("Truncate %s: expected %v got %v", bucket, expected, rs)
+		}
+	}
+}
+
+func TestAggFillPoints(t *testing.T) {
+	var opt = &AggOption{Fill: AggFillZero}
+	loc, err := opt.Normalize(AggDefaultTimezone)
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	var from = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var val = 5.0
+	var points = []*AggPoint{
+		{Time: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), Val: &val},
+	}
+
+	rs, err := opt.FillPoints(points, from, from.AddDate(0, 0, 3), loc)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if len(rs) != 3 || *rs[0].Val != 0 || *rs[1].Val != val || *rs[2].Val != 0 {
+		t.Fatalf("Fill zero invalid: %+v", rs)
+	}
+
+	opt.Fill = AggFillNull
+	rs, err = opt.FillPoints(points, from, from.AddDate(0, 0, 3), loc)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if len(rs) != 3 || rs[0].Val != nil || rs[2].Val != nil {
+		t.Fatalf("Fill null invalid: %+v", rs)
+	}
+}
+
+func TestAggNormalizeInvalid(t *testing.T) {
+	var opts = []*AggOption{
+		{Bucket: "minute"},
+		{Func: "median"},
+		{Fill: "previous"},
+		{Timezone: "Mars/Olympus"},
+	}
+	for _, opt := range opts {
+		if _, err := opt.Normalize(AggDefaultTimezone); nil == err {
+			t.Fatalf("Expected error for %+v", opt)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/iott-cloud/internal/models"
+)
+
+type RAlertRuleCreate struct {
+	Name       string             `json:"name"`                     //
+	ProjectId  int64              `json:"projectId"`                // 0: all project
+	IotId      int64              `json:"iotId"`                    // 0: all iot
+	SensorType dmodels.SensorType `json:"sensorType"`               // 0: all sensor type
+	Kind       models.AlertKind   `json:"kind" binding:"required"`  // status, metric, mint-sign
+	Status     models.OpStatus    `json:"status"`                   // Kind status
+	Field      string             `json:"field"`                    // Kind metric: value (default), lat, lng
+	Op         models.AlertOp     `json:"op"`                       // Kind metric: gt, gte, lt, lte, eq
+	Threshold  float64            `json:"threshold"`                // Kind metric
+	Cooldown   int64              `json:"cooldown" binding:"min=0"` // Second (default: 300)
+	WebhookUrl string             `json:"webhookUrl" binding:"omitempty,url"`
+	Email      string             `json:"email" binding:"omitempty,email"`
+	PushEvent  bool               `json:"pushEvent"`
+} // @name RAlertRuleCreate
+
+type RAlertRuleUpdate struct {
+	Id int64 `json:"-"`
+	RAlertRuleCreate
+	Enabled bool `json:"enabled"`
+} // @name RAlertRuleUpdate
+
+type RAlertRuleGetList struct {
+	ProjectId int64 `json:"projectId" form:"projectId"`
+	IotId     int64 `json:"iotId" form:"iotId"`
+	Skip      int   `json:"skip" form:"skip"`
+	Limit     int   `json:"limit" form:"limit" binding:"max=50"`
+} // @name RAlertRuleGetList
+
+type RAlertGetList struct {
+	RuleId    int64 `json:"ruleId" form:"ruleId"`
+	ProjectId int64 `json:"projectId" form:"projectId"`
+	IotId     int64 `json:"iotId" form:"iotId"`
+	From      int64 `json:"from" form:"from"` // Timestamp
+	To        int64 `json:"to" form:"to"`     // Timestamp
+	Skip      int   `json:"skip" form:"skip"`
+	Limit     int   `json:"limit" form:"limit" binding:"max=50"`
+} // @name RAlertGetList
+
+func (req *RAlertRuleCreate) ToRule() *models.AlertRule {
+	var cooldown = req.Cooldown
+	if cooldown == 0 {
+		cooldown = AlertDefaultCooldown
+	}
+
+	return &models.AlertRule{
+		Name:       req.Name,
+		ProjectId:  req.ProjectId,
+		IotId:      req.IotId,
+		SensorType: req.SensorType,
+		Kind:       req.Kind,
+		Status:     req.Status,
+		Field:      req.Field,
+		Op:         req.Op,
+		Threshold:  req.Threshold,
+		Cooldown:   cooldown,
+		WebhookUrl: req.WebhookUrl,
+		Email:      req.Email,
+		PushEvent:  req.PushEvent,
+		Enabled:    true,
+	}
+}
+
+const AlertDefaultCooldown = 300 // Second
+
+type IAlert interface {
+	IAlertEvaluator
+
+	CreateRule(req *RAlertRuleCreate) (*models.AlertRule, error)
+	UpdateRule(req *RAlertRuleUpdate) (*models.AlertRule, error)
+	DeleteRule(id int64) error
+	GetRules(req *RAlertRuleGetList) ([]*models.AlertRule, error)
+
+	GetAlerts(req *RAlertGetList) ([]*models.Alert, error)
+}
+
+// Evaluate alert rules on device events. Must not block caller
+type IAlertEvaluator interface {
+	OnMetric(sensor *models.Sensor, metric *dmodels.AllMetric)
+	OnStatus(prev models.OpStatus, stt *models.OpIotStatus)
+	OnMintSignFailed(iotId int64, reason error)
+}
+
+// Notify alert to target (url, email address, ...) of channel
+type INotifier interface {
+	Notify(target string, alert *models.Alert) error
+}
@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/Dcarbon/iott-cloud/internal/models"
+)
+
+type RBundleGetList struct {
+	IotId      int64 `json:"iotId" form:"iotId" binding:"required"` //
+	MintSignId int64 `json:"mintSignId" form:"mintSignId"`          // Bundles was anchored to mint signature
+	Skip       int   `json:"skip" form:"skip"`                      //
+	Limit      int   `json:"limit" form:"limit" binding:"max=50"`   //
+} //@name RBundleGetList
+
+// Inclusion proof of metric in bundle. Verify offline:
+//
+//	leaf = keccak256(bytes(data) || bytes(signed))
+//	for sibling in proof: leaf = keccak256(min(leaf, sibling) || max(leaf, sibling))
+//	leaf == root
+type RsMetricProof struct {
+	MetricId    string               `json:"metricId"`    //
+	SignId      string               `json:"signId"`      //
+	Data        string               `json:"data"`        // Hex
+	Signed      string               `json:"signed"`      // Hex
+	Leaf        string               `json:"leaf"`        // Leaf was committed
+	Index       int                  `json:"index"`       //
+	Proof       []string             `json:"proof"`       // Siblings (bottom up)
+	Root        string               `json:"root"`        //
+	Bundle      *models.MetricBundle `json:"bundle"`      //
+	Altered     bool                 `json:"altered"`     // Signature was modified after commitment
+	GeneratedAt time.Time            `json:"generatedAt"` //
+} //@name RsMetricProof
+
+type IBundle interface {
+	// Bundle signatures was created before `to` (all iots)
+	Build(to time.Time) ([]*models.MetricBundle, error)
+	GetBundles(*RBundleGetList) ([]*models.MetricBundle, error)
+	GetProof(metricId string) (*RsMetricProof, error)
+}
@@ -0,0 +1,24 @@
+package domain
+
+import "github.com/Dcarbon/iott-cloud/internal/models"
+
+type RChainMintGetList struct {
+	DomainId int64  `json:"domainId" form:"domainId"`
+	Iot      string `json:"iot" form:"iot"`
+	Skip     int    `json:"skip" form:"skip"`
+	Limit    int    `json:"limit" form:"limit" binding:"max=50"`
+} // @name RChainMintGetList
+
+type RDiscrepancyGetList struct {
+	Kind     models.DiscrepancyKind `json:"kind" form:"kind"`
+	Iot      string                 `json:"iot" form:"iot"`
+	Resolved bool                   `json:"resolved" form:"resolved"` // Include resolved
+	Skip     int                    `json:"skip" form:"skip"`
+	Limit    int                    `json:"limit" form:"limit" binding:"max=50"`
+} // @name RDiscrepancyGetList
+
+type IChain interface {
+	GetMints(*RChainMintGetList) ([]*models.ChainMint, error)
+	GetDiscrepancies(*RDiscrepancyGetList) ([]*models.MintDiscrepancy, error)
+	ResolveDiscrepancy(id int64) error
+}
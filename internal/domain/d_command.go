@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/iott-cloud/internal/models"
+)
+
+// Commands from other services (routing key of rabbitmq message)
+const (
+	CmdMintRedeemed        = "mint.redeemed"         // RMintRedeemed
+	CmdIotChangeStatus     = "iot.change_status"     // RIotChangeStatus
+	CmdProjectChangeStatus = "project.change_status" // RProjectChangeStatus
+)
+
+// Mint signature was redeemed on-chain
+type RMintRedeemed struct {
+	Iot         dmodels.EthAddress `json:"iot" binding:"required"`        // IoT address
+	Nonce       int64              `json:"nonce" binding:"required"`      //
+	TxHash      string             `json:"txHash" binding:"required"`     //
+	BlockNumber int64              `json:"blockNumber"`                   //
+	RedeemedAt  int64              `json:"redeemedAt" binding:"required"` // Unix (second) of block
+} // @name RMintRedeemed
+
+func (req *RMintRedeemed) ToReport() *RMintTxReport {
+	return &RMintTxReport{
+		Iot:         req.Iot,
+		Nonce:       req.Nonce,
+		State:       models.MintStateConfirmed,
+		TxHash:      req.TxHash,
+		BlockNumber: req.BlockNumber,
+		RedeemedAt:  req.RedeemedAt,
+	}
+}
+
+type RProjectChangeStatus struct {
+	ProjectId int64                 `json:"projectId" binding:"required"`
+	Status    *models.ProjectStatus `json:"status" binding:"required"`
+} // @name RProjectChangeStatus
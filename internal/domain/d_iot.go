@@ -17,6 +17,7 @@ type RIotCreate struct {
 	Address  dmodels.EthAddress `json:"address" binding:"required"`
 	Type     models.IOTType     `json:"type"  binding:"required"`
 	Position *models.Point4326  `json:"position" binding:"required"`
+	DomainId int64              `json:"domainId"` // Sign domain (default: domain of project)
 }
 
 type RIotChangeStatus struct {
@@ -30,11 +31,12 @@ type RIotUpdate struct {
 } //@name RIotChangeStatus
 
 type RIotGetList struct {
-	Skip      int                  `json:"skip" form:"skip"`
-	Limit     int                  `json:"limit" form:"limit" binding:"max=50"`
-	ProjectId int64                `json:"projectId" form:"projectId" binding:"required"`
-	Status    dmodels.DeviceStatus `json:"status" form:"status"`
-}
+	IotFilter
+	Sort   IotSort `json:"sort" form:"sort"`     // id, createdAt, address, type, status (prefix "-": desc)
+	Cursor string  `json:"cursor" form:"cursor"` // Next/prev cursor of previous page
+	Skip   int     `json:"skip" form:"skip"`     // Deprecated (use cursor)
+	Limit  int     `json:"limit" form:"limit" binding:"max=50"`
+} //@name RIotGetList
 
 type RIotMint struct {
 	Nonce  int64  `json:"nonce" binding:"required"`  //
@@ -46,21 +48,53 @@ type RIotMint struct {
 } // @name RIotMint
 
 type RIotGetMintSignList struct {
-	From  int64 `json:"from" form:"from" binding:"required"`
-	To    int64 `json:"to" form:"to" binding:""`
-	IotId int64 `json:"iotId" uri:"iotId" binding:"required"`
-	Sort  Sort  `json:"sort" form:"sort"`
-	Limit int   `json:"limit" form:"limit"`
+	From   int64  `json:"from" form:"from" binding:"required"`
+	To     int64  `json:"to" form:"to" binding:""`
+	IotId  int64  `json:"iotId" uri:"iotId" binding:"required"`
+	Sort   Sort   `json:"sort" form:"sort"`
+	Limit  int    `json:"limit" form:"limit"`
+	Cursor string `json:"cursor" form:"cursor"` // Next/prev cursor of previous page
+
+	State *models.MintState `json:"state" form:"state"` // Filter by redemption state
 } //@name RIotGetMintSignList
 
+// Relayer report tx of mint signature
+type RMintTxReport struct {
+	Iot         dmodels.EthAddress `json:"-"`                         // IoT address (path)
+	Nonce       int64              `json:"nonce" binding:"required"`  //
+	State       models.MintState   `json:"state" binding:"required"`  // 1: submitted 2: confirmed -1: failed
+	TxHash      string             `json:"txHash" binding:"required"` //
+	BlockNumber int64              `json:"blockNumber"`               // Confirmed only
+	Error       string             `json:"error"`                     // Failed only
+	RedeemedAt  int64              `json:"redeemedAt"`                // Unix (second). Confirmed only (default: now)
+	DomainId    int64              `json:"-"`                         // Sign domain of tx (0: current domain of iot)
+} //@name RMintTxReport
+
+// Signed but unclaimed carbon of iot (amount of signature is accumulated)
+type RsMintUnclaimed struct {
+	IotId        int64  `json:"iotId"`        //
+	Signed       string `json:"signed"`       // Hex. Amount of latest signature
+	SignedNonce  int64  `json:"signedNonce"`  //
+	Claimed      string `json:"claimed"`      // Hex. Amount of latest confirmed signature
+	ClaimedNonce int64  `json:"claimedNonce"` //
+	Unclaimed    string `json:"unclaimed"`    // Hex
+} //@name RsMintUnclaimed
+
 type RIotGetMintedList struct {
-	From     int64 `json:"from" form:"from" binding:"required"`
-	To       int64 `json:"to" form:"to" binding:"required"`
-	IotId    int64 `json:"iotId" form:"iotId" binding:""`
-	Interval int   `json:"interval" form:"interval"` // 1 : day 2: month
+	From     int64  `json:"from" form:"from" binding:"required"`
+	To       int64  `json:"to" form:"to" binding:"required"`
+	IotId    int64  `json:"iotId" form:"iotId" binding:""`
+	Interval int    `json:"interval" form:"interval"`            // Deprecated (use bucket). 1 : day 2: month (legacy aggregate if bucket is empty)
+	Limit    int    `json:"limit" form:"limit" binding:"max=50"` // Raw minted only (no bucket)
+	Cursor   string `json:"cursor" form:"cursor"`                // Raw minted only (no bucket)
+	AggOption
 } //@name RIotGetMintedList
 
+// Default timezone of minted aggregation (keep for old client)
+const MintedDefaultTimezone = "Asia/Ho_Chi_Minh"
+
 type RIotCount struct {
+	IotFilter
 } //@name RIotCount
 
 type RIsIotActiced struct {
@@ -75,11 +109,14 @@ type PositionId struct {
 } //@name PositionId
 
 type IIot interface {
+	SetAlerter(alerter IAlertEvaluator)
+	SetOperator(operator IOperator) // Source of operator status filter
+
 	Create(*RIotCreate) (*models.IOTDevice, error)
 	Update(req *RIotUpdate) (*models.IOTDevice, error)
 	ChangeStatus(*RIotChangeStatus) (*models.IOTDevice, error)
 	GetIot(id int64) (*models.IOTDevice, error)
-	GetIots(*RIotGetList) ([]*models.IOTDevice, error)
+	GetIots(*RIotGetList) ([]*models.IOTDevice, *PageInfo, error)
 	GetIotPositions(*RIotGetList) ([]*PositionId, error)
 
 	GetIotByAddress(addr dmodels.EthAddress) (*models.IOTDevice, error)
@@ -91,8 +128,12 @@ type IIot interface {
 	// GetRawMetric(metricId string) (*models.Metric, error)
 
 	CreateMint(mint *RIotMint) error
-	GetMintSigns(*RIotGetMintSignList) ([]*models.MintSign, error)
-	GetMinted(*RIotGetMintedList) ([]*models.Minted, error)
+	GetMintSigns(*RIotGetMintSignList) ([]*models.MintSign, *PageInfo, error)
+	ReportMintTx(*RMintTxReport) (*models.MintSign, error)
+	GetMintUnclaimed(iotId int64) (*RsMintUnclaimed, error)
+	MoveDomain(*RIotMoveDomain) (*RsIotMoveDomain, error)
+	GetMinted(*RIotGetMintedList) ([]*models.Minted, *PageInfo, error)
+	GetMintedSeries(*RIotGetMintedList) (*AggSeries, error)
 
 	CountIot(*RIotCount) (int64, error)
 	IsIotActived(req *RIsIotActiced) (bool, error)
@@ -0,0 +1,119 @@
+package domain
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/iott-cloud/internal/models"
+)
+
+// Filter of iot list & count. Value of set is comma separated.
+// Ex: type=10,11&status=0,10&opStatus=1,10&owner=0x...&address=e445
+type IotFilter struct {
+	ProjectId   int64              `json:"projectId"   form:"projectId"`   // Single project (same as project=)
+	Project     string             `json:"project"     form:"project"`     // Set of project id
+	Type        string             `json:"type"        form:"type"`        // Set of iot type
+	Status      string             `json:"status"      form:"status"`      // Set of device status
+	OpStatus    string             `json:"opStatus"    form:"opStatus"`    // Set of operator status (-1, 1, 10)
+	Owner       dmodels.EthAddress `json:"owner"       form:"owner"`       // Owner of project
+	CreatedFrom int64              `json:"createdFrom" form:"createdFrom"` // Unix (second, inclusive)
+	CreatedTo   int64              `json:"createdTo"   form:"createdTo"`   // Unix (second, exclusive)
+	HasSensors  *bool              `json:"hasSensors"  form:"hasSensors"`  // Iot has (not) any sensor
+	Address     string             `json:"address"     form:"address"`     // Address contains (case insensitive)
+
+	// Parsed sets (internal caller can set them directly)
+	IdIn       []int64                `json:"-" form:"-"`
+	ProjectIn  []int64                `json:"-" form:"-"`
+	TypeIn     []models.IOTType       `json:"-" form:"-"`
+	StatusIn   []dmodels.DeviceStatus `json:"-" form:"-"`
+	OpStatusIn []models.OpStatus      `json:"-" form:"-"`
+} //@name IotFilter
+
+// Sort of iot list: id, createdAt, address, type, status. Prefix "-" is
+// descending. Ex: -createdAt
+type IotSort string
+
+const (
+	IotSortId        = "id"
+	IotSortCreatedAt = "createdAt"
+	IotSortAddress   = "address"
+	IotSortType      = "type"
+	IotSortStatus    = "status"
+)
+
+// Parse set values to ProjectIn, TypeIn, StatusIn, OpStatusIn and validate
+// filter
+func (f *IotFilter) Normalize() error {
+	if f.ProjectId != 0 {
+		f.ProjectIn = append(f.ProjectIn, f.ProjectId)
+	}
+
+	var err = parseSet(f.Project, "project", func(v int64) {
+		f.ProjectIn = append(f.ProjectIn, v)
+	})
+	if nil != err {
+		return err
+	}
+
+	err = parseSet(f.Type, "type", func(v int64) {
+		f.TypeIn = append(f.TypeIn, models.IOTType(v))
+	})
+	if nil != err {
+		return err
+	}
+
+	err = parseSet(f.Status, "status", func(v int64) {
+		f.StatusIn = append(f.StatusIn, dmodels.DeviceStatus(v))
+	})
+	if nil != err {
+		return err
+	}
+
+	err = parseSet(f.OpStatus, "opStatus", func(v int64) {
+		f.OpStatusIn = append(f.OpStatusIn, models.OpStatus(v))
+	})
+	if nil != err {
+		return err
+	}
+
+	if f.CreatedFrom > 0 && f.CreatedTo > 0 && f.CreatedFrom >= f.CreatedTo {
+		return dmodels.ErrBadRequest("createdFrom must be less than createdTo")
+	}
+
+	f.Address = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(f.Address), "0x"))
+	return nil
+}
+
+// Column & direction of sort (default: id asc)
+func (s IotSort) Parse() (string, bool, error) {
+	var field = strings.TrimPrefix(string(s), "-")
+	var desc = strings.HasPrefix(string(s), "-")
+	switch field {
+	case "":
+		return IotSortId, false, nil
+	case IotSortId, IotSortCreatedAt, IotSortAddress, IotSortType, IotSortStatus:
+		return field, desc, nil
+	}
+	return "", false, dmodels.ErrBadRequest("Invalid sort: " + string(s))
+}
+
+func parseSet(s, name string, add func(v int64)) error {
+	if s == "" {
+		return nil
+	}
+
+	for _, it := range strings.Split(s, ",") {
+		it = strings.TrimSpace(it)
+		if it == "" {
+			continue
+		}
+
+		v, err := strconv.ParseInt(it, 10, 64)
+		if nil != err {
+			return dmodels.ErrBadRequest("Invalid " + name + ": " + it)
+		}
+		add(v)
+	}
+	return nil
+}
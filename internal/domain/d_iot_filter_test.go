@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/iott-cloud/internal/models"
+)
+
+func TestIotFilterNormalize(t *testing.T) {
+	var f = &IotFilter{
+		ProjectId: 1,
+		Project:   "2, 3",
+		Type:      "20,21",
+		Status:    "0",
+		OpStatus:  "-1,10",
+		Address:   " 0xE445 ",
+	}
+	if err := f.Normalize(); nil != err {
+		t.Fatal(err)
+	}
+
+	if len(f.ProjectIn) != 3 || f.ProjectIn[0] != 1 || f.ProjectIn[2] != 3 {
+		t.Fatalf("invalid project set: %v", f.ProjectIn)
+	}
+	if len(f.TypeIn) != 2 || f.TypeIn[1] != models.IOTTypeBurnBiomass {
+		t.Fatalf("invalid type set: %v", f.TypeIn)
+	}
+	if len(f.StatusIn) != 1 || f.StatusIn[0] != dmodels.DeviceStatusRegister {
+		t.Fatalf("invalid status set: %v", f.StatusIn)
+	}
+	if len(f.OpStatusIn) != 2 || f.OpStatusIn[0] != models.OpStatusInactived {
+		t.Fatalf("invalid operator status set: %v", f.OpStatusIn)
+	}
+	if f.Address != "e445" {
+		t.Fatalf("invalid address: %s", f.Address)
+	}
+
+	for _, f := range []*IotFilter{
+		{Type: "10,x"},
+		{OpStatus: "actived"},
+		{CreatedFrom: 200, CreatedTo: 100},
+	} {
+		if err := f.Normalize(); nil == err {
+			t.Fatalf("filter must be invalid: %+v", f)
+		}
+	}
+}
+
+func TestIotSortParse(t *testing.T) {
+	var cases = map[IotSort][2]interface{}{
+		"":           {IotSortId, false},
+		"address":    {IotSortAddress, false},
+		"-createdAt": {IotSortCreatedAt, true},
+	}
+	for sort, expected := range cases {
+		field, desc, err := sort.Parse()
+		if nil != err || field != expected[0] || desc != expected[1] {
+			t.Fatalf("parse sort %q: %s %v %v", sort, field, desc, err)
+		}
+	}
+
+	if _, _, err := IotSort("-position").Parse(); nil == err {
+		t.Fatalf("sort by position must be invalid")
+	}
+}
@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Languages are tried after requested languages (description of project)
+var FallbackLanguages = []string{"en", "vi"}
+
+// Language chain of request: languages of Accept-Language (by quality) or
+// lang param first, then FallbackLanguages. Language is primary subtag
+// (vi-VN => vi)
+func LanguageChain(lang, acceptLanguage string) []string {
+	var chain = make([]string, 0, 4)
+	var add = func(l string) {
+		l = normalizeLanguage(l)
+		if l == "" || l == "*" {
+			return
+		}
+		for _, it := range chain {
+			if it == l {
+				return
+			}
+		}
+		chain = append(chain, l)
+	}
+
+	add(lang)
+	for _, l := range ParseAcceptLanguage(acceptLanguage) {
+		add(l)
+	}
+	for _, l := range FallbackLanguages {
+		add(l)
+	}
+	return chain
+}
+
+// Languages of Accept-Language header sorted by quality (q=0 is ignored).
+// Ex: "fr-CH, fr;q=0.9, en;q=0.8" => [fr-CH fr en]
+func ParseAcceptLanguage(header string) []string {
+	type langQ struct {
+		lang string
+		q    float64
+	}
+
+	var langs = make([]*langQ, 0)
+	for _, part := range strings.Split(header, ",") {
+		var fields = strings.Split(strings.TrimSpace(part), ";")
+		var it = &langQ{lang: strings.TrimSpace(fields[0]), q: 1}
+		if it.lang == "" {
+			continue
+		}
+
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				q, err := strconv.ParseFloat(param[2:], 64)
+				if nil == err {
+					it.q = q
+				}
+			}
+		}
+		if it.q > 0 {
+			langs = append(langs, it)
+		}
+	}
+
+	sort.SliceStable(langs, func(i, j int) bool { return langs[i].q > langs[j].q })
+
+	var rs = make([]string, len(langs))
+	for i, it := range langs {
+		rs[i] = it.lang
+	}
+	return rs
+}
+
+func normalizeLanguage(l string) string {
+	l = strings.ToLower(strings.TrimSpace(l))
+	if i := strings.IndexAny(l, "-_"); i > 0 {
+		l = l[:i]
+	}
+	return l
+}
@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Dcarbon/iott-cloud/internal/models"
+)
+
+func TestParseAcceptLanguage(t *testing.T) {
+	var rs = ParseAcceptLanguage("en;q=0.5, fr-CH, de;q=0, fr;q=0.9")
+	if !reflect.DeepEqual(rs, []string{"fr-CH", "fr", "en"}) {
+		t.Fatalf("invalid languages: %v", rs)
+	}
+
+	if rs = ParseAcceptLanguage(""); len(rs) != 0 {
+		t.Fatalf("empty header must has no language: %v", rs)
+	}
+}
+
+func TestLanguageChain(t *testing.T) {
+	var cases = []struct {
+		lang, header string
+		expected     []string
+	}{
+		{"", "", []string{"en", "vi"}},
+		{"", "vi-VN,vi;q=0.9", []string{"vi", "en"}},
+		{"ja", "fr-CH, fr;q=0.9, *;q=0.5", []string{"ja", "fr", "en", "vi"}},
+	}
+	for _, c := range cases {
+		var rs = LanguageChain(c.lang, c.header)
+		if !reflect.DeepEqual(rs, c.expected) {
+			t.Fatalf("chain of (%q, %q): expected %v got %v", c.lang, c.header, c.expected, rs)
+		}
+	}
+}
+
+func TestSelectDescription(t *testing.T) {
+	var descs = []*models.ProjectDescription{
+		{ID: 1, Language: "vi"},
+		{ID: 2, Language: "en-US"},
+	}
+
+	if desc := SelectDescription(descs, LanguageChain("fr", "")); desc == nil || desc.ID != 2 {
+		t.Fatalf("fr must fallback to en: %+v", desc)
+	}
+	if desc := SelectDescription(descs, []string{"vi", "en"}); desc == nil || desc.ID != 1 {
+		t.Fatalf("vi must be selected: %+v", desc)
+	}
+	if desc := SelectDescription(descs, []string{"ja"}); desc != nil {
+		t.Fatalf("ja has no description: %+v", desc)
+	}
+}
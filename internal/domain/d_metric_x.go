@@ -12,7 +12,8 @@ type RXSMCreate struct {
 } // @name RXSMCreate
 
 type RXSMGetList struct {
-	Skip       int                `json:"skip"        form:"skip"`
+	Cursor     string             `json:"cursor"      form:"cursor"` // Next/prev cursor of previous page
+	Skip       int                `json:"skip"        form:"skip"`   // Deprecated (use cursor)
 	Limit      int                `json:"limit"       form:"limit"`
 	From       int64              `json:"from"        form:"from"`
 	To         int64              `json:"to"          form:"to"`
@@ -22,5 +23,5 @@ type RXSMGetList struct {
 
 type IXSM interface {
 	Create(*RXSMCreate) (*models.XSMetric, error)
-	GetList(*RXSMGetList) ([]*models.XSMetric, error)
+	GetList(*RXSMGetList) ([]*models.XSMetric, *PageInfo, error)
 }
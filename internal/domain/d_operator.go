@@ -21,10 +21,53 @@ type RsGetMetrics struct {
 	Metrics []*models.OpSensorMetric `json:"metrics"` //
 }
 
+// Fleet health summary
+type RsOpHealth struct {
+	Total     int64 `json:"total"`     // Num of iot has reported
+	Actived   int64 `json:"actived"`   //
+	Warning   int64 `json:"warning"`   //
+	Inactived int64 `json:"inactived"` //
+	CheckedAt int64 `json:"checkedAt"` // Timestamp
+} // @name RsOpHealth
+
+type ROpOverview struct {
+	Status    models.OpStatus `json:"status" form:"status"`                       // Filter by operator status
+	ProjectId int64           `json:"projectId" form:"projectId"`                 // Filter by project
+	Type      models.IOTType  `json:"type" form:"type"`                           // Filter by iot type
+	Skip      int             `json:"skip" form:"skip" binding:"min=0"`           //
+	Limit     int             `json:"limit" form:"limit" binding:"min=0,max=100"` //
+} // @name ROpOverview
+
+type OpDevice struct {
+	*models.OpIotStatus
+	Metrics []*models.OpSensorMetric `json:"metrics"`
+} // @name OpDevice
+
+type RsOpOverview struct {
+	Total     int64                     `json:"total"`     // Num of iot has reported
+	ByStatus  map[models.OpStatus]int64 `json:"byStatus"`  //
+	ByProject map[int64]int64           `json:"byProject"` //
+	ByType    map[models.IOTType]int64  `json:"byType"`    //
+	Count     int64                     `json:"count"`     // Num of iot matched filter
+	Devices   []*OpDevice               `json:"devices"`   // Page of iot matched filter (latest first)
+} // @name RsOpOverview
+
 type IOperator interface {
+	SetAlerter(alerter IAlertEvaluator)
+
 	SetStatus(req *ROpSetStatus) error
 	GetStatus(iotId int64) (*models.OpIotStatus, error)
+	GetAllStatus() ([]*models.OpIotStatus, error)
+	GetHealth() (*RsOpHealth, error)
+	GetOverview(req *ROpOverview) (*RsOpOverview, error)
 
 	ChangeMetrics(*RChangeMetric, dmodels.SensorType) (*models.OpSensorMetric, error)
 	GetMetrics(iotId int64) (*RsGetMetrics, error)
 }
+
+// Stream of operator events
+type IOpStream interface {
+	// Subscribe events of iots. Channel is closed when cancel was called or
+	// subscriber is too slow (buffer is full)
+	Subscribe(iotIds []int64) (<-chan *models.OpEvent, func())
+}
@@ -0,0 +1,26 @@
+package domain
+
+import (
+	"github.com/Dcarbon/iott-cloud/internal/models"
+)
+
+type RIotOracleEnable struct {
+	IotId  int64 `json:"-"`      // Path
+	Enable bool  `json:"enable"` //
+} // @name RIotOracleEnable
+
+type RMintAuditGetList struct {
+	IotId int64 `json:"-" form:"-"`                          // Path
+	Skip  int   `json:"skip" form:"skip"`                    //
+	Limit int   `json:"limit" form:"limit" binding:"max=50"` //
+} // @name RMintAuditGetList
+
+// Server side mint signing (oracle mode) for IoT can not hold key securely
+type IOracle interface {
+	SetEnable(*RIotOracleEnable) (*models.IOTDevice, error)
+
+	// Aggregate metrics & sign next mint of iot. Return nil if there is no
+	// carbon increment
+	Mint(iotId int64) (*models.MintAudit, error)
+	GetAudits(*RMintAuditGetList) ([]*models.MintAudit, error)
+}
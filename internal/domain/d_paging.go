@@ -0,0 +1,10 @@
+package domain
+
+// Page of keyset (cursor) pagination. Cursor is opaque: pass Next (or Prev)
+// as `cursor` of next request with the same filter
+type PageInfo struct {
+	Next  string `json:"next,omitempty"` // Empty: last page
+	Prev  string `json:"prev,omitempty"` // Empty: first page
+	Total int64  `json:"total"`          // Num of items match filter
+	Limit int    `json:"limit"`          // 0: no limit
+} // @name PageInfo
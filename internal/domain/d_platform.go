@@ -0,0 +1,103 @@
+package domain
+
+import (
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/iott-cloud/internal/models"
+)
+
+// Leaderboard period: calendar days (UTC) ending today, today is included (so
+// far) or all time. 1d is today only, not the last 24 hours
+type LeaderboardPeriod string
+
+const (
+	LeaderboardPeriod1d   LeaderboardPeriod = "1d"
+	LeaderboardPeriod7d   LeaderboardPeriod = "7d"
+	LeaderboardPeriod30d  LeaderboardPeriod = "30d"
+	LeaderboardPeriod90d  LeaderboardPeriod = "90d"
+	LeaderboardPeriod365d LeaderboardPeriod = "365d"
+	LeaderboardPeriodAll  LeaderboardPeriod = "all"
+)
+
+var leaderboardDays = map[LeaderboardPeriod]int{
+	LeaderboardPeriod1d:   1,
+	LeaderboardPeriod7d:   7,
+	LeaderboardPeriod30d:  30,
+	LeaderboardPeriod90d:  90,
+	LeaderboardPeriod365d: 365,
+	LeaderboardPeriodAll:  0,
+}
+
+type LeaderboardKind string
+
+const (
+	LeaderboardKindProject LeaderboardKind = "project"
+	LeaderboardKindIot     LeaderboardKind = "iot"
+)
+
+type RLeaderboard struct {
+	Kind   LeaderboardKind   `json:"kind" form:"kind"`                     // project (default), iot
+	Period LeaderboardPeriod `json:"period" form:"period"`                 // 1d (today UTC), 7d, 30d (default), 90d, 365d, all
+	Limit  int               `json:"limit" form:"limit" binding:"max=100"` // Default: 10
+} //@name RLeaderboard
+
+type LeaderboardItem struct {
+	Rank    int                `json:"rank"`              // Start from 1
+	Id      int64              `json:"id"`                // Project id or iot id
+	Project int64              `json:"project,omitempty"` // Project of iot
+	Address dmodels.EthAddress `json:"address,omitempty"` // Address of iot
+	Carbon  int64              `json:"carbon"`            // Minted in period
+} //@name LeaderboardItem
+
+type RsLeaderboard struct {
+	Kind        LeaderboardKind    `json:"kind"`        //
+	Period      LeaderboardPeriod  `json:"period"`      //
+	From        string             `json:"from"`        // First day of period (yyyy-mm-dd, UTC), empty for all
+	Items       []*LeaderboardItem `json:"items"`       //
+	RefreshedAt int64              `json:"refreshedAt"` // Unix (second) of summaries
+} //@name RsLeaderboard
+
+type RsPlatformStats struct {
+	Projects    int64                    `json:"projects"`    //
+	Iots        int64                    `json:"iots"`        // All status
+	ActiveIots  int64                    `json:"activeIots"`  // Status success
+	IotsByType  map[models.IOTType]int64 `json:"iotsByType"`  //
+	Sensors     int64                    `json:"sensors"`     //
+	TotalMinted int64                    `json:"totalMinted"` // CO2e minted (all time)
+	Metrics24h  int64                    `json:"metrics24h"`  // Metrics ingested in 24h before refreshedAt
+	RefreshedAt int64                    `json:"refreshedAt"` // Unix (second) of summaries
+} //@name RsPlatformStats
+
+// Platform stats & leaderboard are served from summaries are refreshed
+// periodically
+type IPlatform interface {
+	Refresh() error
+	GetStats() (*RsPlatformStats, error)
+	GetLeaderboard(*RLeaderboard) (*RsLeaderboard, error)
+}
+
+// Set default and validate
+func (req *RLeaderboard) Normalize() error {
+	if req.Kind == "" {
+		req.Kind = LeaderboardKindProject
+	}
+	if req.Kind != LeaderboardKindProject && req.Kind != LeaderboardKindIot {
+		return dmodels.ErrBadRequest("Invalid kind: " + string(req.Kind))
+	}
+
+	if req.Period == "" {
+		req.Period = LeaderboardPeriod30d
+	}
+	if _, ok := leaderboardDays[req.Period]; !ok {
+		return dmodels.ErrBadRequest("Invalid period: " + string(req.Period))
+	}
+
+	if req.Limit <= 0 {
+		req.Limit = 10
+	}
+	return nil
+}
+
+// Days of period (0 is all time)
+func (p LeaderboardPeriod) Days() int {
+	return leaderboardDays[p]
+}
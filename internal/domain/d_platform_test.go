@@ -0,0 +1,25 @@
+package domain
+
+import "testing"
+
+func TestLeaderboardNormalize(t *testing.T) {
+	var req = &RLeaderboard{}
+	if err := req.Normalize(); nil != err {
+		t.Fatalf("default leaderboard error: %v", err)
+	}
+	if req.Kind != LeaderboardKindProject || req.Period != LeaderboardPeriod30d || req.Limit != 10 {
+		t.Fatalf("invalid default: %+v", req)
+	}
+	if req.Period.Days() != 30 || LeaderboardPeriodAll.Days() != 0 {
+		t.Fatalf("invalid days of period")
+	}
+
+	for _, it := range []*RLeaderboard{
+		{Kind: "user"},
+		{Period: "2d"},
+	} {
+		if err := it.Normalize(); nil == err {
+			t.Fatalf("leaderboard %+v must be invalid", it)
+		}
+	}
+}
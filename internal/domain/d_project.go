@@ -13,11 +13,16 @@ type IProject interface {
 	UpdateDesc(req *RProjectUpdateDesc) (*models.ProjectDescription, error)
 	UpdateSpecs(req *RProjectUpdateSpecs) (*models.ProjectSpecs, error)
 
-	GetById(id int64, lang string) (*models.Project, error)
-	GetList(filter *RProjectFilter) ([]*models.Project, error)
+	GetById(id int64, langs ...string) (*models.Project, error) // Descs: first language of langs has description
+	GetList(filter *RProjectFilter) ([]*models.Project, *PageInfo, error)
+	Search(req *RProjectSearch) ([]*ProjectHit, *PageInfo, error)
 	GetOwner(projectId int64) (string, error)
 
 	AddImage(*RProjectAddImage) (*models.ProjectImage, error)
+	GetImages(projectId int64) ([]*models.ProjectImage, error)            // By position
+	DeleteImage(projectId, imageId int64) (*models.ProjectImage, error)   // Deleted image (caller removes files)
+	SetCoverImage(projectId, imageId int64) (*models.ProjectImage, error) //
+	ReorderImages(*RProjectReorderImages) ([]*models.ProjectImage, error) //
 	ChangeStatus(id string, status models.ProjectStatus) error
 }
 
@@ -28,6 +33,7 @@ type RProjectCreate struct {
 	Descs        []*RProjectUpdateDesc `json:"descs" binding:"required"`    //
 	Area         float64               `json:"area"`
 	LocationName string                `json:"locationName"`
+	DomainId     int64                 `json:"domainId"` // Sign domain of IoTs (0: default)
 } // @name RProjectCreate
 
 type RProjectUpdateDesc struct {
@@ -43,16 +49,49 @@ type RProjectUpdateSpecs struct {
 } //@name RProjectUpdateSpecs
 
 type RProjectFilter struct {
-	Skip  int    `json:"skip" form:"skip"`
-	Limit int    `json:"limit" form:"limit;max=50"`
-	Owner string `json:"owner" form:"owner"`
+	Cursor string `json:"cursor" form:"cursor"` // Next/prev cursor of previous page
+	Skip   int    `json:"skip" form:"skip"`     // Deprecated (use cursor)
+	Limit  int    `json:"limit" form:"limit" binding:"max=50"`
+	Owner  string `json:"owner" form:"owner"`
 } // @name RProjectFilter
 
+type RProjectSearch struct {
+	Q      string   `json:"q" form:"q" binding:"required,max=200"` // Text search (name, description, location name)
+	Lang   string   `json:"lang" form:"lang"`                      // Language (default: by Accept-Language)
+	Cursor string   `json:"cursor" form:"cursor"`                  // Next/prev cursor of previous page
+	Skip   int      `json:"skip" form:"skip" binding:"min=0"`      // Deprecated (use cursor)
+	Limit  int      `json:"limit" form:"limit" binding:"max=50"`   //
+	Langs  []string `json:"-" form:"-"`                            // Language chain (see LanguageChain)
+} // @name RProjectSearch
+
+type ProjectHit struct {
+	*models.Project
+	Score float64 `json:"score"` // Rank of text search
+} // @name ProjectHit
+
+// Max edge (px) of thumbnail by size name
+var ProjectThumbSizes = map[string]int{
+	"sm": 160,
+	"md": 480,
+	"lg": 1280,
+}
+
 type RProjectAddImage struct {
-	ProjectID int64  `json:"projectID"`
-	ImgPath   string `json:"imgPath"`
+	ProjectID int64             `json:"projectID"`
+	ImgPath   string            `json:"imgPath"`
+	Thumbs    map[string]string `json:"thumbs"` // Thumbnail path by size
+	Mime      string            `json:"mime"`   //
+	Width     int               `json:"width"`  //
+	Height    int               `json:"height"` //
+	Size      int64             `json:"size"`   // Bytes
+	Cover     bool              `json:"cover"`  // Set as cover (first image of project is cover)
 } //@name RProjectAddImage
 
+type RProjectReorderImages struct {
+	ProjectId int64   `json:"projectId" form:"-"`           // Path
+	Ids       []int64 `json:"ids" binding:"required,min=1"` // All image ids of project in new order
+} //@name RProjectReorderImages
+
 func (rproject *RProjectCreate) ToProject() *models.Project {
 	var project = &models.Project{
 		ID:        0,
@@ -60,6 +99,7 @@ func (rproject *RProjectCreate) ToProject() *models.Project {
 		Owner:     rproject.Owner,
 		Location:  rproject.Location,
 		Specs:     rproject.Specs.ToProjectSpecs(),
+		DomainId:  rproject.DomainId,
 		Descs:     make([]*models.ProjectDescription, len(rproject.Descs)),
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
@@ -89,3 +129,17 @@ func (rspec *RProjectUpdateSpecs) ToProjectSpecs() *models.ProjectSpecs {
 		Specs:     rspec.Specs,
 	}
 }
+
+// Description of first language of chain has description (nil if project
+// has no description in chain)
+func SelectDescription(descs []*models.ProjectDescription, chain []string,
+) *models.ProjectDescription {
+	for _, lang := range chain {
+		for _, desc := range descs {
+			if normalizeLanguage(desc.Language) == lang {
+				return desc
+			}
+		}
+	}
+	return nil
+}
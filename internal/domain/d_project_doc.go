@@ -0,0 +1,138 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/go-shared/libs/esign"
+	"github.com/Dcarbon/iott-cloud/internal/models"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Prefix of signed (anchored) chain hash
+const DocSignPrefix = "dcarbon_doc_"
+
+type IProjectDoc interface {
+	Create(req *RProjectDocCreate, file *DocFile) (*models.ProjectDoc, error)
+	AddVersion(req *RProjectDocVersion, file *DocFile) (*models.ProjectDoc, error)
+	Update(req *RProjectDocUpdate) (*models.ProjectDoc, error)
+
+	GetList(req *RProjectDocGetList) ([]*models.ProjectDoc, error)                                         // Latest version is set
+	GetById(projectId, docId int64) (*models.ProjectDoc, error)                                            // All versions (newest first)
+	GetVersion(projectId, docId int64, version int) (*models.ProjectDoc, *models.ProjectDocVersion, error) // 0: latest
+}
+
+type RProjectDocCreate struct {
+	ProjectId int64                   `json:"projectId" form:"-"`                            // Path
+	Type      models.ProjectDocType   `json:"type" form:"type" binding:"required"`           //
+	Title     string                  `json:"title" form:"title" binding:"required,max=256"` //
+	Access    models.ProjectDocAccess `json:"access" form:"access"`                          // Default: private
+	Note      string                  `json:"note" form:"note" binding:"max=1024"`           // Note of first version
+} //@name RProjectDocCreate
+
+type RProjectDocVersion struct {
+	ProjectId int64  `json:"projectId" form:"-"`                  // Path
+	DocId     int64  `json:"docId" form:"-"`                      // Path
+	Note      string `json:"note" form:"note" binding:"max=1024"` //
+} //@name RProjectDocVersion
+
+type RProjectDocUpdate struct {
+	ProjectId int64                   `json:"projectId" form:"-"`      // Path
+	DocId     int64                   `json:"docId" form:"-"`          // Path
+	Title     string                  `json:"title" binding:"max=256"` // Empty: not changed
+	Access    models.ProjectDocAccess `json:"access"`                  // Empty: not changed
+} //@name RProjectDocUpdate
+
+type RProjectDocGetList struct {
+	ProjectId int64                 `json:"projectId" form:"-"` // Path
+	Type      models.ProjectDocType `json:"type" form:"type"`   //
+	Private   bool                  `json:"-" form:"-"`         // Include private documents (caller can read)
+} //@name RProjectDocGetList
+
+// Uploaded content of version (was saved to storage)
+type DocFile struct {
+	Path     string             //
+	FileName string             //
+	Mime     string             //
+	Size     int64              //
+	Sha256   string             // Hex
+	Uploader dmodels.EthAddress //
+}
+
+type DocVersionCheck struct {
+	Version        int   `json:"version"`                //
+	ChainValid     bool  `json:"chainValid"`             // Chain hash matches content hash & previous version
+	Signed         bool  `json:"signed"`                 // Version was anchored
+	SignatureValid bool  `json:"signatureValid"`         // Signature of chain hash by signer
+	ContentValid   *bool `json:"contentValid,omitempty"` // Content of storage matches sha256 (nil: not checked)
+} //@name DocVersionCheck
+
+type RsDocVerify struct {
+	DocId    int64              `json:"docId"`    //
+	Valid    bool               `json:"valid"`    // All checks are passed
+	Versions []*DocVersionCheck `json:"versions"` // Oldest first
+} //@name RsDocVerify
+
+func (req *RProjectDocCreate) Normalize() error {
+	if !req.Type.IsValid() {
+		return dmodels.ErrBadRequest("Invalid document type: " + string(req.Type))
+	}
+	if req.Access == "" {
+		req.Access = models.ProjectDocPrivate
+	}
+	if !req.Access.IsValid() {
+		return dmodels.ErrBadRequest("Invalid access: " + string(req.Access))
+	}
+	return nil
+}
+
+// Chain hash of version: sha256("prev|projectId|docId|version|sha256") (hex).
+// Prev of version 1 is empty, so modified (or removed) older version breaks
+// chain of newer versions
+func DocChainHash(prev string, projectId, docId int64, version int, contentHash string) string {
+	var h = sha256.Sum256([]byte(fmt.Sprintf("%s|%d|%d|%d|%s", prev, projectId, docId, version, contentHash)))
+	return hex.EncodeToString(h[:])
+}
+
+// Verify chain hash and signature of versions (any order) of document
+func VerifyDocVersions(doc *models.ProjectDoc) *RsDocVerify {
+	var byVersion = make(map[int]*models.ProjectDocVersion, len(doc.Versions))
+	for _, v := range doc.Versions {
+		byVersion[v.Version] = v
+	}
+
+	var rs = &RsDocVerify{
+		DocId:    doc.ID,
+		Valid:    len(byVersion) == doc.Version,
+		Versions: make([]*DocVersionCheck, 0, doc.Version),
+	}
+
+	var prev = ""
+	for version := 1; version <= doc.Version; version++ {
+		var v, ok = byVersion[version]
+		if !ok {
+			rs.Valid = false
+			prev = ""
+			continue
+		}
+
+		var check = &DocVersionCheck{
+			Version:    version,
+			ChainValid: v.ChainHash == DocChainHash(prev, doc.ProjectID, doc.ID, version, v.Sha256),
+			Signed:     v.Signature != "",
+		}
+		if check.Signed {
+			signed, err := hexutil.Decode(v.Signature)
+			check.SignatureValid = nil == err && nil == esign.VerifyPersonalSign(
+				string(v.Signer), []byte(DocSignPrefix+v.ChainHash), signed,
+			)
+		}
+
+		rs.Valid = rs.Valid && check.ChainValid && (!check.Signed || check.SignatureValid)
+		rs.Versions = append(rs.Versions, check)
+		prev = v.ChainHash
+	}
+	return rs
+}
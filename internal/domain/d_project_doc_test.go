@@ -0,0 +1,101 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/Dcarbon/iott-cloud/internal/models"
+	"github.com/Dcarbon/iott-cloud/internal/signer"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+const testDocKey = "0123456789012345678901234567890123456789012345678901234567890123"
+
+func newTestDoc(t *testing.T, s signer.Signer, hashes ...string) *models.ProjectDoc {
+	var doc = &models.ProjectDoc{ID: 2, ProjectID: 1, Version: len(hashes)}
+	var prev = ""
+	for i, hash := range hashes {
+		var v = &models.ProjectDocVersion{DocID: doc.ID, Version: i + 1, Sha256: hash}
+		v.ChainHash = DocChainHash(prev, doc.ProjectID, doc.ID, v.Version, hash)
+		if nil != s {
+			signed, err := signer.SignText(s, []byte(DocSignPrefix+v.ChainHash))
+			if nil != err {
+				t.Fatalf("sign chain hash: %s", err)
+			}
+			v.Signer = s.Address()
+			v.Signature = hexutil.Encode(signed)
+		}
+		doc.Versions = append(doc.Versions, v)
+		prev = v.ChainHash
+	}
+	return doc
+}
+
+func TestDocChainHash(t *testing.T) {
+	var h1 = DocChainHash("", 1, 2, 1, "aa")
+	if h1 != DocChainHash("", 1, 2, 1, "aa") || len(h1) != 64 {
+		t.Fatalf("chain hash must be deterministic hex sha256: %s", h1)
+	}
+	if h1 == DocChainHash("", 1, 2, 1, "ab") || h1 == DocChainHash("", 1, 3, 1, "aa") {
+		t.Fatalf("chain hash must depend on content and document")
+	}
+	if DocChainHash(h1, 1, 2, 2, "bb") == DocChainHash("", 1, 2, 2, "bb") {
+		t.Fatalf("chain hash must depend on previous version")
+	}
+}
+
+func TestVerifyDocVersions(t *testing.T) {
+	s, err := signer.NewLocalSigner(testDocKey)
+	if nil != err {
+		t.Fatalf("new signer: %s", err)
+	}
+
+	var doc = newTestDoc(t, s, "aa", "bb", "cc")
+	var rs = VerifyDocVersions(doc)
+	if !rs.Valid || len(rs.Versions) != 3 {
+		t.Fatalf("document must be valid: %+v", rs)
+	}
+	for _, check := range rs.Versions {
+		if !check.ChainValid || !check.Signed || !check.SignatureValid {
+			t.Fatalf("version must be valid: %+v", check)
+		}
+	}
+
+	if rs = VerifyDocVersions(newTestDoc(t, nil, "aa", "bb")); !rs.Valid || rs.Versions[0].Signed {
+		t.Fatalf("unsigned document must be valid: %+v", rs)
+	}
+
+	// Modified content hash of version 2
+	doc.Versions[1].Sha256 = "bc"
+	if rs = VerifyDocVersions(doc); rs.Valid || rs.Versions[1].ChainValid || !rs.Versions[2].ChainValid {
+		t.Fatalf("modified version must be invalid: %+v", rs)
+	}
+
+	// Re-chained version 2 breaks chain of version 3
+	doc.Versions[1].ChainHash = DocChainHash(doc.Versions[0].ChainHash, 1, 2, 2, "bc")
+	if rs = VerifyDocVersions(doc); rs.Valid || !rs.Versions[1].ChainValid || rs.Versions[2].ChainValid {
+		t.Fatalf("re-chained version must be invalid: %+v", rs)
+	}
+
+	// Removed version 2
+	doc = newTestDoc(t, nil, "aa", "bb", "cc")
+	doc.Versions = append(doc.Versions[:1], doc.Versions[2])
+	if rs = VerifyDocVersions(doc); rs.Valid || len(rs.Versions) != 2 || rs.Versions[1].ChainValid {
+		t.Fatalf("removed version must be invalid: %+v", rs)
+	}
+}
+
+func TestProjectDocCreateNormalize(t *testing.T) {
+	var req = &RProjectDocCreate{Type: models.ProjectDocPDD}
+	if err := req.Normalize(); nil != err || req.Access != models.ProjectDocPrivate {
+		t.Fatalf("default access must be private: %v %s", err, req.Access)
+	}
+
+	for _, it := range []*RProjectDocCreate{
+		{Type: "invoice"},
+		{Type: models.ProjectDocPDD, Access: "internal"},
+	} {
+		if err := it.Normalize(); nil == err {
+			t.Fatalf("document %+v must be invalid", it)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/iott-cloud/internal/models"
+)
+
+type RProjectStats struct {
+	ProjectId int64 `json:"projectId" form:"-"` // Path
+	From      int64 `json:"from" form:"from"`   // Unix (second), inclusive (default: to - 30 days)
+	To        int64 `json:"to" form:"to"`       // Unix (second), exclusive (default: next hour)
+	AggOption       // Minted series (default bucket: day)
+} //@name RProjectStats
+
+type ProjectDeviceStats struct {
+	Total    int64                          `json:"total"`
+	ByStatus map[dmodels.DeviceStatus]int64 `json:"byStatus"`
+	ByType   map[models.IOTType]int64       `json:"byType"`
+} // @name ProjectDeviceStats
+
+// Latest metric of sensor type (in all iots of project)
+type ProjectLatestMetric struct {
+	SensorType dmodels.SensorType `json:"sensorType"`
+	SensorId   int64              `json:"sensorId"`
+	IotId      int64              `json:"iotId"`
+	Metric     *dmodels.AllMetric `json:"metric"`
+	CreatedAt  int64              `json:"createdAt"` // Unix (second)
+} // @name ProjectLatestMetric
+
+type RsProjectStats struct {
+	ProjectId     int64                  `json:"projectId"`     //
+	From          int64                  `json:"from"`          //
+	To            int64                  `json:"to"`            //
+	Devices       *ProjectDeviceStats    `json:"devices"`       //
+	TotalMinted   int64                  `json:"totalMinted"`   // All time
+	PeriodMinted  int64                  `json:"periodMinted"`  // In period
+	ActiveDays    int64                  `json:"activeDays"`    // Days of period has minted (any iot)
+	ActiveRatio   float64                `json:"activeRatio"`   // Iot-days has minted / (success iots * days of period)
+	LatestMetrics []*ProjectLatestMetric `json:"latestMetrics"` // By sensor type
+	Minted        *AggSeries             `json:"minted"`        // Minted series of project
+	ComputedAt    int64                  `json:"computedAt"`    // Unix (second), stats may be cached
+} // @name RsProjectStats
+
+type IProjectStats interface {
+	GetStats(*RProjectStats) (*RsProjectStats, error)
+}
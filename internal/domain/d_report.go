@@ -0,0 +1,16 @@
+package domain
+
+import (
+	"github.com/Dcarbon/iott-cloud/internal/report"
+)
+
+type RReport struct {
+	ProjectId int64         `json:"projectId" form:"-"`                  // Path
+	From      int64         `json:"from" form:"from" binding:"required"` // Unix (second), inclusive
+	To        int64         `json:"to" form:"to" binding:"required"`     // Unix (second), exclusive
+	Format    report.Format `json:"format" form:"format"`                // json (default), csv, pdf
+} //@name RReport
+
+type IReport interface {
+	Generate(*RReport) (*report.Report, error)
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/iott-cloud/internal/export"
 	"github.com/Dcarbon/iott-cloud/internal/models"
 )
 
@@ -40,9 +41,10 @@ type RChangeSensorStatus struct {
 // }
 
 type RGetSensors struct {
-	Skip  int   `json:"skip"`  //
-	Limit int   `json:"limit"` //
-	IotId int64 `json:"iotId"` //
+	Cursor string `json:"cursor"` // Next/prev cursor of previous page
+	Skip   int    `json:"skip"`   // Deprecated (use cursor)
+	Limit  int    `json:"limit"`  //
+	IotId  int64  `json:"iotId"`  //
 }
 
 type RCreateSM struct {
@@ -61,22 +63,50 @@ type RCreateSensorMetric struct {
 } //@name RCreateSensorMetric
 
 type RGetSM struct {
-	From     int64 `json:"from" form:"from" binding:"required"`          // Timestamp start
-	To       int64 `json:"to" form:"to" binding:"required"`              // Timestamp end
-	IotId    int64 `json:"iotId" form:"iotId" binding:"required"`        //
-	SensorId int64 `json:"sensorId" form:"sensorId" `                    //
-	Skip     int64 `json:"skip" form:"skip"`                             //
-	Limit    int64 `json:"limit" form:"limit" binding:"required,max=50"` //
-	WithSign bool  `json:"withSign" form:"withSign"`
-	Sort     Sort  `json:"sort" form:"sort"`
+	From     int64  `json:"from" form:"from" binding:"required"`          // Timestamp start
+	To       int64  `json:"to" form:"to" binding:"required"`              // Timestamp end
+	IotId    int64  `json:"iotId" form:"iotId" binding:"required"`        //
+	SensorId int64  `json:"sensorId" form:"sensorId" `                    //
+	Skip     int64  `json:"skip" form:"skip"`                             // Deprecated (use cursor)
+	Limit    int64  `json:"limit" form:"limit" binding:"required,max=50"` //
+	WithSign bool   `json:"withSign" form:"withSign"`
+	Sort     Sort   `json:"sort" form:"sort"`
+	Cursor   string `json:"cursor" form:"cursor"` // Next/prev cursor of previous page
+}
+
+// Streaming export of metrics (no limit)
+type RExportSM struct {
+	From     int64         `json:"from" form:"from" binding:"required"`   // Timestamp start (inclusive)
+	To       int64         `json:"to" form:"to" binding:"required"`       // Timestamp end (exclusive)
+	IotId    int64         `json:"iotId" form:"iotId" binding:"required"` //
+	SensorId int64         `json:"sensorId" form:"sensorId"`              //
+	Format   export.Format `json:"format" form:"format"`                  // csv (default), ndjson, parquet
+	WithSign bool          `json:"withSign" form:"withSign"`              // Include raw data & signature
+	Gzip     bool          `json:"gzip" form:"gzip"`                      //
 }
 
 type RSMAggregate struct {
-	From     int64 `json:"from" form:"from" binding:"required"`         // Timestamp start
-	To       int64 `json:"to" form:"to" binding:"required"`             // Timestamp end
-	IotId    int64 `json:"iotId" form:"iotId" binding:"required"`       //
-	SensorId int64 `json:"sensorId" form:"sensorId" binding:"required"` //
-	Interval int   `json:"interval" form:"interval" binding:"required"` // 1 : day 2: month
+	From      int64   `json:"from" form:"from" binding:"required"`   // Timestamp start
+	To        int64   `json:"to" form:"to" binding:"required"`       // Timestamp end
+	IotId     int64   `json:"iotId" form:"iotId" binding:"required"` //
+	SensorId  int64   `json:"sensorId" form:"sensorId"`              //
+	SensorIds []int64 `json:"sensorIds" form:"sensorIds"`            // Multiple sensor (merge with sensorId)
+	Interval  int     `json:"interval" form:"interval"`              // Deprecated (use bucket). 1 : day 2: month (legacy aggregate if bucket is empty)
+	AggOption
+}
+
+// Sensor ids of request (sensorId and sensorIds)
+func (req *RSMAggregate) GetSensorIds() []int64 {
+	var ids = make([]int64, 0, len(req.SensorIds)+1)
+	if req.SensorId != 0 {
+		ids = append(ids, req.SensorId)
+	}
+	for _, id := range req.SensorIds {
+		if id != 0 && id != req.SensorId {
+			ids = append(ids, id)
+		}
+	}
+	return ids
 }
 
 type TimeValue struct {
@@ -86,16 +116,19 @@ type TimeValue struct {
 
 type ISensor interface {
 	SetOperatorCache(op IOperator)
+	SetAlerter(alerter IAlertEvaluator)
 
 	CreateSensor(*RCreateSensor) (*models.Sensor, error)
 	ChangeSensorStatus(*RChangeSensorStatus) (*models.Sensor, error)
 	GetSensor(*SensorID) (*models.Sensor, error)
-	GetSensors(*RGetSensors) ([]*models.Sensor, error)
+	GetSensors(*RGetSensors) ([]*models.Sensor, *PageInfo, error)
 	GetSensorType(req *SensorID) (dmodels.SensorType, error)
 
 	CreateSM(*RCreateSM) (*models.SmSignature, error)                     // old
 	CreateSensorMetric(*RCreateSensorMetric) (*models.SmSignature, error) // New
 
-	GetMetrics(*RGetSM) ([]*Metric, error)
+	GetMetrics(*RGetSM) ([]*Metric, *PageInfo, error)
+	ExportMetrics(req *RExportSM, fn func(*export.Metric) error) error
 	GetAggregatedMetrics(*RSMAggregate) ([]*TimeValue, error)
+	AggregateMetrics(*RSMAggregate) ([]*AggSeries, error)
 }
@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/go-shared/libs/esign"
+	"github.com/Dcarbon/iott-cloud/internal/models"
+)
+
+type RSignDomainCreate struct {
+	Label             string             `json:"label" binding:"required"`             // Ex: bsc-v1
+	Name              string             `json:"name"`                                 // Default: CARBON
+	Version           string             `json:"version" binding:"required"`           //
+	ChainId           int64              `json:"chainId" binding:"required"`           //
+	VerifyingContract dmodels.EthAddress `json:"verifyingContract" binding:"required"` //
+	IsDefault         bool               `json:"isDefault"`                            //
+} // @name RSignDomainCreate
+
+// Move IoTs (by ids or all IoTs of project) to other sign domain
+type RIotMoveDomain struct {
+	DomainId  int64   `json:"domainId" uri:"id"` //
+	IotIds    []int64 `json:"iotIds"`            //
+	ProjectId int64   `json:"projectId"`         // Move all IoTs & bind project to domain
+	Force     bool    `json:"force"`             // Move IoT has pending signature (it must be re-signed)
+} // @name RIotMoveDomain
+
+type RsIotMoveDomain struct {
+	Moved   []int64          `json:"moved"`   //
+	Skipped map[int64]string `json:"skipped"` // Iot id -> reason
+} // @name RsIotMoveDomain
+
+type ISignDomain interface {
+	Create(*RSignDomainCreate) (*models.SignDomain, error)
+	SetDefault(id int64) error
+	GetList() ([]*models.SignDomain, error)
+	GetById(id int64) (*models.SignDomain, error) // Id 0: default domain
+
+	// Domain & typed data of mint signature (id 0: default domain)
+	GetMinter(id int64) (*models.SignDomain, *esign.ERC712, error)
+}
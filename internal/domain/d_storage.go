@@ -0,0 +1,12 @@
+package domain
+
+import "io"
+
+// Storage of uploaded files. Path of public file is relative to server host,
+// path of private file is only read by Open
+type IStorage interface {
+	PutProjectFile(projectId int64, name string, data []byte) (string, error)
+	PutPrivateFile(projectId int64, name string, data []byte) (string, error)
+	Open(path string) (io.ReadCloser, error)
+	Delete(path string) error
+}
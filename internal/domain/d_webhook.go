@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"github.com/Dcarbon/iott-cloud/internal/models"
+)
+
+type RWebhookCreate struct {
+	Url       string   `json:"url" binding:"required,url"` //
+	Events    []string `json:"events"`                     // Empty: all events
+	ProjectId int64    `json:"projectId"`                  // 0: all project
+	CreatedBy int64    `json:"-"`                          //
+} // @name RWebhookCreate
+
+type RWebhookUpdate struct {
+	Id        int64    `json:"-"`                          //
+	Url       string   `json:"url" binding:"required,url"` //
+	Events    []string `json:"events"`                     //
+	ProjectId int64    `json:"projectId"`                  //
+	Enabled   bool     `json:"enabled"`                    //
+} // @name RWebhookUpdate
+
+type RWebhookGetList struct {
+	ProjectId int64 `json:"projectId" form:"projectId"`
+	Skip      int   `json:"skip" form:"skip"`
+	Limit     int   `json:"limit" form:"limit" binding:"max=50"`
+} // @name RWebhookGetList
+
+type RWebhookDeliveryGetList struct {
+	SubId  int64                `json:"-"`                    //
+	Status models.WebhookStatus `json:"status" form:"status"` // pending, success, dead
+	Event  string               `json:"event" form:"event"`   //
+	Skip   int                  `json:"skip" form:"skip"`     //
+	Limit  int                  `json:"limit" form:"limit" binding:"max=50"`
+} // @name RWebhookDeliveryGetList
+
+type IWebhook interface {
+	CreateSub(req *RWebhookCreate) (*models.WebhookSub, error)
+	UpdateSub(req *RWebhookUpdate) (*models.WebhookSub, error)
+	DeleteSub(id int64) error
+	GetSubs(req *RWebhookGetList) ([]*models.WebhookSub, error)
+
+	GetDeliveries(req *RWebhookDeliveryGetList) ([]*models.WebhookDelivery, error)
+	Redeliver(subId int64, deliveryId string) (*models.WebhookDelivery, error)
+
+	// Queue delivery of event for all matched subscriptions. Event id is
+	// idempotency key: event was queued is ignored
+	Dispatch(evt *models.EventEnvelope) error
+}
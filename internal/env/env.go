@@ -5,4 +5,10 @@ import "github.com/Dcarbon/go-shared/libs/utils"
 var ServerHost = utils.StringEnv("SERVER_HOST", "localhost:4001")
 var ServerScheme = utils.StringEnv("SERVER_SCHEME", "http")
 
+// Allowed origins of browser stream (comma separated). Origin of server host is always allowed
+var StreamOrigins = utils.StringEnv("STREAM_ORIGINS", "")
+
 var StorageHost = utils.StringEnv("STORAGE_HOST", "")
+var StorageDriver = utils.StringEnv("STORAGE_DRIVER", "remote") // remote, local
+var StorageLocalDir = utils.StringEnv("STORAGE_LOCAL_DIR", "./static")
+var StoragePrivateDir = utils.StringEnv("STORAGE_PRIVATE_DIR", "./private")
@@ -0,0 +1,116 @@
+// Catalogue of domain events was published to rabbitmq (routing key is event
+// name) and webhooks. Body of message is models.EventEnvelope, data is
+// payload of event at envelope version (json schema in ./schemas)
+package events
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Dcarbon/iott-cloud/internal/models"
+)
+
+//go:embed schemas/*.json
+var schemaFS embed.FS
+
+type Definition struct {
+	Name        string          `json:"name"`        // Event name (routing key)
+	Version     int             `json:"version"`     // Current version of payload
+	Description string          `json:"description"` //
+	Schema      json.RawMessage `json:"schema"`      // Json schema of payload
+} // @name EventDefinition
+
+var catalogue = []*Definition{
+	{
+		Name:        models.EventIotCreated,
+		Version:     1,
+		Description: "IoT device was registered",
+	},
+	{
+		Name:        models.EventIotStatusChanged,
+		Version:     1,
+		Description: "Status of IoT device was changed",
+	},
+	{
+		Name:        models.EventSensorCreated,
+		Version:     1,
+		Description: "Sensor was registered to IoT device",
+	},
+	{
+		Name:        models.EventSensorStatusChanged,
+		Version:     1,
+		Description: "Status of sensor was changed",
+	},
+	{
+		Name:        models.EventMetricAccepted,
+		Version:     1,
+		Description: "Signed sensor metric was verified and saved",
+	},
+	{
+		Name:        models.EventMintSigned,
+		Version:     1,
+		Description: "Mint signature was accepted with carbon increment",
+	},
+	{
+		Name:        models.EventProjectCreated,
+		Version:     1,
+		Description: "Project was created",
+	},
+	{
+		Name:        models.EventProjectUpdated,
+		Version:     1,
+		Description: "Description or specs of project was updated",
+	},
+	{
+		Name:        models.EventProjectStatusChanged,
+		Version:     1,
+		Description: "Status of project was changed",
+	},
+	{
+		Name:        models.EventProjectImageAdded,
+		Version:     1,
+		Description: "Image was added to project",
+	},
+	{
+		Name:        models.EventProjectImageRemoved,
+		Version:     1,
+		Description: "Image was removed from project",
+	},
+	{
+		Name:        models.EventProjectDocVersion,
+		Version:     1,
+		Description: "Document (or new version of document) was uploaded to project",
+	},
+}
+
+var byName = make(map[string]*Definition)
+
+func init() {
+	for _, def := range catalogue {
+		var path = fmt.Sprintf("schemas/%s.v%d.json", def.Name, def.Version)
+		raw, err := schemaFS.ReadFile(path)
+		if nil != err {
+			panic("Missing schema of event: " + path)
+		}
+		def.Schema = raw
+		byName[def.Name] = def
+	}
+}
+
+func Catalogue() []*Definition {
+	return catalogue
+}
+
+func Get(name string) (*Definition, bool) {
+	def, ok := byName[name]
+	return def, ok
+}
+
+// Current version of event (0 if event is not in catalogue)
+func Version(name string) int {
+	if def, ok := byName[name]; ok {
+		return def.Version
+	}
+	return 0
+}
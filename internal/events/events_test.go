@@ -0,0 +1,102 @@
+package events
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/iott-cloud/internal/models"
+)
+
+type schemaTest struct {
+	Required   []string                   `json:"required"`
+	Properties map[string]json.RawMessage `json:"properties"`
+}
+
+func TestCatalogueCoverEvents(t *testing.T) {
+	for _, name := range models.EventTypes {
+		if Version(name) == 0 {
+			t.Fatalf("Event %s is not in catalogue", name)
+		}
+	}
+	if len(Catalogue()) != len(models.EventTypes) {
+		t.Fatalf("Catalogue has event was not declared in models")
+	}
+}
+
+// Json of payload must have all required fields & no undocumented field
+func TestPayloadMatchSchema(t *testing.T) {
+	var addr = dmodels.EthAddress("0x5c7b6a0d4b3e3fa1c5c3e3a2a1d2e5f1b1c2d3e4")
+	var now = time.Now()
+	var samples = map[string]interface{}{
+		models.EventIotCreated: NewIotV1(&models.IOTDevice{
+			ID: 1, Project: 1, Address: addr,
+		}),
+		models.EventIotStatusChanged: NewIotV1(&models.IOTDevice{
+			ID: 1, Project: 1, Address: addr,
+		}),
+		models.EventSensorCreated:       NewSensorV1(&models.Sensor{ID: 1, IotID: 1, Address: &addr}),
+		models.EventSensorStatusChanged: NewSensorV1(&models.Sensor{ID: 1, IotID: 1, Address: &addr}),
+		models.EventMetricAccepted: NewMetricV1(&models.Sm{
+			ID: "1", IotID: 1, SensorID: 1, Indicator: &dmodels.AllMetric{}, CreatedAt: now,
+		}, dmodels.SensorTypeFlow),
+		models.EventMintSigned: NewMintV1(
+			&models.MintSign{IotId: 1, Iot: string(addr), Nonce: 1, Amount: "0x1", CreatedAt: now},
+			&models.Minted{ID: "1", Carbon: 1},
+		),
+		models.EventProjectCreated: NewProjectV1(&models.Project{
+			ID: 1, Owner: addr, Location: &models.Point4326{},
+		}),
+		models.EventProjectStatusChanged: NewProjectV1(&models.Project{
+			ID: 1, Owner: addr, Location: &models.Point4326{},
+		}),
+		models.EventProjectUpdated: &ProjectUpdatedV1{
+			Id: 1, Field: "specs", Specs: map[string]float64{"power": 1},
+		},
+		models.EventProjectImageAdded: NewProjectImageV1(&models.ProjectImage{
+			ID: 1, ProjectID: 1, Image: "a.png", CreatedAt: now,
+		}),
+		models.EventProjectImageRemoved: NewProjectImageV1(&models.ProjectImage{
+			ID: 1, ProjectID: 1, Image: "a.png", CreatedAt: now,
+		}),
+		models.EventProjectDocVersion: NewProjectDocVersionV1(
+			&models.ProjectDoc{ID: 1, ProjectID: 1, Type: models.ProjectDocPDD, Access: models.ProjectDocPublic},
+			&models.ProjectDocVersion{Version: 1, Sha256: "00", ChainHash: "11", CreatedAt: now},
+		),
+	}
+
+	for _, def := range Catalogue() {
+		var schema = &schemaTest{}
+		var err = json.Unmarshal(def.Schema, schema)
+		if nil != err {
+			t.Fatalf("Schema of %s is invalid: %s", def.Name, err)
+		}
+
+		sample, ok := samples[def.Name]
+		if !ok {
+			t.Fatalf("Missing sample of %s", def.Name)
+		}
+		raw, err := json.Marshal(sample)
+		if nil != err {
+			t.Fatalf("Marshal sample of %s error: %s", def.Name, err)
+		}
+
+		var fields = make(map[string]interface{})
+		err = json.Unmarshal(raw, &fields)
+		if nil != err {
+			t.Fatalf("Unmarshal sample of %s error: %s", def.Name, err)
+		}
+
+		for _, name := range schema.Required {
+			if _, ok := fields[name]; !ok {
+				t.Fatalf("Payload of %s missing required field %s", def.Name, name)
+			}
+		}
+		for name := range fields {
+			if _, ok := schema.Properties[name]; !ok {
+				t.Fatalf("Field %s of %s is not in schema", name, def.Name)
+			}
+		}
+	}
+}
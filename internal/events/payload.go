@@ -0,0 +1,190 @@
+package events
+
+import (
+	"time"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/iott-cloud/internal/models"
+)
+
+// Payloads of events. Field can be added in the same version, breaking change
+// (remove/rename/retype field) must bump version of event in catalogue
+
+type GPS struct {
+	Lng float64 `json:"lng"`
+	Lat float64 `json:"lat"`
+} // @name EventGPS
+
+// iot.created, iot.status_changed (v1)
+type IotV1 struct {
+	Id       int64                `json:"id"`
+	Project  int64                `json:"project"`
+	Address  string               `json:"address"`
+	Type     models.IOTType       `json:"type"`
+	Status   dmodels.DeviceStatus `json:"status"`
+	Position *GPS                 `json:"position"`
+} // @name EventIotV1
+
+// sensor.created, sensor.status_changed (v1)
+type SensorV1 struct {
+	Id        int64                `json:"id"`
+	IotId     int64                `json:"iotId"`
+	Address   string               `json:"address"`
+	Type      dmodels.SensorType   `json:"type"`
+	Status    dmodels.DeviceStatus `json:"status"`
+	CreatedAt time.Time            `json:"createdAt"`
+} // @name EventSensorV1
+
+// sensor.metric_accepted (v1)
+type MetricV1 struct {
+	Id         string             `json:"id"`
+	IotId      int64              `json:"iotId"`
+	SensorId   int64              `json:"sensorId"`
+	SensorType dmodels.SensorType `json:"sensorType"`
+	Metric     *dmodels.AllMetric `json:"metric"`
+	CreatedAt  time.Time          `json:"createdAt"`
+} // @name EventMetricV1
+
+// mint.signed (v1)
+type MintV1 struct {
+	IotId     int64     `json:"iotId"`
+	Iot       string    `json:"iot"`       // Iot address
+	DomainId  int64     `json:"domainId"`  // Sign domain (see /domains)
+	Nonce     int64     `json:"nonce"`     //
+	Amount    string    `json:"amount"`    // Hex. Total amount was signed
+	Increment int64     `json:"increment"` // Amount was minted by this signature
+	MintedId  string    `json:"mintedId"`  //
+	Signer    string    `json:"signer"`    // Oracle address. Empty: signed by iot
+	CreatedAt time.Time `json:"createdAt"` //
+} // @name EventMintV1
+
+// project.created, project.status_changed (v1)
+type ProjectV1 struct {
+	Id           int64                `json:"id"`
+	Owner        string               `json:"owner"`
+	Status       models.ProjectStatus `json:"status"`
+	LocationName string               `json:"locationName"`
+	Location     *GPS                 `json:"location"`
+	Area         float64              `json:"area"`
+	CreatedAt    time.Time            `json:"createdAt"`
+} // @name EventProjectV1
+
+// project.updated (v1)
+type ProjectUpdatedV1 struct {
+	Id       int64              `json:"id"`
+	Field    string             `json:"field"`              // desc, specs
+	Language string             `json:"language,omitempty"` // Field desc
+	Specs    map[string]float64 `json:"specs,omitempty"`    // Field specs
+} // @name EventProjectUpdatedV1
+
+// project.image_added, project.image_removed (v1)
+type ProjectImageV1 struct {
+	Id        int64             `json:"id"`
+	ProjectId int64             `json:"projectId"`
+	Image     string            `json:"image"`
+	Thumbs    map[string]string `json:"thumbs,omitempty"`
+	Cover     bool              `json:"cover"`
+	CreatedAt time.Time         `json:"createdAt"`
+} // @name EventProjectImageV1
+
+// project.doc_version_added (v1). Content is not included (private document)
+type ProjectDocVersionV1 struct {
+	DocId     int64     `json:"docId"`
+	ProjectId int64     `json:"projectId"`
+	Type      string    `json:"type"`
+	Access    string    `json:"access"`
+	Version   int       `json:"version"`
+	Sha256    string    `json:"sha256"`
+	ChainHash string    `json:"chainHash"`
+	CreatedAt time.Time `json:"createdAt"`
+} // @name EventProjectDocVersionV1
+
+func NewIotV1(iot *models.IOTDevice) *IotV1 {
+	return &IotV1{
+		Id:       iot.ID,
+		Project:  iot.Project,
+		Address:  string(iot.Address),
+		Type:     iot.Type,
+		Status:   iot.Status,
+		Position: &GPS{Lng: iot.Position.Lng, Lat: iot.Position.Lat},
+	}
+}
+
+func NewSensorV1(sensor *models.Sensor) *SensorV1 {
+	var payload = &SensorV1{
+		Id:        sensor.ID,
+		IotId:     sensor.IotID,
+		Type:      sensor.Type,
+		Status:    sensor.Status,
+		CreatedAt: sensor.CreatedAt,
+	}
+	if nil != sensor.Address {
+		payload.Address = string(*sensor.Address)
+	}
+	return payload
+}
+
+func NewMetricV1(sm *models.Sm, sType dmodels.SensorType) *MetricV1 {
+	return &MetricV1{
+		Id:         sm.ID,
+		IotId:      sm.IotID,
+		SensorId:   sm.SensorID,
+		SensorType: sType,
+		Metric:     sm.Indicator,
+		CreatedAt:  sm.CreatedAt,
+	}
+}
+
+func NewMintV1(mint *models.MintSign, minted *models.Minted) *MintV1 {
+	return &MintV1{
+		IotId:     mint.IotId,
+		Iot:       mint.Iot,
+		DomainId:  mint.DomainId,
+		Nonce:     mint.Nonce,
+		Amount:    mint.Amount,
+		Increment: minted.Carbon,
+		MintedId:  minted.ID,
+		Signer:    mint.Signer,
+		CreatedAt: mint.CreatedAt,
+	}
+}
+
+func NewProjectV1(project *models.Project) *ProjectV1 {
+	var payload = &ProjectV1{
+		Id:           project.ID,
+		Owner:        string(project.Owner),
+		Status:       project.Status,
+		LocationName: project.LocationName,
+		Area:         project.Area,
+		CreatedAt:    project.CreatedAt,
+	}
+	if nil != project.Location {
+		payload.Location = &GPS{Lng: project.Location.Lng, Lat: project.Location.Lat}
+	}
+	return payload
+}
+
+func NewProjectImageV1(img *models.ProjectImage) *ProjectImageV1 {
+	return &ProjectImageV1{
+		Id:        img.ID,
+		ProjectId: img.ProjectID,
+		Image:     img.Image,
+		Thumbs:    img.Thumbs,
+		Cover:     img.Cover,
+		CreatedAt: img.CreatedAt,
+	}
+}
+
+func NewProjectDocVersionV1(doc *models.ProjectDoc, v *models.ProjectDocVersion,
+) *ProjectDocVersionV1 {
+	return &ProjectDocVersionV1{
+		DocId:     doc.ID,
+		ProjectId: doc.ProjectID,
+		Type:      string(doc.Type),
+		Access:    string(doc.Access),
+		Version:   v.Version,
+		Sha256:    v.Sha256,
+		ChainHash: v.ChainHash,
+		CreatedAt: v.CreatedAt,
+	}
+}
@@ -0,0 +1,121 @@
+// Streaming writers of metric export (csv, ndjson, parquet). Rows are written
+// as they are read from db (parquet buffers one row group)
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+)
+
+type Format string
+
+const (
+	FormatCSV     Format = "csv"
+	FormatNDJSON  Format = "ndjson" // One json object per line
+	FormatParquet Format = "parquet"
+)
+
+var ErrInvalidFormat = errors.New("invalid export format (csv, ndjson, parquet)")
+
+func (f Format) IsValid() bool {
+	return f == FormatCSV || f == FormatNDJSON || f == FormatParquet
+}
+
+func (f Format) ContentType() string {
+	switch f {
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	case FormatParquet:
+		return "application/vnd.apache.parquet"
+	}
+	return "text/csv"
+}
+
+// Row of metric export
+type Metric struct {
+	ID         string    `json:"id"`
+	IotId      int64     `json:"iotId"`
+	SensorId   int64     `json:"sensorId"`
+	SensorType int32     `json:"sensorType"`
+	CreatedAt  time.Time `json:"createdAt"`
+	Value      float64   `json:"value"`
+	Lng        float64   `json:"lng"`
+	Lat        float64   `json:"lat"`
+	Data       string    `json:"data,omitempty"`   // Hex json of signature (withSign)
+	Signed     string    `json:"signed,omitempty"` // Signature (withSign)
+} // @name ExportMetric
+
+type Writer interface {
+	Write(m *Metric) error
+	Close() error // Flush buffered rows (underlay writer is not closed)
+}
+
+func NewWriter(w io.Writer, format Format, withSign bool) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCsvWriter(w, withSign)
+	case FormatNDJSON:
+		return &ndjsonWriter{enc: json.NewEncoder(w), withSign: withSign}, nil
+	case FormatParquet:
+		return newParquetWriter(w, withSign), nil
+	}
+	return nil, ErrInvalidFormat
+}
+
+type csvWriter struct {
+	w        *csv.Writer
+	withSign bool
+}
+
+func newCsvWriter(w io.Writer, withSign bool) (*csvWriter, error) {
+	var cw = &csvWriter{w: csv.NewWriter(w), withSign: withSign}
+	var header = []string{"id", "iot_id", "sensor_id", "sensor_type", "created_at", "value", "lng", "lat"}
+	if withSign {
+		header = append(header, "data", "signed")
+	}
+	return cw, cw.w.Write(header)
+}
+
+func (cw *csvWriter) Write(m *Metric) error {
+	var row = []string{
+		m.ID,
+		strconv.FormatInt(m.IotId, 10),
+		strconv.FormatInt(m.SensorId, 10),
+		strconv.Itoa(int(m.SensorType)),
+		m.CreatedAt.UTC().Format(time.RFC3339),
+		strconv.FormatFloat(m.Value, 'f', -1, 64),
+		strconv.FormatFloat(m.Lng, 'f', -1, 64),
+		strconv.FormatFloat(m.Lat, 'f', -1, 64),
+	}
+	if cw.withSign {
+		row = append(row, m.Data, m.Signed)
+	}
+	return cw.w.Write(row)
+}
+
+func (cw *csvWriter) Close() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+type ndjsonWriter struct {
+	enc      *json.Encoder
+	withSign bool
+}
+
+func (nw *ndjsonWriter) Write(m *Metric) error {
+	if !nw.withSign {
+		var row = *m
+		row.Data, row.Signed = "", ""
+		return nw.enc.Encode(&row)
+	}
+	return nw.enc.Encode(m)
+}
+
+func (nw *ndjsonWriter) Close() error {
+	return nil
+}
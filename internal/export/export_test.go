@@ -0,0 +1,120 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+func testMetrics(n int) []*Metric {
+	var metrics = make([]*Metric, n)
+	for i := range metrics {
+		metrics[i] = &Metric{
+			ID:         fmt.Sprintf("m%d", i),
+			IotId:      10,
+			SensorId:   100,
+			SensorType: 2,
+			CreatedAt:  time.Unix(1704067200+int64(i)*60, 0),
+			Value:      float64(i) + 0.5,
+			Data:       "0x7b7d",
+			Signed:     "0x01",
+		}
+	}
+	return metrics
+}
+
+func writeAll(t *testing.T, format Format, withSign bool, metrics []*Metric) []byte {
+	var buf = &bytes.Buffer{}
+	w, err := NewWriter(buf, format, withSign)
+	if nil != err {
+		t.Fatalf("create %s writer: %s", format, err)
+	}
+	for _, m := range metrics {
+		if err = w.Write(m); nil != err {
+			t.Fatalf("write %s: %s", format, err)
+		}
+	}
+	if err = w.Close(); nil != err {
+		t.Fatalf("close %s: %s", format, err)
+	}
+	return buf.Bytes()
+}
+
+func TestWriteCSV(t *testing.T) {
+	rows, err := csv.NewReader(bytes.NewReader(writeAll(t, FormatCSV, false, testMetrics(3)))).ReadAll()
+	if nil != err {
+		t.Fatalf("read csv: %s", err)
+	}
+	if len(rows) != 4 || len(rows[0]) != 8 || rows[2][0] != "m1" || rows[2][5] != "1.5" {
+		t.Fatalf("invalid csv: %v", rows)
+	}
+
+	rows, _ = csv.NewReader(bytes.NewReader(writeAll(t, FormatCSV, true, testMetrics(1)))).ReadAll()
+	if len(rows[0]) != 10 || rows[1][9] != "0x01" {
+		t.Fatalf("csv must contain signature: %v", rows)
+	}
+}
+
+func TestWriteNDJSON(t *testing.T) {
+	var scanner = bufio.NewScanner(bytes.NewReader(writeAll(t, FormatNDJSON, false, testMetrics(3))))
+	var n = 0
+	for scanner.Scan() {
+		var m = &Metric{}
+		if err := json.Unmarshal(scanner.Bytes(), m); nil != err {
+			t.Fatalf("decode line %d: %s", n, err)
+		}
+		if m.ID != fmt.Sprintf("m%d", n) || m.Signed != "" {
+			t.Fatalf("invalid line %d: %+v", n, m)
+		}
+		n++
+	}
+	if n != 3 {
+		t.Fatalf("expected 3 lines, got %d", n)
+	}
+}
+
+func TestInvalidFormat(t *testing.T) {
+	_, err := NewWriter(&bytes.Buffer{}, Format("xml"), false)
+	if err != ErrInvalidFormat {
+		t.Fatalf("expected ErrInvalidFormat, got %v", err)
+	}
+}
+
+func TestWriteParquet(t *testing.T) {
+	// More than one row group
+	var metrics = testMetrics(parquetRowGroup + 5)
+	var raw = writeAll(t, FormatParquet, true, metrics)
+
+	file, err := parquet.OpenFile(bytes.NewReader(raw), int64(len(raw)))
+	if nil != err {
+		t.Fatalf("open parquet: %s", err)
+	}
+	if file.NumRows() != int64(len(metrics)) || len(file.RowGroups()) != 2 {
+		t.Fatalf("invalid parquet: %d rows, %d row groups", file.NumRows(), len(file.RowGroups()))
+	}
+
+	var rows = make([]parquetSignedMetric, 2)
+	var reader = parquet.NewReader(bytes.NewReader(raw))
+	defer reader.Close()
+	for i := range rows {
+		if err = reader.Read(&rows[i]); nil != err {
+			t.Fatalf("read parquet: %s", err)
+		}
+	}
+	if rows[1].ID != "m1" || rows[1].Value != 1.5 || rows[1].Signed != "0x01" ||
+		!rows[1].CreatedAt.Equal(metrics[1].CreatedAt) {
+		t.Fatalf("invalid parquet row: %+v", rows[1])
+	}
+
+	raw = writeAll(t, FormatParquet, false, testMetrics(1))
+	file, err = parquet.OpenFile(bytes.NewReader(raw), int64(len(raw)))
+	if nil != err || len(file.Schema().Fields()) != 8 {
+		t.Fatalf("parquet without sign must have 8 columns: %v", err)
+	}
+}
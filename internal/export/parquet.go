@@ -0,0 +1,79 @@
+package export
+
+import (
+	"io"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// Rows are buffered by parquet writer and flushed as a row group every
+// parquetRowGroup rows (footer is written on Close)
+const parquetRowGroup = 10000
+
+type parquetMetric struct {
+	ID         string    `parquet:"id"`
+	IotId      int64     `parquet:"iot_id"`
+	SensorId   int64     `parquet:"sensor_id"`
+	SensorType int32     `parquet:"sensor_type"`
+	CreatedAt  time.Time `parquet:"created_at,timestamp(millisecond)"`
+	Value      float64   `parquet:"value"`
+	Lng        float64   `parquet:"lng"`
+	Lat        float64   `parquet:"lat"`
+}
+
+type parquetSignedMetric struct {
+	parquetMetric
+	Data   string `parquet:"data"`
+	Signed string `parquet:"signed"`
+}
+
+type parquetWriter struct {
+	w        *parquet.Writer
+	withSign bool
+	rows     int
+}
+
+func newParquetWriter(w io.Writer, withSign bool) *parquetWriter {
+	var schema = parquet.SchemaOf(parquetMetric{})
+	if withSign {
+		schema = parquet.SchemaOf(parquetSignedMetric{})
+	}
+	return &parquetWriter{
+		w:        parquet.NewWriter(w, schema, parquet.Compression(&parquet.Snappy)),
+		withSign: withSign,
+	}
+}
+
+func (pw *parquetWriter) Write(m *Metric) error {
+	var row = parquetMetric{
+		ID:         m.ID,
+		IotId:      m.IotId,
+		SensorId:   m.SensorId,
+		SensorType: m.SensorType,
+		CreatedAt:  m.CreatedAt.UTC(),
+		Value:      m.Value,
+		Lng:        m.Lng,
+		Lat:        m.Lat,
+	}
+
+	var err error
+	if pw.withSign {
+		err = pw.w.Write(&parquetSignedMetric{parquetMetric: row, Data: m.Data, Signed: m.Signed})
+	} else {
+		err = pw.w.Write(&row)
+	}
+	if nil != err {
+		return err
+	}
+
+	pw.rows++
+	if pw.rows%parquetRowGroup == 0 {
+		return pw.w.Flush()
+	}
+	return nil
+}
+
+func (pw *parquetWriter) Close() error {
+	return pw.w.Close()
+}
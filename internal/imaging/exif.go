@@ -0,0 +1,122 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+)
+
+const exifTagOrientation = 0x0112
+
+// EXIF orientation (1-8) of jpeg. 1 (normal) if jpeg has no (valid) EXIF
+func Orientation(raw []byte) int {
+	if !bytes.HasPrefix(raw, magicJPEG) {
+		return 1
+	}
+
+	// Segments: 0xFF marker, length (big endian, included itself)
+	var i = 2
+	for i+4 <= len(raw) {
+		if raw[i] != 0xFF {
+			return 1
+		}
+
+		var marker = raw[i+1]
+		if marker == 0xDA || marker == 0xD9 { // Start of scan, end of image
+			return 1
+		}
+
+		var length = int(binary.BigEndian.Uint16(raw[i+2:]))
+		if length < 2 || i+2+length > len(raw) {
+			return 1
+		}
+
+		var seg = raw[i+4 : i+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(seg, []byte("Exif\x00\x00")) {
+			return tiffOrientation(seg[6:])
+		}
+		i += 2 + length
+	}
+	return 1
+}
+
+// Orientation tag in IFD0 of TIFF header
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	var ifd = int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+
+	var count = int(order.Uint16(tiff[ifd:]))
+	for k := 0; k < count; k++ {
+		var entry = ifd + 2 + k*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) != exifTagOrientation {
+			continue
+		}
+
+		var v = int(order.Uint16(tiff[entry+8:]))
+		if v < 1 || v > 8 {
+			return 1
+		}
+		return v
+	}
+	return 1
+}
+
+// Apply EXIF orientation to pixels (result is orientation 1)
+func orient(src *image.NRGBA, orientation int) *image.NRGBA {
+	if orientation <= 1 || orientation > 8 {
+		return src
+	}
+
+	var sw, sh = src.Rect.Dx(), src.Rect.Dy()
+	var dw, dh = sw, sh
+	if orientation >= 5 {
+		dw, dh = sh, sw
+	}
+
+	var dst = image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			// Source of destination pixel
+			var sx, sy int
+			switch orientation {
+			case 2: // Mirror horizontal
+				sx, sy = sw-1-x, y
+			case 3: // Rotate 180
+				sx, sy = sw-1-x, sh-1-y
+			case 4: // Mirror vertical
+				sx, sy = x, sh-1-y
+			case 5: // Transpose
+				sx, sy = y, x
+			case 6: // Rotate 90 CW
+				sx, sy = y, sh-1-x
+			case 7: // Transverse
+				sx, sy = sw-1-y, sh-1-x
+			case 8: // Rotate 270 CW
+				sx, sy = sw-1-y, x
+			}
+
+			var i, j = sy*src.Stride + sx*4, y*dst.Stride + x*4
+			copy(dst.Pix[j:j+4], src.Pix[i:i+4])
+		}
+	}
+	return dst
+}
@@ -0,0 +1,230 @@
+// Validate, orient, resize and re-encode uploaded images (jpeg, png) with
+// standard library only. Image is re-encoded from pixels, so metadata (EXIF,
+// text chunks, ...) of upload is dropped. EXIF orientation of jpeg is applied
+// to pixels before it is dropped.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+)
+
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+)
+
+// Quality of re-encoded jpeg
+const JPEGQuality = 85
+
+var ErrFormat = errors.New("image format is not supported (jpeg, png)")
+
+var (
+	magicJPEG = []byte{0xFF, 0xD8, 0xFF}
+	magicPNG  = []byte{0x89, 'P', 'N', 'G', 0x0D, 0x0A, 0x1A, 0x0A}
+)
+
+// Dimension limits of image (after orientation). Zero is no limit. Decoded
+// image holds 4 bytes per pixel
+type Limits struct {
+	MinWidth  int
+	MinHeight int
+	MaxWidth  int
+	MaxHeight int
+	MaxPixels int // Width x height
+}
+
+type Image struct {
+	Format string
+	Width  int
+	Height int
+	pixels *image.NRGBA
+}
+
+// Format of data by magic bytes (empty if not supported)
+func Sniff(raw []byte) string {
+	switch {
+	case bytes.HasPrefix(raw, magicJPEG):
+		return FormatJPEG
+	case bytes.HasPrefix(raw, magicPNG):
+		return FormatPNG
+	}
+	return ""
+}
+
+// Decode and validate image. Dimensions are checked before pixels are decoded
+func Decode(raw []byte, limits *Limits) (*Image, error) {
+	var format = Sniff(raw)
+	if format == "" {
+		return nil, ErrFormat
+	}
+
+	var cfg image.Config
+	var err error
+	if format == FormatJPEG {
+		cfg, err = jpeg.DecodeConfig(bytes.NewReader(raw))
+	} else {
+		cfg, err = png.DecodeConfig(bytes.NewReader(raw))
+	}
+	if nil != err {
+		return nil, fmt.Errorf("invalid %s image: %s", format, err)
+	}
+
+	var orientation = 1
+	if format == FormatJPEG {
+		orientation = Orientation(raw)
+	}
+
+	var width, height = cfg.Width, cfg.Height
+	if orientation >= 5 {
+		width, height = height, width
+	}
+	if nil != limits {
+		err = limits.check(width, height)
+		if nil != err {
+			return nil, err
+		}
+	}
+
+	var src image.Image
+	if format == FormatJPEG {
+		src, err = jpeg.Decode(bytes.NewReader(raw))
+	} else {
+		src, err = png.Decode(bytes.NewReader(raw))
+	}
+	if nil != err {
+		return nil, fmt.Errorf("invalid %s image: %s", format, err)
+	}
+
+	var pixels = orient(toNRGBA(src), orientation)
+	var img = &Image{
+		Format: format,
+		Width:  pixels.Rect.Dx(),
+		Height: pixels.Rect.Dy(),
+		pixels: pixels,
+	}
+	return img, nil
+}
+
+func (img *Image) Mime() string {
+	return "image/" + img.Format
+}
+
+func (img *Image) Ext() string {
+	if img.Format == FormatJPEG {
+		return ".jpg"
+	}
+	return ".png"
+}
+
+// Downscale image to fit in box maxEdge x maxEdge (aspect ratio is kept).
+// Image is smaller than box is returned as is
+func (img *Image) Thumbnail(maxEdge int) *Image {
+	if img.Width <= maxEdge && img.Height <= maxEdge {
+		return img
+	}
+
+	var width, height = maxEdge, maxEdge
+	if img.Width > img.Height {
+		height = atLeast1(img.Height * maxEdge / img.Width)
+	} else {
+		width = atLeast1(img.Width * maxEdge / img.Height)
+	}
+
+	var pixels = resize(img.pixels, width, height)
+	return &Image{
+		Format: img.Format,
+		Width:  width,
+		Height: height,
+		pixels: pixels,
+	}
+}
+
+// Encode image in its format (without metadata)
+func (img *Image) Encode() ([]byte, error) {
+	var buf = &bytes.Buffer{}
+	var err error
+	if img.Format == FormatJPEG {
+		err = jpeg.Encode(buf, img.pixels, &jpeg.Options{Quality: JPEGQuality})
+	} else {
+		err = png.Encode(buf, img.pixels)
+	}
+	if nil != err {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (l *Limits) check(width, height int) error {
+	if (l.MinWidth > 0 && width < l.MinWidth) || (l.MinHeight > 0 && height < l.MinHeight) {
+		return fmt.Errorf("image is too small (%dx%d, min: %dx%d)", width, height, l.MinWidth, l.MinHeight)
+	}
+	if (l.MaxWidth > 0 && width > l.MaxWidth) || (l.MaxHeight > 0 && height > l.MaxHeight) {
+		return fmt.Errorf("image is too large (%dx%d, max: %dx%d)", width, height, l.MaxWidth, l.MaxHeight)
+	}
+	if l.MaxPixels > 0 && width*height > l.MaxPixels {
+		return fmt.Errorf("image is too large (%dx%d, max pixels: %d)", width, height, l.MaxPixels)
+	}
+	return nil
+}
+
+func toNRGBA(src image.Image) *image.NRGBA {
+	if dst, ok := src.(*image.NRGBA); ok && dst.Rect.Min == (image.Point{}) {
+		return dst
+	}
+
+	var b = src.Bounds()
+	var dst = image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Rect, src, b.Min, draw.Src)
+	return dst
+}
+
+// Area average downscale (colors are weighted by alpha)
+func resize(src *image.NRGBA, width, height int) *image.NRGBA {
+	var dst = image.NewNRGBA(image.Rect(0, 0, width, height))
+	var sw, sh = src.Rect.Dx(), src.Rect.Dy()
+
+	for y := 0; y < height; y++ {
+		var y0 = y * sh / height
+		var y1 = y0 + atLeast1((y+1)*sh/height-y0)
+		for x := 0; x < width; x++ {
+			var x0 = x * sw / width
+			var x1 = x0 + atLeast1((x+1)*sw/width-x0)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				var i = sy*src.Stride + x0*4
+				for sx := x0; sx < x1; sx++ {
+					var pa = uint64(src.Pix[i+3])
+					r += uint64(src.Pix[i]) * pa
+					g += uint64(src.Pix[i+1]) * pa
+					b += uint64(src.Pix[i+2]) * pa
+					a += pa
+					n++
+					i += 4
+				}
+			}
+
+			var j = y*dst.Stride + x*4
+			if a > 0 {
+				dst.Pix[j] = uint8(r / a)
+				dst.Pix[j+1] = uint8(g / a)
+				dst.Pix[j+2] = uint8(b / a)
+			}
+			dst.Pix[j+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
+
+func atLeast1(v int) int {
+	if v < 1 {
+		return 1
+	}
+	return v
+}
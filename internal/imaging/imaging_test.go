@@ -0,0 +1,169 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func newPNG(t *testing.T, w, h int) []byte {
+	var img = image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 100, A: 255})
+		}
+	}
+
+	var buf = &bytes.Buffer{}
+	if err := png.Encode(buf, img); nil != err {
+		t.Fatalf("encode png: %s", err)
+	}
+	return buf.Bytes()
+}
+
+// Jpeg has APP1 (EXIF) segment with orientation
+func newJPEG(t *testing.T, w, h int, orientation uint16) []byte {
+	var buf = &bytes.Buffer{}
+	var err = jpeg.Encode(buf, image.NewGray(image.Rect(0, 0, w, h)), nil)
+	if nil != err {
+		t.Fatalf("encode jpeg: %s", err)
+	}
+	var raw = buf.Bytes()
+
+	// TIFF (big endian): header, IFD0 has 1 entry, next IFD: 0
+	var tiff = []byte{'M', 'M', 0, 42, 0, 0, 0, 8, 0, 1}
+	tiff = binary.BigEndian.AppendUint16(tiff, exifTagOrientation)
+	tiff = append(tiff, 0, 3, 0, 0, 0, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+
+	var app1 = append([]byte("Exif\x00\x00"), tiff...)
+	var seg = []byte{0xFF, 0xE1}
+	seg = binary.BigEndian.AppendUint16(seg, uint16(len(app1)+2))
+	seg = append(seg, app1...)
+
+	var rs = append([]byte{}, raw[:2]...)
+	rs = append(rs, seg...)
+	return append(rs, raw[2:]...)
+}
+
+func TestSniff(t *testing.T) {
+	if Sniff(newPNG(t, 2, 2)) != FormatPNG {
+		t.Fatalf("png is not detected")
+	}
+	if Sniff(newJPEG(t, 2, 2, 1)) != FormatJPEG {
+		t.Fatalf("jpeg is not detected")
+	}
+	if Sniff([]byte("GIF89a")) != "" {
+		t.Fatalf("gif must not be supported")
+	}
+
+	_, err := Decode([]byte("<svg></svg>"), nil)
+	if err != ErrFormat {
+		t.Fatalf("decode svg must be ErrFormat: %v", err)
+	}
+}
+
+func TestDecodeLimits(t *testing.T) {
+	var raw = newPNG(t, 40, 20)
+	var limits = &Limits{MinWidth: 10, MinHeight: 10, MaxWidth: 100, MaxHeight: 100}
+	img, err := Decode(raw, limits)
+	if nil != err {
+		t.Fatalf("decode: %s", err)
+	}
+	if img.Width != 40 || img.Height != 20 || img.Mime() != "image/png" {
+		t.Fatalf("invalid image: %dx%d %s", img.Width, img.Height, img.Mime())
+	}
+
+	if _, err = Decode(raw, &Limits{MinHeight: 21}); nil == err {
+		t.Fatalf("image must be too small")
+	}
+	if _, err = Decode(raw, &Limits{MaxWidth: 39}); nil == err {
+		t.Fatalf("image must be too large")
+	}
+	if _, err = Decode(raw, &Limits{MaxPixels: 799}); nil == err {
+		t.Fatalf("image must have too many pixels")
+	}
+	if _, err = Decode(raw[:len(raw)/2], nil); nil == err {
+		t.Fatalf("truncated image must be invalid")
+	}
+}
+
+func TestOrientation(t *testing.T) {
+	for orientation := uint16(1); orientation <= 8; orientation++ {
+		var raw = newJPEG(t, 30, 10, orientation)
+		if got := Orientation(raw); got != int(orientation) {
+			t.Fatalf("orientation: %d != %d", got, orientation)
+		}
+
+		img, err := Decode(raw, nil)
+		if nil != err {
+			t.Fatalf("decode orientation %d: %s", orientation, err)
+		}
+
+		var w, h = 30, 10
+		if orientation >= 5 {
+			w, h = 10, 30
+		}
+		if img.Width != w || img.Height != h {
+			t.Fatalf("orientation %d: %dx%d", orientation, img.Width, img.Height)
+		}
+
+		// Re-encoded image has no EXIF
+		out, err := img.Encode()
+		if nil != err {
+			t.Fatalf("encode: %s", err)
+		}
+		if Orientation(out) != 1 || bytes.Contains(out, []byte("Exif\x00\x00")) {
+			t.Fatalf("EXIF must be stripped")
+		}
+	}
+}
+
+func TestOrient(t *testing.T) {
+	// 2x1: red, blue
+	var src = image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	src.Set(0, 0, color.NRGBA{R: 255, A: 255})
+	src.Set(1, 0, color.NRGBA{B: 255, A: 255})
+
+	var rotated = orient(src, 6) // Rotate 90 CW: red on top
+	if rotated.Rect.Dx() != 1 || rotated.Rect.Dy() != 2 {
+		t.Fatalf("invalid size of rotated: %v", rotated.Rect)
+	}
+	if rotated.NRGBAAt(0, 0).R != 255 || rotated.NRGBAAt(0, 1).B != 255 {
+		t.Fatalf("invalid rotate 90")
+	}
+
+	var mirrored = orient(src, 2)
+	if mirrored.NRGBAAt(0, 0).B != 255 {
+		t.Fatalf("invalid mirror")
+	}
+}
+
+func TestThumbnail(t *testing.T) {
+	img, err := Decode(newPNG(t, 200, 100), nil)
+	if nil != err {
+		t.Fatalf("decode: %s", err)
+	}
+
+	var thumb = img.Thumbnail(50)
+	if thumb.Width != 50 || thumb.Height != 25 {
+		t.Fatalf("invalid thumbnail: %dx%d", thumb.Width, thumb.Height)
+	}
+	if img.Thumbnail(300) != img {
+		t.Fatalf("image must not be upscaled")
+	}
+
+	raw, err := thumb.Encode()
+	if nil != err {
+		t.Fatalf("encode: %s", err)
+	}
+	decoded, err := Decode(raw, nil)
+	if nil != err || decoded.Width != 50 || decoded.Height != 25 {
+		t.Fatalf("invalid encoded thumbnail: %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+// Binary merkle tree (keccak256) with sorted pair hashing: node =
+// keccak256(min(a, b) || max(a, b)), so proof is only list of siblings and can
+// be verified offline (same as OpenZeppelin MerkleProof). Node has no sibling
+// is promoted to next level. Leaf is double hashed (as OpenZeppelin
+// StandardMerkleTree) so a 64 bytes data can't be proven as inner node.
+package merkle
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	ErrEmptyTree  = errors.New("merkle tree has no leaf")
+	ErrOutOfRange = errors.New("leaf index out of range")
+)
+
+type Tree struct {
+	levels [][]common.Hash // levels[0]: leaves, last: root
+}
+
+// Leaf of data parts: keccak256(keccak256(part0 || part1 || ...))
+func Leaf(parts ...[]byte) common.Hash {
+	var inner = crypto.Keccak256(parts...)
+	return crypto.Keccak256Hash(inner)
+}
+
+func HashPair(a, b common.Hash) common.Hash {
+	if bytes.Compare(a[:], b[:]) > 0 {
+		a, b = b, a
+	}
+	return crypto.Keccak256Hash(a[:], b[:])
+}
+
+func New(leaves []common.Hash) (*Tree, error) {
+	if len(leaves) == 0 {
+		return nil, ErrEmptyTree
+	}
+
+	var level = make([]common.Hash, len(leaves))
+	copy(level, leaves)
+
+	var tree = &Tree{levels: [][]common.Hash{level}}
+	for len(level) > 1 {
+		var next = make([]common.Hash, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+			} else {
+				next = append(next, HashPair(level[i], level[i+1]))
+			}
+		}
+		tree.levels = append(tree.levels, next)
+		level = next
+	}
+	return tree, nil
+}
+
+func (tree *Tree) Root() common.Hash {
+	return tree.levels[len(tree.levels)-1][0]
+}
+
+func (tree *Tree) Len() int {
+	return len(tree.levels[0])
+}
+
+// Siblings of leaf (index) from bottom to top
+func (tree *Tree) Proof(index int) ([]common.Hash, error) {
+	if index < 0 || index >= tree.Len() {
+		return nil, ErrOutOfRange
+	}
+
+	var proof = make([]common.Hash, 0, len(tree.levels))
+	for _, level := range tree.levels[:len(tree.levels)-1] {
+		var sibling = index ^ 1
+		if sibling < len(level) {
+			proof = append(proof, level[sibling])
+		}
+		index /= 2
+	}
+	return proof, nil
+}
+
+func Verify(root, leaf common.Hash, proof []common.Hash) bool {
+	var hash = leaf
+	for _, sibling := range proof {
+		hash = HashPair(hash, sibling)
+	}
+	return hash == root
+}
@@ -0,0 +1,79 @@
+package merkle
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func newLeaves(n int) []common.Hash {
+	var leaves = make([]common.Hash, n)
+	for i := range leaves {
+		leaves[i] = Leaf([]byte(fmt.Sprintf("data-%d", i)), []byte("signed"))
+	}
+	return leaves
+}
+
+func TestTreeProof(t *testing.T) {
+	for n := 1; n <= 17; n++ {
+		var leaves = newLeaves(n)
+		tree, err := New(leaves)
+		if nil != err {
+			t.Fatalf("new tree %d: %s", n, err)
+		}
+
+		for i, leaf := range leaves {
+			proof, err := tree.Proof(i)
+			if nil != err {
+				t.Fatalf("proof %d/%d: %s", i, n, err)
+			}
+			if !Verify(tree.Root(), leaf, proof) {
+				t.Fatalf("proof of leaf %d/%d is invalid", i, n)
+			}
+		}
+	}
+}
+
+func TestTreeTampered(t *testing.T) {
+	var leaves = newLeaves(5)
+	tree, _ := New(leaves)
+	proof, _ := tree.Proof(2)
+
+	var tampered = Leaf([]byte("data-2"), []byte("other"))
+	if Verify(tree.Root(), tampered, proof) {
+		t.Fatalf("tampered leaf must not be verified")
+	}
+
+	leaves[3] = tampered
+	other, _ := New(leaves)
+	if other.Root() == tree.Root() {
+		t.Fatalf("root of tampered tree must be different")
+	}
+}
+
+func TestTreeEmpty(t *testing.T) {
+	_, err := New(nil)
+	if err != ErrEmptyTree {
+		t.Fatalf("expected ErrEmptyTree, got %v", err)
+	}
+
+	tree, _ := New(newLeaves(1))
+	if tree.Root() != newLeaves(1)[0] {
+		t.Fatalf("root of single leaf tree must be leaf")
+	}
+	if _, err = tree.Proof(1); err != ErrOutOfRange {
+		t.Fatalf("expected ErrOutOfRange, got %v", err)
+	}
+}
+
+func TestTreeInnerNode(t *testing.T) {
+	var leaves = newLeaves(4)
+	tree, _ := New(leaves)
+
+	// Data of inner node (leaf0 || leaf1) must not be proven as leaf
+	var forged = Leaf(leaves[0][:], leaves[1][:])
+	if Verify(tree.Root(), forged, []common.Hash{HashPair(leaves[2], leaves[3])}) {
+		t.Fatalf("inner node must not be verified as leaf")
+	}
+}
@@ -0,0 +1,177 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+)
+
+const (
+	TableNameAlertRule = "alert_rules"
+	TableNameAlert     = "alerts"
+)
+
+type AlertKind string
+
+const (
+	AlertKindStatus   AlertKind = "status"    // Operator status of iot changed to rule status
+	AlertKindMetric   AlertKind = "metric"    // Sensor metric field matched condition
+	AlertKindMintSign AlertKind = "mint-sign" // Mint signature verification failed
+)
+
+type AlertOp string
+
+const (
+	AlertOpGT  AlertOp = "gt"
+	AlertOpGTE AlertOp = "gte"
+	AlertOpLT  AlertOp = "lt"
+	AlertOpLTE AlertOp = "lte"
+	AlertOpEQ  AlertOp = "eq"
+)
+
+// Field of metric (kind metric)
+const (
+	AlertFieldValue = "value"
+	AlertFieldLat   = "lat"
+	AlertFieldLng   = "lng"
+)
+
+// Notify channel
+const (
+	AlertChannelWebhook = "webhook"
+	AlertChannelEmail   = "email"
+	AlertChannelEvent   = "event"
+)
+
+type AlertRule struct {
+	ID         int64              `json:"id" gorm:"primaryKey"`        //
+	Name       string             `json:"name"`                        //
+	ProjectId  int64              `json:"projectId" gorm:"index"`      // 0: all project
+	IotId      int64              `json:"iotId" gorm:"index"`          // 0: all iot
+	SensorType dmodels.SensorType `json:"sensorType"`                  // 0: all sensor type (only for metric)
+	Kind       AlertKind          `json:"kind"`                        //
+	Status     OpStatus           `json:"status"`                      // Kind status: fire when iot change to this status
+	Field      string             `json:"field"`                       // Kind metric: value, lat, lng
+	Op         AlertOp            `json:"op"`                          // Kind metric
+	Threshold  float64            `json:"threshold"`                   // Kind metric
+	Cooldown   int64              `json:"cooldown"`                    // Second. Min duration between 2 alert of rule & iot
+	WebhookUrl string             `json:"webhookUrl"`                  // Notify by webhook if not empty
+	Email      string             `json:"email"`                       // Notify by email if not empty
+	PushEvent  bool               `json:"pushEvent"`                   // Notify by rabbitmq event
+	Enabled    bool               `json:"enabled" gorm:"default:true"` //
+	CreatedAt  time.Time          `json:"createdAt"`                   //
+	UpdatedAt  time.Time          `json:"updatedAt"`                   //
+} // @name AlertRule
+
+func (*AlertRule) TableName() string { return TableNameAlertRule }
+
+// Check rule can apply for iot (project) & sensor type
+func (rule *AlertRule) IsApply(kind AlertKind, projectId, iotId int64, sType dmodels.SensorType) bool {
+	if !rule.Enabled || rule.Kind != kind {
+		return false
+	}
+	if rule.ProjectId != 0 && rule.ProjectId != projectId {
+		return false
+	}
+	if rule.IotId != 0 && rule.IotId != iotId {
+		return false
+	}
+	if rule.SensorType != 0 && rule.SensorType != sType {
+		return false
+	}
+	return true
+}
+
+// Extract field value of metric and compare with threshold
+func (rule *AlertRule) MatchMetric(metric *dmodels.AllMetric) (float64, bool) {
+	if nil == metric {
+		return 0, false
+	}
+
+	var val float64
+	switch rule.Field {
+	case AlertFieldLat:
+		val = float64(metric.Lat)
+	case AlertFieldLng:
+		val = float64(metric.Lng)
+	default:
+		val = float64(metric.Val)
+	}
+
+	switch rule.Op {
+	case AlertOpGT:
+		return val, val > rule.Threshold
+	case AlertOpGTE:
+		return val, val >= rule.Threshold
+	case AlertOpLT:
+		return val, val < rule.Threshold
+	case AlertOpLTE:
+		return val, val <= rule.Threshold
+	case AlertOpEQ:
+		return val, val == rule.Threshold
+	}
+	return val, false
+}
+
+// Channels (and target) to notify
+func (rule *AlertRule) Channels() map[string]string {
+	var channels = make(map[string]string)
+	if rule.WebhookUrl != "" {
+		channels[AlertChannelWebhook] = rule.WebhookUrl
+	}
+	if rule.Email != "" {
+		channels[AlertChannelEmail] = rule.Email
+	}
+	if rule.PushEvent {
+		channels[AlertChannelEvent] = ""
+	}
+	return channels
+}
+
+func (rule *AlertRule) IsValid() error {
+	switch rule.Kind {
+	case AlertKindStatus:
+		if rule.Status == 0 {
+			return dmodels.ErrBadRequest("Status of rule is required")
+		}
+	case AlertKindMetric:
+		switch rule.Field {
+		case "", AlertFieldValue, AlertFieldLat, AlertFieldLng:
+		default:
+			return dmodels.ErrBadRequest("Invalid field of rule: " + rule.Field)
+		}
+		switch rule.Op {
+		case AlertOpGT, AlertOpGTE, AlertOpLT, AlertOpLTE, AlertOpEQ:
+		default:
+			return dmodels.ErrBadRequest("Invalid op of rule: " + string(rule.Op))
+		}
+	case AlertKindMintSign:
+	default:
+		return dmodels.ErrBadRequest("Invalid kind of rule: " + string(rule.Kind))
+	}
+
+	if len(rule.Channels()) == 0 {
+		return dmodels.ErrBadRequest("Rule must have at least one notify channel")
+	}
+	return nil
+}
+
+// Alert was fired
+type Alert struct {
+	ID        string    `json:"id" gorm:"primaryKey"`                //
+	RuleId    int64     `json:"ruleId" gorm:"index"`                 //
+	Kind      AlertKind `json:"kind"`                                //
+	ProjectId int64     `json:"projectId"`                           //
+	IotId     int64     `json:"iotId" gorm:"index:idx_alert_iot_ca"` //
+	SensorId  int64     `json:"sensorId,omitempty"`                  //
+	Value     float64   `json:"value"`                               // Metric value or iot status
+	Message   string    `json:"message"`                             //
+	CreatedAt time.Time `json:"createdAt" gorm:"index:idx_alert_iot_ca"`
+} // @name Alert
+
+func (*Alert) TableName() string { return TableNameAlert }
+
+func (alert *Alert) String() string {
+	return fmt.Sprintf("[%s] iot %d: %s", alert.Kind, alert.IotId, alert.Message)
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+)
+
+func TestAlertRuleMatchMetric(t *testing.T) {
+	var metric = &dmodels.AllMetric{
+		DefaultMetric: dmodels.DefaultMetric{Val: 10},
+		GPSMetric:     dmodels.GPSMetric{Lat: 21, Lng: 105},
+	}
+
+	var cases = []struct {
+		rule    *AlertRule
+		matched bool
+	}{
+		{&AlertRule{Op: AlertOpGT, Threshold: 9}, true},
+		{&AlertRule{Op: AlertOpGT, Threshold: 10}, false},
+		{&AlertRule{Op: AlertOpGTE, Threshold: 10}, true},
+		{&AlertRule{Op: AlertOpLT, Threshold: 10}, false},
+		{&AlertRule{Op: AlertOpLTE, Threshold: 10}, true},
+		{&AlertRule{Op: AlertOpEQ, Threshold: 10}, true},
+		{&AlertRule{Field: "lat", Op: AlertOpLT, Threshold: 20}, false},
+		{&AlertRule{Field: "lng", Op: AlertOpGT, Threshold: 100}, true},
+		{&AlertRule{Op: "unknown", Threshold: 0}, false},
+	}
+
+	for i, c := range cases {
+		_, matched := c.rule.MatchMetric(metric)
+		if matched != c.matched {
+			t.Fatalf("Case %d: expect matched %v but got %v", i, c.matched, matched)
+		}
+	}
+}
+
+func TestAlertRuleIsApply(t *testing.T) {
+	var rule = &AlertRule{
+		ProjectId:  1,
+		SensorType: dmodels.SensorTypeFlow,
+		Kind:       AlertKindMetric,
+		Enabled:    true,
+	}
+
+	if !rule.IsApply(AlertKindMetric, 1, 10, dmodels.SensorTypeFlow) {
+		t.Fatalf("Rule must be applied for iot of project")
+	}
+	if rule.IsApply(AlertKindMetric, 2, 10, dmodels.SensorTypeFlow) {
+		t.Fatalf("Rule must not be applied for other project")
+	}
+	if rule.IsApply(AlertKindMetric, 1, 10, dmodels.SensorTypePower) {
+		t.Fatalf("Rule must not be applied for other sensor type")
+	}
+	if rule.IsApply(AlertKindStatus, 1, 10, dmodels.SensorTypeFlow) {
+		t.Fatalf("Rule must not be applied for other kind")
+	}
+
+	rule.Enabled = false
+	if rule.IsApply(AlertKindMetric, 1, 10, dmodels.SensorTypeFlow) {
+		t.Fatalf("Disabled rule must not be applied")
+	}
+}
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+const (
+	TableNameMetricBundle     = "metric_bundles"
+	TableNameMetricBundleLeaf = "metric_bundle_leaves"
+	TableNameMetricExcluded   = "metric_bundle_excluded"
+)
+
+// Merkle commitment over signed metrics (sensor_metrics_signature) of iot was
+// created in period [From, To) and was not in previous bundles.
+// Leaf = keccak256(keccak256(data || signed)) (bytes of hex Data, Signed)
+type MetricBundle struct {
+	ID         int64      `json:"id" gorm:"primaryKey"`              //
+	IotId      int64      `json:"iotId" gorm:"index"`                //
+	From       time.Time  `json:"from"`                              // Period start
+	To         time.Time  `json:"to" gorm:"index"`                   // Period end (exclusive)
+	Root       string     `json:"root"`                              // Hex
+	Leaves     int        `json:"leaves"`                            // Num of signatures
+	MintSignId int64      `json:"mintSignId" gorm:"index;default:0"` // First mint signature after bundle (0: not anchored)
+	AnchoredAt *time.Time `json:"anchoredAt"`                        //
+	CreatedAt  time.Time  `json:"createdAt"`                         //
+} //@name MetricBundle
+
+func (*MetricBundle) TableName() string { return TableNameMetricBundle }
+
+// Leaf of bundle was committed (leaf hash is kept so modified signature is
+// detected)
+type MetricBundleLeaf struct {
+	BundleId int64  `json:"bundleId" gorm:"primaryKey"` //
+	Idx      int    `json:"idx" gorm:"primaryKey"`      // Position in tree
+	SignId   string `json:"signId" gorm:"uniqueIndex"`  // Id of sensor metric signature
+	Leaf     string `json:"leaf"`                       // Hex
+} //@name MetricBundleLeaf
+
+func (*MetricBundleLeaf) TableName() string { return TableNameMetricBundleLeaf }
+
+// Signature can't be committed (data or signed is not hex): it is recorded
+// once so builder doesn't query it again
+type MetricExcluded struct {
+	SignId    string    `json:"signId" gorm:"primaryKey"` //
+	IotId     int64     `json:"iotId" gorm:"index"`       //
+	Reason    string    `json:"reason"`                   //
+	CreatedAt time.Time `json:"createdAt"`                //
+} //@name MetricExcluded
+
+func (*MetricExcluded) TableName() string { return TableNameMetricExcluded }
@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+const (
+	TableNameChainMint       = "chain_mints"
+	TableNameChainCursor     = "chain_cursors"
+	TableNameMintDiscrepancy = "mint_discrepancies"
+)
+
+type DiscrepancyKind string
+
+const (
+	DiscrepancyMissingSign    DiscrepancyKind = "missing_sign"    // Mint on-chain without signature
+	DiscrepancyAmountMismatch DiscrepancyKind = "amount_mismatch" // Amount on-chain != signed amount
+	DiscrepancyTxMismatch     DiscrepancyKind = "tx_mismatch"     // Signature was confirmed by other tx
+	DiscrepancyMintedMismatch DiscrepancyKind = "minted_mismatch" // Total amount on-chain of iot > total minted of iot
+	DiscrepancyInvalidLog     DiscrepancyKind = "invalid_log"     // Log of contract can't be parsed as mint
+	DiscrepancyReconcile      DiscrepancyKind = "reconcile_error" // Signature can't be updated by mint (permanent error)
+)
+
+// Mint event of carbon contract (only confirmed blocks)
+type ChainMint struct {
+	ID          string    `json:"id" gorm:"primaryKey"`                  // <tx hash>-<log index>
+	DomainId    int64     `json:"domainId" gorm:"index"`                 // Sign domain (chain & contract)
+	Contract    string    `json:"contract"`                              //
+	BlockNumber int64     `json:"blockNumber" gorm:"index"`              //
+	BlockHash   string    `json:"blockHash"`                             //
+	BlockTime   time.Time `json:"blockTime"`                             //
+	TxHash      string    `json:"txHash"`                                //
+	LogIndex    int       `json:"logIndex"`                              //
+	Iot         string    `json:"iot" gorm:"index:idx_chain_mint_iot"`   // IoT address (lower case)
+	Nonce       int64     `json:"nonce" gorm:"index:idx_chain_mint_iot"` //
+	Amount      string    `json:"amount"`                                // Hex
+	Reconciled  bool      `json:"reconciled" gorm:"index"`               // Was compared with mint_sign
+	CreatedAt   time.Time `json:"createdAt"`                             //
+}
+
+func (*ChainMint) TableName() string { return TableNameChainMint }
+
+// Last indexed block
+type ChainCursor struct {
+	Name        string    `json:"name" gorm:"primaryKey"` // domain-<sign domain id>
+	BlockNumber int64     `json:"blockNumber"`            //
+	BlockHash   string    `json:"blockHash"`              // For detect reorg
+	UpdatedAt   time.Time `json:"updatedAt"`              //
+}
+
+func (*ChainCursor) TableName() string { return TableNameChainCursor }
+
+type MintDiscrepancy struct {
+	ID          int64           `json:"id" gorm:"primaryKey"`                                //
+	Kind        DiscrepancyKind `json:"kind" gorm:"uniqueIndex:idx_discrepancy_mint"`        //
+	ChainMintId string          `json:"chainMintId" gorm:"uniqueIndex:idx_discrepancy_mint"` //
+	Iot         string          `json:"iot" gorm:"index"`                                    //
+	Nonce       int64           `json:"nonce"`                                               //
+	Detail      string          `json:"detail"`                                              //
+	ResolvedAt  *time.Time      `json:"resolvedAt"`                                          // Null: open
+	CreatedAt   time.Time       `json:"createdAt"`                                           //
+}
+
+func (*MintDiscrepancy) TableName() string { return TableNameMintDiscrepancy }
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const TableNameOutbox = "outbox_events"
+
+// Domain events was published to rabbitmq & webhooks
+const (
+	EventIotCreated           = "iot.created"
+	EventIotStatusChanged     = "iot.status_changed"
+	EventSensorCreated        = "sensor.created"
+	EventSensorStatusChanged  = "sensor.status_changed"
+	EventMetricAccepted       = "sensor.metric_accepted"
+	EventMintSigned           = "mint.signed"
+	EventProjectCreated       = "project.created"
+	EventProjectUpdated       = "project.updated"
+	EventProjectStatusChanged = "project.status_changed"
+	EventProjectImageAdded    = "project.image_added"
+	EventProjectImageRemoved  = "project.image_removed"
+	EventProjectDocVersion    = "project.doc_version_added"
+)
+
+var EventTypes = []string{
+	EventIotCreated,
+	EventIotStatusChanged,
+	EventSensorCreated,
+	EventSensorStatusChanged,
+	EventMetricAccepted,
+	EventMintSigned,
+	EventProjectCreated,
+	EventProjectUpdated,
+	EventProjectStatusChanged,
+	EventProjectImageAdded,
+	EventProjectImageRemoved,
+	EventProjectDocVersion,
+}
+
+func IsEventType(event string) bool {
+	for _, e := range EventTypes {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Message of domain event (rabbitmq body & webhook payload)
+type EventEnvelope struct {
+	Id        string          `json:"id"`        // Event id (idempotency key)
+	Event     string          `json:"event"`     //
+	Version   int             `json:"version"`   // Version of data schema
+	ProjectId int64           `json:"projectId"` //
+	Data      json.RawMessage `json:"data"`      //
+	CreatedAt time.Time       `json:"createdAt"` //
+} // @name EventEnvelope
+
+// Event was written in the same transaction of state change, then
+// published by relay (at least once)
+type OutboxEvent struct {
+	ID            string     `json:"id" gorm:"primaryKey"`                                     // Idempotency key
+	Event         string     `json:"event"`                                                    //
+	Version       int        `json:"version"`                                                  // Version of payload schema
+	ProjectId     int64      `json:"projectId"`                                                //
+	Payload       string     `json:"payload"`                                                  // Json of event data
+	Attempts      int        `json:"attempts"`                                                 //
+	LastError     string     `json:"lastError"`                                                //
+	NextAttemptAt time.Time  `json:"nextAttemptAt" gorm:"index:idx_outbox_next;default:now()"` // Claimed or failed event is not published before
+	DeadAt        *time.Time `json:"deadAt"`                                                   // Reached max attempts (not published anymore)
+	PublishedAt   *time.Time `json:"publishedAt" gorm:"index:idx_outbox_published"`            // Null: waiting for relay
+	CreatedAt     time.Time  `json:"createdAt" gorm:"index:idx_outbox_published"`              //
+} // @name OutboxEvent
+
+func (*OutboxEvent) TableName() string { return TableNameOutbox }
+
+func (evt *OutboxEvent) Envelope() *EventEnvelope {
+	return &EventEnvelope{
+		Id:        evt.ID,
+		Event:     evt.Event,
+		Version:   evt.Version,
+		ProjectId: evt.ProjectId,
+		Data:      json.RawMessage(evt.Payload),
+		CreatedAt: evt.CreatedAt,
+	}
+}
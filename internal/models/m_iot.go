@@ -1,6 +1,10 @@
 package models
 
-import "github.com/Dcarbon/go-shared/dmodels"
+import (
+	"time"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+)
 
 type IOTType int
 
@@ -24,12 +28,16 @@ const (
 )
 
 type IOTDevice struct {
-	ID       int64                `json:"id"         gorm:"primary_key"`
-	Project  int64                `json:"project"    gorm:"index"`
-	Address  dmodels.EthAddress   `json:"address"    gorm:"unique"`
-	Type     IOTType              `json:"type"       `
-	Status   dmodels.DeviceStatus `json:"status"     `
-	Position Point4326            `json:"position"   gorm:"type:geometry(POINT, 4326)"`
+	ID        int64                `json:"id"         gorm:"primary_key"`
+	Project   int64                `json:"project"    gorm:"index"`
+	Address   dmodels.EthAddress   `json:"address"    gorm:"unique"`
+	Type      IOTType              `json:"type"       `
+	Status    dmodels.DeviceStatus `json:"status"     `
+	Position  Point4326            `json:"position"   gorm:"type:geometry(POINT, 4326)"`
+	DomainId  int64                `json:"domainId"   gorm:"index"`               // Sign domain of mint signature
+	Oracle    bool                 `json:"oracle"     `                           // Mint is signed by oracle (server)
+	OracleAt  *time.Time           `json:"oracleAt"   `                           // Oracle mode was enabled at (metric before it isn't counted)
+	CreatedAt time.Time            `json:"createdAt"  gorm:"index;default:now()"` // Iot created before this column: time of migration
 } // @name IOTDevice
 
 func (*IOTDevice) TableName() string { return TableNameIOT }
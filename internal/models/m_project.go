@@ -28,6 +28,7 @@ type Project struct {
 	Area         float64               `json:"area,omitempty"`                               //
 	Descs        []*ProjectDescription `json:"descs,omitempty" gorm:"foreignKey:ProjectID"`  //
 	Images       []*ProjectImage       `json:"images,omitempty" gorm:"foreignKey:ProjectID"` //
+	DomainId     int64                 `json:"domainId"`                                     // Sign domain of new IoT (0: default)
 	CreatedAt    time.Time             `json:"createdAt"`                                    //
 	UpdatedAt    time.Time             `json:"updatedAt"`                                    //
 } //@name Project
@@ -57,10 +58,18 @@ type ProjectSpecs struct {
 func (*ProjectSpecs) TableName() string { return TableNameProjectSpecs }
 
 type ProjectImage struct {
-	ID        int64     `json:"id"`        //
-	ProjectID int64     `json:"projectId"` //
-	Image     string    `json:"image"`     // Image path
-	CreatedAt time.Time `json:"createdAt"`
+	ID        int64      `json:"id"`                                       //
+	ProjectID int64      `json:"projectId"  gorm:"index"`                  //
+	Image     string     `json:"image"`                                    // Image path (metadata is stripped)
+	Thumbs    MapSString `json:"thumbs,omitempty" gorm:"type:json"`        // Thumbnail path by size (sm, md, lg)
+	Mime      string     `json:"mime,omitempty"`                           //
+	Width     int        `json:"width,omitempty"`                          //
+	Height    int        `json:"height,omitempty"`                         //
+	Size      int64      `json:"size,omitempty"`                           // Bytes of image
+	Cover     bool       `json:"cover"      gorm:"not null;default:false"` // Cover image of project (one per project)
+	Position  int        `json:"position"   gorm:"not null;default:0"`     // Order of image in project (asc)
+	CreatedAt time.Time  `json:"createdAt"`
+	FilesKept bool       `json:"filesKept,omitempty" gorm:"-"` // Deleted image: files are kept in storage (can't delete)
 }
 
 func (*ProjectImage) TableName() string { return TableNameProjectImage }
@@ -86,3 +95,25 @@ func (m MapSFloat) Value() (driver.Value, error) {
 	}
 	return json.Marshal(m)
 }
+
+type MapSString map[string]string //@name MapSString
+
+// Null is empty map (image was added before thumbnails)
+func (m *MapSString) Scan(value interface{}) error {
+	switch vt := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return json.Unmarshal([]byte(vt), m)
+	case []byte:
+		return json.Unmarshal(vt, m)
+	}
+	return errors.New("scan value type for MapSString invalid")
+}
+
+func (m MapSString) Value() (driver.Value, error) {
+	if nil == m {
+		return nil, nil
+	}
+	return json.Marshal(m)
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+)
+
+type ProjectDocType string
+
+const (
+	ProjectDocPDD                ProjectDocType = "pdd"                 // Project design document
+	ProjectDocMonitoringPlan     ProjectDocType = "monitoring_plan"     //
+	ProjectDocMonitoringReport   ProjectDocType = "monitoring_report"   //
+	ProjectDocValidationReport   ProjectDocType = "validation_report"   //
+	ProjectDocVerificationReport ProjectDocType = "verification_report" //
+	ProjectDocOther              ProjectDocType = "other"               //
+)
+
+var ProjectDocTypes = []ProjectDocType{
+	ProjectDocPDD,
+	ProjectDocMonitoringPlan,
+	ProjectDocMonitoringReport,
+	ProjectDocValidationReport,
+	ProjectDocVerificationReport,
+	ProjectDocOther,
+}
+
+func (t ProjectDocType) IsValid() bool {
+	for _, it := range ProjectDocTypes {
+		if it == t {
+			return true
+		}
+	}
+	return false
+}
+
+type ProjectDocAccess string
+
+const (
+	ProjectDocPublic  ProjectDocAccess = "public"  // Anyone
+	ProjectDocPrivate ProjectDocAccess = "private" // Owner of project, verifier, admin
+)
+
+func (a ProjectDocAccess) IsValid() bool {
+	return a == ProjectDocPublic || a == ProjectDocPrivate
+}
+
+// Document of project. Content is in versions (latest: Version)
+type ProjectDoc struct {
+	ID        int64                `json:"id" gorm:"primaryKey"`                       //
+	ProjectID int64                `json:"projectId" gorm:"index"`                     //
+	Type      ProjectDocType       `json:"type" gorm:"index"`                          //
+	Title     string               `json:"title"`                                      //
+	Access    ProjectDocAccess     `json:"access"`                                     //
+	Version   int                  `json:"version"`                                    // Latest version
+	Latest    *ProjectDocVersion   `json:"latest,omitempty" gorm:"-"`                  //
+	Versions  []*ProjectDocVersion `json:"versions,omitempty" gorm:"foreignKey:DocID"` // Newest first
+	CreatedBy dmodels.EthAddress   `json:"createdBy"`                                  //
+	CreatedAt time.Time            `json:"createdAt"`                                  //
+	UpdatedAt time.Time            `json:"updatedAt"`                                  //
+} //@name ProjectDoc
+
+func (*ProjectDoc) TableName() string { return TableNameProjectDoc }
+
+// Version of document. ChainHash links version to previous version of
+// document (see domain.DocChainHash), Signature is signed chain hash by
+// server (anchor, empty if document signer is not configured)
+type ProjectDocVersion struct {
+	ID        int64              `json:"id" gorm:"primaryKey"`                                           //
+	DocID     int64              `json:"docId" gorm:"index:idx_project_doc_version,unique,priority:1"`   //
+	Version   int                `json:"version" gorm:"index:idx_project_doc_version,unique,priority:2"` // Start from 1
+	Path      string             `json:"-"`                                                              // Storage path
+	FileName  string             `json:"fileName"`                                                       //
+	Mime      string             `json:"mime"`                                                           //
+	Size      int64              `json:"size"`                                                           // Bytes
+	Sha256    string             `json:"sha256"`                                                         // Hex of content
+	ChainHash string             `json:"chainHash"`                                                      // Hex
+	Signer    dmodels.EthAddress `json:"signer,omitempty"`                                               //
+	Signature string             `json:"signature,omitempty"`                                            // Hex (EIP-191 of "dcarbon_doc_" + chainHash)
+	Note      string             `json:"note,omitempty"`                                                 //
+	Uploader  dmodels.EthAddress `json:"uploader"`                                                       //
+	CreatedAt time.Time          `json:"createdAt"`                                                      //
+} //@name ProjectDocVersion
+
+func (*ProjectDocVersion) TableName() string { return TableNameProjectDocV }
@@ -113,6 +113,7 @@ func (smx *SMExtract) IsValid(sType dmodels.SensorType) error {
 	return nil
 }
 
+// Sign as sensor/iot device (only for test). pkey: private key (hex)
 func (smx *SMExtract) Signed(pkey string) (*SmSignature, error) {
 	raw, err := json.Marshal(smx)
 	if nil != err {
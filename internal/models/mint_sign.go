@@ -14,24 +14,51 @@ const (
 	TableNameMinted   = "minted"
 )
 
-// var minterDomain = esign.MustNewERC712(
-// 	&esign.TypedDataDomain{
-// 		Name:              "Carbon",
-// 		Version:           "1",
-// 		ChainId:           1,
-// 		VerifyingContract: "0xA1E064Fd61B76cf11CE3b5816344f861b6318cea",
-// 	},
-// 	esign.MustNewTypedDataField(
-// 		"Mint",
-// 		esign.TypedDataStruct,
-// 		esign.MustNewTypedDataField("iot", esign.TypedDataAddress),
-// 		esign.MustNewTypedDataField("amount", "uint256"),
-// 		esign.MustNewTypedDataField("nonce", "uint256"),
-// 	),
-// )
+// Typed data (Mint) of mint signature in domain
+func NewMinter(typedDomain *esign.TypedDataDomain) (*esign.ERC712, error) {
+	return esign.NewERC712(
+		typedDomain,
+		esign.MustNewTypedDataField(
+			"Mint",
+			esign.TypedDataStruct,
+			esign.MustNewTypedDataField("iot", esign.TypedDataAddress),
+			esign.MustNewTypedDataField("amount", esign.TypedDataUint256),
+			esign.MustNewTypedDataField("nonce", esign.TypedDataUint256),
+		),
+	)
+}
 
 // const Precision = int64(1e9)
 
+// Redemption state of mint signature on carbon contract
+type MintState int
+
+const (
+	MintStateFailed    MintState = -1 // Tx was reverted/dropped, can be submitted again
+	MintStatePending   MintState = 0  // Signed, wait for relayer
+	MintStateSubmitted MintState = 1  // Tx was sent
+	MintStateConfirmed MintState = 2  // Tx was mined (carbon was claimed)
+)
+
+func (state MintState) IsValid() bool {
+	return state >= MintStateFailed && state <= MintStateConfirmed
+}
+
+// Signature can be replaced by new signature of the same nonce
+func (state MintState) IsUpdatable() bool {
+	return state == MintStatePending || state == MintStateFailed
+}
+
+func (state MintState) CanMoveTo(next MintState) bool {
+	switch state {
+	case MintStatePending, MintStateFailed:
+		return next == MintStateSubmitted || next == MintStateConfirmed
+	case MintStateSubmitted:
+		return next == MintStateConfirmed || next == MintStateFailed
+	}
+	return false
+}
+
 type MintSign struct {
 	ID        int64     `json:"id" gorm:"primary_key"` //
 	IotId     int64     `json:"iotId"`                 // IoT id
@@ -42,12 +69,25 @@ type MintSign struct {
 	S         string    `json:"s" `                    //
 	V         string    `json:"v" `                    //
 	CreatedAt time.Time `json:"createdAt" `            //
-	UpdatedAt time.Time `json:"updatedAt" `            //
+	UpdatedAt time.Time `json:"updatedAt" `            // Signed (or re-signed) at
+
+	DomainId       int64      `json:"domainId" gorm:"index"`        // Sign domain was verified signature
+	State          MintState  `json:"state" gorm:"index;default:0"` //
+	TxHash         string     `json:"txHash"`                       // Tx of on-chain mint (latest)
+	BlockNumber    int64      `json:"blockNumber"`                  // Block of confirmed tx
+	TxError        string     `json:"txError"`                      // Reason of failed tx
+	RedeemedAt     *time.Time `json:"redeemedAt"`                   // Null: not confirmed yet
+	StateUpdatedAt *time.Time `json:"stateUpdatedAt"`               // Latest state report (tx of relayer or indexer)
+	Signer         string     `json:"signer"`                       // Oracle address. Empty: signed by iot
+}
+
+func (msign *MintSign) IsRedeemed() bool {
+	return msign.State == MintStateConfirmed
 }
 
 func (*MintSign) TableName() string { return TableNameMintSign }
 
-// Only for test
+// Sign as iot device (only for test, server key must use signer.SignMint)
 // pk: private key (hex)
 func (msign *MintSign) Sign(dMinter *esign.ERC712, pk string) ([]byte, error) {
 	signedRaw, err := dMinter.Sign(pk, map[string]interface{}{
@@ -66,6 +106,7 @@ func (msign *MintSign) Sign(dMinter *esign.ERC712, pk string) ([]byte, error) {
 	return signedRaw, nil
 }
 
+// Verify signature by signer of mint (iot or oracle)
 func (msign *MintSign) Verify(dMinter *esign.ERC712) error {
 	var data = map[string]interface{}{
 		"iot":    msign.Iot,
@@ -81,7 +122,12 @@ func (msign *MintSign) Verify(dMinter *esign.ERC712) error {
 		return dmodels.NewError(ecodes.IOTInvalidMintSign, "Invalid mint sign: "+err.Error())
 	}
 
-	err = dMinter.Verify(msign.Iot, signed, data)
+	var signer = msign.Iot
+	if msign.Signer != "" {
+		signer = msign.Signer
+	}
+
+	err = dMinter.Verify(signer, signed, data)
 	if nil != err {
 		return dmodels.NewError(ecodes.IOTInvalidMintSign, "Invalid mint sign: "+err.Error())
 	}
@@ -63,3 +63,28 @@ func TestMintVerify(t *testing.T) {
 	err := m.Verify(testDomainMinter)
 	utils.PanicError("TestMintVerify", err)
 }
+
+func TestMintStateTransition(t *testing.T) {
+	var cases = []struct {
+		from, to MintState
+		ok       bool
+	}{
+		{MintStatePending, MintStateSubmitted, true},
+		{MintStatePending, MintStateConfirmed, true},
+		{MintStatePending, MintStateFailed, false},
+		{MintStateSubmitted, MintStateConfirmed, true},
+		{MintStateSubmitted, MintStateFailed, true},
+		{MintStateFailed, MintStateSubmitted, true},
+		{MintStateConfirmed, MintStateSubmitted, false},
+		{MintStateConfirmed, MintStateFailed, false},
+	}
+	for _, c := range cases {
+		if c.from.CanMoveTo(c.to) != c.ok {
+			t.Fatalf("Move mint state from %d to %d must be %v", c.from, c.to, c.ok)
+		}
+	}
+
+	if MintStateSubmitted.IsUpdatable() || !MintStateFailed.IsUpdatable() {
+		t.Fatalf("Only pending/failed signature can be replaced")
+	}
+}
@@ -7,6 +7,8 @@ type OpIotStatus struct {
 	Address dmodels.EthAddress `json:"address,omitempty"` // Iot address
 	Status  OpStatus           `json:"status,omitempty"`  // Operator status
 	Latest  int64              `json:"latest,omitempty"`  // Last update
+	Type    IOTType            `json:"type,omitempty"`    // Iot type
+	Project int64              `json:"project,omitempty"` // Project id
 }
 
 type OpSensorMetric struct {
@@ -15,3 +17,19 @@ type OpSensorMetric struct {
 	Metric *dmodels.AllMetric `json:"metric,omitempty"`
 	Latest int64              `json:"latest,omitempty"`
 }
+
+type OpEventType string
+
+const (
+	OpEventStatus OpEventType = "status" // Operator status of iot was changed
+	OpEventMetric OpEventType = "metric" // New sensor metric
+)
+
+// Operator event (push to stream)
+type OpEvent struct {
+	Type     OpEventType     `json:"type"`
+	IotId    int64           `json:"iotId"`
+	Previous OpStatus        `json:"previous,omitempty"` // Status before changed (only status event)
+	Status   *OpIotStatus    `json:"status,omitempty"`
+	Metric   *OpSensorMetric `json:"metric,omitempty"`
+} // @name OpEvent
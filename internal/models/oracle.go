@@ -0,0 +1,64 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+const TableNameMintAudit = "mint_audit"
+
+// Metrics of sensor was used by oracle
+type OracleInput struct {
+	SensorId int64   `json:"sensorId"`
+	Count    int64   `json:"count"`
+	Sum      float64 `json:"sum"`
+} // @name OracleInput
+
+type OracleInputs []*OracleInput //@name OracleInputs
+
+func (s *OracleInputs) Scan(value interface{}) error {
+	switch vt := value.(type) {
+	case string:
+		return json.Unmarshal([]byte(vt), s)
+	case []byte:
+		return json.Unmarshal(vt, s)
+	case nil:
+		return nil
+	}
+	return errors.New("scan value type for OracleInputs invalid")
+}
+
+func (s OracleInputs) Value() (driver.Value, error) {
+	if nil == s {
+		return "[]", nil
+	}
+	return json.Marshal(s)
+}
+
+// Inputs of mint signature was signed by oracle. Increment of signature is
+// floor(Total * Rate) - floor(previous Total * Rate), Total is sum of metric
+// values since first oracle signature (so rounding is not accumulated)
+type MintAudit struct {
+	ID         string       `json:"id" gorm:"primaryKey"`    //
+	MintSignId int64        `json:"mintSignId" gorm:"index"` //
+	IotId      int64        `json:"iotId" gorm:"index"`      //
+	DomainId   int64        `json:"domainId"`                //
+	Nonce      int64        `json:"nonce"`                   //
+	Amount     string       `json:"amount"`                  // Hex. Total amount was signed
+	Increment  int64        `json:"increment"`               //
+	Signer     string       `json:"signer"`                  // Oracle address
+	Hash       string       `json:"hash"`                    // EIP712 digest was signed
+	From       time.Time    `json:"from"`                    // Metric window (from, to]
+	To         time.Time    `json:"to"`                      //
+	Count      int64        `json:"count"`                   // Num of metric in window
+	Sum        float64      `json:"sum"`                     // Sum of metric in window
+	Total      float64      `json:"total"`                   // Sum of metric since oracle was enabled
+	Rate       float64      `json:"rate"`                    // Carbon per metric unit
+	Digest     string       `json:"digest"`                  // sha256 of metric ids (ordered) in window
+	Inputs     OracleInputs `json:"inputs" gorm:"type:json"` // By sensor
+	CreatedAt  time.Time    `json:"createdAt" gorm:"index"`  //
+} // @name MintAudit
+
+func (*MintAudit) TableName() string { return TableNameMintAudit }
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Dcarbon/go-shared/libs/esign"
+)
+
+const TableNameSignDomain = "sign_domains"
+
+// EIP712 domain (chain & version of carbon contract) of mint signature. IoT is
+// bound to a domain, its signature is verified by this domain
+type SignDomain struct {
+	ID                int64     `json:"id" gorm:"primaryKey"`                                 //
+	Label             string    `json:"label" gorm:"unique"`                                  // Ex: bsc-v1
+	Name              string    `json:"name"`                                                 // EIP712 domain name
+	Version           string    `json:"version" gorm:"uniqueIndex:idx_sign_domain"`           //
+	ChainId           int64     `json:"chainId" gorm:"uniqueIndex:idx_sign_domain"`           //
+	VerifyingContract string    `json:"verifyingContract" gorm:"uniqueIndex:idx_sign_domain"` // Lower case
+	IsDefault         bool      `json:"isDefault"`                                            // For new IoT (project has no domain)
+	CreatedAt         time.Time `json:"createdAt"`                                            //
+	UpdatedAt         time.Time `json:"updatedAt"`                                            //
+} //@name SignDomain
+
+func (*SignDomain) TableName() string { return TableNameSignDomain }
+
+func (d *SignDomain) TypedDomain() *esign.TypedDataDomain {
+	return &esign.TypedDataDomain{
+		Name:              d.Name,
+		Version:           d.Version,
+		ChainId:           d.ChainId,
+		VerifyingContract: d.VerifyingContract,
+	}
+}
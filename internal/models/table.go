@@ -6,6 +6,8 @@ const (
 	TableNameProjectDesc  = "projects_desc"
 	TableNameProjectSpecs = "projects_specs"
 	TableNameProjectImage = "projects_image"
+	TableNameProjectDoc   = "projects_doc"
+	TableNameProjectDocV  = "projects_doc_version"
 
 	TableNameIOT = "iots"
 
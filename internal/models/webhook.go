@@ -0,0 +1,145 @@
+package models
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+const (
+	TableNameWebhookSub      = "webhook_subs"
+	TableNameWebhookDelivery = "webhook_deliveries"
+)
+
+type WebhookStatus string
+
+const (
+	WebhookStatusPending WebhookStatus = "pending" // Wait for (re)delivery
+	WebhookStatusSuccess WebhookStatus = "success" //
+	WebhookStatusDead    WebhookStatus = "dead"    // Dead letter: reached max attempts
+)
+
+// Header of delivery request
+const (
+	WebhookHeaderEvent     = "X-DCarbon-Event"
+	WebhookHeaderDelivery  = "X-DCarbon-Delivery"
+	WebhookHeaderTimestamp = "X-DCarbon-Timestamp"
+	WebhookHeaderSignature = "X-DCarbon-Signature"
+)
+
+type WebhookSub struct {
+	ID        int64     `json:"id" gorm:"primaryKey"`        //
+	Url       string    `json:"url"`                         //
+	Secret    string    `json:"secret,omitempty"`            // Only returned when created
+	Events    Strings   `json:"events" gorm:"type:json"`     // Empty: all events
+	ProjectId int64     `json:"projectId" gorm:"index"`      // 0: all project
+	Enabled   bool      `json:"enabled" gorm:"default:true"` //
+	CreatedBy int64     `json:"createdBy"`                   // User id
+	CreatedAt time.Time `json:"createdAt"`                   //
+	UpdatedAt time.Time `json:"updatedAt"`                   //
+} // @name WebhookSub
+
+func (*WebhookSub) TableName() string { return TableNameWebhookSub }
+
+func (sub *WebhookSub) IsMatch(event string, projectId int64) bool {
+	if !sub.Enabled {
+		return false
+	}
+	if sub.ProjectId != 0 && sub.ProjectId != projectId {
+		return false
+	}
+	if len(sub.Events) == 0 {
+		return true
+	}
+	for _, e := range sub.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Signature of payload: hex(hmac-sha256(secret, "<timestamp>.<body>"))
+func (sub *WebhookSub) Sign(timestamp int64, body []byte) string {
+	var mac = hmac.New(sha256.New, []byte(sub.Secret))
+	mac.Write([]byte(fmt.Sprintf("%d.", timestamp)))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Carrier-grade NAT (RFC 6598), not covered by net.IP.IsPrivate
+var cgnatNet = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// Target of webhook must be public address: loopback, private, link-local
+// (cloud metadata), multicast and unspecified address are rejected
+func IsWebhookIP(ip net.IP) bool {
+	return ip != nil &&
+		!ip.IsLoopback() &&
+		!ip.IsPrivate() &&
+		!ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() &&
+		!ip.IsMulticast() &&
+		!ip.IsUnspecified() &&
+		!cgnatNet.Contains(ip)
+}
+
+type WebhookDelivery struct {
+	ID           string        `json:"id" gorm:"primaryKey"`                             //
+	SubId        int64         `json:"subId" gorm:"uniqueIndex:idx_webhook_sub_event"`   //
+	EventId      string        `json:"eventId" gorm:"uniqueIndex:idx_webhook_sub_event"` // Idempotency key
+	Event        string        `json:"event"`                                            //
+	Payload      string        `json:"payload"`                                          // Json of WebhookPayload
+	Status       WebhookStatus `json:"status" gorm:"index:idx_webhook_status_next"`      //
+	Attempts     int           `json:"attempts"`                                         //
+	NextAt       time.Time     `json:"nextAt" gorm:"index:idx_webhook_status_next"`      // Time of next attempt
+	ResponseCode int           `json:"responseCode"`                                     // Of last attempt
+	LastError    string        `json:"lastError"`                                        // Of last attempt
+	CreatedAt    time.Time     `json:"createdAt"`                                        //
+	UpdatedAt    time.Time     `json:"updatedAt"`                                        //
+} // @name WebhookDelivery
+
+func (*WebhookDelivery) TableName() string { return TableNameWebhookDelivery }
+
+// Delay before next attempt: base * 2^(attempts - 1), max 1 day
+func WebhookBackoff(attempts int, base time.Duration) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var delay = base
+	for i := 1; i < attempts && delay < 24*time.Hour; i++ {
+		delay *= 2
+	}
+	if delay > 24*time.Hour {
+		delay = 24 * time.Hour
+	}
+	return delay
+}
+
+type Strings []string //@name Strings
+
+func (s *Strings) Scan(value interface{}) error {
+	switch vt := value.(type) {
+	case string:
+		return json.Unmarshal([]byte(vt), s)
+	case []byte:
+		return json.Unmarshal(vt, s)
+	case nil:
+		return nil
+	}
+	return errors.New("scan value type for Strings invalid")
+}
+
+func (s Strings) Value() (driver.Value, error) {
+	if nil == s {
+		return "[]", nil
+	}
+	return json.Marshal(s)
+}
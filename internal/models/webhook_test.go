@@ -0,0 +1,78 @@
+package models
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestWebhookSign(t *testing.T) {
+	var sub = &WebhookSub{Secret: "secret"}
+	var body = []byte(`{"id":"1"}`)
+
+	var s1 = sub.Sign(1700000000, body)
+	if s1 != sub.Sign(1700000000, body) {
+		t.Fatalf("Signature must be deterministic")
+	}
+	if s1 == sub.Sign(1700000001, body) {
+		t.Fatalf("Signature must cover timestamp")
+	}
+	if s1[:7] != "sha256=" {
+		t.Fatalf("Signature must be prefixed by algorithm: %s", s1)
+	}
+}
+
+func TestWebhookIsMatch(t *testing.T) {
+	var sub = &WebhookSub{
+		Enabled:   true,
+		ProjectId: 1,
+		Events:    Strings{EventIotCreated},
+	}
+	if !sub.IsMatch(EventIotCreated, 1) {
+		t.Fatalf("Subscription must match event of project")
+	}
+	if sub.IsMatch(EventIotCreated, 2) {
+		t.Fatalf("Subscription must not match other project")
+	}
+	if sub.IsMatch(EventMintSigned, 1) {
+		t.Fatalf("Subscription must not match other event")
+	}
+
+	sub.Events = nil
+	if !sub.IsMatch(EventMintSigned, 1) {
+		t.Fatalf("Subscription without events must match all events")
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	var base = 30 * time.Second
+	var cases = map[int]time.Duration{
+		0:  30 * time.Second,
+		1:  30 * time.Second,
+		2:  60 * time.Second,
+		4:  240 * time.Second,
+		30: 24 * time.Hour,
+	}
+	for attempts, expected := range cases {
+		if v := WebhookBackoff(attempts, base); v != expected {
+			t.Fatalf("Attempts %d: expect %s but got %s", attempts, expected, v)
+		}
+	}
+}
+
+func TestIsWebhookIP(t *testing.T) {
+	for _, it := range []string{"8.8.8.8", "2606:4700::1111"} {
+		if !IsWebhookIP(net.ParseIP(it)) {
+			t.Fatalf("%s must be allowed", it)
+		}
+	}
+
+	for _, it := range []string{
+		"127.0.0.1", "10.1.2.3", "172.16.0.1", "192.168.1.1", "169.254.169.254",
+		"100.64.0.1", "0.0.0.0", "::1", "fe80::1", "fd00::1", "::ffff:127.0.0.1",
+	} {
+		if IsWebhookIP(net.ParseIP(it)) {
+			t.Fatalf("%s must be rejected", it)
+		}
+	}
+}
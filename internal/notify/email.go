@@ -0,0 +1,50 @@
+package notify
+
+import (
+	"fmt"
+	"net/smtp"
+	"strings"
+
+	"github.com/Dcarbon/go-shared/libs/utils"
+	"github.com/Dcarbon/iott-cloud/internal/models"
+)
+
+// Send alert to target email address via SMTP
+type Email struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// Config by env SMTP_HOST, SMTP_PORT, SMTP_USER, SMTP_PASSWORD, SMTP_FROM
+func NewEmail() *Email {
+	var host = utils.StringEnv("SMTP_HOST", "localhost")
+	var user = utils.StringEnv("SMTP_USER", "")
+
+	var email = &Email{
+		addr: fmt.Sprintf("%s:%d", host, utils.Int64Env("SMTP_PORT", 587)),
+		from: utils.StringEnv("SMTP_FROM", "alert@dcarbon.org"),
+	}
+	if user != "" {
+		email.auth = smtp.PlainAuth("", user, utils.StringEnv("SMTP_PASSWORD", ""), host)
+	}
+	return email
+}
+
+func (email *Email) Notify(target string, alert *models.Alert) error {
+	var msg = strings.Join([]string{
+		"From: " + email.from,
+		"To: " + target,
+		"Subject: [DCarbon alert] " + string(alert.Kind) + fmt.Sprintf(" iot %d", alert.IotId),
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		alert.Message,
+		"",
+		fmt.Sprintf("Rule: %d", alert.RuleId),
+		fmt.Sprintf("Project: %d", alert.ProjectId),
+		fmt.Sprintf("Value: %v", alert.Value),
+		"Time: " + alert.CreatedAt.UTC().String(),
+	}, "\r\n")
+
+	return smtp.SendMail(email.addr, email.auth, email.from, []string{target}, []byte(msg))
+}
@@ -0,0 +1,21 @@
+package notify
+
+import (
+	"github.com/Dcarbon/go-shared/libs/ievent"
+	"github.com/Dcarbon/iott-cloud/internal/models"
+)
+
+const EventAlert = "alert"
+
+// Push alert to rabbitmq. Target is ignored
+type Event struct {
+	pusher ievent.IPublisher
+}
+
+func NewEvent(pusher ievent.IPublisher) *Event {
+	return &Event{pusher: pusher}
+}
+
+func (evt *Event) Notify(target string, alert *models.Alert) error {
+	return evt.pusher.Push(EventAlert, alert)
+}
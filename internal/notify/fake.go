@@ -0,0 +1,52 @@
+package notify
+
+import (
+	"sync"
+
+	"github.com/Dcarbon/iott-cloud/internal/models"
+)
+
+type FakeSent struct {
+	Target string
+	Alert  *models.Alert
+}
+
+// Keep notified alert in memory (for test)
+type Fake struct {
+	mut  *sync.Mutex
+	sent []*FakeSent
+	Err  error // Error will be returned by Notify
+}
+
+func NewFake() *Fake {
+	return &Fake{
+		mut:  &sync.Mutex{},
+		sent: make([]*FakeSent, 0),
+	}
+}
+
+func (fake *Fake) Notify(target string, alert *models.Alert) error {
+	fake.mut.Lock()
+	defer fake.mut.Unlock()
+
+	if fake.Err != nil {
+		return fake.Err
+	}
+	fake.sent = append(fake.sent, &FakeSent{Target: target, Alert: alert})
+	return nil
+}
+
+func (fake *Fake) Sent() []*FakeSent {
+	fake.mut.Lock()
+	defer fake.mut.Unlock()
+
+	var rs = make([]*FakeSent, len(fake.sent))
+	copy(rs, fake.sent)
+	return rs
+}
+
+func (fake *Fake) Reset() {
+	fake.mut.Lock()
+	defer fake.mut.Unlock()
+	fake.sent = fake.sent[:0]
+}
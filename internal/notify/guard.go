@@ -0,0 +1,67 @@
+package notify
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/go-shared/libs/utils"
+	"github.com/Dcarbon/iott-cloud/internal/models"
+)
+
+var ErrInternalAddress = errors.New("webhook url resolves to internal address")
+
+// Allow url of internal address (WEBHOOK_ALLOW_PRIVATE=1, for local test only)
+func AllowPrivate() bool {
+	return utils.IntEnv("WEBHOOK_ALLOW_PRIVATE", 0) == 1
+}
+
+// Url must be http(s) and all addresses of host must be public (address is
+// checked again when request is sent, host may be re-resolved)
+func ValidWebhookUrl(raw string, allowPrivate bool) error {
+	u, err := url.Parse(raw)
+	if nil != err || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return dmodels.ErrBadRequest("Invalid webhook url: " + raw)
+	}
+	if allowPrivate {
+		return nil
+	}
+
+	ips, err := net.LookupIP(u.Hostname())
+	if nil != err {
+		return dmodels.ErrBadRequest("Can't resolve host of webhook url: " + u.Hostname())
+	}
+	for _, ip := range ips {
+		if !models.IsWebhookIP(ip) {
+			return dmodels.ErrBadRequest("Webhook url must not target internal address: " + u.Hostname())
+		}
+	}
+	return nil
+}
+
+// Client refuses to connect internal address (checked on connect, so it
+// covers dns rebinding and redirect). Proxy is disabled, it would bypass check
+func NewGuardedClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	var dialer = &net.Dialer{Timeout: 10 * time.Second}
+	if !allowPrivate {
+		dialer.Control = func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if nil != err {
+				return err
+			}
+			if !models.IsWebhookIP(net.ParseIP(host)) {
+				return ErrInternalAddress
+			}
+			return nil
+		}
+	}
+
+	var transport = http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
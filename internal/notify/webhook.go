@@ -0,0 +1,41 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Dcarbon/iott-cloud/internal/models"
+)
+
+// Post alert (json) to target url. Client refuses internal address (url is
+// also checked when rule is saved)
+type Webhook struct {
+	client *http.Client
+}
+
+func NewWebhook() *Webhook {
+	return &Webhook{
+		client: NewGuardedClient(10*time.Second, AllowPrivate()),
+	}
+}
+
+func (wh *Webhook) Notify(target string, alert *models.Alert) error {
+	raw, err := json.Marshal(alert)
+	if nil != err {
+		return err
+	}
+
+	resp, err := wh.client.Post(target, "application/json", bytes.NewReader(raw))
+	if nil != err {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s response status %d", target, resp.StatusCode)
+	}
+	return nil
+}
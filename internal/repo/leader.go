@@ -0,0 +1,65 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"hash/fnv"
+	"log"
+	"sync"
+)
+
+// Leader election of background job by postgres session advisory lock. Lock
+// is held by a dedicated connection: it is released (other replica takes it)
+// when connection is closed or process is stopped
+type leaderLock struct {
+	name string
+	key  int64
+	db   *sql.DB
+	mtx  sync.Mutex
+	conn *sql.Conn // Non nil: this replica is leader
+}
+
+func newLeaderLock(db *sql.DB, name string) *leaderLock {
+	var h = fnv.New64a()
+	h.Write([]byte("iott-cloud:" + name))
+	return &leaderLock{
+		name: name,
+		key:  int64(h.Sum64()),
+		db:   db,
+	}
+}
+
+// Try to be (or stay) leader
+func (l *leaderLock) IsLeader(ctx context.Context) bool {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	if nil != l.conn {
+		if err := l.conn.PingContext(ctx); nil == err {
+			return true
+		}
+		log.Printf("Leader %s lost connection of lock\n", l.name)
+		l.conn.Close()
+		l.conn = nil
+	}
+
+	conn, err := l.db.Conn(ctx)
+	if nil != err {
+		log.Printf("Leader %s get connection error: %s\n", l.name, err)
+		return false
+	}
+
+	var locked = false
+	err = conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", l.key).Scan(&locked)
+	if nil != err || !locked {
+		if nil != err {
+			log.Printf("Leader %s lock error: %s\n", l.name, err)
+		}
+		conn.Close()
+		return false
+	}
+
+	log.Printf("This replica is leader of %s\n", l.name)
+	l.conn = conn
+	return true
+}
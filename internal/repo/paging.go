@@ -0,0 +1,186 @@
+package repo
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/iott-cloud/internal/domain"
+	"gorm.io/gorm"
+)
+
+type keyKind int
+
+const (
+	keyInt keyKind = iota
+	keyString
+	keyTime
+	keyFloat
+)
+
+// Sort key of list: (column, id) is unique, column is empty if list is
+// sorted by id only
+type keyset struct {
+	column string
+	kind   keyKind
+	id     string
+	idKind keyKind
+	desc   bool
+}
+
+// Value of cursor is encoded as string (type is by keyset)
+type pageCursor struct {
+	Val  string `json:"v,omitempty"`
+	Id   string `json:"i"`
+	Prev bool   `json:"p,omitempty"` // Cursor of previous page
+}
+
+func encodeCursor(ks *keyset, val, id interface{}, prev bool) string {
+	var c = &pageCursor{Id: formatKey(id), Prev: prev}
+	if ks.column != "" {
+		c.Val = formatKey(val)
+	}
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(ks *keyset, s string) (*pageCursor, interface{}, interface{}, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if nil != err {
+		return nil, nil, nil, err
+	}
+
+	var c = &pageCursor{}
+	err = json.Unmarshal(raw, c)
+	if nil != err {
+		return nil, nil, nil, err
+	}
+
+	id, err := parseKey(ks.idKind, c.Id)
+	if nil != err {
+		return nil, nil, nil, err
+	}
+	if ks.column == "" {
+		return c, nil, id, nil
+	}
+
+	val, err := parseKey(ks.kind, c.Val)
+	if nil != err {
+		return nil, nil, nil, err
+	}
+	return c, val, id, nil
+}
+
+func formatKey(v interface{}) string {
+	switch v := v.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case string:
+		return v
+	}
+	return fmt.Sprint(v)
+}
+
+func parseKey(kind keyKind, s string) (interface{}, error) {
+	switch kind {
+	case keyInt:
+		return strconv.ParseInt(s, 10, 64)
+	case keyTime:
+		return time.Parse(time.RFC3339Nano, s)
+	case keyFloat:
+		return strconv.ParseFloat(s, 64)
+	}
+	return s, nil
+}
+
+func (ks *keyset) order(desc bool) string {
+	var dir = " asc"
+	if desc {
+		dir = " desc"
+	}
+	if ks.column == "" {
+		return ks.id + dir
+	}
+	return ks.column + dir + ", " + ks.id + dir
+}
+
+// Get page of query (filter only, sel is applied after count). Skip (old
+// offset paging) is used only for first page. limit <= 0: all items. name:
+// name of item in error
+func paginate[T any](query *gorm.DB, name string, sel string, ks *keyset,
+	cursor string, skip, limit int,
+	keyOf func(*T) (interface{}, interface{}),
+) ([]*T, *domain.PageInfo, error) {
+	var page = &domain.PageInfo{Limit: limit}
+	var err = query.Session(&gorm.Session{}).Count(&page.Total).Error
+	if nil != err {
+		return nil, nil, dmodels.ParsePostgresError(name, err)
+	}
+
+	var c = &pageCursor{}
+	if cursor != "" {
+		var val, id interface{}
+		c, val, id, err = decodeCursor(ks, cursor)
+		if nil != err {
+			return nil, nil, dmodels.ErrBadRequest("Invalid cursor")
+		}
+
+		// Previous page: reverse order from first item of current page
+		var op = ">"
+		if ks.desc != c.Prev {
+			op = "<"
+		}
+		if ks.column == "" {
+			query = query.Where(ks.id+" "+op+" ?", id)
+		} else {
+			query = query.Where(fmt.Sprintf("(%s, %s) %s (?, ?)", ks.column, ks.id, op), val, id)
+		}
+	} else if skip > 0 {
+		query = query.Offset(skip)
+	}
+
+	if sel != "" {
+		query = query.Select(sel)
+	}
+	query = query.Order(ks.order(ks.desc != c.Prev))
+	if limit > 0 {
+		query = query.Limit(limit + 1)
+	}
+
+	var rows = make([]*T, 0)
+	err = query.Find(&rows).Error
+	if nil != err {
+		return nil, nil, dmodels.ParsePostgresError(name, err)
+	}
+
+	var more = limit > 0 && len(rows) > limit
+	if more {
+		rows = rows[:limit]
+	}
+	if c.Prev {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+
+	if len(rows) > 0 && limit > 0 {
+		var hasNext = more || c.Prev
+		var hasPrev = (c.Prev && more) || (!c.Prev && (cursor != "" || skip > 0))
+		if hasNext {
+			val, id := keyOf(rows[len(rows)-1])
+			page.Next = encodeCursor(ks, val, id, false)
+		}
+		if hasPrev {
+			val, id := keyOf(rows[0])
+			page.Prev = encodeCursor(ks, val, id, true)
+		}
+	}
+	return rows, page, nil
+}
@@ -0,0 +1,108 @@
+package repo
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/iott-cloud/internal/domain"
+	"github.com/Dcarbon/iott-cloud/internal/models"
+	"gorm.io/gorm"
+)
+
+// Table can be aggregated by time bucket
+type aggSource struct {
+	table     string // Table name
+	seriesCol string // Column identify series
+	valueExpr string // Expression of value
+}
+
+var aggSourceSm = &aggSource{
+	table:     models.TableNameSm,
+	seriesCol: "sensor_id",
+	valueExpr: "CAST (indicator ->> 'value' as float)",
+}
+
+var aggSourceMinted = &aggSource{
+	table:     models.TableNameMinted,
+	seriesCol: "iot_id",
+	valueExpr: "carbon",
+}
+
+type aggRow struct {
+	Sid    int64
+	Bucket time.Time
+	Val    *float64
+}
+
+// Aggregate value of series (ids) in range [from, to).
+// Option must be normalized before
+func (src *aggSource) aggregate(db *gorm.DB, opt *domain.AggOption, loc *time.Location,
+	from, to time.Time, ids []int64, conds ...interface{},
+) ([]*domain.AggSeries, error) {
+	if len(ids) == 0 {
+		return nil, dmodels.ErrBadRequest("Missing series id")
+	}
+
+	var query = db.Table(src.table).
+		Select(
+			fmt.Sprintf(
+				"%s as sid, date_trunc('%s', created_at AT TIME ZONE ?) as bucket, %s as val",
+				src.seriesCol, opt.Bucket, aggFuncExpr(opt.Func, src.valueExpr),
+			),
+			opt.Timezone,
+		).
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Where(src.seriesCol+" IN ?", ids).
+		Group("sid, bucket").
+		Order("sid, bucket")
+
+	if len(conds) > 0 {
+		query = query.Where(conds[0], conds[1:]...)
+	}
+
+	var rows = make([]*aggRow, 0)
+	var err = query.Scan(&rows).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Aggregate", err)
+	}
+
+	var points = make(map[int64][]*domain.AggPoint, len(ids))
+	for _, row := range rows {
+		// Bucket is local time (timestamp without timezone)
+		var b = row.Bucket
+		points[row.Sid] = append(points[row.Sid], &domain.AggPoint{
+			Time: time.Date(b.Year(), b.Month(), b.Day(), b.Hour(), b.Minute(), b.Second(), 0, loc),
+			Val:  row.Val,
+		})
+	}
+
+	var rs = make([]*domain.AggSeries, 0, len(ids))
+	for _, id := range ids {
+		filled, err := opt.FillPoints(points[id], from, to, loc)
+		if nil != err {
+			return nil, err
+		}
+		if nil == filled {
+			filled = make([]*domain.AggPoint, 0)
+		}
+		rs = append(rs, &domain.AggSeries{Id: id, Points: filled})
+	}
+	return rs, nil
+}
+
+func aggFuncExpr(fn domain.AggFunc, valueExpr string) string {
+	switch fn {
+	case domain.AggFuncAvg:
+		return "AVG(" + valueExpr + ")"
+	case domain.AggFuncMin:
+		return "MIN(" + valueExpr + ")"
+	case domain.AggFuncMax:
+		return "MAX(" + valueExpr + ")"
+	case domain.AggFuncCount:
+		return "CAST(COUNT(*) as float)"
+	case domain.AggFuncLast:
+		return "(ARRAY_AGG(" + valueExpr + " ORDER BY created_at DESC))[1]"
+	}
+	return "SUM(" + valueExpr + ")"
+}
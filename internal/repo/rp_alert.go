@@ -0,0 +1,355 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/iott-cloud/internal/domain"
+	"github.com/Dcarbon/iott-cloud/internal/models"
+	"github.com/Dcarbon/iott-cloud/internal/notify"
+	"github.com/Dcarbon/iott-cloud/internal/rss"
+	"github.com/go-redis/redis/v8"
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	keyAlertCooldown = "alert_cd_%d_%d" // String (SET NX EX) rule id, iot id
+
+	alertRulesTTL = time.Minute // Reload enabled rules after
+)
+
+type AlertRepo struct {
+	db        *gorm.DB
+	redis     *redis.Client
+	iot       domain.IIot
+	iots      *sync.Map                   // Cache of iot (id => *models.IOTDevice)
+	notifiers map[string]domain.INotifier // Channel => notifier
+
+	mut      *sync.RWMutex
+	rules    []*models.AlertRule // Enabled rules
+	loadedAt time.Time
+
+	allowPrivate bool // Allow webhook url of internal address (WEBHOOK_ALLOW_PRIVATE=1)
+}
+
+func NewAlertRepo(iot domain.IIot, notifiers map[string]domain.INotifier,
+) (*AlertRepo, error) {
+	var db = rss.GetDB()
+	var err = db.AutoMigrate(
+		&models.AlertRule{},
+		&models.Alert{},
+	)
+	if nil != err {
+		return nil, err
+	}
+
+	var impl = &AlertRepo{
+		db:           db,
+		redis:        rss.GetRedis(),
+		iot:          iot,
+		iots:         &sync.Map{},
+		notifiers:    notifiers,
+		allowPrivate: notify.AllowPrivate(),
+		mut:          &sync.RWMutex{},
+	}
+	return impl, nil
+}
+
+func (impl *AlertRepo) CreateRule(req *domain.RAlertRuleCreate,
+) (*models.AlertRule, error) {
+	var rule = req.ToRule()
+	var err = impl.validRule(rule)
+	if nil != err {
+		return nil, err
+	}
+
+	err = impl.tblRule().Create(rule).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Alert rule", err)
+	}
+	impl.invalidRules()
+	return rule, nil
+}
+
+func (impl *AlertRepo) UpdateRule(req *domain.RAlertRuleUpdate,
+) (*models.AlertRule, error) {
+	var rule = req.ToRule()
+	rule.ID = req.Id
+	rule.Enabled = req.Enabled
+
+	var err = impl.validRule(rule)
+	if nil != err {
+		return nil, err
+	}
+
+	var old = &models.AlertRule{}
+	err = impl.tblRule().Where("id = ?", req.Id).First(old).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Alert rule", err)
+	}
+	rule.CreatedAt = old.CreatedAt
+
+	err = impl.tblRule().Save(rule).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Alert rule", err)
+	}
+	impl.invalidRules()
+	return rule, nil
+}
+
+func (impl *AlertRepo) DeleteRule(id int64) error {
+	var err = impl.tblRule().Where("id = ?", id).Delete(&models.AlertRule{}).Error
+	if nil != err {
+		return dmodels.ParsePostgresError("Alert rule", err)
+	}
+	impl.invalidRules()
+	return nil
+}
+
+func (impl *AlertRepo) GetRules(req *domain.RAlertRuleGetList,
+) ([]*models.AlertRule, error) {
+	if req.Limit <= 0 {
+		req.Limit = 20
+	}
+
+	var tbl = impl.tblRule().Offset(req.Skip).Limit(req.Limit)
+	if req.ProjectId > 0 {
+		tbl = tbl.Where("project_id = ?", req.ProjectId)
+	}
+	if req.IotId > 0 {
+		tbl = tbl.Where("iot_id = ?", req.IotId)
+	}
+
+	var rules = make([]*models.AlertRule, 0)
+	var err = tbl.Order("id asc").Find(&rules).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Alert rule", err)
+	}
+	return rules, nil
+}
+
+func (impl *AlertRepo) GetAlerts(req *domain.RAlertGetList,
+) ([]*models.Alert, error) {
+	if req.Limit <= 0 {
+		req.Limit = 20
+	}
+
+	var tbl = impl.tblAlert().Offset(req.Skip).Limit(req.Limit)
+	if req.RuleId > 0 {
+		tbl = tbl.Where("rule_id = ?", req.RuleId)
+	}
+	if req.ProjectId > 0 {
+		tbl = tbl.Where("project_id = ?", req.ProjectId)
+	}
+	if req.IotId > 0 {
+		tbl = tbl.Where("iot_id = ?", req.IotId)
+	}
+	if req.From > 0 {
+		tbl = tbl.Where("created_at >= ?", time.Unix(req.From, 0))
+	}
+	if req.To > 0 {
+		tbl = tbl.Where("created_at < ?", time.Unix(req.To, 0))
+	}
+
+	var alerts = make([]*models.Alert, 0)
+	var err = tbl.Order("created_at desc").Find(&alerts).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Alert", err)
+	}
+	return alerts, nil
+}
+
+func (impl *AlertRepo) OnMetric(sensor *models.Sensor, metric *dmodels.AllMetric) {
+	go impl.evaluateMetric(sensor, metric)
+}
+
+func (impl *AlertRepo) OnStatus(prev models.OpStatus, stt *models.OpIotStatus) {
+	if prev == stt.Status {
+		return
+	}
+	go impl.evaluateStatus(stt)
+}
+
+func (impl *AlertRepo) OnMintSignFailed(iotId int64, reason error) {
+	go impl.evaluateMintSign(iotId, reason)
+}
+
+func (impl *AlertRepo) evaluateMetric(sensor *models.Sensor, metric *dmodels.AllMetric) {
+	var projectId int64
+	iot, err := impl.getIot(sensor.IotID)
+	if nil != err {
+		log.Println("Alert get iot error: ", sensor.IotID, err)
+	} else {
+		projectId = iot.Project
+	}
+
+	for _, rule := range impl.getRules() {
+		if !rule.IsApply(models.AlertKindMetric, projectId, sensor.IotID, sensor.Type) {
+			continue
+		}
+
+		val, matched := rule.MatchMetric(metric)
+		if !matched {
+			continue
+		}
+
+		impl.fire(rule, &models.Alert{
+			Kind:      models.AlertKindMetric,
+			ProjectId: projectId,
+			IotId:     sensor.IotID,
+			SensorId:  sensor.ID,
+			Value:     val,
+			Message: fmt.Sprintf(
+				"Sensor %d %s = %v (%s %v)",
+				sensor.ID, rule.Field, val, rule.Op, rule.Threshold,
+			),
+		})
+	}
+}
+
+func (impl *AlertRepo) evaluateStatus(stt *models.OpIotStatus) {
+	for _, rule := range impl.getRules() {
+		if !rule.IsApply(models.AlertKindStatus, stt.Project, stt.Id, 0) ||
+			rule.Status != stt.Status {
+			continue
+		}
+
+		impl.fire(rule, &models.Alert{
+			Kind:      models.AlertKindStatus,
+			ProjectId: stt.Project,
+			IotId:     stt.Id,
+			Value:     float64(stt.Status),
+			Message:   fmt.Sprintf("Iot status changed to %d (latest: %d)", stt.Status, stt.Latest),
+		})
+	}
+}
+
+func (impl *AlertRepo) evaluateMintSign(iotId int64, reason error) {
+	var projectId int64
+	iot, err := impl.getIot(iotId)
+	if nil != err {
+		log.Println("Alert get iot error: ", iotId, err)
+	} else {
+		projectId = iot.Project
+	}
+
+	for _, rule := range impl.getRules() {
+		if !rule.IsApply(models.AlertKindMintSign, projectId, iotId, 0) {
+			continue
+		}
+
+		impl.fire(rule, &models.Alert{
+			Kind:      models.AlertKindMintSign,
+			ProjectId: projectId,
+			IotId:     iotId,
+			Message:   "Mint sign verification failed: " + reason.Error(),
+		})
+	}
+}
+
+// Save & notify alert if rule is not in cooldown for iot
+func (impl *AlertRepo) fire(rule *models.AlertRule, alert *models.Alert) {
+	if rule.Cooldown > 0 {
+		ok, err := impl.redis.SetNX(
+			context.TODO(),
+			fmt.Sprintf(keyAlertCooldown, rule.ID, alert.IotId),
+			time.Now().Unix(),
+			time.Duration(rule.Cooldown)*time.Second,
+		).Result()
+		if nil != err {
+			log.Println("Set alert cooldown error: ", err)
+			return
+		}
+		if !ok {
+			return
+		}
+	}
+
+	alert.ID = uuid.NewV4().String()
+	alert.RuleId = rule.ID
+	alert.CreatedAt = time.Now()
+
+	var err = impl.tblAlert().Create(alert).Error
+	if nil != err {
+		log.Println("Save alert error: ", err)
+	}
+
+	for channel, target := range rule.Channels() {
+		notifier, ok := impl.notifiers[channel]
+		if !ok {
+			log.Println("Alert notifier is not configured: ", channel)
+			continue
+		}
+
+		err = notifier.Notify(target, alert)
+		if nil != err {
+			log.Printf("Notify alert %s via %s error: %s\n", alert.ID, channel, err)
+		}
+	}
+}
+
+func (impl *AlertRepo) getRules() []*models.AlertRule {
+	impl.mut.RLock()
+	var rules, loadedAt = impl.rules, impl.loadedAt
+	impl.mut.RUnlock()
+
+	if rules != nil && time.Since(loadedAt) < alertRulesTTL {
+		return rules
+	}
+
+	rules = make([]*models.AlertRule, 0)
+	var err = impl.tblRule().Where("enabled = ?", true).Find(&rules).Error
+	if nil != err {
+		log.Println("Load alert rules error: ", err)
+		return rules
+	}
+
+	impl.mut.Lock()
+	impl.rules = rules
+	impl.loadedAt = time.Now()
+	impl.mut.Unlock()
+	return rules
+}
+
+func (impl *AlertRepo) invalidRules() {
+	impl.mut.Lock()
+	impl.rules = nil
+	impl.mut.Unlock()
+}
+
+func (impl *AlertRepo) getIot(iotId int64) (*models.IOTDevice, error) {
+	if cached, ok := impl.iots.Load(iotId); ok {
+		return cached.(*models.IOTDevice), nil
+	}
+
+	iot, err := impl.iot.GetIot(iotId)
+	if nil != err {
+		return nil, err
+	}
+	impl.iots.Store(iotId, iot)
+	return iot, nil
+}
+
+func (impl *AlertRepo) validRule(rule *models.AlertRule) error {
+	var err = rule.IsValid()
+	if nil != err {
+		return err
+	}
+	if rule.WebhookUrl != "" {
+		return notify.ValidWebhookUrl(rule.WebhookUrl, impl.allowPrivate)
+	}
+	return nil
+}
+
+func (impl *AlertRepo) tblRule() *gorm.DB {
+	return impl.db.Table(models.TableNameAlertRule)
+}
+
+func (impl *AlertRepo) tblAlert() *gorm.DB {
+	return impl.db.Table(models.TableNameAlert)
+}
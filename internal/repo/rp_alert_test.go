@@ -0,0 +1,101 @@
+package repo
+
+import (
+	"testing"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/go-shared/libs/utils"
+	"github.com/Dcarbon/iott-cloud/internal/domain"
+	"github.com/Dcarbon/iott-cloud/internal/models"
+	"github.com/Dcarbon/iott-cloud/internal/notify"
+)
+
+var alertFake = notify.NewFake()
+var alertTest *AlertRepo
+
+func init() {
+	var err error
+	alertTest, err = NewAlertRepo(iotRepoTest, map[string]domain.INotifier{
+		models.AlertChannelWebhook: alertFake,
+		models.AlertChannelEmail:   alertFake,
+		models.AlertChannelEvent:   alertFake,
+	})
+	utils.PanicError("", err)
+	alertTest.allowPrivate = true
+}
+
+func TestAlertMetric(t *testing.T) {
+	rule, err := alertTest.CreateRule(&domain.RAlertRuleCreate{
+		Name:       "Flow too high",
+		IotId:      1,
+		SensorType: dmodels.SensorTypeFlow,
+		Kind:       models.AlertKindMetric,
+		Op:         models.AlertOpGT,
+		Threshold:  100,
+		Cooldown:   60,
+		WebhookUrl: "http://localhost/alert",
+	})
+	utils.PanicError("", err)
+	defer alertTest.DeleteRule(rule.ID)
+
+	alertFake.Reset()
+	var sensor = &models.Sensor{ID: 1, IotID: 1, Type: dmodels.SensorTypeFlow}
+	var metric = &dmodels.AllMetric{
+		DefaultMetric: dmodels.DefaultMetric{Val: 120},
+	}
+
+	// Second alert is suppressed by cooldown
+	alertTest.evaluateMetric(sensor, metric)
+	alertTest.evaluateMetric(sensor, metric)
+
+	var sent = alertFake.Sent()
+	if len(sent) != 1 {
+		t.Fatalf("Expect 1 notification but got %d", len(sent))
+	}
+	utils.Dump("Alert", sent[0])
+
+	alerts, err := alertTest.GetAlerts(&domain.RAlertGetList{RuleId: rule.ID})
+	utils.PanicError("", err)
+	utils.Dump("Alerts", alerts)
+}
+
+func TestAlertStatus(t *testing.T) {
+	rule, err := alertTest.CreateRule(&domain.RAlertRuleCreate{
+		Name:      "Iot inactived",
+		IotId:     1,
+		Kind:      models.AlertKindStatus,
+		Status:    models.OpStatusInactived,
+		PushEvent: true,
+	})
+	utils.PanicError("", err)
+	defer alertTest.DeleteRule(rule.ID)
+
+	alertFake.Reset()
+	alertTest.evaluateStatus(&models.OpIotStatus{
+		Id:     1,
+		Status: models.OpStatusWarning,
+	})
+	if len(alertFake.Sent()) != 0 {
+		t.Fatalf("Rule must not be fired for other status")
+	}
+
+	alertTest.evaluateStatus(&models.OpIotStatus{
+		Id:     1,
+		Status: models.OpStatusInactived,
+	})
+	if len(alertFake.Sent()) != 1 {
+		t.Fatalf("Rule must be fired when iot is inactived")
+	}
+}
+
+func TestAlertRuleInvalid(t *testing.T) {
+	for _, req := range []*domain.RAlertRuleCreate{
+		{Kind: models.AlertKindMetric, Field: "temp", Op: models.AlertOpGT, PushEvent: true},
+		{Kind: models.AlertKindMintSign, WebhookUrl: "ftp://example.com/alert"},
+	} {
+		_, err := alertTest.CreateRule(req)
+		if nil == err {
+			t.Fatalf("Rule must be rejected: %+v", req)
+		}
+	}
+}
@@ -0,0 +1,388 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/go-shared/ecodes"
+	"github.com/Dcarbon/go-shared/libs/utils"
+	"github.com/Dcarbon/iott-cloud/internal/domain"
+	"github.com/Dcarbon/iott-cloud/internal/merkle"
+	"github.com/Dcarbon/iott-cloud/internal/models"
+	"github.com/Dcarbon/iott-cloud/internal/rss"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"gorm.io/gorm"
+)
+
+// Build merkle bundles of signed metrics periodically
+type BundleRepo struct {
+	db     *gorm.DB
+	period time.Duration // Bundle period (aligned to unix epoch)
+	delay  time.Duration // Wait for late signature before bundle period
+	anchor bool          // Anchor bundle to next mint signature
+	leader *leaderLock   // Only one replica builds
+}
+
+func NewBundleRepo() (*BundleRepo, error) {
+	var db = rss.GetDB()
+	var err = db.AutoMigrate(
+		&models.MetricBundle{},
+		&models.MetricBundleLeaf{},
+		&models.MetricExcluded{},
+	)
+	if nil != err {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if nil != err {
+		return nil, err
+	}
+
+	var impl = &BundleRepo{
+		db:     db,
+		period: time.Duration(utils.Int64Env("BUNDLE_PERIOD", 86400)) * time.Second,
+		delay:  time.Duration(utils.Int64Env("BUNDLE_DELAY", 3600)) * time.Second,
+		anchor: utils.IntEnv("BUNDLE_ANCHOR", 1) == 1,
+		leader: newLeaderLock(sqlDB, "metric-bundle"),
+	}
+	return impl, nil
+}
+
+// Build (and anchor) bundles periodically on leader replica
+func (impl *BundleRepo) Run() {
+	var ticker = time.NewTicker(time.Duration(utils.Int64Env("BUNDLE_CHECK_PERIOD", 600)) * time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if !impl.leader.IsLeader(context.Background()) {
+			continue
+		}
+
+		var to = time.Now().Add(-impl.delay).Truncate(impl.period)
+		bundles, err := impl.Build(to)
+		if nil != err {
+			log.Println("Build metric bundles error: ", err)
+		}
+		for _, b := range bundles {
+			log.Printf("Metric bundle %d of iot %d: %d leaves, root %s\n", b.ID, b.IotId, b.Leaves, b.Root)
+		}
+
+		if impl.anchor {
+			err = impl.Anchor()
+			if nil != err {
+				log.Println("Anchor metric bundles error: ", err)
+			}
+		}
+	}
+}
+
+// Bundle signatures were created before `to` (aligned to period): one bundle
+// per iot per period
+func (impl *BundleRepo) Build(to time.Time) ([]*models.MetricBundle, error) {
+	var iotIds = make([]int64, 0)
+	var err = impl.unbundled(impl.db, to).
+		Distinct("sms.iot_id").
+		Pluck("sms.iot_id", &iotIds).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Metric signature", err)
+	}
+
+	var bundles = make([]*models.MetricBundle, 0, len(iotIds))
+	for _, iotId := range iotIds {
+		for {
+			// Period of oldest unbundled signature
+			var oldest = make([]time.Time, 0, 1)
+			var err = impl.unbundled(impl.db, to).
+				Where("sms.iot_id = ?", iotId).
+				Order("sms.created_at asc").
+				Limit(1).
+				Pluck("sms.created_at", &oldest).Error
+			if nil != err {
+				return bundles, dmodels.ParsePostgresError("Metric signature", err)
+			}
+			if len(oldest) == 0 {
+				break
+			}
+
+			var from = oldest[0].Truncate(impl.period)
+			var end = from.Add(impl.period)
+			if end.After(to) {
+				end = to
+			}
+
+			bundle, err := impl.build(iotId, from, end)
+			if nil != err {
+				return bundles, err
+			}
+			if nil != bundle {
+				bundles = append(bundles, bundle)
+			}
+		}
+	}
+	return bundles, nil
+}
+
+// Bind bundles has no anchor to first mint signature of iot after bundled
+// period
+func (impl *BundleRepo) Anchor() error {
+	var err = impl.db.Exec(`
+		UPDATE `+models.TableNameMetricBundle+` AS b
+		SET mint_sign_id = ms.id, anchored_at = ?
+		FROM (
+			SELECT DISTINCT ON (b2.id) b2.id AS bundle_id, s.id
+			FROM `+models.TableNameMetricBundle+` AS b2
+			JOIN `+models.TableNameMintSign+` AS s
+				ON s.iot_id = b2.iot_id AND s.created_at >= b2."to"
+			WHERE b2.mint_sign_id = 0
+			ORDER BY b2.id, s.created_at ASC
+		) AS ms
+		WHERE b.id = ms.bundle_id`,
+		time.Now(),
+	).Error
+	if nil != err {
+		return dmodels.ParsePostgresError("Metric bundle", err)
+	}
+	return nil
+}
+
+func (impl *BundleRepo) GetBundles(req *domain.RBundleGetList,
+) ([]*models.MetricBundle, error) {
+	if req.Limit <= 0 {
+		req.Limit = 20
+	}
+
+	var tbl = impl.tblBundle().Where("iot_id = ?", req.IotId)
+	if req.MintSignId > 0 {
+		tbl = tbl.Where("mint_sign_id = ?", req.MintSignId)
+	}
+
+	var bundles = make([]*models.MetricBundle, 0)
+	var err = tbl.Order("id desc").
+		Offset(req.Skip).
+		Limit(req.Limit).
+		Find(&bundles).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Metric bundle", err)
+	}
+	return bundles, nil
+}
+
+// metricId: id of sensor metric or id of metric signature
+func (impl *BundleRepo) GetProof(metricId string) (*domain.RsMetricProof, error) {
+	var signIds = make([]string, 0, 1)
+	var err = impl.db.Table(models.TableNameSm).
+		Where("id = ?", metricId).
+		Pluck("sign_id", &signIds).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Sensor metric", err)
+	}
+
+	var signId = metricId
+	if len(signIds) > 0 {
+		signId = signIds[0]
+	}
+
+	var leaf = &models.MetricBundleLeaf{}
+	err = impl.tblLeaf().Where("sign_id = ?", signId).First(leaf).Error
+	if nil != err {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, impl.notBundled(signId)
+		}
+		return nil, dmodels.ParsePostgresError("Metric bundle leaf", err)
+	}
+
+	var bundle = &models.MetricBundle{}
+	err = impl.tblBundle().Where("id = ?", leaf.BundleId).First(bundle).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Metric bundle", err)
+	}
+
+	var leafHexs = make([]string, 0, bundle.Leaves)
+	err = impl.tblLeaf().
+		Where("bundle_id = ?", bundle.ID).
+		Order("idx asc").
+		Pluck("leaf", &leafHexs).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Metric bundle leaf", err)
+	}
+
+	var leaves = make([]common.Hash, len(leafHexs))
+	for i, h := range leafHexs {
+		leaves[i] = common.HexToHash(h)
+	}
+
+	tree, err := merkle.New(leaves)
+	if nil != err {
+		return nil, dmodels.ErrInternal(err)
+	}
+	if tree.Root().Hex() != bundle.Root {
+		return nil, dmodels.ErrInternal(errors.New("leaves of bundle do not match root"))
+	}
+
+	proof, err := tree.Proof(leaf.Idx)
+	if nil != err {
+		return nil, dmodels.ErrInternal(err)
+	}
+
+	var rs = &domain.RsMetricProof{
+		MetricId:    metricId,
+		SignId:      signId,
+		Leaf:        leaf.Leaf,
+		Index:       leaf.Idx,
+		Proof:       make([]string, len(proof)),
+		Root:        bundle.Root,
+		Bundle:      bundle,
+		GeneratedAt: time.Now(),
+	}
+	for i, p := range proof {
+		rs.Proof[i] = p.Hex()
+	}
+
+	var signed = &models.SmSignature{}
+	err = impl.db.Table(models.TableNameSmSignature).Where("id = ?", signId).First(signed).Error
+	if nil != err && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, dmodels.ParsePostgresError("Metric signature", err)
+	}
+
+	rs.Data = signed.Data
+	rs.Signed = signed.Signed
+	current, err := signatureLeaf(signed)
+	rs.Altered = nil != err || current.Hex() != leaf.Leaf
+	return rs, nil
+}
+
+// Bundle signatures of iot were created in [from, to). Invalid signature is
+// recorded as excluded. Return nil when there is no valid signature
+func (impl *BundleRepo) build(iotId int64, from, to time.Time,
+) (*models.MetricBundle, error) {
+	var bundle *models.MetricBundle
+	var err = impl.db.Transaction(func(tx *gorm.DB) error {
+		// Signature is in one bundle (unique sign_id): concurrent builder of
+		// the same iot is rolled back
+		var signs = make([]*models.SmSignature, 0)
+		var err = impl.unbundled(tx, to).
+			Where("sms.iot_id = ? AND sms.created_at >= ?", iotId, from).
+			Select("sms.*").
+			Order("sms.created_at asc, sms.id asc").
+			Find(&signs).Error
+		if nil != err {
+			return dmodels.ParsePostgresError("Metric signature", err)
+		}
+		if len(signs) == 0 {
+			return nil
+		}
+
+		var leaves = make([]common.Hash, 0, len(signs))
+		var rows = make([]*models.MetricBundleLeaf, 0, len(signs))
+		var excluded = make([]*models.MetricExcluded, 0)
+		for _, sign := range signs {
+			leaf, err := signatureLeaf(sign)
+			if nil != err {
+				log.Printf("Metric signature %s is invalid hex, exclude: %s\n", sign.ID, err)
+				excluded = append(excluded, &models.MetricExcluded{
+					SignId:    sign.ID,
+					IotId:     iotId,
+					Reason:    err.Error(),
+					CreatedAt: time.Now(),
+				})
+				continue
+			}
+			rows = append(rows, &models.MetricBundleLeaf{
+				Idx:    len(leaves),
+				SignId: sign.ID,
+				Leaf:   leaf.Hex(),
+			})
+			leaves = append(leaves, leaf)
+		}
+
+		if len(excluded) > 0 {
+			err = tx.Table(models.TableNameMetricExcluded).CreateInBatches(excluded, 500).Error
+			if nil != err {
+				return dmodels.ParsePostgresError("Metric excluded", err)
+			}
+		}
+		if len(leaves) == 0 {
+			return nil
+		}
+
+		tree, err := merkle.New(leaves)
+		if nil != err {
+			return dmodels.ErrInternal(err)
+		}
+
+		bundle = &models.MetricBundle{
+			IotId:     iotId,
+			From:      from,
+			To:        to,
+			Root:      tree.Root().Hex(),
+			Leaves:    tree.Len(),
+			CreatedAt: time.Now(),
+		}
+		err = tx.Table(models.TableNameMetricBundle).Create(bundle).Error
+		if nil != err {
+			return dmodels.ParsePostgresError("Metric bundle", err)
+		}
+
+		for _, row := range rows {
+			row.BundleId = bundle.ID
+		}
+		err = tx.Table(models.TableNameMetricBundleLeaf).CreateInBatches(rows, 500).Error
+		if nil != err {
+			return dmodels.ParsePostgresError("Metric bundle leaf", err)
+		}
+		return nil
+	})
+	if nil != err {
+		return nil, err
+	}
+	return bundle, nil
+}
+
+// Signature has no leaf: excluded or not bundled yet
+func (impl *BundleRepo) notBundled(signId string) error {
+	var reasons = make([]string, 0, 1)
+	var err = impl.db.Table(models.TableNameMetricExcluded).
+		Where("sign_id = ?", signId).
+		Pluck("reason", &reasons).Error
+	if nil != err {
+		return dmodels.ParsePostgresError("Metric excluded", err)
+	}
+	if len(reasons) > 0 {
+		return dmodels.NewError(ecodes.NotExisted, "Metric signature was excluded from bundle: "+reasons[0])
+	}
+	return dmodels.NewError(ecodes.NotExisted, "Metric is not bundled yet")
+}
+
+// Signatures was created before `to` and was not in any bundle (or excluded)
+func (impl *BundleRepo) unbundled(tx *gorm.DB, to time.Time) *gorm.DB {
+	return tx.Table(models.TableNameSmSignature+" AS sms").
+		Joins("LEFT JOIN "+models.TableNameMetricBundleLeaf+" AS l ON l.sign_id = sms.id").
+		Joins("LEFT JOIN "+models.TableNameMetricExcluded+" AS e ON e.sign_id = sms.id").
+		Where("l.sign_id IS NULL AND e.sign_id IS NULL AND sms.created_at < ?", to)
+}
+
+func (impl *BundleRepo) tblBundle() *gorm.DB {
+	return impl.db.Table(models.TableNameMetricBundle)
+}
+
+func (impl *BundleRepo) tblLeaf() *gorm.DB {
+	return impl.db.Table(models.TableNameMetricBundleLeaf)
+}
+
+func signatureLeaf(sign *models.SmSignature) (common.Hash, error) {
+	data, err := hexutil.Decode(sign.Data)
+	if nil != err {
+		return common.Hash{}, err
+	}
+
+	signed, err := hexutil.Decode(sign.Signed)
+	if nil != err {
+		return common.Hash{}, err
+	}
+	return merkle.Leaf(data, signed), nil
+}
@@ -0,0 +1,57 @@
+package repo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Dcarbon/go-shared/libs/utils"
+	"github.com/Dcarbon/iott-cloud/internal/domain"
+	"github.com/Dcarbon/iott-cloud/internal/merkle"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var bundleRepoTest *BundleRepo
+
+func init() {
+	var err error
+	bundleRepoTest, err = NewBundleRepo()
+	if nil != err {
+		panic(err.Error())
+	}
+}
+
+func TestBundleBuild(t *testing.T) {
+	bundles, err := bundleRepoTest.Build(time.Now())
+	utils.PanicError("TestBundleBuild", err)
+	utils.Dump("Bundles", bundles)
+
+	err = bundleRepoTest.Anchor()
+	utils.PanicError("TestBundleAnchor", err)
+}
+
+func TestBundleProof(t *testing.T) {
+	bundles, err := bundleRepoTest.GetBundles(&domain.RBundleGetList{IotId: 292})
+	utils.PanicError("TestBundleProof", err)
+	if len(bundles) == 0 {
+		return
+	}
+
+	var signIds = make([]string, 0, 1)
+	err = bundleRepoTest.tblLeaf().
+		Where("bundle_id = ?", bundles[0].ID).
+		Limit(1).
+		Pluck("sign_id", &signIds).Error
+	utils.PanicError("TestBundleProof get leaf", err)
+
+	proof, err := bundleRepoTest.GetProof(signIds[0])
+	utils.PanicError("TestBundleProof", err)
+
+	var siblings = make([]common.Hash, len(proof.Proof))
+	for i, p := range proof.Proof {
+		siblings[i] = common.HexToHash(p)
+	}
+	if !merkle.Verify(common.HexToHash(proof.Root), common.HexToHash(proof.Leaf), siblings) {
+		t.Fatalf("Proof of metric is invalid")
+	}
+	utils.Dump("Proof", proof)
+}
@@ -0,0 +1,98 @@
+package repo
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/iott-cloud/internal/domain"
+	"github.com/Dcarbon/iott-cloud/internal/models"
+	"github.com/Dcarbon/iott-cloud/internal/rss"
+	"gorm.io/gorm"
+)
+
+type ChainRepo struct {
+	db *gorm.DB
+}
+
+func NewChainRepo() (*ChainRepo, error) {
+	var db = rss.GetDB()
+	var err = db.AutoMigrate(
+		&models.ChainMint{},
+		&models.ChainCursor{},
+		&models.MintDiscrepancy{},
+	)
+	if nil != err {
+		return nil, err
+	}
+
+	var impl = &ChainRepo{
+		db: db,
+	}
+	return impl, nil
+}
+
+func (impl *ChainRepo) GetMints(req *domain.RChainMintGetList,
+) ([]*models.ChainMint, error) {
+	if req.Limit <= 0 {
+		req.Limit = 20
+	}
+
+	var tbl = impl.tblMint().Offset(req.Skip).Limit(req.Limit)
+	if req.DomainId > 0 {
+		tbl = tbl.Where("domain_id = ?", req.DomainId)
+	}
+	if req.Iot != "" {
+		tbl = tbl.Where("iot = ?", strings.ToLower(req.Iot))
+	}
+
+	var mints = make([]*models.ChainMint, 0)
+	var err = tbl.Order("block_number desc, log_index desc").Find(&mints).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Chain mint", err)
+	}
+	return mints, nil
+}
+
+func (impl *ChainRepo) GetDiscrepancies(req *domain.RDiscrepancyGetList,
+) ([]*models.MintDiscrepancy, error) {
+	if req.Limit <= 0 {
+		req.Limit = 20
+	}
+
+	var tbl = impl.tblDiscrepancy().Offset(req.Skip).Limit(req.Limit)
+	if req.Kind != "" {
+		tbl = tbl.Where("kind = ?", req.Kind)
+	}
+	if req.Iot != "" {
+		tbl = tbl.Where("iot = ?", strings.ToLower(req.Iot))
+	}
+	if !req.Resolved {
+		tbl = tbl.Where("resolved_at IS NULL")
+	}
+
+	var data = make([]*models.MintDiscrepancy, 0)
+	var err = tbl.Order("id desc").Find(&data).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Mint discrepancy", err)
+	}
+	return data, nil
+}
+
+func (impl *ChainRepo) ResolveDiscrepancy(id int64) error {
+	var err = impl.tblDiscrepancy().
+		Where("id = ? AND resolved_at IS NULL", id).
+		Update("resolved_at", time.Now()).Error
+	if nil != err {
+		return dmodels.ParsePostgresError("Mint discrepancy", err)
+	}
+	return nil
+}
+
+func (impl *ChainRepo) tblMint() *gorm.DB {
+	return impl.db.Table(models.TableNameChainMint)
+}
+
+func (impl *ChainRepo) tblDiscrepancy() *gorm.DB {
+	return impl.db.Table(models.TableNameMintDiscrepancy)
+}
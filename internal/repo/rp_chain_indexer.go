@@ -0,0 +1,470 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/go-shared/ecodes"
+	"github.com/Dcarbon/go-shared/libs/utils"
+	"github.com/Dcarbon/iott-cloud/internal/chain"
+	"github.com/Dcarbon/iott-cloud/internal/domain"
+	"github.com/Dcarbon/iott-cloud/internal/models"
+	"github.com/Dcarbon/iott-cloud/internal/rss"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const chainReconcileBatch = 100
+
+// Follow mint events of carbon contract (of a sign domain) & reconcile them
+// with mint_sign, minted. Only block deeper than confirmations is indexed. Hash
+// of cursor is checked every poll, indexer rewinds (confirmations blocks) when
+// it was reorged. Only one replica (leader) indexes a domain
+type ChainIndexer struct {
+	db            *gorm.DB
+	client        chain.IClient
+	domain        *models.SignDomain // Chain & contract
+	contract      common.Address
+	iot           domain.IIot
+	confirmations uint64        //
+	startBlock    uint64        // Deploy block of contract
+	batch         uint64        // Max blocks per poll
+	period        time.Duration // Poll period
+	leader        *leaderLock   //
+}
+
+func NewChainIndexer(client chain.IClient, sd *models.SignDomain, iot domain.IIot,
+) (*ChainIndexer, error) {
+	if !common.IsHexAddress(sd.VerifyingContract) {
+		return nil, dmodels.ErrBadRequest("Invalid contract address: " + sd.VerifyingContract)
+	}
+
+	var db = rss.GetDB()
+	var err = db.AutoMigrate(
+		&models.ChainMint{},
+		&models.ChainCursor{},
+		&models.MintDiscrepancy{},
+	)
+	if nil != err {
+		return nil, err
+	}
+
+	// Deploy block of contract of domain
+	var startBlock = utils.Int64Env(
+		fmt.Sprintf("CHAIN_START_BLOCK_%d", sd.ID),
+		utils.Int64Env("CHAIN_START_BLOCK", 0),
+	)
+
+	sqlDB, err := db.DB()
+	if nil != err {
+		return nil, err
+	}
+
+	var idx = &ChainIndexer{
+		db:            db,
+		client:        client,
+		domain:        sd,
+		contract:      common.HexToAddress(sd.VerifyingContract),
+		iot:           iot,
+		confirmations: uint64(utils.Int64Env("CHAIN_CONFIRMATIONS", 12)),
+		startBlock:    uint64(startBlock),
+		batch:         uint64(utils.Int64Env("CHAIN_BATCH", 2000)),
+		period:        time.Duration(utils.Int64Env("CHAIN_POLL_PERIOD", 15)) * time.Second,
+		leader:        newLeaderLock(sqlDB, fmt.Sprintf("chain-indexer-domain-%d", sd.ID)),
+	}
+	return idx, nil
+}
+
+func (idx *ChainIndexer) Run() {
+	var ticker = time.NewTicker(idx.period)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if !idx.leader.IsLeader(context.Background()) {
+			continue
+		}
+
+		for {
+			n, err := idx.Process(context.Background())
+			if nil != err {
+				log.Println("Chain indexer process error: ", err)
+				break
+			}
+			if uint64(n) < idx.batch {
+				break
+			}
+		}
+
+		var _, err = idx.Reconcile()
+		if nil != err {
+			log.Println("Chain indexer reconcile error: ", err)
+		}
+	}
+}
+
+// Index next range of confirmed blocks. Return num of blocks was indexed
+func (idx *ChainIndexer) Process(ctx context.Context) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	head, err := idx.client.BlockNumber(ctx)
+	if nil != err {
+		return 0, err
+	}
+	if head < idx.confirmations {
+		return 0, nil
+	}
+	var safe = head - idx.confirmations
+
+	cursor, err := idx.getCursor()
+	if nil != err {
+		return 0, err
+	}
+
+	err = idx.checkReorg(ctx, cursor)
+	if nil != err {
+		return 0, err
+	}
+
+	var from = uint64(cursor.BlockNumber + 1)
+	if from > safe {
+		return 0, nil
+	}
+	var to = from + idx.batch - 1
+	if to > safe {
+		to = safe
+	}
+
+	logs, invalid, err := chain.FetchMints(ctx, idx.client, idx.contract, from, to)
+	if nil != err {
+		return 0, err
+	}
+
+	// Invalid log never becomes valid: record it & move on
+	var discrepancies = make([]*models.MintDiscrepancy, 0, len(invalid))
+	for _, l := range invalid {
+		log.Printf("Chain invalid mint log %s-%d: %s\n", l.Log.TxHash.Hex(), l.Log.Index, l.Err)
+		discrepancies = append(discrepancies, &models.MintDiscrepancy{
+			Kind:        models.DiscrepancyInvalidLog,
+			ChainMintId: fmt.Sprintf("%s-%d", l.Log.TxHash.Hex(), l.Log.Index),
+			Detail:      fmt.Sprintf("Block %d: %s", l.Log.BlockNumber, l.Err),
+			CreatedAt:   time.Now(),
+		})
+	}
+
+	var headers = make(map[uint64]time.Time)
+	var mints = make([]*models.ChainMint, 0, len(logs))
+	for _, l := range logs {
+		blockTime, ok := headers[l.BlockNumber]
+		if !ok {
+			header, err := idx.client.HeaderByNumber(ctx, new(big.Int).SetUint64(l.BlockNumber))
+			if nil != err {
+				return 0, err
+			}
+			blockTime = time.Unix(int64(header.Time), 0)
+			headers[l.BlockNumber] = blockTime
+		}
+
+		mints = append(mints, &models.ChainMint{
+			ID:          fmt.Sprintf("%s-%d", l.TxHash.Hex(), l.LogIndex),
+			DomainId:    idx.domain.ID,
+			Contract:    strings.ToLower(idx.contract.Hex()),
+			BlockNumber: int64(l.BlockNumber),
+			BlockHash:   l.BlockHash.Hex(),
+			BlockTime:   blockTime,
+			TxHash:      l.TxHash.Hex(),
+			LogIndex:    int(l.LogIndex),
+			Iot:         strings.ToLower(l.Iot.Hex()),
+			Nonce:       l.Nonce.Int64(),
+			Amount:      hexutil.EncodeBig(l.Amount),
+			CreatedAt:   time.Now(),
+		})
+	}
+
+	toHeader, err := idx.client.HeaderByNumber(ctx, new(big.Int).SetUint64(to))
+	if nil != err {
+		return 0, err
+	}
+
+	err = idx.db.Transaction(func(tx *gorm.DB) error {
+		if len(mints) > 0 {
+			var err = tx.Table(models.TableNameChainMint).
+				Clauses(clause.OnConflict{DoNothing: true}).
+				Create(&mints).Error
+			if nil != err {
+				return err
+			}
+		}
+		if len(discrepancies) > 0 {
+			var err = tx.Table(models.TableNameMintDiscrepancy).
+				Clauses(clause.OnConflict{DoNothing: true}).
+				Create(&discrepancies).Error
+			if nil != err {
+				return err
+			}
+		}
+		return idx.saveCursor(tx, int64(to), toHeader.Hash().Hex())
+	})
+	if nil != err {
+		return 0, dmodels.ParsePostgresError("Chain mint", err)
+	}
+	return int(to - from + 1), nil
+}
+
+// Compare indexed mints with signatures. Confirm signature was minted
+// on-chain, record discrepancy otherwise. Mint was rejected by domain
+// (permanent error) is recorded as discrepancy, it doesn't block next mints
+func (idx *ChainIndexer) Reconcile() (int, error) {
+	var mints = make([]*models.ChainMint, 0)
+	var err = idx.tblMint().
+		Where("domain_id = ? AND reconciled = ?", idx.domain.ID, false).
+		Order("block_number asc, log_index asc").
+		Limit(chainReconcileBatch).
+		Find(&mints).Error
+	if nil != err {
+		return 0, dmodels.ParsePostgresError("Chain mint", err)
+	}
+
+	for i, m := range mints {
+		err = idx.reconcile(m)
+		if isPermanent(err) {
+			err = idx.addDiscrepancy(m, models.DiscrepancyReconcile, err.Error())
+		}
+		if nil != err {
+			return i, err
+		}
+
+		err = idx.tblMint().Where("id = ?", m.ID).Update("reconciled", true).Error
+		if nil != err {
+			return i, dmodels.ParsePostgresError("Chain mint", err)
+		}
+	}
+	return len(mints), nil
+}
+
+func (idx *ChainIndexer) reconcile(m *models.ChainMint) error {
+	var sign = &models.MintSign{}
+	var err = idx.db.Table(models.TableNameMintSign).
+		Where("lower(iot) = ? AND nonce = ? AND domain_id = ?", m.Iot, m.Nonce, m.DomainId).
+		First(sign).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return idx.addDiscrepancy(m, models.DiscrepancyMissingSign, "No signature of nonce")
+	}
+	if nil != err {
+		return dmodels.ParsePostgresError("Mint sign", err)
+	}
+
+	chainAmount, err := hexutil.DecodeBig(m.Amount)
+	if nil != err {
+		return dmodels.ErrInternal(err)
+	}
+	signed, err := dmodels.NewBigNumberFromHex(sign.Amount)
+	if nil != err || signed.Cmp(chainAmount) != 0 {
+		return idx.addDiscrepancy(
+			m, models.DiscrepancyAmountMismatch,
+			fmt.Sprintf("Amount on-chain: %s, signed: %s", m.Amount, sign.Amount),
+		)
+	}
+
+	if sign.IsRedeemed() {
+		if !strings.EqualFold(sign.TxHash, m.TxHash) {
+			err = idx.addDiscrepancy(
+				m, models.DiscrepancyTxMismatch,
+				"Signature was confirmed by tx: "+sign.TxHash,
+			)
+			if nil != err {
+				return err
+			}
+		}
+	} else {
+		_, err = idx.iot.ReportMintTx(&domain.RMintTxReport{
+			Iot:         dmodels.EthAddress(sign.Iot),
+			Nonce:       sign.Nonce,
+			State:       models.MintStateConfirmed,
+			TxHash:      m.TxHash,
+			BlockNumber: m.BlockNumber,
+			RedeemedAt:  m.BlockTime.Unix(),
+			DomainId:    m.DomainId,
+		})
+		if nil != err {
+			return err
+		}
+	}
+
+	// Amount on-chain is cumulative per (iot, domain) while minted is total
+	// of iot: compare minted with sum of latest amount of every domain
+	var amounts = make([]string, 0)
+	err = idx.db.Raw(
+		`SELECT DISTINCT ON (domain_id) amount FROM `+models.TableNameChainMint+
+			` WHERE iot = ? ORDER BY domain_id, nonce DESC`,
+		m.Iot,
+	).Scan(&amounts).Error
+	if nil != err {
+		return dmodels.ParsePostgresError("Chain mint", err)
+	}
+	var onChain = big.NewInt(0)
+	for _, a := range amounts {
+		amount, err := hexutil.DecodeBig(a)
+		if nil != err {
+			return dmodels.ErrInternal(err)
+		}
+		onChain.Add(onChain, amount)
+	}
+
+	var total int64
+	err = idx.db.Table(models.TableNameMinted).
+		Where("iot_id = ?", sign.IotId).
+		Select("COALESCE(SUM(carbon), 0)").
+		Scan(&total).Error
+	if nil != err {
+		return dmodels.ParsePostgresError("Minted", err)
+	}
+	if onChain.Cmp(big.NewInt(total)) > 0 {
+		return idx.addDiscrepancy(
+			m, models.DiscrepancyMintedMismatch,
+			fmt.Sprintf("Total on-chain: %s, total minted: %d", onChain, total),
+		)
+	}
+	return nil
+}
+
+// Rewind cursor (& remove indexed mints after it, reset signatures confirmed
+// by them) when block of cursor was replaced by reorg
+func (idx *ChainIndexer) checkReorg(ctx context.Context, cursor *models.ChainCursor) error {
+	if cursor.BlockHash == "" {
+		return nil
+	}
+
+	header, err := idx.client.HeaderByNumber(ctx, big.NewInt(cursor.BlockNumber))
+	if nil != err {
+		return err
+	}
+	if header.Hash().Hex() == cursor.BlockHash {
+		return nil
+	}
+
+	var rewind = cursor.BlockNumber - int64(idx.confirmations)
+	if rewind < int64(idx.startBlock)-1 {
+		rewind = int64(idx.startBlock) - 1
+	}
+	log.Printf("Chain reorg at block %d (hash %s), rewind to %d\n",
+		cursor.BlockNumber, cursor.BlockHash, rewind,
+	)
+
+	err = idx.db.Transaction(func(tx *gorm.DB) error {
+		var err = tx.Table(models.TableNameMintDiscrepancy).
+			Where(
+				"resolved_at IS NULL AND chain_mint_id IN (?)",
+				tx.Table(models.TableNameChainMint).
+					Select("id").
+					Where("domain_id = ? AND block_number > ?", idx.domain.ID, rewind),
+			).
+			Delete(&models.MintDiscrepancy{}).Error
+		if nil != err {
+			return err
+		}
+
+		err = tx.Table(models.TableNameChainMint).
+			Where("domain_id = ? AND block_number > ?", idx.domain.ID, rewind).
+			Delete(&models.ChainMint{}).Error
+		if nil != err {
+			return err
+		}
+
+		// Confirmation of orphaned block is dropped: tx is submitted again
+		// until it is re-indexed
+		err = tx.Table(models.TableNameMintSign).
+			Where("domain_id = ? AND state = ? AND block_number > ?",
+				idx.domain.ID, models.MintStateConfirmed, rewind,
+			).
+			Updates(map[string]interface{}{
+				"state":            models.MintStateSubmitted,
+				"block_number":     0,
+				"redeemed_at":      nil,
+				"state_updated_at": time.Now(),
+			}).Error
+		if nil != err {
+			return err
+		}
+		return idx.saveCursor(tx, rewind, "")
+	})
+	if nil != err {
+		return dmodels.ParsePostgresError("Chain reorg", err)
+	}
+
+	cursor.BlockNumber = rewind
+	cursor.BlockHash = ""
+	return nil
+}
+
+func (idx *ChainIndexer) getCursor() (*models.ChainCursor, error) {
+	var cursors = make([]*models.ChainCursor, 0, 1)
+	var err = idx.db.Table(models.TableNameChainCursor).
+		Where("name = ?", idx.cursorName()).
+		Find(&cursors).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Chain cursor", err)
+	}
+	if len(cursors) == 0 {
+		return &models.ChainCursor{
+			Name:        idx.cursorName(),
+			BlockNumber: int64(idx.startBlock) - 1,
+		}, nil
+	}
+	return cursors[0], nil
+}
+
+func (idx *ChainIndexer) saveCursor(tx *gorm.DB, number int64, hash string) error {
+	return tx.Table(models.TableNameChainCursor).
+		Clauses(clause.OnConflict{UpdateAll: true}).
+		Create(&models.ChainCursor{
+			Name:        idx.cursorName(),
+			BlockNumber: number,
+			BlockHash:   hash,
+			UpdatedAt:   time.Now(),
+		}).Error
+}
+
+func (idx *ChainIndexer) addDiscrepancy(m *models.ChainMint, kind models.DiscrepancyKind, detail string,
+) error {
+	log.Printf("Mint discrepancy %s of %s nonce %d: %s\n", kind, m.Iot, m.Nonce, detail)
+	var err = idx.db.Table(models.TableNameMintDiscrepancy).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.MintDiscrepancy{
+			Kind:        kind,
+			ChainMintId: m.ID,
+			Iot:         m.Iot,
+			Nonce:       m.Nonce,
+			Detail:      detail,
+			CreatedAt:   time.Now(),
+		}).Error
+	if nil != err {
+		return dmodels.ParsePostgresError("Mint discrepancy", err)
+	}
+	return nil
+}
+
+func (idx *ChainIndexer) cursorName() string {
+	return fmt.Sprintf("domain-%d", idx.domain.ID)
+}
+
+func (idx *ChainIndexer) tblMint() *gorm.DB {
+	return idx.db.Table(models.TableNameChainMint)
+}
+
+// Error of domain (except internal) won't succeed on retry
+func isPermanent(err error) bool {
+	var derr *dmodels.Error
+	if errors.As(err, &derr) {
+		return derr.Code != ecodes.Internal
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package repo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/go-shared/libs/utils"
+	"github.com/Dcarbon/iott-cloud/internal/chain/chaintest"
+	"github.com/Dcarbon/iott-cloud/internal/domain"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestChainIndexer(t *testing.T) {
+	sim, err := chaintest.NewSim()
+	utils.PanicError("Create simulated chain", err)
+	defer sim.Close()
+
+	var iot = common.HexToAddress("0xe445517abb524002bb04c96f96abb87b8b19b53d")
+	utils.PanicError("Mint", sim.Mint(iot, 1, 9e9))
+	utils.PanicError("Mint", sim.Mint(iot, 2, 12e9))
+
+	sd, err := signDomainRepoTest.Create(&domain.RSignDomainCreate{
+		Label:             "simulated-" + sim.Contract.Hex(),
+		Version:           "1",
+		ChainId:           1337,
+		VerifyingContract: dmodels.EthAddress(sim.Contract.Hex()),
+	})
+	utils.PanicError("Create sign domain", err)
+
+	indexer, err := NewChainIndexer(sim.Client, sd, iotRepoTest)
+	utils.PanicError("Create chain indexer", err)
+	indexer.confirmations = 2
+
+	// Confirm mint events
+	for i := 0; i < 3; i++ {
+		sim.Commit()
+	}
+
+	n, err := indexer.Process(context.Background())
+	utils.PanicError("Index chain", err)
+	utils.Dump("Indexed blocks", n)
+
+	n, err = indexer.Reconcile()
+	utils.PanicError("Reconcile", err)
+	utils.Dump("Reconciled mints", n)
+
+	chainRepo, err := NewChainRepo()
+	utils.PanicError("Create chain repo", err)
+
+	mints, err := chainRepo.GetMints(&domain.RChainMintGetList{DomainId: sd.ID, Iot: iot.Hex()})
+	utils.PanicError("Get chain mints", err)
+	utils.Dump("Chain mints", mints)
+
+	data, err := chainRepo.GetDiscrepancies(&domain.RDiscrepancyGetList{Iot: iot.Hex()})
+	utils.PanicError("Get discrepancies", err)
+	utils.Dump("Discrepancies", data)
+}
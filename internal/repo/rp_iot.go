@@ -1,6 +1,7 @@
 package repo
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"math/big"
@@ -8,27 +9,32 @@ import (
 	"time"
 
 	"github.com/Dcarbon/go-shared/dmodels"
-	"github.com/Dcarbon/go-shared/libs/esign"
+	"github.com/Dcarbon/go-shared/ecodes"
 	"github.com/Dcarbon/iott-cloud/internal/domain"
+	"github.com/Dcarbon/iott-cloud/internal/events"
 	"github.com/Dcarbon/iott-cloud/internal/models"
 	"github.com/Dcarbon/iott-cloud/internal/rss"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	uuid "github.com/satori/go.uuid"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
 type iotRepo struct {
-	db      *gorm.DB
-	dMinter *esign.ERC712
+	db       *gorm.DB
+	domains  domain.ISignDomain
+	alerter  domain.IAlertEvaluator
+	operator domain.IOperator
 }
 
-func NewIOTRepo(dMinter *esign.ERC712,
+func NewIOTRepo(domains domain.ISignDomain,
 ) (domain.IIot, error) {
 	var db = rss.GetDB()
 	err := db.AutoMigrate(
 		&models.IOTDevice{},
 		&models.MintSign{},
 		&models.Minted{},
+		&models.OutboxEvent{},
 		// &models.Metric{},
 	)
 	if nil != err {
@@ -37,11 +43,24 @@ func NewIOTRepo(dMinter *esign.ERC712,
 
 	var ip = &iotRepo{
 		db:      db,
-		dMinter: dMinter,
+		domains: domains,
+	}
+
+	err = ip.bindLegacyDomain()
+	if nil != err {
+		return nil, err
 	}
 	return ip, nil
 }
 
+func (ip *iotRepo) SetAlerter(alerter domain.IAlertEvaluator) {
+	ip.alerter = alerter
+}
+
+func (ip *iotRepo) SetOperator(operator domain.IOperator) {
+	ip.operator = operator
+}
+
 func (ip *iotRepo) Create(req *domain.RIotCreate,
 ) (*models.IOTDevice, error) {
 	var iot = &models.IOTDevice{
@@ -51,11 +70,34 @@ func (ip *iotRepo) Create(req *domain.RIotCreate,
 		Type:     req.Type,
 		Status:   dmodels.DeviceStatusRegister,
 		Position: *req.Position,
+		DomainId: req.DomainId,
 	}
 
-	var err = ip.tblIOT().Create(iot).Error
+	if iot.DomainId == 0 {
+		var err = ip.db.Table(models.TableNameProject).
+			Where("id = ?", iot.Project).
+			Select("domain_id").
+			Scan(&iot.DomainId).Error
+		if nil != err {
+			return nil, dmodels.ParsePostgresError("Project", err)
+		}
+	}
+
+	sd, err := ip.domains.GetById(iot.DomainId)
 	if nil != err {
-		return nil, dmodels.ParsePostgresError("IOT", err)
+		return nil, err
+	}
+	iot.DomainId = sd.ID
+
+	err = ip.db.Transaction(func(dbTx *gorm.DB) error {
+		var err = dbTx.Table(models.TableNameIOT).Create(iot).Error
+		if nil != err {
+			return dmodels.ParsePostgresError("IOT", err)
+		}
+		return writeOutbox(dbTx, models.EventIotCreated, iot.Project, events.NewIotV1(iot))
+	})
+	if nil != err {
+		return nil, err
 	}
 	return iot, nil
 }
@@ -63,17 +105,25 @@ func (ip *iotRepo) Create(req *domain.RIotCreate,
 func (ip *iotRepo) ChangeStatus(req *domain.RIotChangeStatus,
 ) (*models.IOTDevice, error) {
 	var iot = &models.IOTDevice{}
-	var err = ip.tblIOT().
-		Model(iot).
-		Clauses(clause.Returning{}).
-		Where("id = ?", req.IotId).
-		Update("status", req.Status).
-		Error
+	var err = ip.db.Transaction(func(dbTx *gorm.DB) error {
+		var rs = dbTx.Table(models.TableNameIOT).
+			Model(iot).
+			Clauses(clause.Returning{}).
+			Where("id = ?", req.IotId).
+			Update("status", req.Status)
+		if nil != rs.Error {
+			return dmodels.ParsePostgresError("IOT", rs.Error)
+		}
+		if rs.RowsAffected == 0 {
+			return dmodels.ErrNotFound("IOT")
+		}
+		return writeOutbox(dbTx, models.EventIotStatusChanged, iot.Project, events.NewIotV1(iot))
+	})
 	if nil != err {
-		return nil, dmodels.ParsePostgresError("IOT", err)
+		return nil, err
 	}
 
-	return iot, err
+	return iot, nil
 }
 
 func (ip *iotRepo) Update(req *domain.RIotUpdate,
@@ -105,19 +155,34 @@ func (ip *iotRepo) GetIot(id int64) (*models.IOTDevice, error) {
 }
 
 func (ip *iotRepo) GetIots(req *domain.RIotGetList,
-) ([]*models.IOTDevice, error) {
-	var data = make([]*models.IOTDevice, 0)
-	var err = ip.queryGetIots(req).Find(&data).Error
+) ([]*models.IOTDevice, *domain.PageInfo, error) {
+	field, desc, err := req.Sort.Parse()
 	if nil != err {
-		return nil, dmodels.ParsePostgresError("IOT", err)
+		return nil, nil, err
+	}
+
+	query, err := ip.queryGetIots(&req.IotFilter)
+	if nil != err {
+		return nil, nil, err
 	}
-	return data, nil
+
+	var sort = iotSorts[field]
+	var ks = &keyset{column: sort.column, kind: sort.kind, id: "id", idKind: keyInt, desc: desc}
+	return paginate(
+		query, "IOT", "", ks, req.Cursor, req.Skip, req.Limit,
+		func(iot *models.IOTDevice) (interface{}, interface{}) { return sort.value(iot), iot.ID },
+	)
 }
 
 func (ip *iotRepo) GetIotPositions(req *domain.RIotGetList,
 ) ([]*domain.PositionId, error) {
+	query, err := ip.queryGetIots(&req.IotFilter)
+	if nil != err {
+		return nil, err
+	}
+
 	var locs = make([]*domain.PositionId, 0)
-	var err = ip.queryGetIots(req).Select("id, position").Find(&locs).Error
+	err = query.Select("id, position").Find(&locs).Error
 	if nil != err {
 		return nil, dmodels.ParsePostgresError("IOT", err)
 	}
@@ -170,6 +235,10 @@ func (ip *iotRepo) CreateMint(req *domain.RIotMint,
 	// 	return dmodels.NewError(ecodes.IOTNotAllowed, "IOT is not allow")
 	// }
 
+	if iot.Oracle {
+		return dmodels.NewError(ecodes.IOTNotAllowed, "Mint of iot is signed by oracle")
+	}
+
 	var mint = &models.MintSign{
 		ID:        0,
 		Nonce:     req.Nonce,
@@ -182,14 +251,24 @@ func (ip *iotRepo) CreateMint(req *domain.RIotMint,
 		CreatedAt: time.Now(),
 	}
 
-	e1 = mint.Verify(ip.dMinter)
+	sd, minter, e1 := ip.domains.GetMinter(iot.DomainId)
 	if nil != e1 {
 		return e1
 	}
+	mint.DomainId = sd.ID
 
+	e1 = mint.Verify(minter)
+	if nil != e1 {
+		if ip.alerter != nil {
+			ip.alerter.OnMintSignFailed(iot.ID, e1)
+		}
+		return e1
+	}
+
+	// Nonce is counted by contract (domain)
 	var latest = make([]*models.MintSign, 0, 1)
 	e1 = ip.tblSign().
-		Where("iot = ?", mint.Iot).
+		Where("iot = ? AND domain_id = ?", mint.Iot, mint.DomainId).
 		Order("created_at desc").
 		Limit(1).
 		Find(&latest).Error
@@ -201,6 +280,11 @@ func (ip *iotRepo) CreateMint(req *domain.RIotMint,
 		latest = append(latest, &models.MintSign{})
 	}
 
+	if latest[0].Nonce == mint.Nonce && !latest[0].State.IsUpdatable() {
+		// Signature of this nonce was sent on-chain, device must sign next nonce
+		return dmodels.ErrInvalidNonce()
+	}
+
 	if latest[0].Nonce == mint.Nonce || latest[0].Nonce+1 == mint.Nonce {
 		oldAmount, e1 := dmodels.NewBigNumberFromHex(latest[0].Amount)
 		if nil != e1 {
@@ -219,7 +303,23 @@ func (ip *iotRepo) CreateMint(req *domain.RIotMint,
 			Carbon: incAmount.Int64(),
 		}
 
-		return ip.db.Transaction(func(dbTx *gorm.DB) error {
+		e1 = ip.db.Transaction(func(dbTx *gorm.DB) error {
+			// Lock iot: it must not be moved (MoveDomain) after signature
+			// was verified by its domain
+			if iot.DomainId > 0 {
+				var ids = make([]int64, 0, 1)
+				err := dbTx.Table(models.TableNameIOT).
+					Clauses(clause.Locking{Strength: "UPDATE"}).
+					Where("id = ? AND domain_id = ?", iot.ID, iot.DomainId).
+					Pluck("id", &ids).Error
+				if nil != err {
+					return dmodels.ParsePostgresError("IOT", err)
+				}
+				if len(ids) == 0 {
+					return dmodels.ErrBadRequest("IoT was moved to other sign domain, sign again")
+				}
+			}
+
 			if latest[0].Nonce+1 == mint.Nonce {
 				err := dbTx.Table(models.TableNameMintSign).Create(mint).Error
 				if nil != err {
@@ -234,10 +334,13 @@ func (ip *iotRepo) CreateMint(req *domain.RIotMint,
 						"r":          mint.R,
 						"s":          mint.S,
 						"v":          mint.V,
+						"state":      models.MintStatePending,
+						"tx_hash":    "",
+						"tx_error":   "",
 						"updated_at": time.Now(),
 					}).Error
 				if nil != err {
-					dmodels.ParsePostgresError("", err)
+					return dmodels.ParsePostgresError("", err)
 				}
 			}
 
@@ -245,71 +348,364 @@ func (ip *iotRepo) CreateMint(req *domain.RIotMint,
 			if nil != err {
 				return dmodels.ParsePostgresError("", err)
 			}
-			return nil
+			return writeOutbox(
+				dbTx, models.EventMintSigned, iot.Project, events.NewMintV1(mint, minted),
+			)
 		})
+		if nil != e1 {
+			return e1
+		}
+		invalidateProjectStats(iot.Project)
+		return nil
 
 	}
 	return dmodels.ErrInvalidNonce()
 }
 
 func (ip *iotRepo) GetMintSigns(req *domain.RIotGetMintSignList,
-) ([]*models.MintSign, error) {
+) ([]*models.MintSign, *domain.PageInfo, error) {
 	var iot, err = ip.GetIot(req.IotId)
 	if nil != err {
-		return nil, err
+		return nil, nil, err
 	}
 
-	var signeds = make([]*models.MintSign, 0)
 	var query = ip.tblSign().
 		Where(
 			"updated_at > ? AND updated_at < ? AND  iot = ?",
 			time.Unix(req.From, 0), time.Unix(req.To, 0), iot.Address,
 		)
 
-	if req.Sort > 0 {
-		query = query.Order("updated_at desc")
+	if nil != req.State {
+		query = query.Where("state = ?", *req.State)
+	}
+
+	var ks = &keyset{column: "updated_at", kind: keyTime, id: "id", idKind: keyInt, desc: req.Sort > 0}
+	return paginate(
+		query, "Get mint sign", "", ks, req.Cursor, 0, req.Limit,
+		func(m *models.MintSign) (interface{}, interface{}) { return m.UpdatedAt, m.ID },
+	)
+}
+
+// Update redemption state of signature. Re-reported state (same state & tx)
+// is ignored. Nonce is counted per domain: signature is looked up in
+// req.DomainId (current domain of iot by default)
+func (ip *iotRepo) ReportMintTx(req *domain.RMintTxReport,
+) (*models.MintSign, error) {
+	if !req.State.IsValid() || req.State == models.MintStatePending {
+		return nil, dmodels.ErrBadRequest("Invalid mint state")
+	}
+
+	var domainId = req.DomainId
+	if domainId == 0 {
+		iot, err := ip.GetIotByAddress(req.Iot)
+		if nil != err {
+			return nil, err
+		}
+		if iot.ID == 0 {
+			return nil, dmodels.ErrNotFound("IOT")
+		}
+		domainId = iot.DomainId
+	}
+
+	var sign = &models.MintSign{}
+	var err = ip.tblSign().
+		Where("iot = ? AND nonce = ? AND domain_id = ?", req.Iot, req.Nonce, domainId).
+		First(sign).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Mint sign", err)
+	}
+
+	if sign.State == req.State && sign.TxHash == req.TxHash {
+		return sign, nil
+	}
+	if !sign.State.CanMoveTo(req.State) {
+		return nil, dmodels.ErrBadRequest(
+			fmt.Sprintf("Mint sign can't move from state %d to %d (tx: %s)", sign.State, req.State, sign.TxHash),
+		)
+	}
+
+	var updates = map[string]interface{}{
+		"state":            req.State,
+		"tx_hash":          req.TxHash,
+		"tx_error":         req.Error,
+		"state_updated_at": time.Now(),
+	}
+	if req.State == models.MintStateConfirmed {
+		var redeemedAt = time.Now()
+		if req.RedeemedAt > 0 {
+			redeemedAt = time.Unix(req.RedeemedAt, 0)
+		}
+		updates["block_number"] = req.BlockNumber
+		updates["redeemed_at"] = redeemedAt
+	}
+
+	// Guard by current state (other report may come at the same time)
+	var tx = ip.tblSign().
+		Where("id = ? AND state = ?", sign.ID, sign.State).
+		Updates(updates)
+	if nil != tx.Error {
+		return nil, dmodels.ParsePostgresError("Mint sign", tx.Error)
+	}
+	if tx.RowsAffected == 0 {
+		return nil, dmodels.ErrInternal(errors.New("mint sign was changed by other report"))
+	}
+
+	err = ip.tblSign().Where("id = ?", sign.ID).First(sign).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Mint sign", err)
+	}
+	return sign, nil
+}
+
+func (ip *iotRepo) GetMintUnclaimed(iotId int64,
+) (*domain.RsMintUnclaimed, error) {
+	var iot, err = ip.GetIot(iotId)
+	if nil != err {
+		return nil, err
+	}
+
+	var rs = &domain.RsMintUnclaimed{
+		IotId:     iotId,
+		Signed:    "0x0",
+		Claimed:   "0x0",
+		Unclaimed: "0x0",
+	}
+
+	// Amount is accumulated per domain: only current domain of iot is claimable
+	var signed = make([]*models.MintSign, 0, 1)
+	err = ip.tblSign().
+		Where("iot = ? AND domain_id = ?", iot.Address, iot.DomainId).
+		Order("nonce desc").
+		Limit(1).
+		Find(&signed).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Mint sign", err)
+	}
+	if len(signed) == 0 {
+		return rs, nil
+	}
+
+	var claimed = make([]*models.MintSign, 0, 1)
+	err = ip.tblSign().
+		Where("iot = ? AND domain_id = ? AND state = ?", iot.Address, iot.DomainId, models.MintStateConfirmed).
+		Order("nonce desc").
+		Limit(1).
+		Find(&claimed).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Mint sign", err)
+	}
+
+	signedAmount, err := dmodels.NewBigNumberFromHex(signed[0].Amount)
+	if nil != err {
+		return nil, dmodels.ErrInternal(err)
+	}
+	rs.Signed = signed[0].Amount
+	rs.SignedNonce = signed[0].Nonce
+
+	var unclaimed = big.NewInt(0).Set(signedAmount.Int)
+	if len(claimed) > 0 {
+		claimedAmount, err := dmodels.NewBigNumberFromHex(claimed[0].Amount)
+		if nil != err {
+			return nil, dmodels.ErrInternal(err)
+		}
+		rs.Claimed = claimed[0].Amount
+		rs.ClaimedNonce = claimed[0].Nonce
+		unclaimed.Sub(unclaimed, claimedAmount.Int)
+	}
+	rs.Unclaimed = hexutil.EncodeBig(unclaimed)
+	return rs, nil
+}
+
+// IoT has signature was sent on-chain (submitted) can't be moved. Pending
+// signature is dropped by new domain, so it's only moved when req.Force
+func (ip *iotRepo) MoveDomain(req *domain.RIotMoveDomain,
+) (*domain.RsIotMoveDomain, error) {
+	sd, err := ip.domains.GetById(req.DomainId)
+	if nil != err {
+		return nil, err
+	}
+	if len(req.IotIds) == 0 && req.ProjectId == 0 {
+		return nil, dmodels.ErrBadRequest("Iot ids or project id is required")
+	}
+
+	var iots = make([]*models.IOTDevice, 0)
+	var query = ip.tblIOT().Where("domain_id <> ?", sd.ID)
+	if req.ProjectId > 0 {
+		query = query.Where("project = ?", req.ProjectId)
 	} else {
-		query = query.Order("updated_at asc")
+		query = query.Where("id IN ?", req.IotIds)
+	}
+	err = query.Find(&iots).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("IOT", err)
+	}
+
+	var rs = &domain.RsIotMoveDomain{
+		Moved:   make([]int64, 0, len(iots)),
+		Skipped: make(map[int64]string),
+	}
+	for _, iot := range iots {
+		reason, err := ip.moveDomain(iot, sd.ID, req.Force)
+		if nil != err {
+			return nil, err
+		}
+		if reason != "" {
+			rs.Skipped[iot.ID] = reason
+			continue
+		}
+		log.Printf("Move iot %d from sign domain %d to %d\n", iot.ID, iot.DomainId, sd.ID)
+		rs.Moved = append(rs.Moved, iot.ID)
+	}
+
+	if req.ProjectId > 0 {
+		err = ip.db.Table(models.TableNameProject).
+			Where("id = ?", req.ProjectId).
+			Update("domain_id", sd.ID).Error
+		if nil != err {
+			return nil, dmodels.ParsePostgresError("Project", err)
+		}
+	}
+	return rs, nil
+}
+
+// Check & move in a tx holding iot row (CreateMint holds it too), so no
+// signature is created by old domain after check. Return reason of skip
+func (ip *iotRepo) moveDomain(iot *models.IOTDevice, domainId int64, force bool,
+) (string, error) {
+	var reason = ""
+	var err = ip.db.Transaction(func(tx *gorm.DB) error {
+		var ids = make([]int64, 0, 1)
+		var err = tx.Table(models.TableNameIOT).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND domain_id = ?", iot.ID, iot.DomainId).
+			Pluck("id", &ids).Error
+		if nil != err {
+			return err
+		}
+		if len(ids) == 0 {
+			reason = "Sign domain was changed by other request"
+			return nil
+		}
+
+		var states = make([]models.MintState, 0)
+		err = tx.Table(models.TableNameMintSign).
+			Where("iot = ? AND domain_id = ?", strings.ToLower(string(iot.Address)), iot.DomainId).
+			Where("state IN ?", []models.MintState{models.MintStatePending, models.MintStateSubmitted}).
+			Distinct("state").
+			Pluck("state", &states).Error
+		if nil != err {
+			return err
+		}
+
+		for _, state := range states {
+			if state == models.MintStateSubmitted {
+				reason = "Has signature was submitted on-chain"
+				break
+			}
+			if state == models.MintStatePending && !force {
+				reason = "Has pending signature (use force to move)"
+			}
+		}
+		if reason != "" {
+			return nil
+		}
+
+		return tx.Table(models.TableNameIOT).
+			Where("id = ?", iot.ID).
+			Update("domain_id", domainId).Error
+	})
+	if nil != err {
+		return "", dmodels.ParsePostgresError("IOT", err)
+	}
+	return reason, nil
+}
+
+// Bind IoT & signature were created before sign domain to default domain
+func (ip *iotRepo) bindLegacyDomain() error {
+	sd, err := ip.domains.GetById(0)
+	if nil != err {
+		return err
 	}
 
-	if req.Limit > 0 {
-		query = query.Limit(req.Limit)
+	err = ip.tblIOT().Where("domain_id = 0").Update("domain_id", sd.ID).Error
+	if nil != err {
+		return dmodels.ParsePostgresError("IOT", err)
 	}
-	err = query.Find(&signeds).Error
+
+	err = ip.tblSign().Where("domain_id = 0").Update("domain_id", sd.ID).Error
 	if nil != err {
-		return nil, dmodels.ParsePostgresError("Get mint sign", err)
+		return dmodels.ParsePostgresError("Mint sign", err)
 	}
-	return signeds, nil
+	return nil
 }
 
 func (ip *iotRepo) GetMinted(req *domain.RIotGetMintedList,
-) ([]*models.Minted, error) {
-	var tz = "Asia/Ho_Chi_Minh"
+) ([]*models.Minted, *domain.PageInfo, error) {
+	if req.Bucket == "" && req.Interval > 0 {
+		rs, err := ip.getMintedAggregate(req)
+		return rs, nil, err
+	}
+	if req.Bucket != "" {
+		series, err := ip.GetMintedSeries(req)
+		if nil != err {
+			return nil, nil, err
+		}
+
+		var rs = make([]*models.Minted, 0, len(series.Points))
+		for _, p := range series.Points {
+			var minted = &models.Minted{CreatedAt: p.Time}
+			if p.Val != nil {
+				minted.Carbon = int64(*p.Val)
+			}
+			rs = append(rs, minted)
+		}
+		return rs, nil, nil
+	}
+
 	var query = ip.db.Table(models.TableNameMinted).
 		Where(
 			"created_at > ? AND created_at < ? AND iot_id = ? ",
 			time.Unix(req.From, 0), time.Unix(req.To, 0), req.IotId,
 		)
-	if req.Interval > 0 {
-		var group = "day"
-		if req.Interval == 2 {
-			group = "month"
+	var ks = &keyset{column: "created_at", kind: keyTime, id: "id", idKind: keyString}
+	data, page, err := paginate(
+		query, "Minted", "id, created_at as ca, carbon", ks, req.Cursor, 0, req.Limit,
+		func(m *aggMinted) (interface{}, interface{}) { return m.Ca, m.ID },
+	)
+	if nil != err {
+		return nil, nil, err
+	}
+
+	var rs = make([]*models.Minted, len(data))
+	for i, it := range data {
+		rs[i] = &models.Minted{
+			ID:        it.ID,
+			CreatedAt: it.Ca,
+			Carbon:    it.Carbon,
 		}
+	}
 
-		query = query.Raw(
-			fmt.Sprintf(`SELECT date_trunc('%s', created_at, ?) as ca, sum(carbon) as carbon
-							FROM minted
-							WHERE created_at > ? AND created_at < ? and iot_id = ?
-							GROUP BY ca
-							`, group),
-			tz, time.Unix(req.From, 0).Format(time.RFC3339), time.Unix(req.To, 0).Format(time.RFC3339), req.IotId,
-		)
-	} else {
-		query = query.Select("created_at as ca, carbon").Order("ca asc")
+	return rs, page, nil
+}
+
+// Deprecated interval (1: day, 2: month) without bucket
+func (ip *iotRepo) getMintedAggregate(req *domain.RIotGetMintedList,
+) ([]*models.Minted, error) {
+	var group = "day"
+	if req.Interval == 2 {
+		group = "month"
 	}
 
 	var data = make([]*aggMinted, 0)
-	var err = query.Find(&data).Error
+	var err = ip.db.Raw(
+		fmt.Sprintf(`SELECT date_trunc('%s', created_at, ?) as ca, sum(carbon) as carbon
+						FROM minted
+						WHERE created_at > ? AND created_at < ? and iot_id = ?
+						GROUP BY ca
+						`, group),
+		domain.MintedDefaultTimezone,
+		time.Unix(req.From, 0).Format(time.RFC3339), time.Unix(req.To, 0).Format(time.RFC3339), req.IotId,
+	).Find(&data).Error
 	if nil != err {
 		return nil, dmodels.ParsePostgresError("", err)
 	}
@@ -321,16 +717,40 @@ func (ip *iotRepo) GetMinted(req *domain.RIotGetMintedList,
 			Carbon:    it.Carbon,
 		}
 	}
-
 	return rs, nil
 }
 
+func (ip *iotRepo) GetMintedSeries(req *domain.RIotGetMintedList,
+) (*domain.AggSeries, error) {
+	req.SetInterval(req.Interval)
+	loc, err := req.Normalize(domain.MintedDefaultTimezone)
+	if nil != err {
+		return nil, err
+	}
+
+	series, err := aggSourceMinted.aggregate(
+		ip.db, &req.AggOption, loc,
+		time.Unix(req.From, 0), time.Unix(req.To, 0), []int64{req.IotId},
+	)
+	if nil != err {
+		return nil, err
+	}
+	return series[0], nil
+}
+
+// Count iot matched filter (default status: success)
 func (ip *iotRepo) CountIot(req *domain.RIotCount) (int64, error) {
+	if req.Status == "" && len(req.StatusIn) == 0 {
+		req.StatusIn = []dmodels.DeviceStatus{dmodels.DeviceStatusSuccess}
+	}
+
+	query, err := ip.queryGetIots(&req.IotFilter)
+	if nil != err {
+		return 0, err
+	}
+
 	var count = int64(0)
-	var query = ip.tblIOT()
-	var err = query.
-		Where("status = ?", dmodels.DeviceStatusSuccess).
-		Count(&count).Error
+	err = query.Count(&count).Error
 	if nil != err {
 		return 0, dmodels.ParsePostgresError("Count iot", err)
 	}
@@ -351,17 +771,118 @@ func (ip *iotRepo) IsIotActived(req *domain.RIsIotActiced,
 	return count > 0, nil
 }
 
-func (ip *iotRepo) queryGetIots(req *domain.RIotGetList) *gorm.DB {
+type iotSort struct {
+	column string // Empty: sort by id only
+	kind   keyKind
+	value  func(iot *models.IOTDevice) interface{}
+}
+
+var iotSorts = map[string]*iotSort{
+	domain.IotSortId: {
+		value: func(iot *models.IOTDevice) interface{} { return nil },
+	},
+	domain.IotSortCreatedAt: {
+		column: "created_at", kind: keyTime,
+		value: func(iot *models.IOTDevice) interface{} { return iot.CreatedAt },
+	},
+	domain.IotSortAddress: {
+		column: "address", kind: keyString,
+		value: func(iot *models.IOTDevice) interface{} { return string(iot.Address) },
+	},
+	domain.IotSortType: {
+		column: "type", kind: keyInt,
+		value: func(iot *models.IOTDevice) interface{} { return int64(iot.Type) },
+	},
+	domain.IotSortStatus: {
+		column: "status", kind: keyInt,
+		value: func(iot *models.IOTDevice) interface{} { return int64(iot.Status) },
+	},
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func (ip *iotRepo) queryGetIots(f *domain.IotFilter) (*gorm.DB, error) {
+	var err = f.Normalize()
+	if nil != err {
+		return nil, err
+	}
+
 	var query = ip.tblIOT()
-	if req.ProjectId != 0 {
-		query = query.Where("project = ?", req.ProjectId)
+	if len(f.IdIn) > 0 {
+		query = query.Where("id IN ?", f.IdIn)
+	}
+
+	if len(f.ProjectIn) > 0 {
+		query = query.Where("project IN ?", f.ProjectIn)
 	}
 
-	if req.Status != 0 {
-		query = query.Where("status = ?", req.Status)
+	if len(f.TypeIn) > 0 {
+		query = query.Where("type IN ?", f.TypeIn)
 	}
 
-	return query
+	if len(f.StatusIn) > 0 {
+		query = query.Where("status IN ?", f.StatusIn)
+	}
+
+	if f.Owner != "" {
+		query = query.Where(
+			"project IN (SELECT id FROM "+models.TableNameProject+" WHERE owner = ?)", f.Owner,
+		)
+	}
+
+	if f.CreatedFrom > 0 {
+		query = query.Where("created_at >= ?", time.Unix(f.CreatedFrom, 0))
+	}
+
+	if f.CreatedTo > 0 {
+		query = query.Where("created_at < ?", time.Unix(f.CreatedTo, 0))
+	}
+
+	if f.HasSensors != nil {
+		var exists = "EXISTS (SELECT 1 FROM " + models.TableNameSensors + " WHERE iot_id = iots.id)"
+		if !*f.HasSensors {
+			exists = "NOT " + exists
+		}
+		query = query.Where(exists)
+	}
+
+	if f.Address != "" {
+		query = query.Where("address ILIKE ?", "%"+likeEscaper.Replace(f.Address)+"%")
+	}
+
+	if len(f.OpStatusIn) > 0 {
+		ids, err := ip.getIdsByOpStatus(f.OpStatusIn)
+		if nil != err {
+			return nil, err
+		}
+		query = query.Where("id IN ?", ids)
+	}
+
+	return query, nil
+}
+
+// Id of iots has operator status in set (iot has not reported is ignored)
+func (ip *iotRepo) getIdsByOpStatus(statuses []models.OpStatus) ([]int64, error) {
+	if ip.operator == nil {
+		return nil, dmodels.ErrInternal(errors.New("operator status is not available"))
+	}
+
+	all, err := ip.operator.GetAllStatus()
+	if nil != err {
+		return nil, err
+	}
+
+	// Empty IN () is invalid sql: 0 is not id of any iot
+	var ids = []int64{0}
+	for _, stt := range all {
+		for _, s := range statuses {
+			if stt.Status == s {
+				ids = append(ids, stt.Id)
+				break
+			}
+		}
+	}
+	return ids, nil
 }
 
 func (ip *iotRepo) tblIOT() *gorm.DB {
@@ -385,6 +906,7 @@ func (ip *iotRepo) tblSign() *gorm.DB {
 // }
 
 type aggMinted struct {
+	ID     string
 	Ca     time.Time
 	Carbon int64
 }
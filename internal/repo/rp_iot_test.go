@@ -13,16 +13,19 @@ import (
 )
 
 var iotRepoTest domain.IIot
+var signDomainRepoTest *SignDomainRepo
 var iotPrv = utils.StringEnv("IOT_PRIVATE", "")
 var iotAddr = utils.StringEnv("IOT_ADDRESS", "")
 
+var testTypedDomain = &esign.TypedDataDomain{
+	Name:              "CARBON",
+	Version:           "1",
+	ChainId:           1337,
+	VerifyingContract: "0x7BDDCb9699a3823b8B27158BEBaBDE6431152a85",
+}
+
 var testDomainMinter = esign.MustNewERC712(
-	&esign.TypedDataDomain{
-		Name:              "CARBON",
-		Version:           "1",
-		ChainId:           1337,
-		VerifyingContract: "0x7BDDCb9699a3823b8B27158BEBaBDE6431152a85",
-	},
+	testTypedDomain,
 	esign.MustNewTypedDataField(
 		"Mint",
 		esign.TypedDataStruct,
@@ -34,7 +37,12 @@ var testDomainMinter = esign.MustNewERC712(
 
 func init() {
 	var err error
-	iotRepoTest, err = NewIOTRepo(testDomainMinter)
+	signDomainRepoTest, err = NewSignDomainRepo(testTypedDomain)
+	if nil != err {
+		panic(err.Error())
+	}
+
+	iotRepoTest, err = NewIOTRepo(signDomainRepoTest)
 	if nil != err {
 		panic(err.Error())
 	}
@@ -91,12 +99,36 @@ func TestIOTGetIOT(t *testing.T) {
 
 func TestIOTGetIOTPosition(t *testing.T) {
 	var data, err = iotRepoTest.GetIotPositions(&domain.RIotGetList{
-		Status: dmodels.DeviceStatusSuccess,
+		IotFilter: domain.IotFilter{Status: "10"},
 	})
 	utils.PanicError("TestIOTGetIOTPosition", err)
 	utils.Dump("TestIOTGetIOTPosition", data)
 }
 
+func TestIOTGetIOTsFilter(t *testing.T) {
+	var hasSensors = true
+	var data, page, err = iotRepoTest.GetIots(&domain.RIotGetList{
+		IotFilter: domain.IotFilter{
+			Type:       "20,21",
+			Status:     "0,10",
+			HasSensors: &hasSensors,
+			Address:    "0x",
+		},
+		Sort:  "-createdAt",
+		Limit: 5,
+	})
+	utils.PanicError("TestIOTGetIOTsFilter", err)
+	utils.Dump("TestIOTGetIOTsFilter", data)
+
+	count, err := iotRepoTest.CountIot(&domain.RIotCount{
+		IotFilter: domain.IotFilter{Type: "20,21", Status: "0,10", HasSensors: &hasSensors, Address: "0x"},
+	})
+	utils.PanicError("TestIOTGetIOTsFilter", err)
+	if count != page.Total {
+		t.Fatalf("count (%d) must be total of list (%d)", count, page.Total)
+	}
+}
+
 func TestIOTGetIOTByAddress(t *testing.T) {
 	var data, err = iotRepoTest.GetIotByAddress(
 		dmodels.EthAddress("0x72ef9da2af1d657b3fd16e93fb9e6d82c4c615f1"),
@@ -173,7 +205,7 @@ func TestCreateMint(t *testing.T) {
 }
 
 func TestGetMint(t *testing.T) {
-	var signeds, err = iotRepoTest.GetMintSigns(&domain.RIotGetMintSignList{
+	var signeds, _, err = iotRepoTest.GetMintSigns(&domain.RIotGetMintSignList{
 		From:  time.Now().Unix() - 60*86400,
 		To:    time.Now().Unix(),
 		IotId: 16,
@@ -230,10 +262,49 @@ func TestMint(t *testing.T) {
 	utils.PanicError("", err)
 }
 
+func TestReportMintTx(t *testing.T) {
+	var iotAddr = dmodels.EthAddress("0xe445517abb524002bb04c96f96abb87b8b19b53d")
+	var txHash = "0x5e1d3a7c8b2f4e6a9d0c1b3e5f7a9c2d4e6f8a0b1c3d5e7f9a2b4c6d8e0f1a3b"
+
+	sign, err := iotRepoTest.ReportMintTx(&domain.RMintTxReport{
+		Iot:    iotAddr,
+		Nonce:  1,
+		State:  models.MintStateSubmitted,
+		TxHash: txHash,
+	})
+	utils.PanicError("TestReportMintTx submitted", err)
+	utils.Dump("Submitted", sign)
+	var signedAt = sign.UpdatedAt
+
+	var redeemed = &domain.RMintRedeemed{
+		Iot:         iotAddr,
+		Nonce:       1,
+		TxHash:      txHash,
+		BlockNumber: 100,
+		RedeemedAt:  time.Now().Unix(),
+	}
+	sign, err = iotRepoTest.ReportMintTx(redeemed.ToReport())
+	utils.PanicError("TestReportMintTx confirmed", err)
+	if !sign.UpdatedAt.Equal(signedAt) {
+		t.Fatalf("State report must not change signed time")
+	}
+
+	// Re-delivered
+	_, err = iotRepoTest.ReportMintTx(redeemed.ToReport())
+	utils.PanicError("TestReportMintTx redelivered", err)
+	utils.Dump("Confirmed", sign)
+}
+
+func TestGetMintUnclaimed(t *testing.T) {
+	rs, err := iotRepoTest.GetMintUnclaimed(292)
+	utils.PanicError("TestGetMintUnclaimed", err)
+	utils.Dump("Unclaimed", rs)
+}
+
 func TestGetMinted(t *testing.T) {
 	var now = time.Now().Unix()
 	log.Println(now-30*86400, now)
-	data, err := iotRepoTest.GetMinted(&domain.RIotGetMintedList{
+	data, _, err := iotRepoTest.GetMinted(&domain.RIotGetMintedList{
 		From:     1693760400,
 		To:       1693846799,
 		IotId:    291,
@@ -0,0 +1,256 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/iott-cloud/internal/domain"
+	"github.com/Dcarbon/iott-cloud/internal/models"
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	keyOpAll      = "op_all"        // SortedSet (member: iot id, score: latest)
+	keyOpStatus   = "op_status_%d"  // SortedSet (member: iot id, score: latest)
+	keyOpProject  = "op_project_%d" // SortedSet (member: iot id, score: latest)
+	keyOpType     = "op_type_%d"    // SortedSet (member: iot id, score: latest)
+	keyOpProjects = "op_projects"   // Set of project id
+	keyOpTypes    = "op_types"      // Set of iot type
+	keyOpFilter   = "op_filter_%d"  // Temporary intersect of filter
+)
+
+var opStatuses = []models.OpStatus{
+	models.OpStatusInactived,
+	models.OpStatusWarning,
+	models.OpStatusActived,
+}
+
+// Update indexes of iot status. Iot is removed from indexes of project and
+// type of prev (nil: unknown) if it was moved
+func (op *OperatorRepo) index(prev, stt *models.OpIotStatus) error {
+	var ctx = context.TODO()
+	var member = &redis.Z{Score: float64(stt.Latest), Member: stt.Id}
+
+	var pipe = op.redis.TxPipeline()
+	if nil != prev && prev.Project != 0 && prev.Project != stt.Project {
+		pipe.ZRem(ctx, fmt.Sprintf(keyOpProject, prev.Project), stt.Id)
+	}
+	if nil != prev && prev.Type != 0 && prev.Type != stt.Type {
+		pipe.ZRem(ctx, fmt.Sprintf(keyOpType, prev.Type), stt.Id)
+	}
+	for _, status := range opStatuses {
+		if status != stt.Status {
+			pipe.ZRem(ctx, fmt.Sprintf(keyOpStatus, status), stt.Id)
+		}
+	}
+	pipe.ZAdd(ctx, fmt.Sprintf(keyOpStatus, stt.Status), member)
+	pipe.ZAdd(ctx, keyOpAll, member)
+	if stt.Project != 0 {
+		pipe.ZAdd(ctx, fmt.Sprintf(keyOpProject, stt.Project), member)
+		pipe.SAdd(ctx, keyOpProjects, stt.Project)
+	}
+	if stt.Type != 0 {
+		pipe.ZAdd(ctx, fmt.Sprintf(keyOpType, stt.Type), member)
+		pipe.SAdd(ctx, keyOpTypes, int(stt.Type))
+	}
+
+	var _, err = pipe.Exec(ctx)
+	if nil != err {
+		return dmodels.ErrInternal(err)
+	}
+	return nil
+}
+
+// Update latest (score) of iot in indexes (status, project, type were not changed)
+func (op *OperatorRepo) touch(stt *models.OpIotStatus) error {
+	var ctx = context.TODO()
+	var member = &redis.Z{Score: float64(stt.Latest), Member: stt.Id}
+
+	var pipe = op.redis.Pipeline()
+	pipe.ZAddXX(ctx, fmt.Sprintf(keyOpStatus, stt.Status), member)
+	pipe.ZAddXX(ctx, keyOpAll, member)
+	if stt.Project != 0 {
+		pipe.ZAddXX(ctx, fmt.Sprintf(keyOpProject, stt.Project), member)
+	}
+	if stt.Type != 0 {
+		pipe.ZAddXX(ctx, fmt.Sprintf(keyOpType, stt.Type), member)
+	}
+
+	var _, err = pipe.Exec(ctx)
+	if nil != err {
+		return dmodels.ErrInternal(err)
+	}
+	return nil
+}
+
+// Rebuild indexes from status hash (for status was set before indexes existed)
+func (op *OperatorRepo) Reindex() error {
+	statuses, err := op.GetAllStatus()
+	if nil != err {
+		return err
+	}
+
+	for _, stt := range statuses {
+		if stt.Project == 0 || stt.Type == 0 {
+			iot, err := op.getIot(stt.Id)
+			if nil != err {
+				log.Println("Reindex get iot error: ", stt.Id, err)
+			} else {
+				stt.Project = iot.Project
+				stt.Type = iot.Type
+			}
+		}
+
+		err = op.index(nil, stt)
+		if nil != err {
+			return err
+		}
+	}
+	return nil
+}
+
+func (op *OperatorRepo) GetOverview(req *domain.ROpOverview,
+) (*domain.RsOpOverview, error) {
+	var ctx = context.TODO()
+	var rs = &domain.RsOpOverview{
+		ByStatus:  make(map[models.OpStatus]int64),
+		ByProject: make(map[int64]int64),
+		ByType:    make(map[models.IOTType]int64),
+		Devices:   make([]*domain.OpDevice, 0),
+	}
+
+	projects, err := op.redis.SMembers(ctx, keyOpProjects).Result()
+	if nil != err && err != redis.Nil {
+		return nil, dmodels.ErrInternal(err)
+	}
+
+	types, err := op.redis.SMembers(ctx, keyOpTypes).Result()
+	if nil != err && err != redis.Nil {
+		return nil, dmodels.ErrInternal(err)
+	}
+
+	var pipe = op.redis.Pipeline()
+	var total = pipe.ZCard(ctx, keyOpAll)
+	var byStatus = make(map[models.OpStatus]*redis.IntCmd)
+	for _, status := range opStatuses {
+		byStatus[status] = pipe.ZCard(ctx, fmt.Sprintf(keyOpStatus, status))
+	}
+
+	var byProject = make(map[int64]*redis.IntCmd)
+	for _, str := range projects {
+		id, _ := strconv.ParseInt(str, 10, 64)
+		byProject[id] = pipe.ZCard(ctx, fmt.Sprintf(keyOpProject, id))
+	}
+
+	var byType = make(map[models.IOTType]*redis.IntCmd)
+	for _, str := range types {
+		iotType, _ := strconv.Atoi(str)
+		byType[models.IOTType(iotType)] = pipe.ZCard(ctx, fmt.Sprintf(keyOpType, iotType))
+	}
+
+	_, err = pipe.Exec(ctx)
+	if nil != err {
+		return nil, dmodels.ErrInternal(err)
+	}
+
+	rs.Total = total.Val()
+	for k, v := range byStatus {
+		rs.ByStatus[k] = v.Val()
+	}
+	for k, v := range byProject {
+		rs.ByProject[k] = v.Val()
+	}
+	for k, v := range byType {
+		rs.ByType[k] = v.Val()
+	}
+
+	ids, count, err := op.filterIds(req)
+	if nil != err {
+		return nil, err
+	}
+	rs.Count = count
+
+	for _, id := range ids {
+		stt, err := op.GetStatus(id)
+		if nil != err {
+			return nil, err
+		}
+
+		metrics, err := op.GetMetrics(id)
+		if nil != err {
+			return nil, err
+		}
+
+		rs.Devices = append(rs.Devices, &domain.OpDevice{
+			OpIotStatus: stt,
+			Metrics:     metrics.Metrics,
+		})
+	}
+	return rs, nil
+}
+
+// Get page of iot id match filter (order by latest desc) and total matched
+func (op *OperatorRepo) filterIds(req *domain.ROpOverview) ([]int64, int64, error) {
+	if req.Skip < 0 || req.Limit < 0 {
+		return nil, 0, dmodels.ErrBadRequest("skip and limit must not be negative")
+	}
+
+	var ctx = context.TODO()
+	var keys = make([]string, 0, 3)
+	if req.Status != 0 {
+		keys = append(keys, fmt.Sprintf(keyOpStatus, req.Status))
+	}
+	if req.ProjectId != 0 {
+		keys = append(keys, fmt.Sprintf(keyOpProject, req.ProjectId))
+	}
+	if req.Type != 0 {
+		keys = append(keys, fmt.Sprintf(keyOpType, req.Type))
+	}
+
+	var key = keyOpAll
+	switch len(keys) {
+	case 0:
+	case 1:
+		key = keys[0]
+	default:
+		key = fmt.Sprintf(keyOpFilter, time.Now().UnixNano())
+		var pipe = op.redis.TxPipeline()
+		pipe.ZInterStore(ctx, key, &redis.ZStore{Keys: keys, Aggregate: "MAX"})
+		pipe.Expire(ctx, key, 10*time.Second)
+		_, err := pipe.Exec(ctx)
+		if nil != err {
+			return nil, 0, dmodels.ErrInternal(err)
+		}
+		defer op.redis.Del(ctx, key)
+	}
+
+	count, err := op.redis.ZCard(ctx, key).Result()
+	if nil != err && err != redis.Nil {
+		return nil, 0, dmodels.ErrInternal(err)
+	}
+
+	var limit = req.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+
+	members, err := op.redis.ZRevRange(
+		ctx, key, int64(req.Skip), int64(req.Skip+limit-1),
+	).Result()
+	if nil != err && err != redis.Nil {
+		return nil, 0, dmodels.ErrInternal(err)
+	}
+
+	var ids = make([]int64, 0, len(members))
+	for _, m := range members {
+		id, err := strconv.ParseInt(m, 10, 64)
+		if nil == err {
+			ids = append(ids, id)
+		}
+	}
+	return ids, count, nil
+}
@@ -0,0 +1,111 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Dcarbon/iott-cloud/internal/models"
+	"github.com/Dcarbon/iott-cloud/internal/rss"
+	"github.com/go-redis/redis/v8"
+)
+
+const opStreamBuffer = 64 // Max pending events of each subscriber
+
+type opSubscriber struct {
+	iots map[int64]bool
+	ch   chan *models.OpEvent
+}
+
+// Fan out operator events (from redis pubsub) to local subscribers
+type OpStream struct {
+	redis *redis.Client
+	mut   *sync.RWMutex
+	subs  map[*opSubscriber]bool
+}
+
+func NewOpStream() (*OpStream, error) {
+	var stream = &OpStream{
+		redis: rss.GetRedis(),
+		mut:   &sync.RWMutex{},
+		subs:  make(map[*opSubscriber]bool),
+	}
+	go stream.run()
+	return stream, nil
+}
+
+func (stream *OpStream) Subscribe(iotIds []int64,
+) (<-chan *models.OpEvent, func()) {
+	var sub = &opSubscriber{
+		iots: make(map[int64]bool, len(iotIds)),
+		ch:   make(chan *models.OpEvent, opStreamBuffer),
+	}
+	for _, id := range iotIds {
+		sub.iots[id] = true
+	}
+
+	stream.mut.Lock()
+	stream.subs[sub] = true
+	stream.mut.Unlock()
+
+	var once = &sync.Once{}
+	var cancel = func() {
+		once.Do(func() { stream.remove(sub) })
+	}
+	return sub.ch, cancel
+}
+
+func (stream *OpStream) run() {
+	for {
+		var pubsub = stream.redis.Subscribe(context.TODO(), channelOpEvent)
+		for msg := range pubsub.Channel() {
+			var evt = &models.OpEvent{}
+			var err = json.Unmarshal([]byte(msg.Payload), evt)
+			if nil != err {
+				log.Println("Unmarshal operator event error: ", err)
+				continue
+			}
+			stream.dispatch(evt)
+		}
+		pubsub.Close()
+
+		log.Println("Operator stream was disconnected. Reconnect after 5s")
+		time.Sleep(5 * time.Second)
+	}
+}
+
+func (stream *OpStream) dispatch(evt *models.OpEvent) {
+	var slows = make([]*opSubscriber, 0)
+
+	stream.mut.RLock()
+	for sub := range stream.subs {
+		if !sub.iots[evt.IotId] {
+			continue
+		}
+
+		select {
+		case sub.ch <- evt:
+		default:
+			slows = append(slows, sub)
+		}
+	}
+	stream.mut.RUnlock()
+
+	// Drop subscriber can't keep up. Client must reconnect
+	for _, sub := range slows {
+		log.Println("Operator stream subscriber is too slow, close it")
+		stream.remove(sub)
+	}
+}
+
+func (stream *OpStream) remove(sub *opSubscriber) {
+	stream.mut.Lock()
+	defer stream.mut.Unlock()
+
+	if stream.subs[sub] {
+		delete(stream.subs, sub)
+		close(sub.ch)
+	}
+}
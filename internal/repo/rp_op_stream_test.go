@@ -0,0 +1,34 @@
+package repo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Dcarbon/go-shared/libs/utils"
+	"github.com/Dcarbon/iott-cloud/internal/domain"
+	"github.com/Dcarbon/iott-cloud/internal/models"
+)
+
+func TestOpStreamSubscribe(t *testing.T) {
+	stream, err := NewOpStream()
+	utils.PanicError("", err)
+
+	events, cancel := stream.Subscribe([]int64{1})
+	defer cancel()
+
+	// Wait for redis subscription
+	time.Sleep(500 * time.Millisecond)
+
+	err = opTest.SetStatus(&domain.ROpSetStatus{
+		Id:     1,
+		Status: models.OpStatusActived,
+	})
+	utils.PanicError("", err)
+
+	select {
+	case evt := <-events:
+		utils.Dump("Event", evt)
+	case <-time.After(3 * time.Second):
+		t.Fatal("Timeout wait operator event")
+	}
+}
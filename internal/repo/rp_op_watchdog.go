@@ -0,0 +1,108 @@
+package repo
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Dcarbon/go-shared/libs/utils"
+	"github.com/Dcarbon/iott-cloud/internal/models"
+)
+
+// Downgrade status of iot has not reported for a while:
+// actived -> warning (after warningFactor * interval)
+// warning -> inactived (after inactiveFactor * interval)
+// Watchdog runs on every replica: transition is compare-and-set (latest and
+// status), so only one replica changes status and publishes event
+type OpWatchdog struct {
+	op             *OperatorRepo
+	period         time.Duration            // Check period
+	intervals      map[models.IOTType]int64 // Expected reporting interval (second) by iot type
+	defInterval    int64                    // Default reporting interval (second)
+	warningFactor  int64                    //
+	inactiveFactor int64                    //
+}
+
+func NewOpWatchdog(op *OperatorRepo) *OpWatchdog {
+	var wd = &OpWatchdog{
+		op:             op,
+		period:         time.Duration(utils.Int64Env("OP_WATCHDOG_PERIOD", 60)) * time.Second,
+		intervals:      make(map[models.IOTType]int64),
+		defInterval:    utils.Int64Env("IOT_REPORT_INTERVAL", 300),
+		warningFactor:  utils.Int64Env("OP_WARNING_FACTOR", 2),
+		inactiveFactor: utils.Int64Env("OP_INACTIVE_FACTOR", 6),
+	}
+
+	for _, iotType := range []models.IOTType{
+		models.IOTTypeWindPower,
+		models.IOTTypeSolarPower,
+		models.IOTTypeBurnMethane,
+		models.IOTTypeBurnBiomass,
+		models.IOTTypeFertilizer,
+		models.IOTTypeTrash,
+	} {
+		wd.loadInterval(iotType)
+	}
+	return wd
+}
+
+func (wd *OpWatchdog) Run() {
+	var ticker = time.NewTicker(wd.period)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		var err = wd.Check(time.Now().Unix())
+		if nil != err {
+			log.Println("Operator watchdog check error: ", err)
+		}
+	}
+}
+
+// Check all iot status at now (timestamp)
+func (wd *OpWatchdog) Check(now int64) error {
+	statuses, err := wd.op.GetAllStatus()
+	if nil != err {
+		return err
+	}
+
+	for _, stt := range statuses {
+		var next = wd.expectedStatus(stt, now)
+		if next >= stt.Status {
+			continue
+		}
+
+		changed, err := wd.op.transition(stt, next)
+		if nil != err {
+			log.Println("Transition iot status error: ", stt.Id, err)
+			continue
+		}
+		if changed {
+			log.Printf("Iot %d status changed %d -> %d (latest: %d)\n", stt.Id, stt.Status, next, stt.Latest)
+		}
+	}
+	return nil
+}
+
+// Expected status by silent duration. Watchdog only downgrade status
+func (wd *OpWatchdog) expectedStatus(stt *models.OpIotStatus, now int64) models.OpStatus {
+	var interval = wd.defInterval
+	if v, ok := wd.intervals[stt.Type]; ok {
+		interval = v
+	}
+
+	var silent = now - stt.Latest
+	if silent > wd.inactiveFactor*interval {
+		return models.OpStatusInactived
+	}
+	if silent > wd.warningFactor*interval {
+		return models.OpStatusWarning
+	}
+	return models.OpStatusActived
+}
+
+func (wd *OpWatchdog) loadInterval(iotType models.IOTType) {
+	var interval = utils.Int64Env(fmt.Sprintf("IOT_REPORT_INTERVAL_%d", iotType), 0)
+	if interval > 0 {
+		wd.intervals[iotType] = interval
+	}
+}
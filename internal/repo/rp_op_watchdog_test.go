@@ -0,0 +1,41 @@
+package repo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Dcarbon/go-shared/libs/utils"
+	"github.com/Dcarbon/iott-cloud/internal/models"
+)
+
+func TestOpWatchdogExpectedStatus(t *testing.T) {
+	var wd = NewOpWatchdog(opTest)
+	wd.intervals[models.IOTTypeBurnMethane] = 60
+
+	var now = time.Now().Unix()
+	var cases = map[int64]models.OpStatus{
+		now - 60:  models.OpStatusActived,
+		now - 121: models.OpStatusWarning,
+		now - 361: models.OpStatusInactived,
+	}
+	for latest, expected := range cases {
+		var stt = &models.OpIotStatus{
+			Id:     1,
+			Type:   models.IOTTypeBurnMethane,
+			Status: models.OpStatusActived,
+			Latest: latest,
+		}
+		if rs := wd.expectedStatus(stt, now); rs != expected {
+			t.Fatalf("Latest %d: expected %d got %d", latest, expected, rs)
+		}
+	}
+}
+
+func TestOpWatchdogCheck(t *testing.T) {
+	var wd = NewOpWatchdog(opTest)
+	utils.PanicError("", wd.Check(time.Now().Unix()+86400))
+
+	health, err := opTest.GetHealth()
+	utils.PanicError("", err)
+	utils.Dump("Health", health)
+}
@@ -3,8 +3,10 @@ package repo
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/Dcarbon/go-shared/dmodels"
@@ -17,42 +19,212 @@ import (
 const (
 	keyIotStatus = "iot_status"     // HashTable(HSET, HGET)
 	keyIotMetric = "iot_metrics_%d" // HashTable(HSET, HGET)
+
+	channelOpEvent = "op_events" // PubSub (PUBLISH, SUBSCRIBE)
 )
 
+// Cache duration of iot (metadata of status: address, type, project)
+const opIotCacheTTL = 5 * time.Minute
+
+// Set status and return previous (false if not exists)
+// KEYS[1]: status key, ARGV: iot id, new status (json)
+var scriptSetStatus = redis.NewScript(`
+local cur = redis.call('HGET', KEYS[1], ARGV[1])
+redis.call('HSET', KEYS[1], ARGV[1], ARGV[2])
+return cur
+`)
+
+// Set status only if latest and status were not changed (only one replica
+// makes a transition)
+// KEYS[1]: status key, ARGV: iot id, expected latest, new status (json), expected status
+var scriptTransition = redis.NewScript(`
+local cur = redis.call('HGET', KEYS[1], ARGV[1])
+if not cur then
+	return 0
+end
+local stt = cjson.decode(cur)
+if tonumber(stt['latest'] or 0) ~= tonumber(ARGV[2]) or tonumber(stt['status'] or 0) ~= tonumber(ARGV[4]) then
+	return 0
+end
+redis.call('HSET', KEYS[1], ARGV[1], ARGV[3])
+return 1
+`)
+
+type cachedIot struct {
+	iot *models.IOTDevice
+	at  time.Time
+}
+
 type OperatorRepo struct {
-	redis *redis.Client
+	redis   *redis.Client
+	iot     domain.IIot
+	iots    *sync.Map // Cache of iot (id => *cachedIot)
+	alerter domain.IAlertEvaluator
 }
 
-func NewOperatorRepo() (*OperatorRepo, error) {
+func NewOperatorRepo(iot domain.IIot) (*OperatorRepo, error) {
 	var op = &OperatorRepo{
 		redis: rss.GetRedis(),
+		iot:   iot,
+		iots:  &sync.Map{},
 	}
 	return op, nil
 }
 
+func (op *OperatorRepo) SetAlerter(alerter domain.IAlertEvaluator) {
+	op.alerter = alerter
+}
+
+// Set status (latest is now). Event is published and alert is evaluated only
+// if status was changed
 func (op *OperatorRepo) SetStatus(req *domain.ROpSetStatus) error {
 	var stt = &models.OpIotStatus{
 		Id:     req.Id,
 		Status: req.Status,
 		Latest: time.Now().Unix(),
 	}
+
+	iot, err := op.getIot(req.Id)
+	if nil != err {
+		log.Println("Get iot of operator status error: ", err)
+	} else {
+		stt.Address = iot.Address
+		stt.Type = iot.Type
+		stt.Project = iot.Project
+	}
+
 	raw, err := json.Marshal(stt)
 	if nil != err {
 		return dmodels.ErrInternal(err)
 	}
 
-	_, err = op.redis.HSet(
-		context.TODO(),
-		keyIotStatus,
+	var prev = &models.OpIotStatus{Id: req.Id, Status: models.OpStatusInactived}
+	cur, err := scriptSetStatus.Run(
+		context.TODO(), op.redis,
+		[]string{keyIotStatus},
 		fmt.Sprintf("%d", req.Id), string(raw),
-	).Result()
-	if nil != err {
+	).Text()
+	if nil != err && err != redis.Nil {
 		return dmodels.ErrInternal(err)
 	}
+	if cur != "" {
+		err = json.Unmarshal([]byte(cur), prev)
+		if nil != err {
+			log.Println("Unmarshal iot status error: ", err)
+		}
+	}
+
+	var changed = prev.Status != stt.Status
+	if changed || cur == "" || prev.Project != stt.Project || prev.Type != stt.Type {
+		err = op.index(prev, stt)
+	} else {
+		err = op.touch(stt)
+	}
+	if nil != err {
+		log.Println("Index iot status error: ", err)
+	}
+
+	if !changed {
+		return nil
+	}
+
+	op.publish(&models.OpEvent{
+		Type:     models.OpEventStatus,
+		IotId:    req.Id,
+		Previous: prev.Status,
+		Status:   stt,
+	})
 
+	if op.alerter != nil {
+		op.alerter.OnStatus(prev.Status, stt)
+	}
 	return nil
 }
 
+// Change status without touch latest. Ignored (return false) if iot has
+// reported since stt was read
+func (op *OperatorRepo) transition(stt *models.OpIotStatus, status models.OpStatus,
+) (bool, error) {
+	var next = *stt
+	next.Status = status
+
+	raw, err := json.Marshal(&next)
+	if nil != err {
+		return false, dmodels.ErrInternal(err)
+	}
+
+	changed, err := scriptTransition.Run(
+		context.TODO(), op.redis,
+		[]string{keyIotStatus},
+		fmt.Sprintf("%d", stt.Id), stt.Latest, string(raw), int(stt.Status),
+	).Int()
+	if nil != err {
+		return false, dmodels.ErrInternal(err)
+	}
+	if changed == 0 {
+		return false, nil
+	}
+
+	err = op.index(nil, &next)
+	if nil != err {
+		log.Println("Index iot status error: ", err)
+	}
+
+	op.publish(&models.OpEvent{
+		Type:     models.OpEventStatus,
+		IotId:    stt.Id,
+		Previous: stt.Status,
+		Status:   &next,
+	})
+
+	if op.alerter != nil {
+		op.alerter.OnStatus(stt.Status, &next)
+	}
+	return true, nil
+}
+
+func (op *OperatorRepo) GetAllStatus() ([]*models.OpIotStatus, error) {
+	data, err := op.redis.HGetAll(context.TODO(), keyIotStatus).Result()
+	if nil != err && err != redis.Nil {
+		return nil, dmodels.ErrInternal(err)
+	}
+
+	var rs = make([]*models.OpIotStatus, 0, len(data))
+	for _, v := range data {
+		var stt = &models.OpIotStatus{}
+		err = json.Unmarshal([]byte(v), stt)
+		if nil != err {
+			log.Println("Unmarshal iot status error: ", err)
+			continue
+		}
+		rs = append(rs, stt)
+	}
+	return rs, nil
+}
+
+func (op *OperatorRepo) GetHealth() (*domain.RsOpHealth, error) {
+	statuses, err := op.GetAllStatus()
+	if nil != err {
+		return nil, err
+	}
+
+	var health = &domain.RsOpHealth{
+		Total:     int64(len(statuses)),
+		CheckedAt: time.Now().Unix(),
+	}
+	for _, stt := range statuses {
+		switch stt.Status {
+		case models.OpStatusActived:
+			health.Actived++
+		case models.OpStatusWarning:
+			health.Warning++
+		default:
+			health.Inactived++
+		}
+	}
+	return health, nil
+}
+
 func (op *OperatorRepo) GetStatus(iotId int64) (*models.OpIotStatus, error) {
 	str, err := op.redis.HGet(context.TODO(), keyIotStatus, fmt.Sprintf("%d", iotId)).Result()
 	if err == redis.Nil || str == "" {
@@ -95,6 +267,11 @@ func (op *OperatorRepo) ChangeMetrics(req *domain.RChangeMetric, sensorType dmod
 		return nil, dmodels.ErrInternal(err)
 	}
 
+	op.publish(&models.OpEvent{
+		Type:   models.OpEventMetric,
+		IotId:  req.IotId,
+		Metric: metric,
+	})
 	return metric, nil
 }
 
@@ -125,3 +302,37 @@ func (op *OperatorRepo) GetMetrics(iotId int64) (*domain.RsGetMetrics, error) {
 		},
 		nil
 }
+
+// Publish event to all replicas. Error is only logged
+func (op *OperatorRepo) publish(evt *models.OpEvent) {
+	raw, err := json.Marshal(evt)
+	if nil != err {
+		log.Println("Marshal operator event error: ", err)
+		return
+	}
+
+	err = op.redis.Publish(context.TODO(), channelOpEvent, string(raw)).Err()
+	if nil != err {
+		log.Println("Publish operator event error: ", err)
+	}
+}
+
+func (op *OperatorRepo) getIot(iotId int64) (*models.IOTDevice, error) {
+	if v, ok := op.iots.Load(iotId); ok {
+		var cached = v.(*cachedIot)
+		if time.Since(cached.at) < opIotCacheTTL {
+			return cached.iot, nil
+		}
+	}
+
+	if nil == op.iot {
+		return nil, dmodels.ErrInternal(errors.New("missing iot repo for operator"))
+	}
+
+	iot, err := op.iot.GetIot(iotId)
+	if nil != err {
+		return nil, err
+	}
+	op.iots.Store(iotId, &cachedIot{iot: iot, at: time.Now()})
+	return iot, nil
+}
@@ -13,7 +13,7 @@ var opTest *OperatorRepo
 
 func init() {
 	var err error
-	opTest, err = NewOperatorRepo()
+	opTest, err = NewOperatorRepo(iotRepoTest)
 	utils.PanicError("", err)
 }
 
@@ -70,3 +70,14 @@ func TestGetMetrics(t *testing.T) {
 	utils.PanicError("", err)
 	utils.Dump("Metrics: ", metrics)
 }
+
+func TestGetOverview(t *testing.T) {
+	utils.PanicError("", opTest.Reindex())
+
+	overview, err := opTest.GetOverview(&domain.ROpOverview{
+		Status: models.OpStatusActived,
+		Limit:  10,
+	})
+	utils.PanicError("", err)
+	utils.Dump("Overview: ", overview)
+}
@@ -0,0 +1,452 @@
+package repo
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/go-shared/ecodes"
+	"github.com/Dcarbon/go-shared/libs/esign"
+	"github.com/Dcarbon/go-shared/libs/utils"
+	"github.com/Dcarbon/iott-cloud/internal/domain"
+	"github.com/Dcarbon/iott-cloud/internal/events"
+	"github.com/Dcarbon/iott-cloud/internal/models"
+	"github.com/Dcarbon/iott-cloud/internal/rss"
+	"github.com/Dcarbon/iott-cloud/internal/signer"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Sign mint of iot (oracle mode) by verified metrics of flow & power sensors
+type OracleRepo struct {
+	db      *gorm.DB
+	domains domain.ISignDomain
+	signer  signer.Signer              // Nil: oracle is not configured
+	period  time.Duration              // Mint period
+	delay   time.Duration              // Metric is counted after delay (wait for late metric)
+	rates   map[models.IOTType]float64 // Carbon per metric unit by iot type
+	defRate float64                    //
+	leader  *leaderLock                // Only one replica signs
+}
+
+func NewOracleRepo(domains domain.ISignDomain, s signer.Signer,
+) (*OracleRepo, error) {
+	var db = rss.GetDB()
+	var err = db.AutoMigrate(&models.MintAudit{})
+	if nil != err {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if nil != err {
+		return nil, err
+	}
+
+	var impl = &OracleRepo{
+		db:      db,
+		domains: domains,
+		signer:  s,
+		period:  time.Duration(utils.Int64Env("ORACLE_PERIOD", 3600)) * time.Second,
+		delay:   time.Duration(utils.Int64Env("ORACLE_DELAY", 3600)) * time.Second,
+		rates:   make(map[models.IOTType]float64),
+		defRate: floatEnv("ORACLE_RATE", 1),
+		leader:  newLeaderLock(sqlDB, "oracle-mint"),
+	}
+
+	for _, iotType := range []models.IOTType{
+		models.IOTTypeWindPower,
+		models.IOTTypeSolarPower,
+		models.IOTTypeBurnMethane,
+		models.IOTTypeBurnBiomass,
+		models.IOTTypeFertilizer,
+		models.IOTTypeTrash,
+	} {
+		impl.loadRate(iotType)
+	}
+	return impl, nil
+}
+
+// Sign mint for all oracle iots periodically (on leader replica)
+func (impl *OracleRepo) Run() {
+	var ticker = time.NewTicker(impl.period)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if !impl.leader.IsLeader(context.Background()) {
+			continue
+		}
+
+		var ids = make([]int64, 0)
+		var err = impl.db.Table(models.TableNameIOT).
+			Where("oracle = ?", true).
+			Pluck("id", &ids).Error
+		if nil != err {
+			log.Println("Oracle get iots error: ", err)
+			continue
+		}
+
+		for _, id := range ids {
+			audit, err := impl.Mint(id)
+			if nil != err {
+				log.Println("Oracle mint error: ", id, err)
+				continue
+			}
+			if nil != audit {
+				log.Printf("Oracle signed mint of iot %d nonce %d (+%d)\n", id, audit.Nonce, audit.Increment)
+			}
+		}
+	}
+}
+
+func (impl *OracleRepo) SetEnable(req *domain.RIotOracleEnable,
+) (*models.IOTDevice, error) {
+	if req.Enable && impl.signer == nil {
+		return nil, dmodels.NewError(ecodes.NotImplement, "Oracle signer is not configured")
+	}
+
+	// Keep enabled time when oracle was already enabled
+	var iot = &models.IOTDevice{}
+	var err = impl.db.Table(models.TableNameIOT).
+		Model(iot).
+		Clauses(clause.Returning{}).
+		Where("id = ?", req.IotId).
+		Updates(map[string]interface{}{
+			"oracle":    req.Enable,
+			"oracle_at": gorm.Expr("CASE WHEN oracle THEN oracle_at ELSE ? END", time.Now()),
+		}).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("IOT", err)
+	}
+	if iot.ID == 0 {
+		return nil, dmodels.NewError(ecodes.NotExisted, "IOT is not existed")
+	}
+	return iot, nil
+}
+
+// Signature of oracle is computed without lock (signer may be remote), then
+// it is saved in a tx holding iot row when nothing was changed meanwhile
+func (impl *OracleRepo) Mint(iotId int64) (*models.MintAudit, error) {
+	if impl.signer == nil {
+		return nil, dmodels.NewError(ecodes.NotImplement, "Oracle signer is not configured")
+	}
+
+	om, err := impl.prepare(iotId)
+	if nil != err || om == nil {
+		return nil, err
+	}
+
+	hash, err := signer.SignMint(impl.signer, om.domain.TypedDomain(), om.mint)
+	if nil != err {
+		return nil, dmodels.ErrInternal(err)
+	}
+	om.audit.Hash = hexutil.Encode(hash)
+
+	err = om.mint.Verify(om.minter)
+	if nil != err {
+		return nil, err
+	}
+
+	err = impl.db.Transaction(func(tx *gorm.DB) error {
+		// Lock iot: one signature for a nonce
+		var iot = &models.IOTDevice{}
+		var err = tx.Table(models.TableNameIOT).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", iotId).
+			First(iot).Error
+		if nil != err {
+			return dmodels.ParsePostgresError("IOT", err)
+		}
+		if !iot.Oracle || iot.DomainId != om.iot.DomainId {
+			return errOracleChanged
+		}
+
+		latest, err := impl.getLatestSign(tx, om.mint.Iot, om.domain.ID)
+		if nil != err {
+			return err
+		}
+		prev, err := impl.getLatestAudit(tx, iot.ID, om.domain.ID)
+		if nil != err {
+			return err
+		}
+		if latest.ID != om.latestId || latest.Nonce+1 != om.mint.Nonce || prev.ID != om.prevId {
+			return errOracleChanged
+		}
+
+		err = tx.Table(models.TableNameMintSign).Create(om.mint).Error
+		if nil != err {
+			return dmodels.ParsePostgresError("Mint sign", err)
+		}
+
+		err = tx.Table(models.TableNameMinted).Create(om.minted).Error
+		if nil != err {
+			return dmodels.ParsePostgresError("Minted", err)
+		}
+
+		om.audit.MintSignId = om.mint.ID
+		err = tx.Table(models.TableNameMintAudit).Create(om.audit).Error
+		if nil != err {
+			return dmodels.ParsePostgresError("Mint audit", err)
+		}
+
+		return writeOutbox(
+			tx, models.EventMintSigned, iot.Project, events.NewMintV1(om.mint, om.minted),
+		)
+	})
+	if nil != err {
+		return nil, err
+	}
+	invalidateProjectStats(om.iot.Project)
+	return om.audit, nil
+}
+
+var errOracleChanged = dmodels.ErrInternal(
+	errors.New("iot or its signature was changed while oracle was signing, retry next period"),
+)
+
+// Unsigned mint of oracle
+type oracleMint struct {
+	iot      *models.IOTDevice
+	domain   *models.SignDomain
+	minter   *esign.ERC712
+	latestId int64  // Latest signature of domain was read (0: none)
+	prevId   string // Latest audit of domain was read (empty: none)
+	mint     *models.MintSign
+	minted   *models.Minted
+	audit    *models.MintAudit
+}
+
+// Compute mint of window. Window starts at the latest of: previous oracle
+// audit, latest signature of device (metric before it was signed by device)
+// and time oracle was enabled. Return nil when there is nothing to sign
+func (impl *OracleRepo) prepare(iotId int64) (*oracleMint, error) {
+	var iot = &models.IOTDevice{}
+	var err = impl.db.Table(models.TableNameIOT).Where("id = ?", iotId).First(iot).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("IOT", err)
+	}
+	if !iot.Oracle {
+		return nil, dmodels.NewError(ecodes.IOTNotAllowed, "Oracle mode of iot is not enabled")
+	}
+
+	sd, minter, err := impl.domains.GetMinter(iot.DomainId)
+	if nil != err {
+		return nil, err
+	}
+
+	var addr = strings.ToLower(string(iot.Address))
+	latest, err := impl.getLatestSign(impl.db, addr, sd.ID)
+	if nil != err {
+		return nil, err
+	}
+	prev, err := impl.getLatestAudit(impl.db, iot.ID, sd.ID)
+	if nil != err {
+		return nil, err
+	}
+
+	var from = iot.CreatedAt
+	if nil != iot.OracleAt && iot.OracleAt.After(from) {
+		from = *iot.OracleAt
+	}
+	if latest.ID > 0 && latest.Signer == "" && latest.CreatedAt.After(from) {
+		from = latest.CreatedAt
+	}
+	var prevTotal = float64(0)
+	if prev.ID != "" && !prev.To.Before(from) {
+		from = prev.To
+		prevTotal = prev.Total
+	}
+
+	var to = time.Now().Add(-impl.delay)
+	if !to.After(from) {
+		return nil, nil
+	}
+
+	inputs, digest, err := impl.getInputs(impl.db, iot.ID, from, to)
+	if nil != err {
+		return nil, err
+	}
+
+	var count, sum = int64(0), float64(0)
+	for _, in := range inputs {
+		count += in.Count
+		sum += in.Sum
+	}
+	if count == 0 {
+		return nil, nil
+	}
+
+	var rate = impl.getRate(iot.Type)
+	var total = prevTotal + sum
+	var increment = int64(math.Floor(total*rate) - math.Floor(prevTotal*rate))
+	if increment <= 0 {
+		return nil, nil
+	}
+
+	oldAmount, err := dmodels.NewBigNumberFromHex(latest.Amount)
+	if nil != err {
+		oldAmount = dmodels.NewBigNumber(0)
+	}
+	var amount = big.NewInt(0).Add(oldAmount.Int, big.NewInt(increment))
+
+	var mint = &models.MintSign{
+		IotId:     iot.ID,
+		Iot:       addr,
+		Nonce:     latest.Nonce + 1,
+		Amount:    hexutil.EncodeBig(amount),
+		DomainId:  sd.ID,
+		State:     models.MintStatePending,
+		Signer:    string(impl.signer.Address()),
+		CreatedAt: time.Now(),
+	}
+
+	var om = &oracleMint{
+		iot:      iot,
+		domain:   sd,
+		minter:   minter,
+		latestId: latest.ID,
+		prevId:   prev.ID,
+		mint:     mint,
+		minted: &models.Minted{
+			ID:     uuid.NewV4().String(),
+			IotId:  iot.ID,
+			Carbon: increment,
+		},
+		audit: &models.MintAudit{
+			ID:        uuid.NewV4().String(),
+			IotId:     iot.ID,
+			DomainId:  sd.ID,
+			Nonce:     mint.Nonce,
+			Amount:    mint.Amount,
+			Increment: increment,
+			Signer:    mint.Signer,
+			From:      from,
+			To:        to,
+			Count:     count,
+			Sum:       sum,
+			Total:     total,
+			Rate:      rate,
+			Digest:    digest,
+			Inputs:    inputs,
+			CreatedAt: time.Now(),
+		},
+	}
+	return om, nil
+}
+
+func (impl *OracleRepo) getLatestSign(db *gorm.DB, addr string, domainId int64,
+) (*models.MintSign, error) {
+	var latest = &models.MintSign{Amount: "0x0"}
+	var err = db.Table(models.TableNameMintSign).
+		Where("iot = ? AND domain_id = ?", addr, domainId).
+		Order("nonce desc").
+		Limit(1).
+		Find(latest).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Mint sign", err)
+	}
+	return latest, nil
+}
+
+func (impl *OracleRepo) getLatestAudit(db *gorm.DB, iotId, domainId int64,
+) (*models.MintAudit, error) {
+	var prev = &models.MintAudit{}
+	var err = db.Table(models.TableNameMintAudit).
+		Where("iot_id = ? AND domain_id = ?", iotId, domainId).
+		Order("created_at desc").
+		Limit(1).
+		Find(prev).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Mint audit", err)
+	}
+	return prev, nil
+}
+
+func (impl *OracleRepo) GetAudits(req *domain.RMintAuditGetList,
+) ([]*models.MintAudit, error) {
+	if req.Limit <= 0 {
+		req.Limit = 20
+	}
+
+	var audits = make([]*models.MintAudit, 0)
+	var err = impl.db.Table(models.TableNameMintAudit).
+		Where("iot_id = ?", req.IotId).
+		Order("created_at desc").
+		Offset(req.Skip).
+		Limit(req.Limit).
+		Find(&audits).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Mint audit", err)
+	}
+	return audits, nil
+}
+
+// Verified metrics of flow & power sensors in (from, to] by sensor, and
+// sha256 of metric ids
+func (impl *OracleRepo) getInputs(tx *gorm.DB, iotId int64, from, to time.Time,
+) (models.OracleInputs, string, error) {
+	var query = func() *gorm.DB {
+		return tx.Table(models.TableNameSm+" AS sm").
+			Joins("JOIN "+models.TableNameSensors+" AS s ON s.id = sm.sensor_id").
+			Where("sm.iot_id = ? AND sm.sign_id <> ''", iotId).
+			Where("s.type IN ?", []dmodels.SensorType{dmodels.SensorTypeFlow, dmodels.SensorTypePower}).
+			Where("sm.created_at > ? AND sm.created_at <= ?", from, to)
+	}
+
+	var inputs = make(models.OracleInputs, 0)
+	var err = query().
+		Select("sm.sensor_id, COUNT(*) as count, SUM(CAST (sm.indicator ->> 'value' as float)) as sum").
+		Group("sm.sensor_id").
+		Order("sm.sensor_id asc").
+		Find(&inputs).Error
+	if nil != err {
+		return nil, "", dmodels.ParsePostgresError("Sensor metric", err)
+	}
+
+	var ids = make([]string, 0)
+	err = query().Order("sm.id asc").Pluck("sm.id", &ids).Error
+	if nil != err {
+		return nil, "", dmodels.ParsePostgresError("Sensor metric", err)
+	}
+
+	var digest = sha256.Sum256([]byte(strings.Join(ids, "\n")))
+	return inputs, hex.EncodeToString(digest[:]), nil
+}
+
+func (impl *OracleRepo) getRate(iotType models.IOTType) float64 {
+	if v, ok := impl.rates[iotType]; ok {
+		return v
+	}
+	return impl.defRate
+}
+
+func (impl *OracleRepo) loadRate(iotType models.IOTType) {
+	var rate = floatEnv(fmt.Sprintf("ORACLE_RATE_%d", iotType), 0)
+	if rate > 0 {
+		impl.rates[iotType] = rate
+	}
+}
+
+// Rate may be fractional (carbon per metric unit)
+func floatEnv(key string, def float64) float64 {
+	var raw = utils.StringEnv(key, "")
+	if raw == "" {
+		return def
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if nil != err {
+		log.Printf("Invalid %s: %s (use %v)\n", key, raw, def)
+		return def
+	}
+	return v
+}
@@ -0,0 +1,83 @@
+package repo
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dcarbon/go-shared/libs/utils"
+	"github.com/Dcarbon/iott-cloud/internal/domain"
+	"github.com/Dcarbon/iott-cloud/internal/models"
+	"github.com/Dcarbon/iott-cloud/internal/signer"
+)
+
+var oracleRepoTest *OracleRepo
+
+func init() {
+	s, err := signer.NewLocalSigner("0123456789012345678901234567890123456789012345678901234567890123")
+	if nil != err {
+		panic(err.Error())
+	}
+
+	oracleRepoTest, err = NewOracleRepo(signDomainRepoTest, s)
+	if nil != err {
+		panic(err.Error())
+	}
+}
+
+func TestOracleMint(t *testing.T) {
+	_, err := oracleRepoTest.SetEnable(&domain.RIotOracleEnable{IotId: 292, Enable: true})
+	utils.PanicError("TestOracleMint enable", err)
+
+	audit, err := oracleRepoTest.Mint(292)
+	utils.PanicError("TestOracleMint", err)
+	utils.Dump("Audit", audit)
+
+	_, err = oracleRepoTest.SetEnable(&domain.RIotOracleEnable{IotId: 292, Enable: false})
+	utils.PanicError("TestOracleMint disable", err)
+}
+
+// Metrics were signed by device (while oracle is disabled) must not be
+// counted again by oracle
+func TestOracleMintAfterDeviceMint(t *testing.T) {
+	_, err := oracleRepoTest.SetEnable(&domain.RIotOracleEnable{IotId: 292, Enable: false})
+	utils.PanicError("TestOracleMintAfterDeviceMint disable", err)
+
+	iot, err := iotRepoTest.GetIot(292)
+	utils.PanicError("TestOracleMintAfterDeviceMint get iot", err)
+
+	var addr = strings.ToLower(string(iot.Address))
+	latest, err := oracleRepoTest.getLatestSign(oracleRepoTest.db, addr, iot.DomainId)
+	utils.PanicError("TestOracleMintAfterDeviceMint latest", err)
+
+	var device = &models.MintSign{
+		IotId:     iot.ID,
+		Iot:       addr,
+		Nonce:     latest.Nonce + 1,
+		Amount:    latest.Amount,
+		DomainId:  iot.DomainId,
+		State:     models.MintStatePending,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	err = oracleRepoTest.db.Table(models.TableNameMintSign).Create(device).Error
+	utils.PanicError("TestOracleMintAfterDeviceMint device mint", err)
+
+	_, err = oracleRepoTest.SetEnable(&domain.RIotOracleEnable{IotId: 292, Enable: true})
+	utils.PanicError("TestOracleMintAfterDeviceMint enable", err)
+
+	audit, err := oracleRepoTest.Mint(292)
+	utils.PanicError("TestOracleMintAfterDeviceMint", err)
+	if nil != audit && audit.From.Before(device.CreatedAt) {
+		t.Fatalf("Window %s must start after device mint %s", audit.From, device.CreatedAt)
+	}
+
+	_, err = oracleRepoTest.SetEnable(&domain.RIotOracleEnable{IotId: 292, Enable: false})
+	utils.PanicError("TestOracleMintAfterDeviceMint disable", err)
+}
+
+func TestOracleGetAudits(t *testing.T) {
+	audits, err := oracleRepoTest.GetAudits(&domain.RMintAuditGetList{IotId: 292})
+	utils.PanicError("TestOracleGetAudits", err)
+	utils.Dump("Audits", audits)
+}
@@ -0,0 +1,253 @@
+package repo
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/go-shared/edef"
+	"github.com/Dcarbon/go-shared/libs/ievent"
+	"github.com/Dcarbon/go-shared/libs/utils"
+	"github.com/Dcarbon/iott-cloud/internal/domain"
+	"github.com/Dcarbon/iott-cloud/internal/events"
+	"github.com/Dcarbon/iott-cloud/internal/models"
+	"github.com/Dcarbon/iott-cloud/internal/rss"
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	outboxBatch = 100             // Max events per poll
+	outboxLease = 2 * time.Minute // Claimed event is hidden from other relays
+)
+
+// Write event in transaction (tx) of state change. Data must be payload of
+// current version of event (see events.Catalogue)
+func writeOutbox(tx *gorm.DB, event string, projectId int64, data interface{},
+) error {
+	var version = events.Version(event)
+	if version == 0 {
+		return dmodels.ErrInternal(errors.New("event is not in catalogue: " + event))
+	}
+
+	raw, err := json.Marshal(data)
+	if nil != err {
+		return dmodels.ErrInternal(err)
+	}
+
+	var now = time.Now()
+	var evt = &models.OutboxEvent{
+		ID:            uuid.NewV4().String(),
+		Event:         event,
+		Version:       version,
+		ProjectId:     projectId,
+		Payload:       string(raw),
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+	err = tx.Table(models.TableNameOutbox).Create(evt).Error
+	if nil != err {
+		return dmodels.ParsePostgresError("Outbox", err)
+	}
+	return nil
+}
+
+// Publish outbox events to rabbitmq & webhooks. Event is marked as published
+// only after all sinks accepted it, so consumer may receive duplicated event
+// (use envelope id for idempotency). Failed event is retried with exponential
+// backoff (so it doesn't block events behind it) and is dead after
+// maxAttempts. Order of events is not guaranteed
+type OutboxRelay struct {
+	db          *gorm.DB
+	pusher      ievent.IPublisher
+	iotPusher   *edef.IOTEvent  // Legacy iot events
+	webhook     domain.IWebhook //
+	period      time.Duration   // Poll period
+	retention   time.Duration   // Keep published events for
+	backoff     time.Duration   // Delay of first retry
+	maxAttempts int             //
+}
+
+func NewOutboxRelay(pusher ievent.IPublisher, webhook domain.IWebhook,
+) (*OutboxRelay, error) {
+	var db = rss.GetDB()
+	var err = db.AutoMigrate(&models.OutboxEvent{})
+	if nil != err {
+		return nil, err
+	}
+
+	var relay = &OutboxRelay{
+		db:          db,
+		pusher:      pusher,
+		iotPusher:   edef.NewIOTEvent(pusher),
+		webhook:     webhook,
+		period:      time.Duration(utils.Int64Env("OUTBOX_POLL_PERIOD", 2)) * time.Second,
+		retention:   time.Duration(utils.Int64Env("OUTBOX_RETENTION_DAYS", 7)) * 24 * time.Hour,
+		backoff:     time.Duration(utils.Int64Env("OUTBOX_BACKOFF", 5)) * time.Second,
+		maxAttempts: int(utils.Int64Env("OUTBOX_MAX_ATTEMPTS", 12)),
+	}
+	return relay, nil
+}
+
+func (relay *OutboxRelay) Run() {
+	var ticker = time.NewTicker(relay.period)
+	defer ticker.Stop()
+
+	var purgeTicker = time.NewTicker(time.Hour)
+	defer purgeTicker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			for {
+				n, err := relay.Process()
+				if nil != err {
+					log.Println("Outbox relay process error: ", err)
+					break
+				}
+				if n < outboxBatch {
+					break
+				}
+			}
+		case <-purgeTicker.C:
+			var err = relay.Purge(time.Now().Add(-relay.retention))
+			if nil != err {
+				log.Println("Outbox relay purge error: ", err)
+			}
+		}
+	}
+}
+
+// Publish due events. Events are claimed (leased) in a short transaction and
+// published after commit, so no row lock is held while sinks are called.
+// Return num of events was handled
+func (relay *OutboxRelay) Process() (int, error) {
+	evts, err := relay.claim(time.Now())
+	if nil != err {
+		return 0, err
+	}
+
+	for _, evt := range evts {
+		relay.finish(evt, relay.publish(evt))
+	}
+	return len(evts), nil
+}
+
+// Lock & lease due events so concurrent relays don't publish them twice
+func (relay *OutboxRelay) claim(now time.Time) ([]*models.OutboxEvent, error) {
+	var evts = make([]*models.OutboxEvent, 0)
+	var err = relay.db.Transaction(func(tx *gorm.DB) error {
+		var err = tx.Table(models.TableNameOutbox).
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("published_at IS NULL AND dead_at IS NULL AND next_attempt_at <= ?", now).
+			Order("next_attempt_at asc, created_at asc").
+			Limit(outboxBatch).
+			Find(&evts).Error
+		if nil != err || len(evts) == 0 {
+			return err
+		}
+
+		var ids = make([]string, len(evts))
+		for i, evt := range evts {
+			ids[i] = evt.ID
+		}
+		return tx.Table(models.TableNameOutbox).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(outboxLease)).Error
+	})
+	if nil != err {
+		return nil, dmodels.ErrInternal(err)
+	}
+	return evts, nil
+}
+
+// Save result of publish
+func (relay *OutboxRelay) finish(evt *models.OutboxEvent, err error) {
+	var now = time.Now()
+	var updates = map[string]interface{}{
+		"attempts":   evt.Attempts + 1,
+		"last_error": "",
+	}
+
+	if nil == err {
+		updates["published_at"] = now
+	} else {
+		log.Printf("Publish outbox event %s (%s) error: %s\n", evt.ID, evt.Event, err)
+		updates["last_error"] = err.Error()
+		if evt.Attempts+1 >= relay.maxAttempts {
+			updates["dead_at"] = now
+			log.Printf("Outbox event %s (%s) is dead after %d attempts\n", evt.ID, evt.Event, evt.Attempts+1)
+		} else {
+			updates["next_attempt_at"] = now.Add(models.WebhookBackoff(evt.Attempts+1, relay.backoff))
+		}
+	}
+
+	var e = relay.db.Table(models.TableNameOutbox).
+		Where("id = ?", evt.ID).
+		Updates(updates).Error
+	if nil != e {
+		log.Println("Save outbox event error: ", evt.ID, e)
+	}
+}
+
+// Remove published events was created before
+func (relay *OutboxRelay) Purge(before time.Time) error {
+	var err = relay.db.Table(models.TableNameOutbox).
+		Where("published_at IS NOT NULL AND created_at < ?", before).
+		Delete(&models.OutboxEvent{}).Error
+	if nil != err {
+		return dmodels.ErrInternal(err)
+	}
+	return nil
+}
+
+func (relay *OutboxRelay) publish(evt *models.OutboxEvent) error {
+	var envelope = evt.Envelope()
+
+	var err = relay.pusher.Push(evt.Event, envelope)
+	if nil != err {
+		return err
+	}
+
+	err = relay.publishLegacy(evt)
+	if nil != err {
+		return err
+	}
+
+	if relay.webhook != nil {
+		return relay.webhook.Dispatch(envelope)
+	}
+	return nil
+}
+
+// Keep old iot events for existing consumers
+func (relay *OutboxRelay) publishLegacy(evt *models.OutboxEvent) error {
+	if evt.Event != models.EventIotCreated && evt.Event != models.EventIotStatusChanged {
+		return nil
+	}
+
+	var iot = &events.IotV1{}
+	var err = json.Unmarshal([]byte(evt.Payload), iot)
+	if nil != err {
+		return err
+	}
+
+	if evt.Event == models.EventIotCreated {
+		var create = &edef.EventIOTCreate{
+			ID:      iot.Id,
+			Status:  iot.Status,
+			Address: iot.Address,
+		}
+		if nil != iot.Position {
+			create.Location = &edef.GPS{Lng: iot.Position.Lng, Lat: iot.Position.Lat}
+		}
+		return relay.iotPusher.PushIOTCreate(create)
+	}
+	return relay.iotPusher.PushIOTChangeStatus(&edef.EventIOTChangeStatus{
+		ID:     iot.Id,
+		Status: iot.Status,
+	})
+}
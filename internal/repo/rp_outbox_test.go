@@ -0,0 +1,75 @@
+package repo
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Dcarbon/go-shared/libs/utils"
+	"github.com/Dcarbon/iott-cloud/internal/models"
+	"github.com/Dcarbon/iott-cloud/internal/rss"
+)
+
+type pusherTest struct {
+	events map[string]*models.EventEnvelope
+}
+
+func (p *pusherTest) Push(key string, data interface{}) error {
+	if evt, ok := data.(*models.EventEnvelope); ok {
+		p.events[evt.Id] = evt
+	}
+	return nil
+}
+
+type pusherFail struct{}
+
+func (p *pusherFail) Push(key string, data interface{}) error {
+	return errors.New("broker is down")
+}
+
+func TestOutboxRelay(t *testing.T) {
+	var pusher = &pusherTest{events: make(map[string]*models.EventEnvelope)}
+	relay, err := NewOutboxRelay(pusher, nil)
+	utils.PanicError("", err)
+
+	err = writeOutbox(rss.GetDB(), models.EventProjectCreated, 1, &models.Project{ID: 1})
+	utils.PanicError("", err)
+
+	_, err = relay.Process()
+	utils.PanicError("", err)
+
+	var pending int64
+	err = rss.GetDB().Table(models.TableNameOutbox).
+		Where("published_at IS NULL").
+		Count(&pending).Error
+	utils.PanicError("", err)
+	if pending != 0 {
+		t.Fatalf("Expect all outbox events were published but %d pending", pending)
+	}
+	utils.Dump("Published", pusher.events)
+}
+
+func TestOutboxRelayDead(t *testing.T) {
+	relay, err := NewOutboxRelay(&pusherFail{}, nil)
+	utils.PanicError("", err)
+	relay.maxAttempts = 2
+	relay.backoff = 0
+
+	err = writeOutbox(rss.GetDB(), models.EventProjectCreated, 1, &models.Project{ID: 1})
+	utils.PanicError("", err)
+
+	for i := 0; i < relay.maxAttempts; i++ {
+		_, err = relay.Process()
+		utils.PanicError("", err)
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	var pending int64
+	err = rss.GetDB().Table(models.TableNameOutbox).
+		Where("published_at IS NULL AND dead_at IS NULL").
+		Count(&pending).Error
+	utils.PanicError("", err)
+	if pending != 0 {
+		t.Fatalf("Expect failed events were dead but %d pending", pending)
+	}
+}
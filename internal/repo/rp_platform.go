@@ -0,0 +1,212 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/go-shared/libs/utils"
+	"github.com/Dcarbon/iott-cloud/internal/domain"
+	"github.com/Dcarbon/iott-cloud/internal/models"
+	"github.com/Dcarbon/iott-cloud/internal/rss"
+	"gorm.io/gorm"
+)
+
+// Materialized summaries of platform. Each view has an unique index (refresh
+// concurrently doesn't block readers)
+const (
+	mvPlatformSummary  = "mv_platform_summary"
+	mvPlatformIotTypes = "mv_platform_iot_types"
+	mvMintedDaily      = "mv_minted_daily"
+)
+
+var platformViews = []string{mvPlatformSummary, mvPlatformIotTypes, mvMintedDaily}
+
+type PlatformRepo struct {
+	db     *gorm.DB
+	period time.Duration // Refresh period
+	leader *leaderLock   // Only one replica refreshes
+}
+
+func NewPlatformRepo() (*PlatformRepo, error) {
+	var db = rss.GetDB()
+	sqlDB, err := db.DB()
+	if nil != err {
+		return nil, err
+	}
+
+	var impl = &PlatformRepo{
+		db:     db,
+		period: time.Duration(utils.Int64Env("PLATFORM_REFRESH_PERIOD", 300)) * time.Second,
+		leader: newLeaderLock(sqlDB, "platform-refresh"),
+	}
+
+	err = impl.migrate()
+	if nil != err {
+		return nil, err
+	}
+	return impl, nil
+}
+
+func (impl *PlatformRepo) migrate() error {
+	for _, stm := range []string{
+		`CREATE MATERIALIZED VIEW IF NOT EXISTS ` + mvPlatformSummary + ` AS
+		SELECT
+			1 AS id,
+			(SELECT COUNT(*) FROM ` + models.TableNameProject + `) AS projects,
+			(SELECT COUNT(*) FROM ` + models.TableNameIOT + `) AS iots,
+			(SELECT COUNT(*) FROM ` + models.TableNameIOT + ` WHERE status = ` +
+			fmt.Sprint(int32(dmodels.DeviceStatusSuccess)) + `) AS active_iots,
+			(SELECT COUNT(*) FROM ` + models.TableNameSensors + `) AS sensors,
+			(SELECT COALESCE(SUM(carbon), 0) FROM ` + models.TableNameMinted + `) AS total_minted,
+			(SELECT COUNT(*) FROM ` + models.TableNameSm + `
+				WHERE created_at >= now() - interval '24 hours') AS metrics24h,
+			now() AS refreshed_at`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_` + mvPlatformSummary + `
+			ON ` + mvPlatformSummary + ` (id)`,
+
+		`CREATE MATERIALIZED VIEW IF NOT EXISTS ` + mvPlatformIotTypes + ` AS
+		SELECT type, COUNT(*) AS count
+		FROM ` + models.TableNameIOT + `
+		GROUP BY type`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_` + mvPlatformIotTypes + `
+			ON ` + mvPlatformIotTypes + ` (type)`,
+
+		// Minted of iot by day (UTC)
+		`CREATE MATERIALIZED VIEW IF NOT EXISTS ` + mvMintedDaily + ` AS
+		SELECT
+			(m.created_at AT TIME ZONE 'UTC')::date AS day,
+			m.iot_id,
+			i.project,
+			SUM(m.carbon) AS carbon
+		FROM ` + models.TableNameMinted + ` AS m
+		JOIN ` + models.TableNameIOT + ` AS i ON i.id = m.iot_id
+		GROUP BY 1, 2, 3`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_` + mvMintedDaily + `
+			ON ` + mvMintedDaily + ` (day, iot_id, project)`,
+	} {
+		var err = impl.db.Exec(stm).Error
+		if nil != err {
+			return dmodels.ParsePostgresError("Platform summary", err)
+		}
+	}
+	return nil
+}
+
+// Refresh summaries periodically (leader replica only)
+func (impl *PlatformRepo) Run() {
+	var ticker = time.NewTicker(impl.period)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if !impl.leader.IsLeader(context.Background()) {
+			continue
+		}
+
+		var err = impl.Refresh()
+		if nil != err {
+			log.Println("Refresh platform summary error: ", err)
+		}
+	}
+}
+
+func (impl *PlatformRepo) Refresh() error {
+	for _, view := range platformViews {
+		var err = impl.db.Exec(`REFRESH MATERIALIZED VIEW CONCURRENTLY ` + view).Error
+		if nil != err {
+			return dmodels.ParsePostgresError("Platform summary", err)
+		}
+	}
+	return nil
+}
+
+func (impl *PlatformRepo) GetStats() (*domain.RsPlatformStats, error) {
+	var summary = &struct {
+		Projects    int64
+		Iots        int64
+		ActiveIots  int64
+		Sensors     int64
+		TotalMinted int64
+		Metrics24h  int64
+		RefreshedAt time.Time
+	}{}
+	var err = impl.db.Table(mvPlatformSummary).Take(summary).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Platform summary", err)
+	}
+
+	var types = make([]*struct {
+		Type  models.IOTType
+		Count int64
+	}, 0)
+	err = impl.db.Table(mvPlatformIotTypes).Scan(&types).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Platform summary", err)
+	}
+
+	var stats = &domain.RsPlatformStats{
+		Projects:    summary.Projects,
+		Iots:        summary.Iots,
+		ActiveIots:  summary.ActiveIots,
+		IotsByType:  make(map[models.IOTType]int64, len(types)),
+		Sensors:     summary.Sensors,
+		TotalMinted: summary.TotalMinted,
+		Metrics24h:  summary.Metrics24h,
+		RefreshedAt: summary.RefreshedAt.Unix(),
+	}
+	for _, it := range types {
+		stats.IotsByType[it.Type] = it.Count
+	}
+	return stats, nil
+}
+
+// Top projects / iots by minted carbon in period
+func (impl *PlatformRepo) GetLeaderboard(req *domain.RLeaderboard,
+) (*domain.RsLeaderboard, error) {
+	var err = req.Normalize()
+	if nil != err {
+		return nil, err
+	}
+
+	var rs = &domain.RsLeaderboard{
+		Kind:   req.Kind,
+		Period: req.Period,
+		Items:  make([]*domain.LeaderboardItem, 0, req.Limit),
+	}
+
+	var query = impl.db.Table(mvMintedDaily + " AS d")
+	if days := req.Period.Days(); days > 0 {
+		var from = time.Now().UTC().AddDate(0, 0, 1-days).Format("2006-01-02")
+		query = query.Where("d.day >= ?", from)
+		rs.From = from
+	}
+
+	if req.Kind == domain.LeaderboardKindIot {
+		query = query.
+			Joins("JOIN " + models.TableNameIOT + " AS i ON i.id = d.iot_id").
+			Select("d.iot_id AS id, d.project, i.address, SUM(d.carbon) AS carbon").
+			Group("d.iot_id, d.project, i.address")
+	} else {
+		query = query.
+			Select("d.project AS id, SUM(d.carbon) AS carbon").
+			Group("d.project")
+	}
+
+	err = query.Order("carbon DESC, id ASC").Limit(req.Limit).Scan(&rs.Items).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Leaderboard", err)
+	}
+	for i, it := range rs.Items {
+		it.Rank = i + 1
+	}
+
+	err = impl.db.Table(mvPlatformSummary).
+		Select("extract(epoch FROM refreshed_at)::bigint").
+		Scan(&rs.RefreshedAt).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Platform summary", err)
+	}
+	return rs, nil
+}
@@ -0,0 +1,37 @@
+package repo
+
+import (
+	"testing"
+
+	"github.com/Dcarbon/go-shared/libs/utils"
+	"github.com/Dcarbon/iott-cloud/internal/domain"
+)
+
+func TestPlatformStats(t *testing.T) {
+	platformRepo, err := NewPlatformRepo()
+	utils.PanicError("TestPlatformStats", err)
+
+	err = platformRepo.Refresh()
+	utils.PanicError("TestPlatformRefresh", err)
+
+	stats, err := platformRepo.GetStats()
+	utils.PanicError("TestPlatformStats", err)
+	utils.Dump("Stats", stats)
+}
+
+func TestPlatformLeaderboard(t *testing.T) {
+	platformRepo, err := NewPlatformRepo()
+	utils.PanicError("TestPlatformLeaderboard", err)
+
+	for _, kind := range []domain.LeaderboardKind{
+		domain.LeaderboardKindProject,
+		domain.LeaderboardKindIot,
+	} {
+		board, err := platformRepo.GetLeaderboard(&domain.RLeaderboard{
+			Kind:   kind,
+			Period: domain.LeaderboardPeriod7d,
+		})
+		utils.PanicError("TestPlatformLeaderboard", err)
+		utils.Dump("Leaderboard "+string(kind), board)
+	}
+}
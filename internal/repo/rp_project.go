@@ -1,10 +1,9 @@
 package repo
 
 import (
-	"time"
-
 	"github.com/Dcarbon/go-shared/dmodels"
 	"github.com/Dcarbon/iott-cloud/internal/domain"
+	"github.com/Dcarbon/iott-cloud/internal/events"
 	"github.com/Dcarbon/iott-cloud/internal/models"
 	"github.com/Dcarbon/iott-cloud/internal/rss"
 	"gorm.io/gorm"
@@ -12,7 +11,8 @@ import (
 )
 
 type projectRepo struct {
-	db *gorm.DB
+	db   *gorm.DB
+	trgm bool // Trigram search (pg_trgm) is available
 }
 
 func NewProjectRepo() (domain.IProject, error) {
@@ -22,6 +22,7 @@ func NewProjectRepo() (domain.IProject, error) {
 		&models.ProjectImage{},
 		&models.ProjectDescription{},
 		&models.ProjectSpecs{},
+		&models.OutboxEvent{},
 	)
 	if nil != err {
 		return nil, err
@@ -30,6 +31,16 @@ func NewProjectRepo() (domain.IProject, error) {
 	var pp = &projectRepo{
 		db: db,
 	}
+
+	err = pp.migrateSearch()
+	if nil != err {
+		return nil, err
+	}
+
+	err = pp.migrateImages()
+	if nil != err {
+		return nil, err
+	}
 	return pp, nil
 }
 
@@ -43,7 +54,7 @@ func (pRepo *projectRepo) Create(req *domain.RProjectCreate,
 			return dmodels.ParsePostgresError("Create project", err)
 		}
 
-		return nil
+		return writeOutbox(dbTx, models.EventProjectCreated, project.ID, events.NewProjectV1(project))
 	})
 
 	if nil != e1 {
@@ -57,17 +68,27 @@ func (pRepo *projectRepo) UpdateDesc(req *domain.RProjectUpdateDesc,
 ) (*models.ProjectDescription, error) {
 	var desc = req.ToProjectDesc()
 
-	var err = pRepo.tblProjectDesc().
-		Clauses(
-			clause.OnConflict{
-				Columns:   []clause.Column{{Name: "project_id"}, {Name: "language"}},
-				UpdateAll: true,
-			},
-			clause.Insert{},
-		).
-		Create(desc).Error
+	var err = pRepo.db.Transaction(func(dbTx *gorm.DB) error {
+		var err = dbTx.Table(models.TableNameProjectDesc).
+			Clauses(
+				clause.OnConflict{
+					Columns:   []clause.Column{{Name: "project_id"}, {Name: "language"}},
+					UpdateAll: true,
+				},
+				clause.Insert{},
+			).
+			Create(desc).Error
+		if nil != err {
+			return dmodels.ParsePostgresError("Update project desc", err)
+		}
+		return writeOutbox(dbTx, models.EventProjectUpdated, desc.ProjectID, &events.ProjectUpdatedV1{
+			Id:       desc.ProjectID,
+			Field:    "desc",
+			Language: desc.Language,
+		})
+	})
 	if nil != err {
-		return nil, dmodels.ParsePostgresError("Update project desc", err)
+		return nil, err
 	}
 	return desc, nil
 }
@@ -76,30 +97,36 @@ func (pRepo *projectRepo) UpdateSpecs(req *domain.RProjectUpdateSpecs,
 ) (*models.ProjectSpecs, error) {
 	var spec = req.ToProjectSpecs()
 
-	var err = pRepo.tblProjectSpec().
-		Clauses(
-			clause.OnConflict{
-				Columns:   []clause.Column{{Name: "project_id"}},
-				DoUpdates: clause.AssignmentColumns([]string{"specs", "updated_at"}),
-			},
-		).Create(spec).Error
+	var err = pRepo.db.Transaction(func(dbTx *gorm.DB) error {
+		var err = dbTx.Table(models.TableNameProjectSpecs).
+			Clauses(
+				clause.OnConflict{
+					Columns:   []clause.Column{{Name: "project_id"}},
+					DoUpdates: clause.AssignmentColumns([]string{"specs", "updated_at"}),
+				},
+			).Create(spec).Error
+		if nil != err {
+			return dmodels.ParsePostgresError("Update project desc", err)
+		}
+		return writeOutbox(dbTx, models.EventProjectUpdated, spec.ProjectID, &events.ProjectUpdatedV1{
+			Id:    spec.ProjectID,
+			Field: "specs",
+			Specs: spec.Specs,
+		})
+	})
 	if nil != err {
-		return nil, dmodels.ParsePostgresError("Update project desc", err)
+		return nil, err
 	}
 	return spec, nil
 }
 
-func (pRepo *projectRepo) GetById(id int64, lang string) (*models.Project, error) {
+func (pRepo *projectRepo) GetById(id int64, langs ...string) (*models.Project, error) {
 	var project = &models.Project{}
 	var query = pRepo.tblProject().Where("id = ?", id).
-		Preload("Images", func(tx *gorm.DB) *gorm.DB {
-			return tx.Select("project_id, image")
-		}).
+		Preload("Images", orderImages).
 		Preload("Specs")
-	if lang != "" {
-		query.Preload("Descs", func(tx *gorm.DB) *gorm.DB {
-			return tx.Where("language = ?", lang)
-		})
+	if len(langs) > 0 {
+		query = query.Preload("Descs")
 	}
 
 	var err = query.First(project).Error
@@ -107,26 +134,23 @@ func (pRepo *projectRepo) GetById(id int64, lang string) (*models.Project, error
 		return nil, dmodels.ParsePostgresError("Project", err)
 	}
 
+	if len(langs) > 0 {
+		selectDescs(project, langs)
+	}
 	return project, nil
 }
 
 func (pRepo *projectRepo) GetList(filter *domain.RProjectFilter,
-) ([]*models.Project, error) {
-	var tbl = pRepo.tblProject().Offset(filter.Skip)
-	if filter.Limit > 0 {
-		tbl = tbl.Limit(filter.Limit)
-	}
-
+) ([]*models.Project, *domain.PageInfo, error) {
+	var tbl = pRepo.tblProject()
 	if filter.Owner != "" {
 		tbl = tbl.Where("owner = ?", filter.Owner)
 	}
 
-	var data = make([]*models.Project, 0)
-	var err = tbl.Find(&data).Error
-	if nil != err {
-		return nil, dmodels.ParsePostgresError("Project", err)
-	}
-	return data, nil
+	return paginate(
+		tbl, "Project", "", &keyset{id: "id", idKind: keyInt}, filter.Cursor, filter.Skip, filter.Limit,
+		func(p *models.Project) (interface{}, interface{}) { return nil, p.ID },
+	)
 }
 
 func (pRepo *projectRepo) GetByBB(min, max *models.Point4326, owner string,
@@ -151,11 +175,23 @@ func (pRepo *projectRepo) GetByID(id int64) (*models.Project, error) {
 
 func (pRepo *projectRepo) ChangeStatus(id string, status models.ProjectStatus,
 ) error {
-	var err = pRepo.tblProject().
-		Where("id = ?", id).
-		Update("status", status).
-		Error
-	return dmodels.ParsePostgresError("Project", err)
+	return pRepo.db.Transaction(func(dbTx *gorm.DB) error {
+		var project = &models.Project{}
+		var rs = dbTx.Table(models.TableNameProject).
+			Model(project).
+			Clauses(clause.Returning{}).
+			Where("id = ?", id).
+			Update("status", status)
+		if nil != rs.Error {
+			return dmodels.ParsePostgresError("Project", rs.Error)
+		}
+		if rs.RowsAffected == 0 {
+			return dmodels.ErrNotFound("Project")
+		}
+		return writeOutbox(
+			dbTx, models.EventProjectStatusChanged, project.ID, events.NewProjectV1(project),
+		)
+	})
 }
 
 func (pRepo *projectRepo) GetOwner(projectId int64) (string, error) {
@@ -169,21 +205,6 @@ func (pRepo *projectRepo) GetOwner(projectId int64) (string, error) {
 	return owner, nil
 }
 
-func (pRepo *projectRepo) AddImage(req *domain.RProjectAddImage,
-) (*models.ProjectImage, error) {
-	var img = &models.ProjectImage{
-		ID:        0,
-		ProjectID: req.ProjectID,
-		Image:     req.ImgPath,
-		CreatedAt: time.Now(),
-	}
-	var err = pRepo.tblImage().Create(img).Error
-	if nil != err {
-		return nil, dmodels.ParsePostgresError("AddImage ", err)
-	}
-	return img, nil
-}
-
 func (pRepo *projectRepo) tblProject() *gorm.DB {
 	return pRepo.db.Table(models.TableNameProject)
 }
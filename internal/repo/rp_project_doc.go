@@ -0,0 +1,330 @@
+package repo
+
+import (
+	"time"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/iott-cloud/internal/domain"
+	"github.com/Dcarbon/iott-cloud/internal/events"
+	"github.com/Dcarbon/iott-cloud/internal/models"
+	"github.com/Dcarbon/iott-cloud/internal/rss"
+	"github.com/Dcarbon/iott-cloud/internal/signer"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Max attempts to add version when document is changed while version is signed
+const docVersionRetry = 3
+
+type ProjectDocRepo struct {
+	db     *gorm.DB
+	signer signer.Signer // Anchor chain hash of version (nil: not anchored)
+}
+
+func NewProjectDocRepo(s signer.Signer) (*ProjectDocRepo, error) {
+	var db = rss.GetDB()
+	var err = db.AutoMigrate(
+		&models.ProjectDoc{},
+		&models.ProjectDocVersion{},
+	)
+	if nil != err {
+		return nil, err
+	}
+
+	var impl = &ProjectDocRepo{
+		db:     db,
+		signer: s,
+	}
+	return impl, nil
+}
+
+// Create document with first version. Id of doc is reserved before signing
+// version, so remote signer is not called inside tx
+func (impl *ProjectDocRepo) Create(req *domain.RProjectDocCreate, file *domain.DocFile,
+) (*models.ProjectDoc, error) {
+	var err = req.Normalize()
+	if nil != err {
+		return nil, err
+	}
+
+	err = impl.db.Table(models.TableNameProject).
+		Where("id = ?", req.ProjectId).
+		Select("id").
+		First(&models.Project{}).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Project", err)
+	}
+
+	var doc = &models.ProjectDoc{
+		ProjectID: req.ProjectId,
+		Type:      req.Type,
+		Title:     req.Title,
+		Access:    req.Access,
+		CreatedBy: file.Uploader,
+	}
+	err = impl.db.
+		Raw("SELECT nextval(pg_get_serial_sequence(?, 'id'))", models.TableNameProjectDoc).
+		Scan(&doc.ID).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Project document", err)
+	}
+
+	v, err := impl.newVersion(doc, "", req.Note, file)
+	if nil != err {
+		return nil, err
+	}
+
+	err = impl.db.Transaction(func(dbTx *gorm.DB) error {
+		var err = dbTx.Table(models.TableNameProjectDoc).Create(doc).Error
+		if nil != err {
+			return dmodels.ParsePostgresError("Project document", err)
+		}
+		return impl.saveVersion(dbTx, doc, v)
+	})
+	if nil != err {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// Append version to document. Version is signed without lock, then it is
+// saved if no other version was added meanwhile (else it is signed again)
+func (impl *ProjectDocRepo) AddVersion(req *domain.RProjectDocVersion, file *domain.DocFile,
+) (*models.ProjectDoc, error) {
+	for i := 0; i < docVersionRetry; i++ {
+		var doc = &models.ProjectDoc{}
+		var err = impl.tblDoc().
+			Where("id = ? AND project_id = ?", req.DocId, req.ProjectId).
+			First(doc).Error
+		if nil != err {
+			return nil, dmodels.ParsePostgresError("Project document", err)
+		}
+
+		prev, err := impl.getChainHash(doc)
+		if nil != err {
+			return nil, err
+		}
+
+		v, err := impl.newVersion(doc, prev, req.Note, file)
+		if nil != err {
+			return nil, err
+		}
+
+		var changed = false
+		err = impl.db.Transaction(func(dbTx *gorm.DB) error {
+			var locked = &models.ProjectDoc{}
+			var err = dbTx.Table(models.TableNameProjectDoc).
+				Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("id = ?", doc.ID).
+				First(locked).Error
+			if nil != err {
+				return dmodels.ParsePostgresError("Project document", err)
+			}
+			if locked.Version != doc.Version {
+				changed = true
+				return nil
+			}
+			*doc = *locked
+			return impl.saveVersion(dbTx, doc, v)
+		})
+		if nil != err {
+			return nil, err
+		}
+		if !changed {
+			return doc, nil
+		}
+	}
+	return nil, dmodels.ErrBadRequest("Project document is being updated, try again")
+}
+
+func (impl *ProjectDocRepo) Update(req *domain.RProjectDocUpdate,
+) (*models.ProjectDoc, error) {
+	if req.Access != "" && !req.Access.IsValid() {
+		return nil, dmodels.ErrBadRequest("Invalid access: " + string(req.Access))
+	}
+
+	var updates = map[string]interface{}{"updated_at": time.Now()}
+	if req.Title != "" {
+		updates["title"] = req.Title
+	}
+	if req.Access != "" {
+		updates["access"] = req.Access
+	}
+
+	var doc = &models.ProjectDoc{}
+	var rs = impl.tblDoc().
+		Model(doc).
+		Clauses(clause.Returning{}).
+		Where("id = ? AND project_id = ?", req.DocId, req.ProjectId).
+		Updates(updates)
+	if nil != rs.Error {
+		return nil, dmodels.ParsePostgresError("Project document", rs.Error)
+	}
+	if rs.RowsAffected == 0 {
+		return nil, dmodels.ErrNotFound("Project document")
+	}
+	return doc, nil
+}
+
+func (impl *ProjectDocRepo) GetList(req *domain.RProjectDocGetList,
+) ([]*models.ProjectDoc, error) {
+	var query = impl.tblDoc().Where("project_id = ?", req.ProjectId)
+	if req.Type != "" {
+		query = query.Where("type = ?", req.Type)
+	}
+	if !req.Private {
+		query = query.Where("access = ?", models.ProjectDocPublic)
+	}
+
+	var docs = make([]*models.ProjectDoc, 0)
+	var err = query.Order("id ASC").Find(&docs).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Project document", err)
+	}
+	if len(docs) == 0 {
+		return docs, nil
+	}
+
+	var ids = make([]int64, len(docs))
+	for i, doc := range docs {
+		ids[i] = doc.ID
+	}
+
+	var latest = make([]*models.ProjectDocVersion, 0, len(docs))
+	err = impl.tblVersion().
+		Where("(doc_id, version) IN (?)",
+			impl.tblDoc().Where("id IN ?", ids).Select("id, version"),
+		).
+		Find(&latest).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Project document", err)
+	}
+
+	var byDoc = make(map[int64]*models.ProjectDocVersion, len(latest))
+	for _, v := range latest {
+		byDoc[v.DocID] = v
+	}
+	for _, doc := range docs {
+		doc.Latest = byDoc[doc.ID]
+	}
+	return docs, nil
+}
+
+func (impl *ProjectDocRepo) GetById(projectId, docId int64,
+) (*models.ProjectDoc, error) {
+	var doc = &models.ProjectDoc{}
+	var err = impl.tblDoc().
+		Where("id = ? AND project_id = ?", docId, projectId).
+		Preload("Versions", func(tx *gorm.DB) *gorm.DB {
+			return tx.Order("version DESC")
+		}).
+		First(doc).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Project document", err)
+	}
+	if len(doc.Versions) > 0 {
+		doc.Latest = doc.Versions[0]
+	}
+	return doc, nil
+}
+
+func (impl *ProjectDocRepo) GetVersion(projectId, docId int64, version int,
+) (*models.ProjectDoc, *models.ProjectDocVersion, error) {
+	var doc = &models.ProjectDoc{}
+	var err = impl.tblDoc().
+		Where("id = ? AND project_id = ?", docId, projectId).
+		First(doc).Error
+	if nil != err {
+		return nil, nil, dmodels.ParsePostgresError("Project document", err)
+	}
+
+	if version <= 0 {
+		version = doc.Version
+	}
+
+	var v = &models.ProjectDocVersion{}
+	err = impl.tblVersion().
+		Where("doc_id = ? AND version = ?", docId, version).
+		First(v).Error
+	if nil != err {
+		return nil, nil, dmodels.ParsePostgresError("Project document version", err)
+	}
+	return doc, v, nil
+}
+
+// Chain hash of latest version of doc ("" if doc has no version)
+func (impl *ProjectDocRepo) getChainHash(doc *models.ProjectDoc) (string, error) {
+	var prev = ""
+	if doc.Version == 0 {
+		return prev, nil
+	}
+	var err = impl.tblVersion().
+		Where("doc_id = ? AND version = ?", doc.ID, doc.Version).
+		Select("chain_hash").
+		Scan(&prev).Error
+	if nil != err {
+		return "", dmodels.ParsePostgresError("Project document version", err)
+	}
+	return prev, nil
+}
+
+// Build next version of doc and sign its chain hash (no db access)
+func (impl *ProjectDocRepo) newVersion(doc *models.ProjectDoc, prev, note string,
+	file *domain.DocFile,
+) (*models.ProjectDocVersion, error) {
+	var v = &models.ProjectDocVersion{
+		DocID:     doc.ID,
+		Version:   doc.Version + 1,
+		Path:      file.Path,
+		FileName:  file.FileName,
+		Mime:      file.Mime,
+		Size:      file.Size,
+		Sha256:    file.Sha256,
+		Note:      note,
+		Uploader:  file.Uploader,
+		CreatedAt: time.Now(),
+	}
+	v.ChainHash = domain.DocChainHash(prev, doc.ProjectID, doc.ID, v.Version, v.Sha256)
+
+	if impl.signer != nil {
+		signed, err := signer.SignText(impl.signer, []byte(domain.DocSignPrefix+v.ChainHash))
+		if nil != err {
+			return nil, dmodels.ErrInternal(err)
+		}
+		v.Signer = impl.signer.Address()
+		v.Signature = hexutil.Encode(signed)
+	}
+	return v, nil
+}
+
+// Save version v to doc (doc is locked or was created in tx)
+func (impl *ProjectDocRepo) saveVersion(tx *gorm.DB, doc *models.ProjectDoc,
+	v *models.ProjectDocVersion,
+) error {
+	var err = tx.Table(models.TableNameProjectDocV).Create(v).Error
+	if nil != err {
+		return dmodels.ParsePostgresError("Project document version", err)
+	}
+
+	doc.Version = v.Version
+	doc.UpdatedAt = v.CreatedAt
+	doc.Latest = v
+	err = tx.Table(models.TableNameProjectDoc).
+		Where("id = ?", doc.ID).
+		Updates(map[string]interface{}{"version": doc.Version, "updated_at": v.CreatedAt}).Error
+	if nil != err {
+		return dmodels.ParsePostgresError("Project document", err)
+	}
+	return writeOutbox(
+		tx, models.EventProjectDocVersion, doc.ProjectID, events.NewProjectDocVersionV1(doc, v),
+	)
+}
+
+func (impl *ProjectDocRepo) tblDoc() *gorm.DB {
+	return impl.db.Table(models.TableNameProjectDoc)
+}
+
+func (impl *ProjectDocRepo) tblVersion() *gorm.DB {
+	return impl.db.Table(models.TableNameProjectDocV)
+}
@@ -0,0 +1,58 @@
+package repo
+
+import (
+	"testing"
+
+	"github.com/Dcarbon/go-shared/libs/utils"
+	"github.com/Dcarbon/iott-cloud/internal/domain"
+	"github.com/Dcarbon/iott-cloud/internal/models"
+)
+
+func TestProjectDoc(t *testing.T) {
+	docRepo, err := NewProjectDocRepo(nil)
+	utils.PanicError("TestProjectDoc", err)
+
+	var file = func(hash string) *domain.DocFile {
+		return &domain.DocFile{
+			Path:     "/private/projects/1/" + hash + ".pdf",
+			FileName: "pdd.pdf",
+			Mime:     "application/pdf",
+			Size:     1024,
+			Sha256:   hash,
+			Uploader: adminAddr,
+		}
+	}
+
+	doc, err := docRepo.Create(&domain.RProjectDocCreate{
+		ProjectId: 1,
+		Type:      models.ProjectDocPDD,
+		Title:     "Project design document",
+	}, file("aa"))
+	utils.PanicError("TestProjectDocCreate", err)
+
+	_, err = docRepo.AddVersion(&domain.RProjectDocVersion{
+		ProjectId: 1,
+		DocId:     doc.ID,
+		Note:      "Revised baseline",
+	}, file("bb"))
+	utils.PanicError("TestProjectDocAddVersion", err)
+
+	_, err = docRepo.Update(&domain.RProjectDocUpdate{
+		ProjectId: 1,
+		DocId:     doc.ID,
+		Access:    models.ProjectDocPublic,
+	})
+	utils.PanicError("TestProjectDocUpdate", err)
+
+	docs, err := docRepo.GetList(&domain.RProjectDocGetList{ProjectId: 1})
+	utils.PanicError("TestProjectDocGetList", err)
+	utils.Dump("Docs", docs)
+
+	doc, err = docRepo.GetById(1, doc.ID)
+	utils.PanicError("TestProjectDocGetById", err)
+	utils.Dump("Verify", domain.VerifyDocVersions(doc))
+
+	_, v, err := docRepo.GetVersion(1, doc.ID, 1)
+	utils.PanicError("TestProjectDocGetVersion", err)
+	utils.Dump("Version", v)
+}
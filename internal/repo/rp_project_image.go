@@ -0,0 +1,254 @@
+package repo
+
+import (
+	"time"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/iott-cloud/internal/domain"
+	"github.com/Dcarbon/iott-cloud/internal/events"
+	"github.com/Dcarbon/iott-cloud/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// One cover image per project
+func (pRepo *projectRepo) migrateImages() error {
+	var err = pRepo.db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_project_image_cover
+		ON ` + models.TableNameProjectImage + ` (project_id) WHERE cover`,
+	).Error
+	if nil != err {
+		return dmodels.ParsePostgresError("Project image", err)
+	}
+	return nil
+}
+
+// Image is appended to images of project. First image of project is cover
+func (pRepo *projectRepo) AddImage(req *domain.RProjectAddImage,
+) (*models.ProjectImage, error) {
+	var img = &models.ProjectImage{
+		ID:        0,
+		ProjectID: req.ProjectID,
+		Image:     req.ImgPath,
+		Thumbs:    req.Thumbs,
+		Mime:      req.Mime,
+		Width:     req.Width,
+		Height:    req.Height,
+		Size:      req.Size,
+		Cover:     req.Cover,
+		CreatedAt: time.Now(),
+	}
+	var err = pRepo.db.Transaction(func(dbTx *gorm.DB) error {
+		var err = lockProject(dbTx, req.ProjectID)
+		if nil != err {
+			return err
+		}
+
+		var last = &struct {
+			Count    int64
+			Position int
+		}{}
+		err = dbTx.Table(models.TableNameProjectImage).
+			Where("project_id = ?", req.ProjectID).
+			Select("COUNT(*) AS count, COALESCE(MAX(position), -1) AS position").
+			Scan(last).Error
+		if nil != err {
+			return dmodels.ParsePostgresError("AddImage ", err)
+		}
+
+		img.Position = last.Position + 1
+		if last.Count == 0 {
+			img.Cover = true
+		}
+		if img.Cover {
+			err = unsetCover(dbTx, req.ProjectID)
+			if nil != err {
+				return err
+			}
+		}
+
+		err = dbTx.Table(models.TableNameProjectImage).Create(img).Error
+		if nil != err {
+			return dmodels.ParsePostgresError("AddImage ", err)
+		}
+		return writeOutbox(
+			dbTx, models.EventProjectImageAdded, img.ProjectID, events.NewProjectImageV1(img),
+		)
+	})
+	if nil != err {
+		return nil, err
+	}
+	return img, nil
+}
+
+func (pRepo *projectRepo) GetImages(projectId int64,
+) ([]*models.ProjectImage, error) {
+	var imgs = make([]*models.ProjectImage, 0)
+	var err = orderImages(pRepo.tblImage().Where("project_id = ?", projectId)).
+		Find(&imgs).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Project image", err)
+	}
+	return imgs, nil
+}
+
+// Next image (by position) becomes cover if deleted image is cover
+func (pRepo *projectRepo) DeleteImage(projectId, imageId int64,
+) (*models.ProjectImage, error) {
+	var img = &models.ProjectImage{}
+	var err = pRepo.db.Transaction(func(dbTx *gorm.DB) error {
+		var err = lockProject(dbTx, projectId)
+		if nil != err {
+			return err
+		}
+
+		err = dbTx.Table(models.TableNameProjectImage).
+			Where("id = ? AND project_id = ?", imageId, projectId).
+			First(img).Error
+		if nil != err {
+			return dmodels.ParsePostgresError("Project image", err)
+		}
+
+		err = dbTx.Table(models.TableNameProjectImage).
+			Where("id = ?", imageId).
+			Delete(&models.ProjectImage{}).Error
+		if nil != err {
+			return dmodels.ParsePostgresError("Project image", err)
+		}
+
+		if img.Cover {
+			err = dbTx.Exec(`
+				UPDATE `+models.TableNameProjectImage+` SET cover = true
+				WHERE id = (
+					SELECT id FROM `+models.TableNameProjectImage+`
+					WHERE project_id = ?
+					ORDER BY position ASC, id ASC
+					LIMIT 1
+				)`,
+				projectId,
+			).Error
+			if nil != err {
+				return dmodels.ParsePostgresError("Project image", err)
+			}
+		}
+		return writeOutbox(
+			dbTx, models.EventProjectImageRemoved, projectId, events.NewProjectImageV1(img),
+		)
+	})
+	if nil != err {
+		return nil, err
+	}
+	return img, nil
+}
+
+func (pRepo *projectRepo) SetCoverImage(projectId, imageId int64,
+) (*models.ProjectImage, error) {
+	var img = &models.ProjectImage{}
+	var err = pRepo.db.Transaction(func(dbTx *gorm.DB) error {
+		var err = lockProject(dbTx, projectId)
+		if nil != err {
+			return err
+		}
+
+		err = dbTx.Table(models.TableNameProjectImage).
+			Where("id = ? AND project_id = ?", imageId, projectId).
+			First(img).Error
+		if nil != err {
+			return dmodels.ParsePostgresError("Project image", err)
+		}
+		if img.Cover {
+			return nil
+		}
+
+		err = unsetCover(dbTx, projectId)
+		if nil != err {
+			return err
+		}
+
+		img.Cover = true
+		err = dbTx.Table(models.TableNameProjectImage).
+			Where("id = ?", imageId).
+			Update("cover", true).Error
+		if nil != err {
+			return dmodels.ParsePostgresError("Project image", err)
+		}
+		return nil
+	})
+	if nil != err {
+		return nil, err
+	}
+	return img, nil
+}
+
+// Ids must be all images of project (each once)
+func (pRepo *projectRepo) ReorderImages(req *domain.RProjectReorderImages,
+) ([]*models.ProjectImage, error) {
+	var err = pRepo.db.Transaction(func(dbTx *gorm.DB) error {
+		var err = lockProject(dbTx, req.ProjectId)
+		if nil != err {
+			return err
+		}
+
+		var ids = make([]int64, 0)
+		err = dbTx.Table(models.TableNameProjectImage).
+			Where("project_id = ?", req.ProjectId).
+			Pluck("id", &ids).Error
+		if nil != err {
+			return dmodels.ParsePostgresError("Project image", err)
+		}
+
+		var remain = make(map[int64]bool, len(ids))
+		for _, id := range ids {
+			remain[id] = true
+		}
+		for _, id := range req.Ids {
+			if !remain[id] {
+				return dmodels.ErrBadRequest("Image is not in project or is duplicated")
+			}
+			delete(remain, id)
+		}
+		if len(remain) > 0 {
+			return dmodels.ErrBadRequest("Ids must contain all images of project")
+		}
+
+		for position, id := range req.Ids {
+			err = dbTx.Table(models.TableNameProjectImage).
+				Where("id = ?", id).
+				Update("position", position).Error
+			if nil != err {
+				return dmodels.ParsePostgresError("Project image", err)
+			}
+		}
+		return nil
+	})
+	if nil != err {
+		return nil, err
+	}
+	return pRepo.GetImages(req.ProjectId)
+}
+
+// Serialize image changes of project (position, cover)
+func lockProject(tx *gorm.DB, projectId int64) error {
+	var err = tx.Table(models.TableNameProject).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", projectId).
+		Select("id").
+		First(&models.Project{}).Error
+	if nil != err {
+		return dmodels.ParsePostgresError("Project", err)
+	}
+	return nil
+}
+
+func unsetCover(tx *gorm.DB, projectId int64) error {
+	var err = tx.Table(models.TableNameProjectImage).
+		Where("project_id = ? AND cover", projectId).
+		Update("cover", false).Error
+	if nil != err {
+		return dmodels.ParsePostgresError("Project image", err)
+	}
+	return nil
+}
+
+func orderImages(tx *gorm.DB) *gorm.DB {
+	return tx.Order("position ASC, id ASC")
+}
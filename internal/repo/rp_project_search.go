@@ -0,0 +1,214 @@
+package repo
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/iott-cloud/internal/domain"
+	"github.com/Dcarbon/iott-cloud/internal/models"
+	"gorm.io/gorm"
+)
+
+// Text search configuration of language (postgres built-in). Language has no
+// configuration (vi, ...) use simple and trigram
+var tsConfigs = map[string]string{
+	"da": "danish",
+	"de": "german",
+	"en": "english",
+	"es": "spanish",
+	"fi": "finnish",
+	"fr": "french",
+	"hu": "hungarian",
+	"it": "italian",
+	"nl": "dutch",
+	"no": "norwegian",
+	"pt": "portuguese",
+	"ro": "romanian",
+	"ru": "russian",
+	"sv": "swedish",
+	"tr": "turkish",
+}
+
+// Primary subtag of language column. Ex: vi-VN => vi
+const sqlLangOf = `lower(split_part(replace(%s, '_', '-'), '-', 1))`
+
+// Min word similarity of trigram match (pg_trgm.word_similarity_threshold of
+// search tx, `<%` operator uses trigram index)
+const trgmThreshold = "0.4"
+
+// Regconfig of language column (immutable, is used by generated column)
+func tsConfigOf(column string) string {
+	var langs = make([]string, 0, len(tsConfigs))
+	for lang := range tsConfigs {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+
+	var sb = &strings.Builder{}
+	sb.WriteString("CASE " + fmt.Sprintf(sqlLangOf, column))
+	for _, lang := range langs {
+		sb.WriteString(" WHEN '" + lang + "' THEN '" + tsConfigs[lang] + "'::regconfig")
+	}
+	sb.WriteString(" ELSE 'simple'::regconfig END")
+	return sb.String()
+}
+
+// Search vector of description (generated column) and trigram indexes. Trigram
+// is disabled if pg_trgm can't be created (search by ILIKE)
+func (pRepo *projectRepo) migrateSearch() error {
+	var err = pRepo.db.Exec(`
+		ALTER TABLE ` + models.TableNameProjectDesc + `
+		ADD COLUMN IF NOT EXISTS search tsvector
+		GENERATED ALWAYS AS (
+			to_tsvector(` + tsConfigOf("language") + `, coalesce(name, '') || ' ' || coalesce("desc", ''))
+		) STORED`,
+	).Error
+	if nil != err {
+		return dmodels.ParsePostgresError("Project search", err)
+	}
+
+	err = pRepo.db.Exec(`CREATE INDEX IF NOT EXISTS idx_project_desc_search
+		ON ` + models.TableNameProjectDesc + ` USING GIN (search)`,
+	).Error
+	if nil != err {
+		return dmodels.ParsePostgresError("Project search", err)
+	}
+
+	err = pRepo.db.Exec(`CREATE EXTENSION IF NOT EXISTS pg_trgm`).Error
+	if nil != err {
+		log.Println("Create extension pg_trgm error (trigram search is disabled): ", err)
+		return nil
+	}
+
+	for _, stm := range []string{
+		`CREATE INDEX IF NOT EXISTS idx_project_desc_name_trgm
+			ON ` + models.TableNameProjectDesc + ` USING GIN (name gin_trgm_ops)`,
+		`CREATE INDEX IF NOT EXISTS idx_project_location_name_trgm
+			ON ` + models.TableNameProject + ` USING GIN (location_name gin_trgm_ops)`,
+	} {
+		err = pRepo.db.Exec(stm).Error
+		if nil != err {
+			return dmodels.ParsePostgresError("Project search", err)
+		}
+	}
+	pRepo.trgm = true
+	return nil
+}
+
+type projectScore struct {
+	Id    int64
+	Score float64
+}
+
+// Search projects by name, description (languages of chain) and location
+// name. Score: full-text rank + trigram similarity. Paged by (score, id)
+func (pRepo *projectRepo) Search(req *domain.RProjectSearch,
+) ([]*domain.ProjectHit, *domain.PageInfo, error) {
+	if len(req.Langs) == 0 {
+		req.Langs = domain.LanguageChain(req.Lang, "")
+	}
+	if req.Limit <= 0 {
+		req.Limit = 20
+	}
+
+	var args = map[string]interface{}{
+		"q":     req.Q,
+		"langs": req.Langs,
+		"like":  "%" + likeEscaper.Replace(req.Q) + "%",
+	}
+
+	var tsQuery = "websearch_to_tsquery(" + tsConfigOf("d.language") + ", @q)"
+	var score = "coalesce(ts_rank(d.search, " + tsQuery + "), 0)"
+	var cond = "d.search @@ " + tsQuery +
+		" OR to_tsvector('simple', coalesce(p.location_name, '')) @@ plainto_tsquery('simple', @q)"
+	if pRepo.trgm {
+		score += " + greatest(coalesce(word_similarity(@q, d.name), 0), word_similarity(@q, coalesce(p.location_name, '')))"
+		cond += " OR @q <% d.name OR @q <% p.location_name"
+	} else {
+		score += " + CASE WHEN d.name ILIKE @like OR p.location_name ILIKE @like THEN 0.1 ELSE 0 END"
+		cond += " OR d.name ILIKE @like OR p.location_name ILIKE @like"
+	}
+
+	var hits []*projectScore
+	var page *domain.PageInfo
+	var err = pRepo.db.Transaction(func(tx *gorm.DB) error {
+		if pRepo.trgm {
+			var err = tx.Exec(
+				"SELECT set_config('pg_trgm.word_similarity_threshold', ?, true)", trgmThreshold,
+			).Error
+			if nil != err {
+				return dmodels.ParsePostgresError("Project search", err)
+			}
+		}
+
+		var scores = tx.Raw(`
+			SELECT p.id, max(`+score+`)::float8 AS score
+			FROM `+models.TableNameProject+` AS p
+			LEFT JOIN `+models.TableNameProjectDesc+` AS d
+				ON d.project_id = p.id AND `+fmt.Sprintf(sqlLangOf, "d.language")+` IN @langs
+			WHERE `+cond+`
+			GROUP BY p.id`,
+			args,
+		)
+
+		var ks = &keyset{column: "score", kind: keyFloat, id: "id", idKind: keyInt, desc: true}
+		var err error
+		hits, page, err = paginate(
+			tx.Table("(?) AS hits", scores), "Project search", "", ks,
+			req.Cursor, req.Skip, req.Limit,
+			func(h *projectScore) (interface{}, interface{}) { return h.Score, h.Id },
+		)
+		return err
+	})
+	if nil != err {
+		return nil, nil, err
+	}
+	if len(hits) == 0 {
+		return []*domain.ProjectHit{}, page, nil
+	}
+
+	var ids = make([]int64, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.Id
+	}
+
+	var projects = make([]*models.Project, 0, len(ids))
+	err = pRepo.tblProject().Where("id IN ?", ids).
+		Preload("Descs").
+		Preload("Images", orderImages).
+		Find(&projects).Error
+	if nil != err {
+		return nil, nil, dmodels.ParsePostgresError("Project", err)
+	}
+
+	var byId = make(map[int64]*models.Project, len(projects))
+	for _, p := range projects {
+		selectDescs(p, req.Langs)
+		byId[p.ID] = p
+	}
+
+	var rs = make([]*domain.ProjectHit, 0, len(hits))
+	for _, hit := range hits {
+		if p, ok := byId[hit.Id]; ok {
+			rs = append(rs, &domain.ProjectHit{Project: p, Score: hit.Score})
+		}
+	}
+	return rs, page, nil
+}
+
+// Keep only description of first language of chain has description (or
+// first description if chain has no description)
+func selectDescs(p *models.Project, chain []string) {
+	if len(p.Descs) == 0 {
+		return
+	}
+
+	var desc = domain.SelectDescription(p.Descs, chain)
+	if desc == nil {
+		desc = p.Descs[0]
+	}
+	p.Descs = []*models.ProjectDescription{desc}
+}
@@ -0,0 +1,264 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/go-shared/libs/utils"
+	"github.com/Dcarbon/iott-cloud/internal/domain"
+	"github.com/Dcarbon/iott-cloud/internal/models"
+	"github.com/Dcarbon/iott-cloud/internal/rss"
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+)
+
+const (
+	keyProjectStats        = "project_stats:%d:%d:%d:%d:%s:%s:%s:%s" // project, version, from, to, bucket, func, tz, fill
+	keyProjectStatsVersion = "project_stats_ver:%d"                  // project
+)
+
+// Minted joined project of iot
+var aggSourceProjectMinted = &aggSource{
+	table: "(SELECT m.created_at, m.carbon, i.project FROM " + models.TableNameMinted +
+		" AS m JOIN " + models.TableNameIOT + " AS i ON i.id = m.iot_id) AS pm",
+	seriesCol: "project",
+	valueExpr: "carbon",
+}
+
+type ProjectStatsRepo struct {
+	db      *gorm.DB
+	redis   *redis.Client
+	ttl     time.Duration // Cache ttl
+	maxDays int64         // Max period of stats
+}
+
+func NewProjectStatsRepo() (*ProjectStatsRepo, error) {
+	var impl = &ProjectStatsRepo{
+		db:      rss.GetDB(),
+		redis:   rss.GetRedis(),
+		ttl:     time.Duration(utils.Int64Env("PROJECT_STATS_TTL", 300)) * time.Second,
+		maxDays: utils.Int64Env("PROJECT_STATS_MAX_DAYS", 366),
+	}
+	return impl, nil
+}
+
+// Stats of project. Result is cached until ttl or new mint of project (see
+// invalidateProjectStats)
+func (impl *ProjectStatsRepo) GetStats(req *domain.RProjectStats,
+) (*domain.RsProjectStats, error) {
+	if req.To == 0 {
+		req.To = time.Now().Truncate(time.Hour).Add(time.Hour).Unix()
+	}
+	if req.From == 0 {
+		req.From = req.To - 30*86400
+	}
+	if req.To <= req.From {
+		return nil, dmodels.ErrBadRequest("Invalid period (to must be greater than from)")
+	}
+	if req.To-req.From > impl.maxDays*86400 {
+		return nil, dmodels.ErrBadRequest("Period of stats is too long")
+	}
+
+	loc, err := req.Normalize(domain.MintedDefaultTimezone)
+	if nil != err {
+		return nil, err
+	}
+
+	var ctx = context.TODO()
+	version, err := impl.redis.Get(ctx, fmt.Sprintf(keyProjectStatsVersion, req.ProjectId)).Int64()
+	if nil != err && err != redis.Nil {
+		log.Println("Get project stats version error: ", err)
+	}
+
+	var key = fmt.Sprintf(keyProjectStats,
+		req.ProjectId, version, req.From, req.To, req.Bucket, req.Func, req.Timezone, req.Fill,
+	)
+	raw, err := impl.redis.Get(ctx, key).Bytes()
+	if nil == err {
+		var cached = &domain.RsProjectStats{}
+		if json.Unmarshal(raw, cached) == nil {
+			return cached, nil
+		}
+	}
+
+	stats, err := impl.compute(req, loc)
+	if nil != err {
+		return nil, err
+	}
+
+	raw, _ = json.Marshal(stats)
+	err = impl.redis.Set(ctx, key, raw, impl.ttl).Err()
+	if nil != err {
+		log.Println("Cache project stats error: ", err)
+	}
+	return stats, nil
+}
+
+func (impl *ProjectStatsRepo) compute(req *domain.RProjectStats, loc *time.Location,
+) (*domain.RsProjectStats, error) {
+	var err = impl.db.Table(models.TableNameProject).
+		Where("id = ?", req.ProjectId).
+		Select("id").
+		First(&models.Project{}).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Project", err)
+	}
+
+	var from, to = time.Unix(req.From, 0), time.Unix(req.To, 0)
+	var stats = &domain.RsProjectStats{
+		ProjectId:     req.ProjectId,
+		From:          req.From,
+		To:            req.To,
+		LatestMetrics: make([]*domain.ProjectLatestMetric, 0),
+		ComputedAt:    time.Now().Unix(),
+	}
+
+	stats.Devices, err = impl.getDevices(req.ProjectId)
+	if nil != err {
+		return nil, err
+	}
+
+	err = impl.db.Raw(`
+		SELECT COALESCE(SUM(m.carbon), 0)
+		FROM `+models.TableNameMinted+` AS m
+		JOIN `+models.TableNameIOT+` AS i ON i.id = m.iot_id
+		WHERE i.project = ?`,
+		req.ProjectId,
+	).Scan(&stats.TotalMinted).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Minted", err)
+	}
+
+	// Days are counted in timezone of request
+	var period = &struct {
+		Carbon  int64
+		Days    int64
+		IotDays int64
+	}{}
+	err = impl.db.Raw(`
+		SELECT
+			COALESCE(SUM(m.carbon), 0) AS carbon,
+			COUNT(DISTINCT date_trunc('day', m.created_at AT TIME ZONE ?)) AS days,
+			COUNT(DISTINCT (m.iot_id, date_trunc('day', m.created_at AT TIME ZONE ?))) AS iot_days
+		FROM `+models.TableNameMinted+` AS m
+		JOIN `+models.TableNameIOT+` AS i ON i.id = m.iot_id
+		WHERE i.project = ? AND m.created_at >= ? AND m.created_at < ?`,
+		req.Timezone, req.Timezone, req.ProjectId, from, to,
+	).Scan(period).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Minted", err)
+	}
+
+	stats.PeriodMinted = period.Carbon
+	stats.ActiveDays = period.Days
+	var days = math.Ceil(float64(req.To-req.From) / 86400)
+	if success := stats.Devices.ByStatus[dmodels.DeviceStatusSuccess]; success > 0 {
+		stats.ActiveRatio = math.Min(float64(period.IotDays)/(float64(success)*days), 1)
+	}
+
+	stats.LatestMetrics, err = impl.getLatestMetrics(req.ProjectId, to)
+	if nil != err {
+		return nil, err
+	}
+
+	series, err := aggSourceProjectMinted.aggregate(
+		impl.db, &req.AggOption, loc, from, to, []int64{req.ProjectId},
+	)
+	if nil != err {
+		return nil, err
+	}
+	stats.Minted = series[0]
+	return stats, nil
+}
+
+func (impl *ProjectStatsRepo) getDevices(projectId int64,
+) (*domain.ProjectDeviceStats, error) {
+	var rows = make([]*struct {
+		Status dmodels.DeviceStatus
+		Type   models.IOTType
+		Count  int64
+	}, 0)
+	var err = impl.db.Table(models.TableNameIOT).
+		Where("project = ?", projectId).
+		Select("status, type, COUNT(*) AS count").
+		Group("status, type").
+		Scan(&rows).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("IOT", err)
+	}
+
+	var devices = &domain.ProjectDeviceStats{
+		ByStatus: make(map[dmodels.DeviceStatus]int64),
+		ByType:   make(map[models.IOTType]int64),
+	}
+	for _, row := range rows {
+		devices.Total += row.Count
+		devices.ByStatus[row.Status] += row.Count
+		devices.ByType[row.Type] += row.Count
+	}
+	return devices, nil
+}
+
+// Latest metric (before to) of each sensor type
+func (impl *ProjectStatsRepo) getLatestMetrics(projectId int64, to time.Time,
+) ([]*domain.ProjectLatestMetric, error) {
+	var rows = make([]*struct {
+		SensorType dmodels.SensorType
+		SensorId   int64
+		IotId      int64
+		Indicator  string
+		CreatedAt  time.Time
+	}, 0)
+	var err = impl.db.Raw(`
+		SELECT DISTINCT ON (s.type)
+			s.type AS sensor_type, m.sensor_id, m.iot_id, m.indicator, m.created_at
+		FROM `+models.TableNameSensors+` AS s
+		JOIN `+models.TableNameIOT+` AS i ON i.id = s.iot_id
+		CROSS JOIN LATERAL (
+			SELECT sensor_id, iot_id, indicator, created_at
+			FROM `+models.TableNameSm+`
+			WHERE sensor_id = s.id AND created_at < ?
+			ORDER BY created_at DESC
+			LIMIT 1
+		) AS m
+		WHERE i.project = ?
+		ORDER BY s.type, m.created_at DESC`,
+		to, projectId,
+	).Scan(&rows).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Sensor metric", err)
+	}
+
+	var rs = make([]*domain.ProjectLatestMetric, 0, len(rows))
+	for _, row := range rows {
+		var metric = &dmodels.AllMetric{}
+		err = json.Unmarshal([]byte(row.Indicator), metric)
+		if nil != err {
+			log.Println("Unmarshal metric of sensor ", row.SensorId, " error: ", err)
+			continue
+		}
+		rs = append(rs, &domain.ProjectLatestMetric{
+			SensorType: row.SensorType,
+			SensorId:   row.SensorId,
+			IotId:      row.IotId,
+			Metric:     metric,
+			CreatedAt:  row.CreatedAt.Unix(),
+		})
+	}
+	return rs, nil
+}
+
+// Drop cached stats of project (called after new mint of project is committed)
+func invalidateProjectStats(projectId int64) {
+	var err = rss.GetRedis().Incr(
+		context.TODO(), fmt.Sprintf(keyProjectStatsVersion, projectId),
+	).Err()
+	if nil != err {
+		log.Println("Invalidate project stats error: ", err)
+	}
+}
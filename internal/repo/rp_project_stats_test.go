@@ -0,0 +1,30 @@
+package repo
+
+import (
+	"testing"
+
+	"github.com/Dcarbon/go-shared/libs/utils"
+	"github.com/Dcarbon/iott-cloud/internal/domain"
+)
+
+func TestProjectStats(t *testing.T) {
+	statsRepo, err := NewProjectStatsRepo()
+	utils.PanicError("TestProjectStats", err)
+
+	var req = &domain.RProjectStats{ProjectId: 1}
+	stats, err := statsRepo.GetStats(req)
+	utils.PanicError("TestProjectStats", err)
+	utils.Dump("Stats", stats)
+
+	// Cached until new mint of project
+	cached, err := statsRepo.GetStats(req)
+	utils.PanicError("TestProjectStats", err)
+	if cached.ComputedAt != stats.ComputedAt {
+		t.Fatalf("stats must be cached")
+	}
+
+	invalidateProjectStats(1)
+	stats, err = statsRepo.GetStats(req)
+	utils.PanicError("TestProjectStats", err)
+	utils.Dump("Stats after invalidate", stats.ComputedAt)
+}
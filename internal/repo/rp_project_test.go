@@ -60,11 +60,70 @@ func TestProjectUpdateSpec(t *testing.T) {
 }
 
 func TestProjectGetByID(t *testing.T) {
-	var rs, err = pRepoTest.GetById(1, "")
+	var rs, err = pRepoTest.GetById(1, domain.LanguageChain("", "fr-CH, fr;q=0.9")...)
 	utils.PanicError("TestProjectGetByID", err)
 	utils.Dump("TestProjectGetByID", rs)
 }
 
+func TestProjectSearch(t *testing.T) {
+	var rs, page, err = pRepoTest.Search(&domain.RProjectSearch{
+		Q:     "biogas",
+		Langs: domain.LanguageChain("", "vi-VN"),
+		Limit: 5,
+	})
+	utils.PanicError("TestProjectSearch", err)
+	utils.Dump("TestProjectSearch", rs)
+
+	if page.Next != "" {
+		next, _, err := pRepoTest.Search(&domain.RProjectSearch{
+			Q:      "biogas",
+			Langs:  domain.LanguageChain("", "vi-VN"),
+			Limit:  5,
+			Cursor: page.Next,
+		})
+		utils.PanicError("TestProjectSearch next", err)
+		for _, hit := range next {
+			for _, prev := range rs {
+				if hit.ID == prev.ID {
+					t.Fatalf("Project %d is in both pages", hit.ID)
+				}
+			}
+		}
+	}
+}
+
+func TestProjectImages(t *testing.T) {
+	var add = func(path string) *models.ProjectImage {
+		img, err := pRepoTest.AddImage(&domain.RProjectAddImage{
+			ProjectID: 1,
+			ImgPath:   path,
+			Thumbs:    map[string]string{"sm": path + "_sm"},
+		})
+		utils.PanicError("TestProjectAddImage", err)
+		return img
+	}
+	var a, b = add("/static/projects/1/a.png"), add("/static/projects/1/b.png")
+
+	_, err := pRepoTest.SetCoverImage(1, b.ID)
+	utils.PanicError("TestProjectSetCoverImage", err)
+
+	imgs, err := pRepoTest.GetImages(1)
+	utils.PanicError("TestProjectGetImages", err)
+
+	var ids = make([]int64, 0, len(imgs))
+	for i := len(imgs) - 1; i >= 0; i-- {
+		ids = append(ids, imgs[i].ID)
+	}
+	imgs, err = pRepoTest.ReorderImages(&domain.RProjectReorderImages{ProjectId: 1, Ids: ids})
+	utils.PanicError("TestProjectReorderImages", err)
+	utils.Dump("Images", imgs)
+
+	_, err = pRepoTest.DeleteImage(1, b.ID)
+	utils.PanicError("TestProjectDeleteImage", err)
+	_, err = pRepoTest.DeleteImage(1, a.ID)
+	utils.PanicError("TestProjectDeleteImage", err)
+}
+
 func TestProjectGetList(t *testing.T) {
 
 }
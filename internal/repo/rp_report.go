@@ -0,0 +1,172 @@
+package repo
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/go-shared/libs/utils"
+	"github.com/Dcarbon/iott-cloud/internal/domain"
+	"github.com/Dcarbon/iott-cloud/internal/models"
+	"github.com/Dcarbon/iott-cloud/internal/report"
+	"github.com/Dcarbon/iott-cloud/internal/rss"
+	"gorm.io/gorm"
+)
+
+// Report is built in memory: size is bounded by period & num of metrics
+type ReportRepo struct {
+	db         *gorm.DB
+	maxDays    int64 // Max period of report
+	maxMetrics int64 // Max num of metric signatures of report
+}
+
+func NewReportRepo() (*ReportRepo, error) {
+	var impl = &ReportRepo{
+		db:         rss.GetDB(),
+		maxDays:    utils.Int64Env("REPORT_MAX_DAYS", 31),
+		maxMetrics: utils.Int64Env("REPORT_MAX_METRICS", 200000),
+	}
+	return impl, nil
+}
+
+func (impl *ReportRepo) Generate(req *domain.RReport) (*report.Report, error) {
+	if req.Format == "" {
+		req.Format = report.FormatJSON
+	}
+	if !req.Format.IsValid() {
+		return nil, dmodels.ErrBadRequest(report.ErrInvalidFormat.Error())
+	}
+	if req.To <= req.From {
+		return nil, dmodels.ErrBadRequest("Invalid period (to must be greater than from)")
+	}
+	if req.To-req.From > impl.maxDays*86400 {
+		return nil, dmodels.ErrBadRequest("Period of report is too long")
+	}
+
+	var rp = &report.Report{
+		Project:     &models.Project{},
+		From:        time.Unix(req.From, 0),
+		To:          time.Unix(req.To, 0),
+		GeneratedAt: time.Now(),
+		Domains:     make([]*models.SignDomain, 0),
+		Iots:        make([]*models.IOTDevice, 0),
+		Sensors:     make([]*models.Sensor, 0),
+		Metrics:     make([]*models.SmSignature, 0),
+		MintSigns:   make([]*models.MintSign, 0),
+		MintBase:    make([]*models.MintSign, 0),
+		Minted:      make([]*models.Minted, 0),
+	}
+
+	var err = impl.db.Table(models.TableNameProject).
+		Where("id = ?", req.ProjectId).
+		First(rp.Project).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Project", err)
+	}
+
+	err = impl.db.Table(models.TableNameIOT).
+		Where("project = ?", req.ProjectId).
+		Order("id asc").
+		Find(&rp.Iots).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("IOT", err)
+	}
+
+	var iotIds = make([]int64, len(rp.Iots))
+	var domainIds = make(map[int64]bool)
+	for i, iot := range rp.Iots {
+		iotIds[i] = iot.ID
+		domainIds[iot.DomainId] = true
+	}
+
+	if len(iotIds) > 0 {
+		err = impl.load(iotIds, rp)
+		if nil != err {
+			return nil, err
+		}
+	}
+
+	for _, m := range append(rp.MintSigns, rp.MintBase...) {
+		domainIds[m.DomainId] = true
+	}
+	var ids = make([]int64, 0, len(domainIds))
+	for id := range domainIds {
+		ids = append(ids, id)
+	}
+	err = impl.db.Table(models.TableNameSignDomain).
+		Where("id IN ?", ids).
+		Order("id asc").
+		Find(&rp.Domains).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Sign domain", err)
+	}
+
+	rp.Summarize()
+	return rp, nil
+}
+
+func (impl *ReportRepo) load(iotIds []int64, rp *report.Report) error {
+	var err = impl.db.Table(models.TableNameSensors).
+		Where("iot_id IN ?", iotIds).
+		Order("id asc").
+		Find(&rp.Sensors).Error
+	if nil != err {
+		return dmodels.ParsePostgresError("Sensor", err)
+	}
+
+	var metrics = func() *gorm.DB {
+		return impl.db.Table(models.TableNameSmSignature).
+			Where("iot_id IN ? AND created_at >= ? AND created_at < ?", iotIds, rp.From, rp.To)
+	}
+
+	var count int64
+	err = metrics().Count(&count).Error
+	if nil != err {
+		return dmodels.ParsePostgresError("Metric signature", err)
+	}
+	if count > impl.maxMetrics {
+		return dmodels.ErrBadRequest(fmt.Sprintf(
+			"Report has too many metrics (%d > %d), use shorter period", count, impl.maxMetrics,
+		))
+	}
+
+	err = metrics().
+		Order("created_at asc, id asc").
+		Find(&rp.Metrics).Error
+	if nil != err {
+		return dmodels.ParsePostgresError("Metric signature", err)
+	}
+
+	// Signature may be updated in place (same nonce): it's in period of its
+	// latest update
+	err = impl.db.Table(models.TableNameMintSign).
+		Where("iot_id IN ? AND updated_at >= ? AND updated_at < ?", iotIds, rp.From, rp.To).
+		Order("iot_id asc, nonce asc").
+		Find(&rp.MintSigns).Error
+	if nil != err {
+		return dmodels.ParsePostgresError("Mint sign", err)
+	}
+
+	err = impl.db.Table(models.TableNameMintSign).
+		Where(
+			"id IN (?)",
+			impl.db.Table(models.TableNameMintSign).
+				Select("DISTINCT ON (iot_id, domain_id) id").
+				Where("iot_id IN ? AND updated_at < ?", iotIds, rp.From).
+				Order("iot_id, domain_id, nonce desc"),
+		).
+		Order("iot_id asc").
+		Find(&rp.MintBase).Error
+	if nil != err {
+		return dmodels.ParsePostgresError("Mint sign", err)
+	}
+
+	err = impl.db.Table(models.TableNameMinted).
+		Where("iot_id IN ? AND created_at >= ? AND created_at < ?", iotIds, rp.From, rp.To).
+		Order("created_at asc").
+		Find(&rp.Minted).Error
+	if nil != err {
+		return dmodels.ParsePostgresError("Minted", err)
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package repo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Dcarbon/go-shared/libs/utils"
+	"github.com/Dcarbon/iott-cloud/internal/domain"
+)
+
+func TestReportGenerate(t *testing.T) {
+	reportRepo, err := NewReportRepo()
+	utils.PanicError("TestReportGenerate", err)
+
+	rp, err := reportRepo.Generate(&domain.RReport{
+		ProjectId: 1,
+		From:      time.Now().Add(-30 * 24 * time.Hour).Unix(),
+		To:        time.Now().Unix(),
+	})
+	utils.PanicError("TestReportGenerate", err)
+	utils.Dump("Summary", rp.Summary)
+}
@@ -7,7 +7,9 @@ import (
 
 	"github.com/Dcarbon/go-shared/dmodels"
 	"github.com/Dcarbon/go-shared/ecodes"
+	"github.com/Dcarbon/go-shared/libs/utils"
 	"github.com/Dcarbon/iott-cloud/internal/domain"
+	"github.com/Dcarbon/iott-cloud/internal/events"
 	"github.com/Dcarbon/iott-cloud/internal/models"
 	"github.com/Dcarbon/iott-cloud/internal/rss"
 	uuid "github.com/satori/go.uuid"
@@ -16,8 +18,10 @@ import (
 )
 
 type SensorRepo struct {
-	db      *gorm.DB
-	opCache domain.IOperator
+	db         *gorm.DB
+	opCache    domain.IOperator
+	alerter    domain.IAlertEvaluator
+	exportDays int64 // Max period of metric export
 }
 
 func NewSensorRepo() (*SensorRepo, error) {
@@ -26,13 +30,15 @@ func NewSensorRepo() (*SensorRepo, error) {
 		&models.Sensor{},
 		&models.SmSignature{},
 		&models.Sm{},
+		&models.OutboxEvent{},
 	)
 	if nil != err {
 		return nil, err
 	}
 
 	var impl = &SensorRepo{
-		db: db,
+		db:         db,
+		exportDays: utils.Int64Env("EXPORT_MAX_DAYS", 366),
 	}
 
 	return impl, nil
@@ -42,6 +48,10 @@ func (impl *SensorRepo) SetOperatorCache(op domain.IOperator) {
 	impl.opCache = op
 }
 
+func (impl *SensorRepo) SetAlerter(alerter domain.IAlertEvaluator) {
+	impl.alerter = alerter
+}
+
 func (impl *SensorRepo) CreateSensor(req *domain.RCreateSensor,
 ) (*models.Sensor, error) {
 	var sensor = &models.Sensor{
@@ -53,9 +63,20 @@ func (impl *SensorRepo) CreateSensor(req *domain.RCreateSensor,
 		CreatedAt: time.Now(),
 	}
 
-	var err = impl.tblSensors().Create(sensor).Error
+	var err = impl.db.Transaction(func(dbTx *gorm.DB) error {
+		var err = dbTx.Table(models.TableNameSensors).Create(sensor).Error
+		if nil != err {
+			return dmodels.ParsePostgresError("Create sensor", err)
+		}
+
+		projectId, err := impl.getProjectId(dbTx, sensor.IotID)
+		if nil != err {
+			return err
+		}
+		return writeOutbox(dbTx, models.EventSensorCreated, projectId, events.NewSensorV1(sensor))
+	})
 	if nil != err {
-		return nil, dmodels.ParsePostgresError("Create sensor", err)
+		return nil, err
 	}
 
 	return sensor, nil
@@ -64,16 +85,31 @@ func (impl *SensorRepo) CreateSensor(req *domain.RCreateSensor,
 func (impl *SensorRepo) ChangeSensorStatus(req *domain.RChangeSensorStatus,
 ) (*models.Sensor, error) {
 	var sensor = &models.Sensor{}
-	var err = impl.tblSensors().
-		Model(sensor).
-		Clauses(clause.Returning{}).
-		Where("id = ?", req.ID).
-		Updates(map[string]interface{}{
-			"status": req.Status,
-		}).
-		Error
+	var err = impl.db.Transaction(func(dbTx *gorm.DB) error {
+		var rs = dbTx.Table(models.TableNameSensors).
+			Model(sensor).
+			Clauses(clause.Returning{}).
+			Where("id = ?", req.ID).
+			Updates(map[string]interface{}{
+				"status": req.Status,
+			})
+		if nil != rs.Error {
+			return dmodels.ParsePostgresError("Change sensor status", rs.Error)
+		}
+		if rs.RowsAffected == 0 {
+			return dmodels.ErrNotFound("Sensor")
+		}
+
+		projectId, err := impl.getProjectId(dbTx, sensor.IotID)
+		if nil != err {
+			return err
+		}
+		return writeOutbox(
+			dbTx, models.EventSensorStatusChanged, projectId, events.NewSensorV1(sensor),
+		)
+	})
 	if nil != err {
-		return nil, dmodels.ParsePostgresError("Change sensor status", err)
+		return nil, err
 	}
 
 	return sensor, nil
@@ -113,22 +149,16 @@ func (impl *SensorRepo) GetSensorType(req *domain.SensorID,
 }
 
 func (impl *SensorRepo) GetSensors(req *domain.RGetSensors,
-) ([]*models.Sensor, error) {
-	var sensors = make([]*models.Sensor, 0, req.Limit)
-	var query = impl.tblSensors().Offset(req.Skip)
-	if req.Limit > 0 {
-		query = query.Limit(req.Limit)
-	}
-
+) ([]*models.Sensor, *domain.PageInfo, error) {
+	var query = impl.tblSensors()
 	if req.IotId != 0 {
 		query = query.Where("iot_id = ?", req.IotId)
 	}
 
-	var err = query.Find(&sensors).Error
-	if nil != err {
-		return nil, dmodels.ParsePostgresError("Get sensors", err)
-	}
-	return sensors, nil
+	return paginate(
+		query, "Get sensors", "", &keyset{id: "id", idKind: keyInt}, req.Cursor, req.Skip, req.Limit,
+		func(s *models.Sensor) (interface{}, interface{}) { return nil, s.ID },
+	)
 }
 
 func (impl *SensorRepo) CreateSM(req *domain.RCreateSM,
@@ -212,22 +242,18 @@ func (impl *SensorRepo) CreateSensorMetric(req *domain.RCreateSensorMetric,
 		}
 	}
 
+	if impl.alerter != nil {
+		impl.alerter.OnMetric(sensor, smx.Indicator)
+	}
+
 	return signed, nil
 }
 
 func (impl *SensorRepo) GetMetrics(req *domain.RGetSM,
-) ([]*domain.Metric, error) {
-	var rs = make([]*domain.Metric, 0)
+) ([]*domain.Metric, *domain.PageInfo, error) {
 	var query = impl.db.Table(models.TableNameSmSignature + " as tblSign").
-		Offset(int(req.Skip)).
-		Limit(int(req.Limit)).
-		Select("tblSign.id, tblSign.data, tblSign.sensor_id, tblSign.iot_id, tblSign.created_at, sensors.type as sensor_type").
 		Joins("JOIN sensors ON tblSign.sensor_id = sensors.id ")
 
-	if req.Sort == domain.SortDesc {
-		query = query.Order("tblSign.created_at desc")
-	}
-
 	if req.From > 0 {
 		query = query.Where("tblSign.created_at > ?", time.Unix(req.From, 0))
 	}
@@ -242,9 +268,19 @@ func (impl *SensorRepo) GetMetrics(req *domain.RGetSM,
 		query = query.Where("tblSign.sensor_id = ?", req.SensorId)
 	}
 
-	var err = query.Find(&rs).Error
+	var ks = &keyset{
+		column: "tblSign.created_at", kind: keyTime,
+		id: "tblSign.id", idKind: keyString,
+		desc: req.Sort == domain.SortDesc,
+	}
+	rs, page, err := paginate(
+		query, "Get metrics",
+		"tblSign.id, tblSign.data, tblSign.sensor_id, tblSign.iot_id, tblSign.created_at, sensors.type as sensor_type",
+		ks, req.Cursor, int(req.Skip), int(req.Limit),
+		func(m *domain.Metric) (interface{}, interface{}) { return m.CreatedAt, m.ID },
+	)
 	if nil != err {
-		return nil, dmodels.ParsePostgresError("Get metrics", err)
+		return nil, nil, err
 	}
 
 	for i, sign := range rs {
@@ -257,12 +293,55 @@ func (impl *SensorRepo) GetMetrics(req *domain.RGetSM,
 		}
 	}
 
-	return rs, nil
+	return rs, page, nil
 }
 
+// Deprecated interval (without bucket) is served by legacy aggregate (time
+// desc, truncated in timezone of db)
 func (impl *SensorRepo) GetAggregatedMetrics(req *domain.RSMAggregate,
 ) ([]*domain.TimeValue, error) {
-	return impl.getMetricAggregate(req)
+	if req.SensorId == 0 {
+		return nil, dmodels.ErrBadRequest("Missing sensor id")
+	}
+	if req.Bucket == "" {
+		return impl.getMetricAggregate(req)
+	}
+	req.SensorIds = nil
+
+	series, err := impl.AggregateMetrics(req)
+	if nil != err {
+		return nil, err
+	}
+
+	var data = make([]*domain.TimeValue, 0)
+	for _, p := range series[0].Points {
+		var tv = &domain.TimeValue{Time: p.Time}
+		if p.Val != nil {
+			tv.Val = *p.Val
+		}
+		data = append(data, tv)
+	}
+	return data, nil
+}
+
+func (impl *SensorRepo) AggregateMetrics(req *domain.RSMAggregate,
+) ([]*domain.AggSeries, error) {
+	req.SetInterval(req.Interval)
+	loc, err := req.Normalize(domain.AggDefaultTimezone)
+	if nil != err {
+		return nil, err
+	}
+
+	var ids = req.GetSensorIds()
+	if len(ids) == 0 {
+		return nil, dmodels.ErrBadRequest("Missing sensor id")
+	}
+
+	return aggSourceSm.aggregate(
+		impl.db, &req.AggOption, loc,
+		time.Unix(req.From, 0), time.Unix(req.To, 0), ids,
+		"iot_id = ?", req.IotId,
+	)
 }
 
 func (impl *SensorRepo) GetSignedMetric(req *domain.RGetSM,
@@ -359,9 +438,15 @@ func (impl *SensorRepo) insertMetric(sensor *models.Sensor, signed *models.SmSig
 			return dmodels.ParsePostgresError("Save sensor metric signature", err)
 		}
 
-		return nil
+		projectId, err := impl.getProjectId(dbTx, sensor.IotID)
+		if nil != err {
+			return err
+		}
+		return writeOutbox(
+			dbTx, models.EventMetricAccepted, projectId, events.NewMetricV1(data, sensor.Type),
+		)
 	})
-	if nil != err {
+	if nil != e1 {
 		return nil, nil, e1
 	}
 	return data, smx, nil
@@ -421,6 +506,21 @@ func (impl *SensorRepo) getMetricAggregate(req *domain.RSMAggregate) ([]*domain.
 	return data, nil
 }
 
+// Project of iot (for event of sensor)
+func (impl *SensorRepo) getProjectId(tx *gorm.DB, iotId int64) (int64, error) {
+	var projectIds = make([]int64, 0, 1)
+	var err = tx.Table(models.TableNameIOT).
+		Where("id = ?", iotId).
+		Pluck("project", &projectIds).Error
+	if nil != err {
+		return 0, dmodels.ParsePostgresError("IOT", err)
+	}
+	if len(projectIds) == 0 {
+		return 0, nil
+	}
+	return projectIds[0], nil
+}
+
 func (impl *SensorRepo) tblSensors() *gorm.DB {
 	return impl.db.Table(models.TableNameSensors)
 }
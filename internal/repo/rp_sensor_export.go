@@ -0,0 +1,85 @@
+package repo
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/iott-cloud/internal/domain"
+	"github.com/Dcarbon/iott-cloud/internal/export"
+	"github.com/Dcarbon/iott-cloud/internal/models"
+	"gorm.io/gorm"
+)
+
+const exportBatch = 1000 // Rows of each fetch from cursor
+
+// Stream metrics of iot in [from, to) by server side cursor, fn is called for
+// each row (in order of time). Export is stopped if fn return error
+func (impl *SensorRepo) ExportMetrics(req *domain.RExportSM,
+	fn func(*export.Metric) error,
+) error {
+	if req.Format == "" {
+		req.Format = export.FormatCSV
+	}
+	if !req.Format.IsValid() {
+		return dmodels.ErrBadRequest(export.ErrInvalidFormat.Error())
+	}
+	if req.To <= req.From {
+		return dmodels.ErrBadRequest("Invalid period (to must be greater than from)")
+	}
+	if req.To-req.From > impl.exportDays*86400 {
+		return dmodels.ErrBadRequest("Period of export is too long")
+	}
+
+	var sign = "'' AS data, '' AS signed"
+	if req.WithSign {
+		sign = "COALESCE(sig.data, '') AS data, COALESCE(sig.signed, '') AS signed"
+	}
+
+	var query = `
+		DECLARE metric_export NO SCROLL CURSOR FOR
+		SELECT sm.id, sm.iot_id, sm.sensor_id, s.type AS sensor_type, sm.created_at,
+			COALESCE(CAST(sm.indicator ->> 'value' AS float), 0) AS value,
+			COALESCE(CAST(sm.indicator ->> 'lng' AS float), 0) AS lng,
+			COALESCE(CAST(sm.indicator ->> 'lat' AS float), 0) AS lat,
+			` + sign + `
+		FROM ` + models.TableNameSm + ` AS sm
+		JOIN ` + models.TableNameSensors + ` AS s ON s.id = sm.sensor_id
+		LEFT JOIN ` + models.TableNameSmSignature + ` AS sig ON sig.id = sm.sign_id
+		WHERE sm.iot_id = ? AND sm.created_at >= ? AND sm.created_at < ?`
+	var args = []interface{}{req.IotId, time.Unix(req.From, 0), time.Unix(req.To, 0)}
+	if req.SensorId != 0 {
+		query += " AND sm.sensor_id = ?"
+		args = append(args, req.SensorId)
+	}
+	query += " ORDER BY sm.created_at ASC, sm.id ASC"
+
+	var fetch = fmt.Sprintf("FETCH FORWARD %d FROM metric_export", exportBatch)
+
+	// Cursor lives in transaction (rolled back when export is stopped)
+	return impl.db.Transaction(func(tx *gorm.DB) error {
+		var err = tx.Exec(query, args...).Error
+		if nil != err {
+			return dmodels.ParsePostgresError("Sensor metric", err)
+		}
+
+		for {
+			var batch = make([]*export.Metric, 0, exportBatch)
+			err = tx.Raw(fetch).Scan(&batch).Error
+			if nil != err {
+				return dmodels.ParsePostgresError("Sensor metric", err)
+			}
+
+			for _, m := range batch {
+				err = fn(m)
+				if nil != err {
+					return err
+				}
+			}
+
+			if len(batch) < exportBatch {
+				return nil
+			}
+		}
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/Dcarbon/go-shared/dmodels"
 	"github.com/Dcarbon/go-shared/libs/utils"
 	"github.com/Dcarbon/iott-cloud/internal/domain"
+	"github.com/Dcarbon/iott-cloud/internal/export"
 	"github.com/Dcarbon/iott-cloud/internal/models"
 )
 
@@ -94,12 +95,36 @@ func TestSensorGetSensor(t *testing.T) {
 }
 
 func TestSensorGetSensors(t *testing.T) {
-	data, err := sensorImpl.GetSensors(&domain.RGetSensors{
+	data, page, err := sensorImpl.GetSensors(&domain.RGetSensors{
 		Skip:  0,
 		Limit: 3,
 	})
 	utils.PanicError("", err)
 	utils.Dump("Changed sensor", data)
+	utils.Dump("Page", page)
+}
+
+func TestSensorGetSensorsCursor(t *testing.T) {
+	var req = &domain.RGetSensors{Limit: 2}
+	first, page, err := sensorImpl.GetSensors(req)
+	utils.PanicError("", err)
+	if page.Next == "" {
+		return
+	}
+
+	req.Cursor = page.Next
+	second, page, err := sensorImpl.GetSensors(req)
+	utils.PanicError("", err)
+	if len(second) == 0 || second[0].ID <= first[len(first)-1].ID {
+		t.Fatalf("next page must be after first page: %v", second)
+	}
+
+	req.Cursor = page.Prev
+	prev, _, err := sensorImpl.GetSensors(req)
+	utils.PanicError("", err)
+	if len(prev) != len(first) || prev[0].ID != first[0].ID {
+		t.Fatalf("prev page must be first page: %v", prev)
+	}
 }
 
 func TestSensorCreateSM(t *testing.T) {
@@ -162,7 +187,7 @@ func TestSensorCreateSMFromIOT(t *testing.T) {
 
 func TestSensorGetSM(t *testing.T) {
 	var now = time.Now().Unix()
-	data, err := sensorImpl.GetMetrics(&domain.RGetSM{
+	data, _, err := sensorImpl.GetMetrics(&domain.RGetSM{
 		From:  now - 1000,
 		To:    now,
 		IotId: 277,
@@ -171,6 +196,22 @@ func TestSensorGetSM(t *testing.T) {
 	utils.Dump("", data)
 }
 
+func TestSensorExportMetrics(t *testing.T) {
+	var now = time.Now().Unix()
+	var count = 0
+	var err = sensorImpl.ExportMetrics(&domain.RExportSM{
+		From:     now - 86400*30,
+		To:       now,
+		IotId:    iotTestSensors[0].ID,
+		WithSign: true,
+	}, func(m *export.Metric) error {
+		count++
+		return nil
+	})
+	utils.PanicError("", err)
+	log.Println("Exported metrics: ", count)
+}
+
 func TestGenerateSignMetric(t *testing.T) {
 	var iotAddr = dmodels.EthAddress("0xE445517AbB524002Bb04C96F96aBb87b8B19b53d")
 	var pKey = "0x0123456789012345678901234567890123456789012345678901234567880000"
@@ -273,3 +314,21 @@ func TestMetricAggregate(t *testing.T) {
 	utils.PanicError("", err)
 	utils.Dump("", data)
 }
+
+func TestMetricAggregateSeries(t *testing.T) {
+	var nowUnix = time.Now().Unix()
+	var data, err = sensorImpl.AggregateMetrics(&domain.RSMAggregate{
+		From:      nowUnix - 7*86400,
+		To:        nowUnix,
+		IotId:     291,
+		SensorIds: []int64{76, 77},
+		AggOption: domain.AggOption{
+			Bucket:   domain.AggBucketHour,
+			Func:     domain.AggFuncAvg,
+			Timezone: "Asia/Ho_Chi_Minh",
+			Fill:     domain.AggFillNull,
+		},
+	})
+	utils.PanicError("", err)
+	utils.Dump("", data)
+}
@@ -0,0 +1,212 @@
+package repo
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/go-shared/libs/esign"
+	"github.com/Dcarbon/iott-cloud/internal/domain"
+	"github.com/Dcarbon/iott-cloud/internal/models"
+	"github.com/Dcarbon/iott-cloud/internal/rss"
+	"gorm.io/gorm"
+)
+
+type signMinter struct {
+	domain *models.SignDomain
+	minter *esign.ERC712
+}
+
+// Minter is cached per process: domain is immutable except default flag, so
+// default flag is always read from db (it may be changed by other replica)
+type SignDomainRepo struct {
+	db      *gorm.DB
+	mut     sync.RWMutex
+	minters map[int64]*signMinter // Domain of minter is never modified (copy is returned)
+}
+
+// Register config domain (fallback) & use it as default when there is no
+// default domain
+func NewSignDomainRepo(config *esign.TypedDataDomain) (*SignDomainRepo, error) {
+	var db = rss.GetDB()
+	var err = db.AutoMigrate(&models.SignDomain{})
+	if nil != err {
+		return nil, err
+	}
+
+	var impl = &SignDomainRepo{
+		db:      db,
+		minters: make(map[int64]*signMinter),
+	}
+
+	err = impl.bootstrap(config)
+	if nil != err {
+		return nil, err
+	}
+	return impl, nil
+}
+
+func (impl *SignDomainRepo) Create(req *domain.RSignDomainCreate,
+) (*models.SignDomain, error) {
+	if req.Name == "" {
+		req.Name = "CARBON"
+	}
+
+	var sd = &models.SignDomain{
+		Label:             req.Label,
+		Name:              req.Name,
+		Version:           req.Version,
+		ChainId:           req.ChainId,
+		VerifyingContract: strings.ToLower(string(req.VerifyingContract)),
+		CreatedAt:         time.Now(),
+		UpdatedAt:         time.Now(),
+	}
+
+	var err = impl.tbl().Create(sd).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Sign domain", err)
+	}
+
+	if req.IsDefault {
+		err = impl.SetDefault(sd.ID)
+		if nil != err {
+			return nil, err
+		}
+		sd.IsDefault = true
+	}
+	return sd, nil
+}
+
+func (impl *SignDomainRepo) SetDefault(id int64) error {
+	var err = impl.db.Transaction(func(tx *gorm.DB) error {
+		var err = tx.Table(models.TableNameSignDomain).
+			Where("id <> ? AND is_default = ?", id, true).
+			Updates(map[string]interface{}{"is_default": false, "updated_at": time.Now()}).Error
+		if nil != err {
+			return err
+		}
+
+		var rs = tx.Table(models.TableNameSignDomain).
+			Where("id = ?", id).
+			Updates(map[string]interface{}{"is_default": true, "updated_at": time.Now()})
+		if nil != rs.Error {
+			return rs.Error
+		}
+		if rs.RowsAffected == 0 {
+			return dmodels.ErrNotFound("Sign domain")
+		}
+		return nil
+	})
+	if nil != err {
+		return dmodels.ParsePostgresError("Sign domain", err)
+	}
+	return nil
+}
+
+func (impl *SignDomainRepo) GetList() ([]*models.SignDomain, error) {
+	var data = make([]*models.SignDomain, 0)
+	var err = impl.tbl().Order("id asc").Find(&data).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Sign domain", err)
+	}
+	return data, nil
+}
+
+func (impl *SignDomainRepo) GetById(id int64) (*models.SignDomain, error) {
+	var sd, _, err = impl.GetMinter(id)
+	if nil != err {
+		return nil, err
+	}
+	return sd, nil
+}
+
+// Id 0: default domain
+func (impl *SignDomainRepo) GetMinter(id int64,
+) (*models.SignDomain, *esign.ERC712, error) {
+	defaultId, err := impl.getDefaultId()
+	if nil != err {
+		return nil, nil, err
+	}
+	if id == 0 {
+		id = defaultId
+	}
+
+	impl.mut.RLock()
+	var m, ok = impl.minters[id]
+	impl.mut.RUnlock()
+	if !ok {
+		var sd = &models.SignDomain{}
+		var err = impl.tbl().Where("id = ?", id).First(sd).Error
+		if nil != err {
+			return nil, nil, dmodels.ParsePostgresError("Sign domain", err)
+		}
+
+		minter, err := models.NewMinter(sd.TypedDomain())
+		if nil != err {
+			return nil, nil, dmodels.ErrInternal(err)
+		}
+
+		m = &signMinter{domain: sd, minter: minter}
+		impl.mut.Lock()
+		impl.minters[id] = m
+		impl.mut.Unlock()
+	}
+
+	var sd = *m.domain
+	sd.IsDefault = sd.ID == defaultId
+	return &sd, m.minter, nil
+}
+
+func (impl *SignDomainRepo) getDefaultId() (int64, error) {
+	var ids = make([]int64, 0, 1)
+	var err = impl.tbl().
+		Where("is_default = ?", true).
+		Limit(1).
+		Pluck("id", &ids).Error
+	if nil != err {
+		return 0, dmodels.ParsePostgresError("Sign domain", err)
+	}
+	if len(ids) == 0 {
+		return 0, dmodels.ErrNotFound("Default sign domain")
+	}
+	return ids[0], nil
+}
+
+func (impl *SignDomainRepo) bootstrap(config *esign.TypedDataDomain) error {
+	var contract = strings.ToLower(config.VerifyingContract)
+
+	var sd = &models.SignDomain{}
+	var err = impl.tbl().
+		Where(
+			"chain_id = ? AND verifying_contract = ? AND version = ?",
+			config.ChainId, contract, config.Version,
+		).
+		FirstOrCreate(sd, &models.SignDomain{
+			Label:             fmt.Sprintf("%d-v%s", config.ChainId, config.Version),
+			Name:              config.Name,
+			Version:           config.Version,
+			ChainId:           config.ChainId,
+			VerifyingContract: contract,
+			CreatedAt:         time.Now(),
+			UpdatedAt:         time.Now(),
+		}).Error
+	if nil != err {
+		return dmodels.ParsePostgresError("Sign domain", err)
+	}
+
+	// Other replica may bootstrap at the same time: only set when there is
+	// no default yet
+	var rs = impl.tbl().
+		Where("id = ? AND NOT EXISTS (?)", sd.ID, impl.tbl().Select("1").Where("is_default = ?", true)).
+		Updates(map[string]interface{}{"is_default": true, "updated_at": time.Now()})
+	if nil != rs.Error {
+		return dmodels.ParsePostgresError("Sign domain", rs.Error)
+	}
+	return nil
+}
+
+func (impl *SignDomainRepo) tbl() *gorm.DB {
+	return impl.db.Table(models.TableNameSignDomain)
+}
@@ -0,0 +1,40 @@
+package repo
+
+import (
+	"testing"
+
+	"github.com/Dcarbon/go-shared/libs/utils"
+	"github.com/Dcarbon/iott-cloud/internal/domain"
+)
+
+func TestSignDomainCreate(t *testing.T) {
+	sd, err := signDomainRepoTest.Create(&domain.RSignDomainCreate{
+		Label:             "bsc-testnet-v2",
+		Version:           "2",
+		ChainId:           97,
+		VerifyingContract: "0x7BDDCb9699a3823b8B27158BEBaBDE6431152a85",
+	})
+	utils.PanicError("TestSignDomainCreate", err)
+	utils.Dump("Sign domain", sd)
+}
+
+func TestSignDomainGetMinter(t *testing.T) {
+	sd, _, err := signDomainRepoTest.GetMinter(0)
+	utils.PanicError("TestSignDomainGetMinter", err)
+	if !sd.IsDefault {
+		t.Fatalf("Domain 0 must be default domain")
+	}
+	utils.Dump("Default domain", sd)
+}
+
+func TestIotMoveDomain(t *testing.T) {
+	sds, err := signDomainRepoTest.GetList()
+	utils.PanicError("Get sign domains", err)
+
+	rs, err := iotRepoTest.MoveDomain(&domain.RIotMoveDomain{
+		DomainId: sds[len(sds)-1].ID,
+		IotIds:   []int64{292},
+	})
+	utils.PanicError("TestIotMoveDomain", err)
+	utils.Dump("Moved", rs)
+}
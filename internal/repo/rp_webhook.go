@@ -0,0 +1,254 @@
+package repo
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/iott-cloud/internal/domain"
+	"github.com/Dcarbon/iott-cloud/internal/models"
+	"github.com/Dcarbon/iott-cloud/internal/notify"
+	"github.com/Dcarbon/iott-cloud/internal/rss"
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WebhookRepo struct {
+	db           *gorm.DB
+	allowPrivate bool // Allow url of internal address (WEBHOOK_ALLOW_PRIVATE=1, for local test only)
+}
+
+func NewWebhookRepo() (*WebhookRepo, error) {
+	var db = rss.GetDB()
+	var err = db.AutoMigrate(
+		&models.WebhookSub{},
+		&models.WebhookDelivery{},
+	)
+	if nil != err {
+		return nil, err
+	}
+
+	var impl = &WebhookRepo{
+		db:           db,
+		allowPrivate: notify.AllowPrivate(),
+	}
+	return impl, nil
+}
+
+func (impl *WebhookRepo) CreateSub(req *domain.RWebhookCreate,
+) (*models.WebhookSub, error) {
+	var err = validEvents(req.Events)
+	if nil != err {
+		return nil, err
+	}
+
+	err = notify.ValidWebhookUrl(req.Url, impl.allowPrivate)
+	if nil != err {
+		return nil, err
+	}
+
+	secret, err := newWebhookSecret()
+	if nil != err {
+		return nil, dmodels.ErrInternal(err)
+	}
+
+	var sub = &models.WebhookSub{
+		Url:       req.Url,
+		Secret:    secret,
+		Events:    models.Strings(req.Events),
+		ProjectId: req.ProjectId,
+		Enabled:   true,
+		CreatedBy: req.CreatedBy,
+	}
+
+	err = impl.tblSub().Create(sub).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Webhook", err)
+	}
+	return sub, nil
+}
+
+func (impl *WebhookRepo) UpdateSub(req *domain.RWebhookUpdate,
+) (*models.WebhookSub, error) {
+	var err = validEvents(req.Events)
+	if nil != err {
+		return nil, err
+	}
+
+	err = notify.ValidWebhookUrl(req.Url, impl.allowPrivate)
+	if nil != err {
+		return nil, err
+	}
+
+	err = impl.tblSub().
+		Where("id = ?", req.Id).
+		Updates(map[string]interface{}{
+			"url":        req.Url,
+			"events":     models.Strings(req.Events),
+			"project_id": req.ProjectId,
+			"enabled":    req.Enabled,
+			"updated_at": time.Now(),
+		}).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Webhook", err)
+	}
+
+	var sub = &models.WebhookSub{}
+	err = impl.tblSub().Where("id = ?", req.Id).First(sub).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Webhook", err)
+	}
+	sub.Secret = ""
+	return sub, nil
+}
+
+func (impl *WebhookRepo) DeleteSub(id int64) error {
+	var err = impl.tblSub().Where("id = ?", id).Delete(&models.WebhookSub{}).Error
+	if nil != err {
+		return dmodels.ParsePostgresError("Webhook", err)
+	}
+	return nil
+}
+
+func (impl *WebhookRepo) GetSubs(req *domain.RWebhookGetList,
+) ([]*models.WebhookSub, error) {
+	if req.Limit <= 0 {
+		req.Limit = 20
+	}
+
+	var tbl = impl.tblSub().Offset(req.Skip).Limit(req.Limit)
+	if req.ProjectId > 0 {
+		tbl = tbl.Where("project_id = ?", req.ProjectId)
+	}
+
+	var subs = make([]*models.WebhookSub, 0)
+	var err = tbl.Order("id asc").Find(&subs).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Webhook", err)
+	}
+
+	for _, sub := range subs {
+		sub.Secret = ""
+	}
+	return subs, nil
+}
+
+func (impl *WebhookRepo) GetDeliveries(req *domain.RWebhookDeliveryGetList,
+) ([]*models.WebhookDelivery, error) {
+	if req.Limit <= 0 {
+		req.Limit = 20
+	}
+
+	var tbl = impl.tblDelivery().
+		Where("sub_id = ?", req.SubId).
+		Offset(req.Skip).
+		Limit(req.Limit)
+	if req.Status != "" {
+		tbl = tbl.Where("status = ?", req.Status)
+	}
+	if req.Event != "" {
+		tbl = tbl.Where("event = ?", req.Event)
+	}
+
+	var deliveries = make([]*models.WebhookDelivery, 0)
+	var err = tbl.Order("created_at desc").Find(&deliveries).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Webhook delivery", err)
+	}
+	return deliveries, nil
+}
+
+// Requeue delivery (normally was dead) for new attempt
+func (impl *WebhookRepo) Redeliver(subId int64, deliveryId string,
+) (*models.WebhookDelivery, error) {
+	var err = impl.tblDelivery().
+		Where("id = ? AND sub_id = ?", deliveryId, subId).
+		Updates(map[string]interface{}{
+			"status":     models.WebhookStatusPending,
+			"next_at":    time.Now(),
+			"updated_at": time.Now(),
+		}).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Webhook delivery", err)
+	}
+
+	var delivery = &models.WebhookDelivery{}
+	err = impl.tblDelivery().
+		Where("id = ? AND sub_id = ?", deliveryId, subId).
+		First(delivery).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Webhook delivery", err)
+	}
+	return delivery, nil
+}
+
+func (impl *WebhookRepo) Dispatch(evt *models.EventEnvelope) error {
+	var subs = make([]*models.WebhookSub, 0)
+	var err = impl.tblSub().
+		Where("enabled = ? AND (project_id = 0 OR project_id = ?)", true, evt.ProjectId).
+		Find(&subs).Error
+	if nil != err {
+		return dmodels.ParsePostgresError("Webhook", err)
+	}
+
+	payload, err := json.Marshal(evt)
+	if nil != err {
+		return dmodels.ErrInternal(err)
+	}
+
+	var deliveries = make([]*models.WebhookDelivery, 0, len(subs))
+	for _, sub := range subs {
+		if !sub.IsMatch(evt.Event, evt.ProjectId) {
+			continue
+		}
+		deliveries = append(deliveries, &models.WebhookDelivery{
+			ID:      uuid.NewV4().String(),
+			SubId:   sub.ID,
+			EventId: evt.Id,
+			Event:   evt.Event,
+			Payload: string(payload),
+			Status:  models.WebhookStatusPending,
+			NextAt:  time.Now(),
+		})
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	err = impl.tblDelivery().
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&deliveries).Error
+	if nil != err {
+		return dmodels.ParsePostgresError("Webhook delivery", err)
+	}
+	return nil
+}
+
+func (impl *WebhookRepo) tblSub() *gorm.DB {
+	return impl.db.Table(models.TableNameWebhookSub)
+}
+
+func (impl *WebhookRepo) tblDelivery() *gorm.DB {
+	return impl.db.Table(models.TableNameWebhookDelivery)
+}
+
+func validEvents(events []string) error {
+	for _, e := range events {
+		if !models.IsEventType(e) {
+			return dmodels.ErrBadRequest("Invalid event type: " + e)
+		}
+	}
+	return nil
+}
+
+func newWebhookSecret() (string, error) {
+	var buf = make([]byte, 32)
+	var _, err = rand.Read(buf)
+	if nil != err {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
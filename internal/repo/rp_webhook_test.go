@@ -0,0 +1,135 @@
+package repo
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Dcarbon/go-shared/libs/utils"
+	"github.com/Dcarbon/iott-cloud/internal/domain"
+	"github.com/Dcarbon/iott-cloud/internal/models"
+	"github.com/Dcarbon/iott-cloud/internal/notify"
+	uuid "github.com/satori/go.uuid"
+)
+
+var webhookTest *WebhookRepo
+
+func init() {
+	var err error
+	webhookTest, err = NewWebhookRepo()
+	utils.PanicError("", err)
+	webhookTest.allowPrivate = true // Test server is local
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	var sub *models.WebhookSub
+	var received = make(chan bool, 1)
+	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		ts, _ := strconv.ParseInt(r.Header.Get(models.WebhookHeaderTimestamp), 10, 64)
+		if r.Header.Get(models.WebhookHeaderSignature) != sub.Sign(ts, body) {
+			w.WriteHeader(401)
+			return
+		}
+		received <- true
+	}))
+	defer server.Close()
+
+	sub, err := webhookTest.CreateSub(&domain.RWebhookCreate{
+		Url:       server.URL,
+		Events:    []string{models.EventIotCreated},
+		ProjectId: 1,
+	})
+	utils.PanicError("", err)
+	defer webhookTest.DeleteSub(sub.ID)
+
+	var evt = &models.EventEnvelope{
+		Id:        uuid.NewV4().String(),
+		Event:     models.EventIotCreated,
+		ProjectId: 1,
+		Data:      []byte(`{"id":1,"project":1}`),
+		CreatedAt: time.Now(),
+	}
+	utils.PanicError("", webhookTest.Dispatch(evt))
+	// Duplicated event is ignored
+	utils.PanicError("", webhookTest.Dispatch(evt))
+
+	_, err = NewWebhookWorker(webhookTest).Process(time.Now())
+	utils.PanicError("", err)
+
+	select {
+	case <-received:
+	default:
+		t.Fatalf("Webhook was not delivered")
+	}
+
+	deliveries, err := webhookTest.GetDeliveries(&domain.RWebhookDeliveryGetList{SubId: sub.ID})
+	utils.PanicError("", err)
+	if len(deliveries) != 1 || deliveries[0].Status != models.WebhookStatusSuccess {
+		utils.Dump("Deliveries", deliveries)
+		t.Fatalf("Expect 1 success delivery")
+	}
+}
+
+func TestWebhookDeadLetter(t *testing.T) {
+	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(500)
+	}))
+	defer server.Close()
+
+	sub, err := webhookTest.CreateSub(&domain.RWebhookCreate{Url: server.URL})
+	utils.PanicError("", err)
+	defer webhookTest.DeleteSub(sub.ID)
+
+	utils.PanicError("", webhookTest.Dispatch(&models.EventEnvelope{
+		Id:        uuid.NewV4().String(),
+		Event:     models.EventMintSigned,
+		ProjectId: 1,
+		Data:      []byte(`{}`),
+		CreatedAt: time.Now(),
+	}))
+
+	var worker = NewWebhookWorker(webhookTest)
+	worker.maxAttempts = 2
+	var now = time.Now()
+	for i := 0; i < worker.maxAttempts; i++ {
+		now = now.Add(24 * time.Hour)
+		_, err = worker.Process(now)
+		utils.PanicError("", err)
+	}
+
+	deliveries, err := webhookTest.GetDeliveries(&domain.RWebhookDeliveryGetList{
+		SubId:  sub.ID,
+		Status: models.WebhookStatusDead,
+	})
+	utils.PanicError("", err)
+	if len(deliveries) != 1 || deliveries[0].Attempts != 2 {
+		utils.Dump("Deliveries", deliveries)
+		t.Fatalf("Expect delivery was moved to dead letter after 2 attempts")
+	}
+}
+
+func TestWebhookInternalUrl(t *testing.T) {
+	var repo = &WebhookRepo{db: webhookTest.db}
+	for _, url := range []string{
+		"http://127.0.0.1:8080/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://localhost/hook",
+		"ftp://example.com/hook",
+	} {
+		_, err := repo.CreateSub(&domain.RWebhookCreate{Url: url})
+		if nil == err {
+			t.Fatalf("Webhook url %s must be rejected", url)
+		}
+	}
+
+	var client = notify.NewGuardedClient(webhookTimeout, false)
+	_, err := client.Get("http://127.0.0.1:1/hook")
+	if !errors.Is(err, notify.ErrInternalAddress) {
+		t.Fatalf("Client must refuse internal address: %v", err)
+	}
+}
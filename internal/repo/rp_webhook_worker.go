@@ -0,0 +1,189 @@
+package repo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Dcarbon/go-shared/libs/utils"
+	"github.com/Dcarbon/iott-cloud/internal/models"
+	"github.com/Dcarbon/iott-cloud/internal/notify"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	webhookBatch   = 50               // Max deliveries per poll
+	webhookLease   = 2 * time.Minute  // Claimed delivery is hidden from other workers
+	webhookTimeout = 15 * time.Second // Timeout of a delivery request
+)
+
+// Send pending deliveries. Failed delivery is retried with exponential
+// backoff and move to dead letter after maxAttempts. Delivery is sent only
+// while its lease is valid, so it is not sent concurrently by other worker;
+// receiver can dedupe retries by delivery id (X-DCarbon-Delivery)
+type WebhookWorker struct {
+	repo        *WebhookRepo
+	client      *http.Client
+	period      time.Duration // Poll period
+	backoff     time.Duration // Delay of first retry
+	maxAttempts int           //
+}
+
+func NewWebhookWorker(repo *WebhookRepo) *WebhookWorker {
+	return &WebhookWorker{
+		repo:        repo,
+		client:      notify.NewGuardedClient(webhookTimeout, repo.allowPrivate),
+		period:      time.Duration(utils.Int64Env("WEBHOOK_POLL_PERIOD", 5)) * time.Second,
+		backoff:     time.Duration(utils.Int64Env("WEBHOOK_BACKOFF", 30)) * time.Second,
+		maxAttempts: int(utils.Int64Env("WEBHOOK_MAX_ATTEMPTS", 8)),
+	}
+}
+
+func (w *WebhookWorker) Run() {
+	var ticker = time.NewTicker(w.period)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		for {
+			n, err := w.Process(time.Now())
+			if nil != err {
+				log.Println("Webhook worker process error: ", err)
+				break
+			}
+			if n < webhookBatch {
+				break
+			}
+		}
+	}
+}
+
+// Deliver due deliveries. Return num of deliveries was processed
+func (w *WebhookWorker) Process(now time.Time) (int, error) {
+	var leaseEnd = time.Now().Add(webhookLease - webhookTimeout)
+	deliveries, err := w.claim(now)
+	if nil != err {
+		return 0, err
+	}
+
+	var subs = make(map[int64]*models.WebhookSub)
+	for i, delivery := range deliveries {
+		if time.Now().After(leaseEnd) {
+			// Rest of batch is claimed again (by any worker) when lease expires
+			log.Printf("Webhook lease expired, %d deliveries are postponed\n", len(deliveries)-i)
+			return i, nil
+		}
+
+		sub, ok := subs[delivery.SubId]
+		if !ok {
+			sub = &models.WebhookSub{}
+			err = w.repo.tblSub().Where("id = ?", delivery.SubId).Find(sub).Error
+			if nil != err {
+				return 0, err
+			}
+			subs[delivery.SubId] = sub
+		}
+
+		if sub.ID == 0 || !sub.Enabled {
+			w.finish(delivery, 0, fmt.Errorf("subscription was removed or disabled"), true)
+			continue
+		}
+
+		code, err := w.send(sub, delivery, now)
+		w.finish(delivery, code, err, errors.Is(err, notify.ErrInternalAddress))
+	}
+	return len(deliveries), nil
+}
+
+// Lock & lease due deliveries so concurrent workers don't send it twice
+func (w *WebhookWorker) claim(now time.Time) ([]*models.WebhookDelivery, error) {
+	var deliveries = make([]*models.WebhookDelivery, 0)
+	var err = w.repo.db.Transaction(func(tx *gorm.DB) error {
+		var err = tx.Table(models.TableNameWebhookDelivery).
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_at <= ?", models.WebhookStatusPending, now).
+			Order("next_at asc").
+			Limit(webhookBatch).
+			Find(&deliveries).Error
+		if nil != err || len(deliveries) == 0 {
+			return err
+		}
+
+		var ids = make([]string, len(deliveries))
+		for i, d := range deliveries {
+			ids[i] = d.ID
+		}
+		return tx.Table(models.TableNameWebhookDelivery).
+			Where("id IN ?", ids).
+			Update("next_at", now.Add(webhookLease)).Error
+	})
+	return deliveries, err
+}
+
+func (w *WebhookWorker) send(sub *models.WebhookSub, delivery *models.WebhookDelivery,
+	now time.Time,
+) (int, error) {
+	var body = []byte(delivery.Payload)
+	req, err := http.NewRequest(http.MethodPost, sub.Url, bytes.NewReader(body))
+	if nil != err {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(models.WebhookHeaderEvent, delivery.Event)
+	req.Header.Set(models.WebhookHeaderDelivery, delivery.ID)
+	req.Header.Set(models.WebhookHeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(models.WebhookHeaderSignature, sub.Sign(now.Unix(), body))
+
+	resp, err := w.client.Do(req)
+	if nil != err {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("response status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// Save result of attempt
+func (w *WebhookWorker) finish(delivery *models.WebhookDelivery, code int, err error,
+	dead bool,
+) {
+	var now = time.Now()
+	var updates = map[string]interface{}{
+		"attempts":      delivery.Attempts + 1,
+		"response_code": code,
+		"last_error":    "",
+		"updated_at":    now,
+	}
+
+	if nil == err {
+		updates["status"] = models.WebhookStatusSuccess
+	} else {
+		updates["last_error"] = err.Error()
+		if dead || delivery.Attempts+1 >= w.maxAttempts {
+			updates["status"] = models.WebhookStatusDead
+			log.Printf("Webhook delivery %s moved to dead letter: %s\n", delivery.ID, err)
+		} else {
+			updates["next_at"] = now.Add(models.WebhookBackoff(delivery.Attempts+1, w.backoff))
+		}
+	}
+
+	// Attempts is version of delivery: result of stale attempt is dropped
+	var rs = w.repo.tblDelivery().
+		Where("id = ? AND attempts = ?", delivery.ID, delivery.Attempts).
+		Updates(updates)
+	if nil != rs.Error {
+		log.Println("Save webhook delivery error: ", delivery.ID, rs.Error)
+	} else if rs.RowsAffected == 0 {
+		log.Println("Webhook delivery was updated by other worker: ", delivery.ID)
+	}
+}
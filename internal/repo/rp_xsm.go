@@ -43,15 +43,8 @@ func (impl *XSMImpl) Create(req *domain.RXSMCreate,
 }
 
 func (impl *XSMImpl) GetList(req *domain.RXSMGetList,
-) ([]*models.XSMetric, error) {
-	var data = make([]*models.XSMetric, 0)
-	var query = impl.tblXSM().Offset(req.Skip).
-		Select("sensor_type, metric, created_at").
-		Order("created_at desc")
-	if req.Limit > 0 {
-		query = query.Limit(req.Limit)
-	}
-
+) ([]*models.XSMetric, *domain.PageInfo, error) {
+	var query = impl.tblXSM()
 	if req.From > 0 {
 		query = query.Where("created_at > ?", time.Unix(req.From, 0))
 	}
@@ -64,11 +57,11 @@ func (impl *XSMImpl) GetList(req *domain.RXSMGetList,
 		query = query.Where("iot_address = ?", req.Address)
 	}
 
-	var err = query.Find(&data).Error
-	if nil != err {
-		return nil, dmodels.ParsePostgresError("", err)
-	}
-	return data, nil
+	var ks = &keyset{column: "created_at", kind: keyTime, id: "id", idKind: keyString, desc: true}
+	return paginate(
+		query, "Experiment metric", "id, sensor_type, metric, created_at", ks, req.Cursor, req.Skip, req.Limit,
+		func(m *models.XSMetric) (interface{}, interface{}) { return m.CreatedAt, m.Id },
+	)
 }
 
 func (impl *XSMImpl) tblXSM() *gorm.DB {
@@ -33,7 +33,7 @@ func TestGetList(t *testing.T) {
 		Address:    "0xe445517abb524002bb04c96f96abb87b8b19b53d",
 		SensorType: 1,
 	}
-	var data, err = getXSMTest().GetList(req)
+	var data, _, err = getXSMTest().GetList(req)
 	utils.PanicError("", err)
 	utils.Dump("", data)
 }
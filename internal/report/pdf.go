@@ -0,0 +1,127 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Minimal text pdf (A4, Courier) for report summary
+const (
+	pdfPageHeight   = 842
+	pdfMarginTop    = 50
+	pdfMarginLeft   = 40
+	pdfFontSize     = 9
+	pdfTitleSize    = 12
+	pdfLineHeight   = 12
+	pdfLinesPerPage = (pdfPageHeight - 2*pdfMarginTop) / pdfLineHeight
+	pdfMaxChars     = 100
+)
+
+type pdfLine struct {
+	text  string
+	title bool
+}
+
+type pdfDoc struct {
+	pages [][]*pdfLine
+}
+
+func newPdfDoc() *pdfDoc {
+	return &pdfDoc{pages: [][]*pdfLine{{}}}
+}
+
+func (doc *pdfDoc) Title(text string) {
+	doc.add(&pdfLine{text: text, title: true})
+}
+
+func (doc *pdfDoc) Line(format string, args ...interface{}) {
+	doc.add(&pdfLine{text: fmt.Sprintf(format, args...)})
+}
+
+func (doc *pdfDoc) add(line *pdfLine) {
+	var last = len(doc.pages) - 1
+	if len(doc.pages[last]) >= pdfLinesPerPage {
+		doc.pages = append(doc.pages, []*pdfLine{})
+		last++
+	}
+	doc.pages[last] = append(doc.pages[last], line)
+}
+
+func (doc *pdfDoc) WriteTo(w io.Writer) (int64, error) {
+	var buf = &bytes.Buffer{}
+	var offsets = make([]int, 0)
+	var obj = func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	// 1: catalog, 2: pages, 3: font, 4: bold font, then page & content
+	// stream of each page
+	var kids = make([]string, len(doc.pages))
+	for i := range doc.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+
+	buf.WriteString("%PDF-1.4\n")
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+	obj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(doc.pages)))
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Courier >>")
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Courier-Bold >>")
+
+	for i, page := range doc.pages {
+		obj(fmt.Sprintf(
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 595 %d] "+
+				"/Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pdfPageHeight, 6+2*i,
+		))
+
+		var content = &bytes.Buffer{}
+		var y = pdfPageHeight - pdfMarginTop
+		for _, line := range page {
+			var font, size = "F1", pdfFontSize
+			if line.title {
+				font, size = "F2", pdfTitleSize
+			}
+			fmt.Fprintf(content, "BT /%s %d Tf %d %d Td (%s) Tj ET\n",
+				font, size, pdfMarginLeft, y, pdfEscape(line.text))
+			y -= pdfLineHeight
+		}
+		obj(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+
+	var xref = buf.Len()
+	fmt.Fprintf(buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	n, err := w.Write(buf.Bytes())
+	return int64(n), err
+}
+
+// Escape text of pdf string (non ascii is replaced by ?)
+func pdfEscape(s string) string {
+	var b = &strings.Builder{}
+	var n = 0
+	for _, r := range s {
+		if n >= pdfMaxChars {
+			b.WriteString("...")
+			break
+		}
+		n++
+
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 32 || r > 126:
+			b.WriteByte('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,239 @@
+package report
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/iott-cloud/internal/models"
+)
+
+// Read exported report (json or zip of csv)
+func ReadFile(path string) (*Report, error) {
+	raw, err := os.ReadFile(path)
+	if nil != err {
+		return nil, err
+	}
+	return Read(raw)
+}
+
+func Read(raw []byte) (*Report, error) {
+	if bytes.HasPrefix(raw, []byte("PK")) {
+		return ReadCSV(raw)
+	}
+	return ReadJSON(raw)
+}
+
+func ReadJSON(raw []byte) (*Report, error) {
+	var rp = &Report{}
+	var err = json.Unmarshal(raw, rp)
+	if nil != err {
+		return nil, err
+	}
+	return rp, nil
+}
+
+// Read zip of csv files (see WriteCSV). Summary and project are not restored
+func ReadCSV(raw []byte) (*Report, error) {
+	zr, err := zip.NewReader(bytes.NewReader(raw), int64(len(raw)))
+	if nil != err {
+		return nil, err
+	}
+
+	var rp = &Report{Project: &models.Project{}}
+	var readers = map[string]func(row *csvRow){
+		"iots.csv": func(row *csvRow) {
+			rp.Iots = append(rp.Iots, &models.IOTDevice{
+				ID:       row.Int64("id"),
+				Address:  dmodels.EthAddress(row.Get("address")),
+				Type:     models.IOTType(row.Int64("type")),
+				Status:   dmodels.DeviceStatus(row.Int64("status")),
+				DomainId: row.Int64("domain_id"),
+				Oracle:   row.Bool("oracle"),
+				Position: models.Point4326{Lng: row.Float64("lng"), Lat: row.Float64("lat")},
+			})
+		},
+		"sensors.csv": func(row *csvRow) {
+			var sensor = &models.Sensor{
+				ID:        row.Int64("id"),
+				IotID:     row.Int64("iot_id"),
+				Type:      dmodels.SensorType(row.Int64("type")),
+				Status:    dmodels.DeviceStatus(row.Int64("status")),
+				CreatedAt: row.Time("created_at"),
+			}
+			if addr := row.Get("address"); addr != "" {
+				var ethAddr = dmodels.EthAddress(addr)
+				sensor.Address = &ethAddr
+			}
+			rp.Sensors = append(rp.Sensors, sensor)
+		},
+		"metrics.csv": func(row *csvRow) {
+			rp.Metrics = append(rp.Metrics, &models.SmSignature{
+				ID:        row.Get("id"),
+				IotID:     row.Int64("iot_id"),
+				SensorID:  row.Int64("sensor_id"),
+				IsIotSign: row.Bool("is_iot_sign"),
+				CreatedAt: row.Time("created_at"),
+				Data:      row.Get("data"),
+				Signed:    row.Get("signed"),
+			})
+		},
+		"mint_signs.csv": func(row *csvRow) {
+			rp.MintSigns = append(rp.MintSigns, readMintSign(row))
+		},
+		"mint_base.csv": func(row *csvRow) {
+			rp.MintBase = append(rp.MintBase, readMintSign(row))
+		},
+		"minted.csv": func(row *csvRow) {
+			rp.Minted = append(rp.Minted, &models.Minted{
+				ID:        row.Get("id"),
+				IotId:     row.Int64("iot_id"),
+				Carbon:    row.Int64("carbon"),
+				CreatedAt: row.Time("created_at"),
+			})
+		},
+		"domains.csv": func(row *csvRow) {
+			rp.Domains = append(rp.Domains, &models.SignDomain{
+				ID:                row.Int64("id"),
+				Label:             row.Get("label"),
+				Name:              row.Get("name"),
+				Version:           row.Get("version"),
+				ChainId:           row.Int64("chain_id"),
+				VerifyingContract: row.Get("verifying_contract"),
+			})
+		},
+	}
+
+	for _, f := range zr.File {
+		var read, ok = readers[f.Name]
+		if !ok {
+			continue
+		}
+
+		err = readCSVFile(f, read)
+		if nil != err {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+	}
+	return rp, nil
+}
+
+func readCSVFile(f *zip.File, read func(row *csvRow)) error {
+	rc, err := f.Open()
+	if nil != err {
+		return err
+	}
+	defer rc.Close()
+
+	var cr = csv.NewReader(rc)
+	header, err := cr.Read()
+	if nil != err {
+		return err
+	}
+
+	var row = &csvRow{cols: make(map[string]int, len(header))}
+	for i, name := range header {
+		row.cols[name] = i
+	}
+
+	for {
+		row.values, err = cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if nil != err {
+			return err
+		}
+
+		read(row)
+		if nil != row.err {
+			return row.err
+		}
+	}
+}
+
+func readMintSign(row *csvRow) *models.MintSign {
+	return &models.MintSign{
+		ID:        row.Int64("id"),
+		IotId:     row.Int64("iot_id"),
+		Iot:       row.Get("iot"),
+		DomainId:  row.Int64("domain_id"),
+		Nonce:     row.Int64("nonce"),
+		Amount:    row.Get("amount"),
+		R:         row.Get("r"),
+		S:         row.Get("s"),
+		V:         row.Get("v"),
+		Signer:    row.Get("signer"),
+		State:     models.MintState(row.Int64("state")),
+		TxHash:    row.Get("tx_hash"),
+		CreatedAt: row.Time("created_at"),
+	}
+}
+
+// Row of csv file by column name (first parse error is kept in err)
+type csvRow struct {
+	cols   map[string]int
+	values []string
+	err    error
+}
+
+func (row *csvRow) Get(name string) string {
+	var i, ok = row.cols[name]
+	if !ok || i >= len(row.values) {
+		return ""
+	}
+	return row.values[i]
+}
+
+func (row *csvRow) Int64(name string) int64 {
+	var s = row.Get(name)
+	if s == "" {
+		return 0
+	}
+	v, err := strconv.ParseInt(s, 10, 64)
+	row.setErr(name, err)
+	return v
+}
+
+func (row *csvRow) Float64(name string) float64 {
+	var s = row.Get(name)
+	if s == "" {
+		return 0
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	row.setErr(name, err)
+	return v
+}
+
+func (row *csvRow) Bool(name string) bool {
+	var s = row.Get(name)
+	if s == "" {
+		return false
+	}
+	v, err := strconv.ParseBool(s)
+	row.setErr(name, err)
+	return v
+}
+
+func (row *csvRow) Time(name string) time.Time {
+	var s = row.Get(name)
+	if s == "" {
+		return time.Time{}
+	}
+	v, err := time.Parse(time.RFC3339, s)
+	row.setErr(name, err)
+	return v
+}
+
+func (row *csvRow) setErr(name string, err error) {
+	if nil != err && nil == row.err {
+		row.err = fmt.Errorf("column %s: %w", name, err)
+	}
+}
@@ -0,0 +1,108 @@
+// Audit report of project over a period: devices, sensors, raw signed metrics,
+// mint signatures and minted carbon. Report contains raw Data/Signed of every
+// signature (and sign domains of mint signatures) so it can be re-verified
+// offline.
+package report
+
+import (
+	"time"
+
+	"github.com/Dcarbon/iott-cloud/internal/models"
+)
+
+type Format string
+
+const (
+	FormatJSON Format = "json"
+	FormatCSV  Format = "csv" // Zip of csv files
+	FormatPDF  Format = "pdf" // Summary only
+)
+
+func (f Format) IsValid() bool {
+	return f == FormatJSON || f == FormatCSV || f == FormatPDF
+}
+
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "application/zip"
+	case FormatPDF:
+		return "application/pdf"
+	}
+	return "application/json"
+}
+
+func (f Format) Ext() string {
+	if f == FormatCSV {
+		return "zip"
+	}
+	return string(f)
+}
+
+type IotSummary struct {
+	IotId     int64  `json:"iotId"`
+	Address   string `json:"address"`
+	Metrics   int    `json:"metrics"`   // Num of signed metrics
+	MintSigns int    `json:"mintSigns"` //
+	Carbon    int64  `json:"carbon"`    // Minted in period
+} // @name ReportIotSummary
+
+type Summary struct {
+	Iots      int           `json:"iots"`
+	Sensors   int           `json:"sensors"`
+	Metrics   int           `json:"metrics"`
+	MintSigns int           `json:"mintSigns"`
+	Carbon    int64         `json:"carbon"` // Minted in period
+	ByIot     []*IotSummary `json:"byIot"`
+} // @name ReportSummary
+
+type Report struct {
+	Project     *models.Project       `json:"project"`
+	From        time.Time             `json:"from"` // Inclusive
+	To          time.Time             `json:"to"`   // Exclusive
+	GeneratedAt time.Time             `json:"generatedAt"`
+	Summary     *Summary              `json:"summary"`
+	Domains     []*models.SignDomain  `json:"domains"` // Sign domains of mint signatures
+	Iots        []*models.IOTDevice   `json:"iots"`
+	Sensors     []*models.Sensor      `json:"sensors"`
+	Metrics     []*models.SmSignature `json:"metrics"` // Raw signed metrics
+	MintSigns   []*models.MintSign    `json:"mintSigns"`
+	MintBase    []*models.MintSign    `json:"mintBase"` // Latest signature of iot before period (base of increment)
+	Minted      []*models.Minted      `json:"minted"`
+} // @name Report
+
+// Fill summary by content of report
+func (rp *Report) Summarize() {
+	var byIot = make(map[int64]*IotSummary)
+	var summary = &Summary{
+		Iots:      len(rp.Iots),
+		Sensors:   len(rp.Sensors),
+		Metrics:   len(rp.Metrics),
+		MintSigns: len(rp.MintSigns),
+		ByIot:     make([]*IotSummary, 0, len(rp.Iots)),
+	}
+
+	for _, iot := range rp.Iots {
+		var s = &IotSummary{IotId: iot.ID, Address: string(iot.Address)}
+		byIot[iot.ID] = s
+		summary.ByIot = append(summary.ByIot, s)
+	}
+
+	for _, m := range rp.Metrics {
+		if s, ok := byIot[m.IotID]; ok {
+			s.Metrics++
+		}
+	}
+	for _, m := range rp.MintSigns {
+		if s, ok := byIot[m.IotId]; ok {
+			s.MintSigns++
+		}
+	}
+	for _, m := range rp.Minted {
+		summary.Carbon += m.Carbon
+		if s, ok := byIot[m.IotId]; ok {
+			s.Carbon += m.Carbon
+		}
+	}
+	rp.Summary = summary
+}
@@ -0,0 +1,124 @@
+package report
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dcarbon/iott-cloud/internal/models"
+)
+
+func newTestReport() *Report {
+	var rp = &Report{
+		Project:     &models.Project{ID: 1, Owner: "0x19adf96848504a06383b47aaa9bbbc6638e81afd", LocationName: "Ha Noi (VN)"},
+		From:        time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:          time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		GeneratedAt: time.Now(),
+		Domains:     []*models.SignDomain{{ID: 1, Label: "local", Name: "CARBON", Version: "1", ChainId: 1337}},
+		Iots: []*models.IOTDevice{
+			{ID: 10, Address: "0x0000000000000000000000000000000000000010"},
+			{ID: 11, Address: "0x0000000000000000000000000000000000000011"},
+		},
+		Sensors: []*models.Sensor{{ID: 100, IotID: 10}},
+		Metrics: []*models.SmSignature{
+			{ID: "m1", IotID: 10, SensorID: 100, Data: "0x7b7d", Signed: "0x01"},
+			{ID: "m2", IotID: 10, SensorID: 100, Data: "0x7b7d", Signed: "0x02"},
+		},
+		MintSigns: []*models.MintSign{{ID: 1, IotId: 10, Nonce: 1, Amount: "0x64", DomainId: 1}},
+		Minted: []*models.Minted{
+			{ID: "a", IotId: 10, Carbon: 60},
+			{ID: "b", IotId: 10, Carbon: 40},
+			{ID: "c", IotId: 11, Carbon: 5},
+		},
+	}
+	rp.Summarize()
+	return rp
+}
+
+func TestSummarize(t *testing.T) {
+	var rp = newTestReport()
+	if rp.Summary.Carbon != 105 || rp.Summary.Metrics != 2 || rp.Summary.Iots != 2 {
+		t.Fatalf("invalid summary: %+v", rp.Summary)
+	}
+	if rp.Summary.ByIot[0].Carbon != 100 || rp.Summary.ByIot[0].Metrics != 2 || rp.Summary.ByIot[1].Carbon != 5 {
+		t.Fatalf("invalid summary by iot: %+v %+v", rp.Summary.ByIot[0], rp.Summary.ByIot[1])
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf = &bytes.Buffer{}
+	var err = Write(buf, newTestReport(), FormatJSON)
+	if nil != err {
+		t.Fatalf("write json: %s", err)
+	}
+
+	var rp = &Report{}
+	err = json.Unmarshal(buf.Bytes(), rp)
+	if nil != err {
+		t.Fatalf("decode json: %s", err)
+	}
+	if len(rp.Metrics) != 2 || rp.Metrics[1].Signed != "0x02" {
+		t.Fatalf("raw signatures must be in report: %+v", rp.Metrics)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf = &bytes.Buffer{}
+	var err = Write(buf, newTestReport(), FormatCSV)
+	if nil != err {
+		t.Fatalf("write csv: %s", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if nil != err {
+		t.Fatalf("open zip: %s", err)
+	}
+
+	var files = make(map[string][][]string)
+	for _, f := range zr.File {
+		rc, _ := f.Open()
+		rows, err := csv.NewReader(rc).ReadAll()
+		rc.Close()
+		if nil != err {
+			t.Fatalf("read %s: %s", f.Name, err)
+		}
+		files[f.Name] = rows
+	}
+
+	if len(files["metrics.csv"]) != 3 || files["metrics.csv"][1][6] != "0x01" {
+		t.Fatalf("invalid metrics.csv: %v", files["metrics.csv"])
+	}
+	if len(files["minted.csv"]) != 4 {
+		t.Fatalf("invalid minted.csv: %v", files["minted.csv"])
+	}
+	if last := files["summary.csv"][len(files["summary.csv"])-1]; last[4] != "105" {
+		t.Fatalf("invalid total of summary.csv: %v", last)
+	}
+}
+
+func TestWritePDF(t *testing.T) {
+	var buf = &bytes.Buffer{}
+	var err = Write(buf, newTestReport(), FormatPDF)
+	if nil != err {
+		t.Fatalf("write pdf: %s", err)
+	}
+
+	var s = buf.String()
+	if !strings.HasPrefix(s, "%PDF-1.4") || !strings.HasSuffix(s, "%%EOF\n") {
+		t.Fatalf("invalid pdf")
+	}
+	if !strings.Contains(s, `Ha Noi \(VN\)`) {
+		t.Fatalf("text of pdf must be escaped")
+	}
+}
+
+func TestWriteInvalidFormat(t *testing.T) {
+	var err = Write(&bytes.Buffer{}, newTestReport(), Format("xml"))
+	if err != ErrInvalidFormat {
+		t.Fatalf("expected ErrInvalidFormat, got %v", err)
+	}
+}
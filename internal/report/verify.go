@@ -0,0 +1,264 @@
+package report
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/iott-cloud/internal/models"
+)
+
+type CheckKind string
+
+const (
+	CheckMetric   CheckKind = "metric"    // Signature of sensor metric
+	CheckMintSign CheckKind = "mint_sign" // EIP-712 signature and nonce sequence of mint
+	CheckMinted   CheckKind = "minted"    // Minted carbon of iot vs increments of mint signatures
+)
+
+type CheckStatus string
+
+const (
+	CheckPass CheckStatus = "PASS"
+	CheckFail CheckStatus = "FAIL"
+	CheckSkip CheckStatus = "SKIP" // Can not be verified by content of export
+)
+
+type Check struct {
+	Kind    CheckKind   `json:"kind"`
+	Id      string      `json:"id"`
+	IotId   int64       `json:"iotId"`
+	Status  CheckStatus `json:"status"`
+	Reasons []string    `json:"reasons,omitempty"`
+}
+
+func (c *Check) fail(format string, args ...interface{}) {
+	c.Status = CheckFail
+	c.Reasons = append(c.Reasons, fmt.Sprintf(format, args...))
+}
+
+func (c *Check) skip(format string, args ...interface{}) {
+	if c.Status == CheckPass {
+		c.Status = CheckSkip
+	}
+	c.Reasons = append(c.Reasons, fmt.Sprintf(format, args...))
+}
+
+type Verification struct {
+	Checks  []*Check `json:"checks"`
+	Passed  int      `json:"passed"`
+	Failed  int      `json:"failed"`
+	Skipped int      `json:"skipped"`
+}
+
+func (v *Verification) OK() bool {
+	return v.Failed == 0
+}
+
+func (v *Verification) add(c *Check) {
+	v.Checks = append(v.Checks, c)
+	switch c.Status {
+	case CheckPass:
+		v.Passed++
+	case CheckFail:
+		v.Failed++
+	default:
+		v.Skipped++
+	}
+}
+
+// Verify exported report offline: signature of every metric (by registered
+// sensor/iot address), signature of every mint (by sign domain), nonce
+// sequence of mints and minted carbon of iot (by increments of amount)
+func Verify(rp *Report) *Verification {
+	var v = &Verification{Checks: make([]*Check, 0, len(rp.Metrics)+len(rp.MintSigns)+len(rp.Iots))}
+
+	var iots = make(map[int64]*models.IOTDevice, len(rp.Iots))
+	for _, iot := range rp.Iots {
+		iots[iot.ID] = iot
+	}
+
+	var sensors = make(map[int64]*models.Sensor, len(rp.Sensors))
+	for _, s := range rp.Sensors {
+		sensors[s.ID] = s
+	}
+
+	for _, m := range rp.Metrics {
+		v.add(verifyMetric(m, iots, sensors))
+	}
+
+	for _, c := range verifyMints(rp, iots) {
+		v.add(c)
+	}
+	return v
+}
+
+func verifyMetric(m *models.SmSignature, iots map[int64]*models.IOTDevice,
+	sensors map[int64]*models.Sensor,
+) *Check {
+	var c = &Check{Kind: CheckMetric, Id: m.ID, IotId: m.IotID, Status: CheckPass}
+
+	var sensor, ok = sensors[m.SensorID]
+	if !ok {
+		c.fail("sensor %d is not registered", m.SensorID)
+		return c
+	}
+	if sensor.IotID != m.IotID {
+		c.fail("sensor %d is not of iot %d", sensor.ID, m.IotID)
+		return c
+	}
+
+	// Sensor has no address: metric is signed by iot
+	var addr dmodels.EthAddress
+	if !sensor.Address.IsEmpty() {
+		addr = *sensor.Address
+	} else if iot, ok := iots[m.IotID]; ok {
+		addr = iot.Address
+	} else {
+		c.fail("iot %d is not registered", m.IotID)
+		return c
+	}
+
+	_, err := m.VerifySignature(addr, sensor.Type)
+	if nil != err {
+		c.fail("invalid signature of %s: %s", addr, err)
+	}
+	return c
+}
+
+// Checks of mint signatures and minted carbon of every iot
+func verifyMints(rp *Report, iots map[int64]*models.IOTDevice) []*Check {
+	var domains = make(map[int64]*models.SignDomain, len(rp.Domains))
+	for _, d := range rp.Domains {
+		domains[d.ID] = d
+	}
+
+	// Nonce is counted by iot & domain
+	type chainKey struct{ iotId, domainId int64 }
+	var bases = make(map[chainKey]*models.MintSign)
+	for _, m := range rp.MintBase {
+		bases[chainKey{m.IotId, m.DomainId}] = m
+	}
+
+	var signs = make([]*models.MintSign, len(rp.MintSigns))
+	copy(signs, rp.MintSigns)
+	sort.SliceStable(signs, func(i, j int) bool {
+		if signs[i].IotId != signs[j].IotId {
+			return signs[i].IotId < signs[j].IotId
+		}
+		if signs[i].DomainId != signs[j].DomainId {
+			return signs[i].DomainId < signs[j].DomainId
+		}
+		return signs[i].Nonce < signs[j].Nonce
+	})
+
+	var checks = make([]*Check, 0, len(signs)+len(rp.Iots))
+	var increments = make(map[int64]*big.Int)
+	var unknown = make(map[int64][]string) // Reasons of increment can not be computed
+	for _, m := range signs {
+		var c = &Check{Kind: CheckMintSign, Id: i64(m.ID), IotId: m.IotId, Status: CheckPass}
+		checks = append(checks, c)
+		verifyMintSign(c, m, iots, domains)
+
+		var key = chainKey{m.IotId, m.DomainId}
+		var prev, ok = bases[key]
+		bases[key] = m
+		if !ok {
+			if m.Nonce != 1 {
+				var reason = fmt.Sprintf("nonce %d is not in export", m.Nonce-1)
+				c.skip("increment is unknown: %s", reason)
+				unknown[m.IotId] = append(unknown[m.IotId], reason)
+				continue
+			}
+			prev = &models.MintSign{Amount: "0x0"}
+		}
+
+		if prev.Nonce+1 != m.Nonce {
+			c.fail("nonce gap: %d -> %d", prev.Nonce, m.Nonce)
+			unknown[m.IotId] = append(unknown[m.IotId], fmt.Sprintf("nonce gap at %d", m.Nonce))
+			continue
+		}
+
+		amount, err := dmodels.NewBigNumberFromHex(m.Amount)
+		if nil != err {
+			c.fail("invalid amount %s", m.Amount)
+			unknown[m.IotId] = append(unknown[m.IotId], fmt.Sprintf("invalid amount of nonce %d", m.Nonce))
+			continue
+		}
+
+		prevAmount, err := dmodels.NewBigNumberFromHex(prev.Amount)
+		if nil != err {
+			prevAmount = dmodels.NewBigNumber(0)
+		}
+
+		var inc = big.NewInt(0).Sub(amount.Int, prevAmount.Int)
+		if inc.Sign() <= 0 {
+			c.fail("amount is not increased: %s -> %s", prev.Amount, m.Amount)
+		}
+
+		if _, ok := increments[m.IotId]; !ok {
+			increments[m.IotId] = big.NewInt(0)
+		}
+		increments[m.IotId].Add(increments[m.IotId], inc)
+	}
+
+	var minted = make(map[int64]*big.Int)
+	for _, m := range rp.Minted {
+		if _, ok := minted[m.IotId]; !ok {
+			minted[m.IotId] = big.NewInt(0)
+		}
+		minted[m.IotId].Add(minted[m.IotId], big.NewInt(m.Carbon))
+	}
+
+	for _, iot := range rp.Iots {
+		var inc, carbon = increments[iot.ID], minted[iot.ID]
+		if inc == nil && carbon == nil && len(unknown[iot.ID]) == 0 {
+			continue
+		}
+		if inc == nil {
+			inc = big.NewInt(0)
+		}
+		if carbon == nil {
+			carbon = big.NewInt(0)
+		}
+
+		var c = &Check{Kind: CheckMinted, Id: i64(iot.ID), IotId: iot.ID, Status: CheckPass}
+		if reasons := unknown[iot.ID]; len(reasons) > 0 {
+			c.skip("minted %s can not be recomputed: %s", carbon, strings.Join(reasons, ", "))
+		} else if inc.Cmp(carbon) != 0 {
+			c.fail("minted %s is not equal to increments of mint signatures %s", carbon, inc)
+		}
+		checks = append(checks, c)
+	}
+	return checks
+}
+
+func verifyMintSign(c *Check, m *models.MintSign, iots map[int64]*models.IOTDevice,
+	domains map[int64]*models.SignDomain,
+) {
+	var iot, ok = iots[m.IotId]
+	if !ok {
+		c.fail("iot %d is not registered", m.IotId)
+	} else if !strings.EqualFold(string(iot.Address), m.Iot) {
+		c.fail("iot address %s is not registered address %s", m.Iot, iot.Address)
+	}
+
+	domain, ok := domains[m.DomainId]
+	if !ok {
+		c.fail("sign domain %d is not in export", m.DomainId)
+		return
+	}
+
+	minter, err := models.NewMinter(domain.TypedDomain())
+	if nil != err {
+		c.fail("invalid sign domain %d: %s", domain.ID, err)
+		return
+	}
+
+	err = m.Verify(minter)
+	if nil != err {
+		c.fail("%s (domain %s)", err, domain.Label)
+	}
+}
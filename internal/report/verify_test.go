@@ -0,0 +1,183 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/iott-cloud/internal/models"
+	"github.com/Dcarbon/iott-cloud/internal/signer"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+const (
+	testIotKey    = "0x0123456789012345678901234567890123456789012345678901234567890001"
+	testSensorKey = "0x0123456789012345678901234567890123456789012345678901234567890002"
+)
+
+func newSignedReport(t *testing.T) *Report {
+	iotSigner, err := signer.NewLocalSigner(testIotKey)
+	if nil != err {
+		t.Fatalf("create iot signer: %s", err)
+	}
+	sensorSigner, err := signer.NewLocalSigner(testSensorKey)
+	if nil != err {
+		t.Fatalf("create sensor signer: %s", err)
+	}
+
+	var sensorAddr = sensorSigner.Address()
+	var domain = &models.SignDomain{
+		ID: 1, Label: "local", Name: "CARBON", Version: "1", ChainId: 1337,
+		VerifyingContract: "0x7bddcb9699a3823b8b27158bebabde6431152a85",
+	}
+	var rp = &Report{
+		Project: &models.Project{ID: 1},
+		From:    time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:      time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		Domains: []*models.SignDomain{domain},
+		Iots:    []*models.IOTDevice{{ID: 10, Address: iotSigner.Address(), DomainId: 1}},
+		Sensors: []*models.Sensor{
+			{ID: 100, IotID: 10, Type: dmodels.SensorTypePower},
+			{ID: 101, IotID: 10, Type: dmodels.SensorTypePower, Address: &sensorAddr},
+		},
+	}
+
+	for i, s := range []signer.Signer{iotSigner, sensorSigner} {
+		raw, err := json.Marshal(&models.SMExtract{
+			From:      1704067200 + int64(i)*60,
+			To:        1704067260 + int64(i)*60,
+			Indicator: &dmodels.AllMetric{DefaultMetric: dmodels.DefaultMetric{Val: 10.1}},
+			Address:   s.Address(),
+		})
+		if nil != err {
+			t.Fatalf("marshal metric: %s", err)
+		}
+		signed, err := signer.SignText(s, raw)
+		if nil != err {
+			t.Fatalf("sign metric: %s", err)
+		}
+		rp.Metrics = append(rp.Metrics, &models.SmSignature{
+			ID:        "m" + i64(int64(i)),
+			IotID:     10,
+			SensorID:  rp.Sensors[i].ID,
+			IsIotSign: true,
+			Data:      hexutil.Encode(raw),
+			Signed:    hexutil.Encode(signed),
+		})
+	}
+
+	for nonce, amount := range []string{"0x64", "0xc8", "0x12c"} {
+		var mint = &models.MintSign{
+			ID:       int64(nonce + 1),
+			IotId:    10,
+			Iot:      string(iotSigner.Address()),
+			Nonce:    int64(nonce + 1),
+			Amount:   amount,
+			DomainId: 1,
+		}
+		_, err = signer.SignMint(iotSigner, domain.TypedDomain(), mint)
+		if nil != err {
+			t.Fatalf("sign mint: %s", err)
+		}
+		if nonce == 0 {
+			rp.MintBase = append(rp.MintBase, mint)
+		} else {
+			rp.MintSigns = append(rp.MintSigns, mint)
+		}
+	}
+
+	rp.Minted = []*models.Minted{
+		{ID: "a", IotId: 10, Carbon: 60},
+		{ID: "b", IotId: 10, Carbon: 40},
+		{ID: "c", IotId: 10, Carbon: 100},
+	}
+	rp.Summarize()
+	return rp
+}
+
+func findCheck(v *Verification, kind CheckKind, id string) *Check {
+	for _, c := range v.Checks {
+		if c.Kind == kind && c.Id == id {
+			return c
+		}
+	}
+	return nil
+}
+
+func TestVerify(t *testing.T) {
+	var v = Verify(newSignedReport(t))
+	if !v.OK() || v.Passed != 5 || v.Skipped != 0 {
+		for _, c := range v.Checks {
+			t.Logf("%s %s %s %v", c.Status, c.Kind, c.Id, c.Reasons)
+		}
+		t.Fatalf("verification must pass: %d passed, %d failed", v.Passed, v.Failed)
+	}
+}
+
+func TestVerifyExported(t *testing.T) {
+	for _, format := range []Format{FormatJSON, FormatCSV} {
+		var buf = &bytes.Buffer{}
+		var err = Write(buf, newSignedReport(t), format)
+		if nil != err {
+			t.Fatalf("write %s: %s", format, err)
+		}
+
+		rp, err := Read(buf.Bytes())
+		if nil != err {
+			t.Fatalf("read %s: %s", format, err)
+		}
+
+		var v = Verify(rp)
+		if !v.OK() || v.Passed != 5 {
+			t.Fatalf("verification of %s export must pass: %+v", format, v)
+		}
+	}
+}
+
+func TestVerifyAltered(t *testing.T) {
+	var rp = newSignedReport(t)
+	rp.Metrics[0].Data = "0x7b7d"
+	rp.Metrics = append(rp.Metrics, &models.SmSignature{ID: "m9", IotID: 10, SensorID: 999})
+	rp.Minted = rp.Minted[1:]
+
+	var v = Verify(rp)
+	if v.OK() || v.Failed != 3 {
+		t.Fatalf("expected 3 failed checks: %+v", v)
+	}
+	for _, id := range []string{"m0", "m9"} {
+		if c := findCheck(v, CheckMetric, id); c == nil || c.Status != CheckFail || len(c.Reasons) == 0 {
+			t.Fatalf("metric %s must be failed: %+v", id, c)
+		}
+	}
+	if c := findCheck(v, CheckMinted, "10"); c == nil || c.Status != CheckFail {
+		t.Fatalf("minted of iot must be failed: %+v", c)
+	}
+}
+
+func TestVerifyNonce(t *testing.T) {
+	// Base of increment is not exported
+	var rp = newSignedReport(t)
+	rp.MintBase = nil
+
+	var v = Verify(rp)
+	if c := findCheck(v, CheckMintSign, "2"); c == nil || c.Status != CheckSkip {
+		t.Fatalf("increment of first nonce must be skipped: %+v", c)
+	}
+	if c := findCheck(v, CheckMinted, "10"); c == nil || c.Status != CheckSkip {
+		t.Fatalf("minted must be skipped: %+v", c)
+	}
+	if !v.OK() {
+		t.Fatalf("skipped checks are not failed: %+v", v)
+	}
+
+	// Nonce 2 is missing
+	rp = newSignedReport(t)
+	rp.MintSigns = rp.MintSigns[1:]
+
+	v = Verify(rp)
+	if c := findCheck(v, CheckMintSign, "3"); c == nil || c.Status != CheckFail {
+		t.Fatalf("nonce gap must be failed: %+v", c)
+	}
+}
@@ -0,0 +1,227 @@
+package report
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/Dcarbon/iott-cloud/internal/models"
+)
+
+var ErrInvalidFormat = errors.New("invalid report format (json, csv, pdf)")
+
+func Write(w io.Writer, rp *Report, format Format) error {
+	switch format {
+	case FormatJSON:
+		return WriteJSON(w, rp)
+	case FormatCSV:
+		return WriteCSV(w, rp)
+	case FormatPDF:
+		return WritePDF(w, rp)
+	}
+	return ErrInvalidFormat
+}
+
+func WriteJSON(w io.Writer, rp *Report) error {
+	var enc = json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(rp)
+}
+
+// Zip of summary.csv, iots.csv, sensors.csv, metrics.csv, mint_signs.csv,
+// mint_base.csv, minted.csv, domains.csv
+func WriteCSV(w io.Writer, rp *Report) error {
+	var zw = zip.NewWriter(w)
+
+	var files = []struct {
+		name   string
+		header []string
+		rows   func(add func(...string))
+	}{
+		{
+			name:   "summary.csv",
+			header: []string{"iot_id", "address", "metrics", "mint_signs", "carbon"},
+			rows: func(add func(...string)) {
+				for _, s := range rp.Summary.ByIot {
+					add(i64(s.IotId), s.Address, strconv.Itoa(s.Metrics), strconv.Itoa(s.MintSigns), i64(s.Carbon))
+				}
+				add("", "total", strconv.Itoa(rp.Summary.Metrics), strconv.Itoa(rp.Summary.MintSigns), i64(rp.Summary.Carbon))
+			},
+		},
+		{
+			name:   "iots.csv",
+			header: []string{"id", "address", "type", "status", "domain_id", "oracle", "lng", "lat"},
+			rows: func(add func(...string)) {
+				for _, iot := range rp.Iots {
+					add(
+						i64(iot.ID), string(iot.Address), strconv.Itoa(int(iot.Type)),
+						strconv.Itoa(int(iot.Status)), i64(iot.DomainId), strconv.FormatBool(iot.Oracle),
+						f64(iot.Position.Lng), f64(iot.Position.Lat),
+					)
+				}
+			},
+		},
+		{
+			name:   "sensors.csv",
+			header: []string{"id", "iot_id", "address", "type", "status", "created_at"},
+			rows: func(add func(...string)) {
+				for _, s := range rp.Sensors {
+					var addr = ""
+					if nil != s.Address {
+						addr = string(*s.Address)
+					}
+					add(
+						i64(s.ID), i64(s.IotID), addr, strconv.Itoa(int(s.Type)),
+						strconv.Itoa(int(s.Status)), ts(s.CreatedAt),
+					)
+				}
+			},
+		},
+		{
+			name:   "metrics.csv",
+			header: []string{"id", "iot_id", "sensor_id", "is_iot_sign", "created_at", "data", "signed"},
+			rows: func(add func(...string)) {
+				for _, m := range rp.Metrics {
+					add(
+						m.ID, i64(m.IotID), i64(m.SensorID), strconv.FormatBool(m.IsIotSign),
+						ts(m.CreatedAt), m.Data, m.Signed,
+					)
+				}
+			},
+		},
+		{
+			name:   "mint_signs.csv",
+			header: mintSignHeader,
+			rows: func(add func(...string)) {
+				for _, m := range rp.MintSigns {
+					add(mintSignRow(m)...)
+				}
+			},
+		},
+		{
+			name:   "mint_base.csv",
+			header: mintSignHeader,
+			rows: func(add func(...string)) {
+				for _, m := range rp.MintBase {
+					add(mintSignRow(m)...)
+				}
+			},
+		},
+		{
+			name:   "minted.csv",
+			header: []string{"id", "iot_id", "carbon", "created_at"},
+			rows: func(add func(...string)) {
+				for _, m := range rp.Minted {
+					add(m.ID, i64(m.IotId), i64(m.Carbon), ts(m.CreatedAt))
+				}
+			},
+		},
+		{
+			name:   "domains.csv",
+			header: []string{"id", "label", "name", "version", "chain_id", "verifying_contract"},
+			rows: func(add func(...string)) {
+				for _, d := range rp.Domains {
+					add(i64(d.ID), d.Label, d.Name, d.Version, i64(d.ChainId), d.VerifyingContract)
+				}
+			},
+		},
+	}
+
+	for _, file := range files {
+		fw, err := zw.Create(file.name)
+		if nil != err {
+			return err
+		}
+
+		var cw = csv.NewWriter(fw)
+		err = cw.Write(file.header)
+		if nil != err {
+			return err
+		}
+
+		file.rows(func(cols ...string) {
+			if nil == err {
+				err = cw.Write(cols)
+			}
+		})
+		if nil != err {
+			return err
+		}
+
+		cw.Flush()
+		if err = cw.Error(); nil != err {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// Summary of report (raw signatures are in json/csv)
+func WritePDF(w io.Writer, rp *Report) error {
+	var doc = newPdfDoc()
+	doc.Title(fmt.Sprintf("Audit report - project %d", rp.Project.ID))
+	doc.Line("Owner: %s", rp.Project.Owner)
+	doc.Line("Location: %s", rp.Project.LocationName)
+	doc.Line("Period: %s - %s", ts(rp.From), ts(rp.To))
+	doc.Line("Generated at: %s", ts(rp.GeneratedAt))
+	doc.Line("")
+
+	doc.Title("Summary")
+	doc.Line("IoT devices: %d", rp.Summary.Iots)
+	doc.Line("Sensors: %d", rp.Summary.Sensors)
+	doc.Line("Signed metrics: %d", rp.Summary.Metrics)
+	doc.Line("Mint signatures: %d", rp.Summary.MintSigns)
+	doc.Line("Minted carbon: %d", rp.Summary.Carbon)
+	doc.Line("")
+
+	doc.Title("By device")
+	doc.Line("%-8s %-44s %10s %8s %14s", "IoT", "Address", "Metrics", "Mints", "Carbon")
+	for _, s := range rp.Summary.ByIot {
+		doc.Line("%-8d %-44s %10d %8d %14d", s.IotId, s.Address, s.Metrics, s.MintSigns, s.Carbon)
+	}
+	doc.Line("")
+
+	doc.Title("Mint signatures")
+	doc.Line("%-8s %-8s %-20s %-6s %s", "IoT", "Nonce", "Amount", "State", "Tx")
+	for _, m := range rp.MintSigns {
+		doc.Line("%-8d %-8d %-20s %-6d %s", m.IotId, m.Nonce, m.Amount, m.State, m.TxHash)
+	}
+	doc.Line("")
+
+	doc.Title("Sign domains")
+	for _, d := range rp.Domains {
+		doc.Line("%d %s: %s v%s chain %d %s", d.ID, d.Label, d.Name, d.Version, d.ChainId, d.VerifyingContract)
+	}
+
+	_, err := doc.WriteTo(w)
+	return err
+}
+
+var mintSignHeader = []string{
+	"id", "iot_id", "iot", "domain_id", "nonce", "amount", "r", "s", "v",
+	"signer", "state", "tx_hash", "created_at",
+}
+
+func mintSignRow(m *models.MintSign) []string {
+	return []string{
+		i64(m.ID), i64(m.IotId), m.Iot, i64(m.DomainId), i64(m.Nonce), m.Amount,
+		m.R, m.S, m.V, m.Signer, strconv.Itoa(int(m.State)), m.TxHash, ts(m.CreatedAt),
+	}
+}
+
+func i64(v int64) string {
+	return strconv.FormatInt(v, 10)
+}
+
+func f64(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func ts(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
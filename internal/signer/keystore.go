@@ -0,0 +1,273 @@
+package signer
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/uuid"
+)
+
+const retiredDir = "retired"
+
+var ErrKeyNotFound = errors.New("key is not found in keystore")
+
+type KeyInfo struct {
+	Address dmodels.EthAddress `json:"address"`
+	Id      string             `json:"id"`
+	File    string             `json:"file"`
+}
+
+// Directory of go-ethereum V3 encrypted json keys (file name:
+// UTC--<time>--<address>, newest key is active key unless address is pinned)
+type Keystore struct {
+	dir     string
+	scryptN int
+	scryptP int
+}
+
+func NewKeystore(dir string) (*Keystore, error) {
+	if dir == "" {
+		return nil, errors.New("keystore dir is empty")
+	}
+
+	var err = os.MkdirAll(dir, 0700)
+	if nil != err {
+		return nil, err
+	}
+
+	var ks = &Keystore{
+		dir:     dir,
+		scryptN: keystore.StandardScryptN,
+		scryptP: keystore.StandardScryptP,
+	}
+	return ks, nil
+}
+
+// Use light scrypt params (fast, only for test)
+func (ks *Keystore) SetLight() {
+	ks.scryptN = keystore.LightScryptN
+	ks.scryptP = keystore.LightScryptP
+}
+
+// Keys of keystore (oldest first)
+func (ks *Keystore) List() ([]*KeyInfo, error) {
+	entries, err := os.ReadDir(ks.dir)
+	if nil != err {
+		return nil, err
+	}
+
+	var keys = make([]*KeyInfo, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		raw, err := os.ReadFile(filepath.Join(ks.dir, entry.Name()))
+		if nil != err {
+			return nil, err
+		}
+
+		var info = &KeyInfo{}
+		if err = json.Unmarshal(raw, info); nil != err || info.Address == "" {
+			continue
+		}
+		info.Address = dmodels.EthAddress("0x" + strings.TrimPrefix(strings.ToLower(string(info.Address)), "0x"))
+		info.File = entry.Name()
+		keys = append(keys, info)
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return keys[i].File < keys[j].File })
+	return keys, nil
+}
+
+// Key of address (empty: newest key)
+func (ks *Keystore) Find(address dmodels.EthAddress) (*KeyInfo, error) {
+	keys, err := ks.List()
+	if nil != err {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, ErrKeyNotFound
+	}
+	if address == "" {
+		return keys[len(keys)-1], nil
+	}
+
+	for _, key := range keys {
+		if strings.EqualFold(string(key.Address), string(address)) {
+			return key, nil
+		}
+	}
+	return nil, ErrKeyNotFound
+}
+
+// Generate new key
+func (ks *Keystore) Create(passphrase []byte) (*KeyInfo, error) {
+	key, err := crypto.GenerateKey()
+	if nil != err {
+		return nil, err
+	}
+	defer zeroKey(key)
+
+	return ks.store(key, passphrase)
+}
+
+// Import private key (hex)
+func (ks *Keystore) Import(pk []byte, passphrase []byte) (*KeyInfo, error) {
+	var raw = make([]byte, hex.DecodedLen(len(pk)))
+	defer zeroBytes(raw)
+
+	n, err := hex.Decode(raw, trimHex(pk))
+	if nil != err {
+		return nil, err
+	}
+
+	key, err := crypto.ToECDSA(raw[:n])
+	if nil != err {
+		return nil, err
+	}
+	defer zeroKey(key)
+
+	return ks.store(key, passphrase)
+}
+
+// Decrypt key of address (empty: newest key)
+func (ks *Keystore) Unlock(address dmodels.EthAddress, passphrase []byte,
+) (*LocalSigner, error) {
+	info, err := ks.Find(address)
+	if nil != err {
+		return nil, err
+	}
+
+	raw, err := os.ReadFile(filepath.Join(ks.dir, info.File))
+	if nil != err {
+		return nil, err
+	}
+
+	key, err := keystore.DecryptKey(raw, string(passphrase))
+	if nil != err {
+		return nil, err
+	}
+	return newLocalSigner(key.PrivateKey), nil
+}
+
+// Create new key (active key), old keys are moved to retired dir if retire
+func (ks *Keystore) Rotate(passphrase []byte, retire bool) (*KeyInfo, []*KeyInfo, error) {
+	olds, err := ks.List()
+	if nil != err {
+		return nil, nil, err
+	}
+
+	info, err := ks.Create(passphrase)
+	if nil != err {
+		return nil, nil, err
+	}
+
+	if !retire {
+		return info, nil, nil
+	}
+
+	err = os.MkdirAll(filepath.Join(ks.dir, retiredDir), 0700)
+	if nil != err {
+		return nil, nil, err
+	}
+
+	for _, old := range olds {
+		err = os.Rename(
+			filepath.Join(ks.dir, old.File),
+			filepath.Join(ks.dir, retiredDir, old.File),
+		)
+		if nil != err {
+			return nil, nil, err
+		}
+	}
+	return info, olds, nil
+}
+
+func (ks *Keystore) store(key *ecdsa.PrivateKey, passphrase []byte,
+) (*KeyInfo, error) {
+	id, err := uuid.NewRandom()
+	if nil != err {
+		return nil, err
+	}
+
+	var addr = crypto.PubkeyToAddress(key.PublicKey)
+	raw, err := keystore.EncryptKey(
+		&keystore.Key{Id: id, Address: addr, PrivateKey: key},
+		string(passphrase), ks.scryptN, ks.scryptP,
+	)
+	if nil != err {
+		return nil, err
+	}
+
+	var info = &KeyInfo{
+		Address: dmodels.EthAddress(strings.ToLower(addr.Hex())),
+		Id:      id.String(),
+		File:    keyFileName(addr),
+	}
+
+	err = os.WriteFile(filepath.Join(ks.dir, info.File), raw, 0600)
+	if nil != err {
+		return nil, err
+	}
+	return info, nil
+}
+
+// Read passphrase from file (trailing new line is removed)
+func ReadPassphrase(path string) ([]byte, error) {
+	raw, err := os.ReadFile(path)
+	if nil != err {
+		return nil, err
+	}
+
+	var n = len(raw)
+	for n > 0 && (raw[n-1] == '\n' || raw[n-1] == '\r') {
+		n--
+	}
+	return raw[:n], nil
+}
+
+// Same naming as geth keystore
+func keyFileName(addr common.Address) string {
+	var ts = time.Now().UTC().Format("2006-01-02T15-04-05.000000000Z")
+	return fmt.Sprintf("UTC--%s--%s", ts, hex.EncodeToString(addr[:]))
+}
+
+func trimHex(s []byte) []byte {
+	for len(s) > 0 && (s[len(s)-1] == '\n' || s[len(s)-1] == '\r' || s[len(s)-1] == ' ') {
+		s = s[:len(s)-1]
+	}
+	if len(s) > 1 && s[0] == '0' && (s[1] == 'x' || s[1] == 'X') {
+		s = s[2:]
+	}
+	return s
+}
+
+func zeroBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+func zeroKey(key *ecdsa.PrivateKey) {
+	if key == nil || key.D == nil {
+		return
+	}
+	var words = key.D.Bits()
+	for i := range words {
+		words[i] = 0
+	}
+	key.D.SetInt64(0)
+}
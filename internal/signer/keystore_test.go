@@ -0,0 +1,107 @@
+package signer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newTestKeystore(t *testing.T) *Keystore {
+	ks, err := NewKeystore(t.TempDir())
+	if nil != err {
+		t.Fatalf("new keystore: %s", err)
+	}
+	ks.SetLight()
+	return ks
+}
+
+func TestKeystoreCreateUnlock(t *testing.T) {
+	var ks = newTestKeystore(t)
+
+	info, err := ks.Create([]byte("secret"))
+	if nil != err {
+		t.Fatalf("create key: %s", err)
+	}
+
+	_, err = ks.Unlock(info.Address, []byte("wrong"))
+	if nil == err {
+		t.Fatalf("unlock by wrong passphrase must be failed")
+	}
+
+	ls, err := ks.Unlock("", []byte("secret"))
+	if nil != err {
+		t.Fatalf("unlock: %s", err)
+	}
+	if ls.Address() != info.Address {
+		t.Fatalf("unlocked %s, expected %s", ls.Address(), info.Address)
+	}
+
+	ls.Close()
+	_, err = ls.SignHash(make([]byte, 32))
+	if err != ErrSignerClosed {
+		t.Fatalf("closed signer must not sign, got %v", err)
+	}
+}
+
+func TestKeystoreImport(t *testing.T) {
+	var ks = newTestKeystore(t)
+	local, _ := NewLocalSigner(testKey)
+
+	info, err := ks.Import([]byte("0x"+testKey+"\n"), []byte("secret"))
+	if nil != err {
+		t.Fatalf("import key: %s", err)
+	}
+	if info.Address != local.Address() {
+		t.Fatalf("imported %s, expected %s", info.Address, local.Address())
+	}
+
+	keys, err := ks.List()
+	if nil != err || len(keys) != 1 {
+		t.Fatalf("list keys: %v %v", keys, err)
+	}
+}
+
+func TestKeystoreRotate(t *testing.T) {
+	var ks = newTestKeystore(t)
+
+	old, _ := ks.Create([]byte("secret"))
+	info, retired, err := ks.Rotate([]byte("secret"), true)
+	if nil != err {
+		t.Fatalf("rotate: %s", err)
+	}
+	if len(retired) != 1 || retired[0].Address != old.Address {
+		t.Fatalf("old key must be retired: %v", retired)
+	}
+
+	active, err := ks.Find("")
+	if nil != err || active.Address != info.Address {
+		t.Fatalf("new key must be active: %v %v", active, err)
+	}
+
+	_, err = os.Stat(filepath.Join(ks.dir, retiredDir, old.File))
+	if nil != err {
+		t.Fatalf("retired key file: %s", err)
+	}
+}
+
+func TestKeystoreFromEnv(t *testing.T) {
+	var ks = newTestKeystore(t)
+	info, _ := ks.Create([]byte("secret"))
+
+	var passfile = filepath.Join(t.TempDir(), "pass")
+	os.WriteFile(passfile, []byte("secret\n"), 0600)
+
+	t.Setenv("TEST_SIGNER", TypeKeystore)
+	t.Setenv("TEST_KEYSTORE", ks.dir)
+	t.Setenv("TEST_PASSPHRASE_FILE", passfile)
+
+	s, err := FromEnv("TEST")
+	if nil != err {
+		t.Fatalf("signer from env: %s", err)
+	}
+	defer s.Close()
+
+	if s.Address() != info.Address {
+		t.Fatalf("signer %s, expected %s", s.Address(), info.Address)
+	}
+}
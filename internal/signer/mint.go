@@ -0,0 +1,77 @@
+package signer
+
+import (
+	"math/big"
+
+	"github.com/Dcarbon/go-shared/libs/esign"
+	"github.com/Dcarbon/iott-cloud/internal/models"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+var mintTypes = apitypes.Types{
+	"EIP712Domain": {
+		{Name: "name", Type: "string"},
+		{Name: "version", Type: "string"},
+		{Name: "chainId", Type: "uint256"},
+		{Name: "verifyingContract", Type: "address"},
+	},
+	"Mint": {
+		{Name: "iot", Type: "address"},
+		{Name: "amount", Type: "uint256"},
+		{Name: "nonce", Type: "uint256"},
+	},
+}
+
+// EIP712 digest of Mint(iot, amount, nonce) in domain (same typed data as
+// models.NewMinter). amount: hex
+func MintHash(domain *esign.TypedDataDomain, iot string, amount string, nonce int64,
+) ([]byte, error) {
+	var typed = apitypes.TypedData{
+		Types:       mintTypes,
+		PrimaryType: "Mint",
+		Domain: apitypes.TypedDataDomain{
+			Name:              domain.Name,
+			Version:           domain.Version,
+			ChainId:           math.NewHexOrDecimal256(domain.ChainId),
+			VerifyingContract: domain.VerifyingContract,
+		},
+		Message: apitypes.TypedDataMessage{
+			"iot":    iot,
+			"amount": amount,
+			"nonce":  big.NewInt(nonce).String(),
+		},
+	}
+
+	hash, _, err := apitypes.TypedDataAndHash(typed)
+	if nil != err {
+		return nil, err
+	}
+	return hash, nil
+}
+
+// Sign mint (iot, amount, nonce) in domain, set R, S, V of mint
+func SignMint(s Signer, domain *esign.TypedDataDomain, mint *models.MintSign,
+) ([]byte, error) {
+	hash, err := MintHash(domain, mint.Iot, mint.Amount, mint.Nonce)
+	if nil != err {
+		return nil, err
+	}
+
+	signed, err := s.SignHash(hash)
+	if nil != err {
+		return nil, err
+	}
+
+	mint.R = hexutil.Encode(signed[:32])
+	mint.S = hexutil.Encode(signed[32:64])
+	mint.V = hexutil.Encode(signed[64:])
+	return hash, nil
+}
+
+// Personal sign (EIP-191) of data
+func SignText(s Signer, data []byte) ([]byte, error) {
+	return s.SignHash(accounts.TextHash(data))
+}
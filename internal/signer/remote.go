@@ -0,0 +1,105 @@
+package signer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Sign by key management service:
+//
+//	POST <url>/sign {"keyId": "...", "hash": "0x..."} -> {"signature": "0x..."}
+//
+// Signature is recovered and checked with address of key before it is used
+type RemoteSigner struct {
+	url     string
+	keyId   string
+	address dmodels.EthAddress
+	client  *http.Client
+}
+
+type remoteSignReq struct {
+	KeyId string `json:"keyId"`
+	Hash  string `json:"hash"`
+}
+
+type remoteSignRes struct {
+	Signature string `json:"signature"`
+}
+
+func NewRemoteSigner(url, keyId string, address dmodels.EthAddress,
+) (*RemoteSigner, error) {
+	if url == "" {
+		return nil, errors.New("url of remote signer is empty")
+	}
+
+	if !common.IsHexAddress(string(address)) {
+		return nil, errors.New("address of remote signer is invalid")
+	}
+
+	var rs = &RemoteSigner{
+		url:     strings.TrimSuffix(url, "/"),
+		keyId:   keyId,
+		address: dmodels.EthAddress(strings.ToLower(string(address))),
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+	return rs, nil
+}
+
+func (rs *RemoteSigner) Address() dmodels.EthAddress {
+	return rs.address
+}
+
+// Key is held by key management service
+func (rs *RemoteSigner) Close() {}
+
+func (rs *RemoteSigner) SignHash(hash []byte) ([]byte, error) {
+	raw, err := json.Marshal(&remoteSignReq{
+		KeyId: rs.keyId,
+		Hash:  hexutil.Encode(hash),
+	})
+	if nil != err {
+		return nil, err
+	}
+
+	res, err := rs.client.Post(rs.url+"/sign", "application/json", bytes.NewReader(raw))
+	if nil != err {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("remote signer response status %d", res.StatusCode)
+	}
+
+	var body = &remoteSignRes{}
+	err = json.NewDecoder(res.Body).Decode(body)
+	if nil != err {
+		return nil, err
+	}
+
+	signed, err := hexutil.Decode(body.Signature)
+	if nil != err {
+		return nil, err
+	}
+	if len(signed) == 65 && signed[64] < 27 {
+		signed[64] += 27
+	}
+
+	signer, err := Recover(hash, signed)
+	if nil != err {
+		return nil, err
+	}
+	if signer != rs.address {
+		return nil, fmt.Errorf("remote signer signed by %s, expected %s", signer, rs.address)
+	}
+	return signed, nil
+}
@@ -0,0 +1,177 @@
+// Signer of keys was held by server (oracle mint key, ...). Private key never
+// leaves signer: local signer keeps it in memory (decrypted from keystore),
+// remote signer asks key management service to sign digest.
+package signer
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/go-shared/libs/utils"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	TypeKeystore = "keystore" // V3 encrypted json keystore
+	TypeLocal    = "local"    // Plain key file (hex)
+	TypeRemote   = "remote"   // Key management service
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid signature length")
+	ErrSignerClosed     = errors.New("signer was closed")
+)
+
+type Signer interface {
+	Address() dmodels.EthAddress
+
+	// Sign 32 bytes digest. Return 65 bytes [R || S || V], V is 27 or 28
+	SignHash(hash []byte) ([]byte, error)
+
+	// Zero key in memory. Signer can not be used after closed
+	Close()
+}
+
+type LocalSigner struct {
+	mut     sync.RWMutex // Close waits for signing in progress
+	key     *ecdsa.PrivateKey
+	address dmodels.EthAddress
+}
+
+// pk: private key (hex)
+func NewLocalSigner(pk string) (*LocalSigner, error) {
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(strings.TrimSpace(pk), "0x"))
+	if nil != err {
+		return nil, err
+	}
+	return newLocalSigner(key), nil
+}
+
+// Load private key (hex) from file
+func LoadLocalSigner(path string) (*LocalSigner, error) {
+	raw, err := os.ReadFile(path)
+	if nil != err {
+		return nil, err
+	}
+	defer zeroBytes(raw)
+
+	var pk = make([]byte, hex.DecodedLen(len(raw)))
+	defer zeroBytes(pk)
+
+	n, err := hex.Decode(pk, trimHex(raw))
+	if nil != err {
+		return nil, err
+	}
+
+	key, err := crypto.ToECDSA(pk[:n])
+	if nil != err {
+		return nil, err
+	}
+	return newLocalSigner(key), nil
+}
+
+func newLocalSigner(key *ecdsa.PrivateKey) *LocalSigner {
+	var addr = crypto.PubkeyToAddress(key.PublicKey)
+	return &LocalSigner{
+		key:     key,
+		address: dmodels.EthAddress(strings.ToLower(addr.Hex())),
+	}
+}
+
+func (ls *LocalSigner) Address() dmodels.EthAddress {
+	return ls.address
+}
+
+func (ls *LocalSigner) SignHash(hash []byte) ([]byte, error) {
+	ls.mut.RLock()
+	defer ls.mut.RUnlock()
+
+	if ls.key == nil {
+		return nil, ErrSignerClosed
+	}
+
+	signed, err := crypto.Sign(hash, ls.key)
+	if nil != err {
+		return nil, err
+	}
+	signed[64] += 27
+	return signed, nil
+}
+
+func (ls *LocalSigner) Close() {
+	ls.mut.Lock()
+	defer ls.mut.Unlock()
+
+	zeroKey(ls.key)
+	ls.key = nil
+}
+
+// Address has signed hash (signature: [R || S || V])
+func Recover(hash []byte, signed []byte) (dmodels.EthAddress, error) {
+	if len(signed) != 65 {
+		return "", ErrInvalidSignature
+	}
+
+	var sig = make([]byte, 65)
+	copy(sig, signed)
+	if sig[64] >= 27 {
+		sig[64] -= 27
+	}
+
+	pub, err := crypto.SigToPub(hash, sig)
+	if nil != err {
+		return "", err
+	}
+	return dmodels.EthAddress(strings.ToLower(crypto.PubkeyToAddress(*pub).Hex())), nil
+}
+
+// Create signer from env (prefix: ORACLE -> ORACLE_SIGNER, ORACLE_KEY_FILE...).
+// Return nil if <prefix>_SIGNER is not set
+//
+//	<prefix>_SIGNER          : keystore, local, remote
+//	<prefix>_KEYSTORE        : (keystore) dir of encrypted keys
+//	<prefix>_ADDRESS         : (keystore) address of key (default: newest key)
+//	                           (remote) address of key
+//	<prefix>_PASSPHRASE_FILE : (keystore) file of passphrase
+//	<prefix>_PASSPHRASE      : (keystore) passphrase if file is not set
+//	<prefix>_KEY_FILE        : (local) file of private key
+//	<prefix>_KMS_URL         : (remote) url of key management service
+//	<prefix>_KMS_KEY_ID      : (remote) key id
+func FromEnv(prefix string) (Signer, error) {
+	var tp = utils.StringEnv(prefix+"_SIGNER", "")
+	switch tp {
+	case "":
+		return nil, nil
+	case TypeKeystore:
+		ks, err := NewKeystore(utils.StringEnv(prefix+"_KEYSTORE", ""))
+		if nil != err {
+			return nil, err
+		}
+
+		var passphrase = []byte(utils.StringEnv(prefix+"_PASSPHRASE", ""))
+		if file := utils.StringEnv(prefix+"_PASSPHRASE_FILE", ""); file != "" {
+			passphrase, err = ReadPassphrase(file)
+			if nil != err {
+				return nil, err
+			}
+		}
+		defer zeroBytes(passphrase)
+
+		return ks.Unlock(dmodels.EthAddress(utils.StringEnv(prefix+"_ADDRESS", "")), passphrase)
+	case TypeLocal:
+		return LoadLocalSigner(utils.StringEnv(prefix+"_KEY_FILE", ""))
+	case TypeRemote:
+		return NewRemoteSigner(
+			utils.StringEnv(prefix+"_KMS_URL", ""),
+			utils.StringEnv(prefix+"_KMS_KEY_ID", ""),
+			dmodels.EthAddress(utils.StringEnv(prefix+"_ADDRESS", "")),
+		)
+	}
+	return nil, fmt.Errorf("invalid signer type %s (%s_SIGNER)", tp, prefix)
+}
@@ -0,0 +1,104 @@
+package signer
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Dcarbon/go-shared/libs/esign"
+	"github.com/Dcarbon/iott-cloud/internal/models"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+const testKey = "0123456789012345678901234567890123456789012345678901234567890123"
+
+var testDomain = &esign.TypedDataDomain{
+	Name:              "CARBON",
+	Version:           "1",
+	ChainId:           1337,
+	VerifyingContract: "0x7BDDCb9699a3823b8B27158BEBaBDE6431152a85",
+}
+
+func TestLocalSignerSignHash(t *testing.T) {
+	ls, err := NewLocalSigner(testKey)
+	if nil != err {
+		t.Fatalf("new local signer: %s", err)
+	}
+
+	hash, err := MintHash(testDomain, "0x19adf96848504a06383b47aaa9bbbc6638e81afd", "0x100", 1)
+	if nil != err {
+		t.Fatalf("mint hash: %s", err)
+	}
+
+	signed, err := ls.SignHash(hash)
+	if nil != err {
+		t.Fatalf("sign hash: %s", err)
+	}
+	if signed[64] != 27 && signed[64] != 28 {
+		t.Fatalf("v must be 27 or 28, got %d", signed[64])
+	}
+
+	addr, err := Recover(hash, signed)
+	if nil != err {
+		t.Fatalf("recover: %s", err)
+	}
+	if addr != ls.Address() {
+		t.Fatalf("recovered %s, expected %s", addr, ls.Address())
+	}
+}
+
+func TestMintHash(t *testing.T) {
+	var iot = "0x19adf96848504a06383b47aaa9bbbc6638e81afd"
+	h1, _ := MintHash(testDomain, iot, "0x100", 1)
+	h2, _ := MintHash(testDomain, iot, "0x100", 2)
+	if hexutil.Encode(h1) == hexutil.Encode(h2) {
+		t.Fatalf("hash of different nonce must be different")
+	}
+
+	var other = *testDomain
+	other.ChainId = 97
+	h3, _ := MintHash(&other, iot, "0x100", 1)
+	if hexutil.Encode(h1) == hexutil.Encode(h3) {
+		t.Fatalf("hash of different domain must be different")
+	}
+}
+
+func TestRemoteSigner(t *testing.T) {
+	ls, _ := NewLocalSigner(testKey)
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req = &remoteSignReq{}
+		json.NewDecoder(r.Body).Decode(req)
+
+		hash, _ := hexutil.Decode(req.Hash)
+		signed, _ := ls.SignHash(hash)
+		json.NewEncoder(w).Encode(&remoteSignRes{Signature: hexutil.Encode(signed)})
+	}))
+	defer srv.Close()
+
+	rs, err := NewRemoteSigner(srv.URL, "oracle", ls.Address())
+	if nil != err {
+		t.Fatalf("new remote signer: %s", err)
+	}
+
+	var mint = &models.MintSign{
+		Iot:    "0x19adf96848504a06383b47aaa9bbbc6638e81afd",
+		Amount: "0x100",
+		Nonce:  1,
+	}
+	_, err = SignMint(rs, testDomain, mint)
+	if nil != err {
+		t.Fatalf("sign mint: %s", err)
+	}
+	if mint.R == "" || mint.S == "" || mint.V == "" {
+		t.Fatalf("signature of mint is empty")
+	}
+
+	// Key of service is not key of signer
+	other, _ := NewLocalSigner("1123456789012345678901234567890123456789012345678901234567890123")
+	rs, _ = NewRemoteSigner(srv.URL, "oracle", other.Address())
+	_, err = rs.SignHash(make([]byte, 32))
+	if nil == err {
+		t.Fatalf("signature of other key must be rejected")
+	}
+}
@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Files are saved in dir (public) or privateDir: projects/{projectId}/{name}
+type Local struct {
+	dir        string
+	privateDir string
+}
+
+func NewLocal(dir, privateDir string) (*Local, error) {
+	for _, d := range []string{dir, privateDir} {
+		var err = os.MkdirAll(d, 0755)
+		if nil != err {
+			return nil, err
+		}
+	}
+
+	var local = &Local{
+		dir:        dir,
+		privateDir: privateDir,
+	}
+	return local, nil
+}
+
+func (l *Local) PutProjectFile(projectId int64, name string, data []byte,
+) (string, error) {
+	return l.put(l.dir, LocalPrefix, projectId, name, data)
+}
+
+func (l *Local) PutPrivateFile(projectId int64, name string, data []byte,
+) (string, error) {
+	return l.put(l.privateDir, PrivatePrefix, projectId, name, data)
+}
+
+func (l *Local) Open(path string) (io.ReadCloser, error) {
+	var file, err = l.file(path)
+	if nil != err {
+		return nil, err
+	}
+	return os.Open(file)
+}
+
+// Remove file of path (was returned by Put...)
+func (l *Local) Delete(path string) error {
+	var file, err = l.file(path)
+	if nil != err {
+		return err
+	}
+
+	err = os.Remove(file)
+	if nil != err && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (l *Local) put(dir, prefix string, projectId int64, name string, data []byte,
+) (string, error) {
+	var rel = "projects/" + strconv.FormatInt(projectId, 10) + "/" + filepath.Base(name)
+	var file = filepath.Join(dir, filepath.FromSlash(rel))
+
+	var err = os.MkdirAll(filepath.Dir(file), 0755)
+	if nil != err {
+		return "", err
+	}
+
+	err = os.WriteFile(file, data, 0644)
+	if nil != err {
+		return "", err
+	}
+	return prefix + "/" + rel, nil
+}
+
+func (l *Local) file(path string) (string, error) {
+	if strings.Contains(path, "..") {
+		return "", ErrInvalidPath
+	}
+
+	for prefix, dir := range map[string]string{
+		LocalPrefix:   l.dir,
+		PrivatePrefix: l.privateDir,
+	} {
+		if !strings.HasPrefix(path, prefix+"/") {
+			continue
+		}
+
+		var rel = filepath.Clean("/" + strings.TrimPrefix(path, prefix+"/"))
+		if rel == "/" {
+			return "", ErrInvalidPath
+		}
+		return filepath.Join(dir, filepath.FromSlash(rel)), nil
+	}
+	return "", ErrInvalidPath
+}
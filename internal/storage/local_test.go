@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocal(t *testing.T) {
+	var dir = t.TempDir()
+	local, err := NewLocal(dir, filepath.Join(dir, "private"))
+	if nil != err {
+		t.Fatalf("new local: %s", err)
+	}
+
+	path, err := local.PutProjectFile(1, "../a.png", []byte("data"))
+	if nil != err {
+		t.Fatalf("put: %s", err)
+	}
+	if path != "/static/projects/1/a.png" {
+		t.Fatalf("invalid path: %s", path)
+	}
+
+	raw, err := os.ReadFile(filepath.Join(dir, "projects", "1", "a.png"))
+	if nil != err || string(raw) != "data" {
+		t.Fatalf("file is not saved: %v", err)
+	}
+
+	for _, invalid := range []string{"/other/a.png", "/static/../a.png", "/static/"} {
+		if err = local.Delete(invalid); err != ErrInvalidPath {
+			t.Fatalf("delete %s must be invalid: %v", invalid, err)
+		}
+	}
+
+	err = local.Delete(path)
+	if nil != err {
+		t.Fatalf("delete: %s", err)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "projects", "1", "a.png")); !os.IsNotExist(err) {
+		t.Fatalf("file is not deleted")
+	}
+
+	// Delete missing file is ok
+	if err = local.Delete(path); nil != err {
+		t.Fatalf("delete missing: %s", err)
+	}
+}
+
+func TestLocalPrivate(t *testing.T) {
+	var dir, privateDir = t.TempDir(), t.TempDir()
+	local, err := NewLocal(dir, privateDir)
+	if nil != err {
+		t.Fatalf("new local: %s", err)
+	}
+
+	path, err := local.PutPrivateFile(2, "doc.pdf", []byte("pdf"))
+	if nil != err {
+		t.Fatalf("put: %s", err)
+	}
+	if path != "/private/projects/2/doc.pdf" {
+		t.Fatalf("invalid path: %s", path)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "projects", "2", "doc.pdf")); !os.IsNotExist(err) {
+		t.Fatalf("private file must not be in public dir")
+	}
+
+	file, err := local.Open(path)
+	if nil != err {
+		t.Fatalf("open: %s", err)
+	}
+	defer file.Close()
+
+	raw, err := io.ReadAll(file)
+	if nil != err || string(raw) != "pdf" {
+		t.Fatalf("invalid content: %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Dcarbon/go-shared/libs/sclient"
+)
+
+// Storage service (file is uploaded from temp dir). Storage service has no
+// private area: private file is refused (ErrNotSupported)
+type Remote struct {
+	client  sclient.IStorage
+	tmpDir  string
+	baseUrl string // Files are served at baseUrl + path
+	http    *http.Client
+}
+
+func NewRemote(client sclient.IStorage, tmpDir, baseUrl string) *Remote {
+	return &Remote{
+		client:  client,
+		tmpDir:  tmpDir,
+		baseUrl: baseUrl,
+		http:    &http.Client{Timeout: 5 * time.Minute},
+	}
+}
+
+func (rm *Remote) PutProjectFile(projectId int64, name string, data []byte,
+) (string, error) {
+	var err = os.MkdirAll(rm.tmpDir, 0777)
+	if nil != err {
+		return "", err
+	}
+
+	var file = filepath.Join(rm.tmpDir, filepath.Base(name))
+	err = os.WriteFile(file, data, 0644)
+	if nil != err {
+		return "", err
+	}
+	defer os.Remove(file)
+
+	return rm.client.UploadToProject(file, projectId)
+}
+
+func (rm *Remote) PutPrivateFile(projectId int64, name string, data []byte,
+) (string, error) {
+	return "", ErrNotSupported
+}
+
+func (rm *Remote) Open(path string) (io.ReadCloser, error) {
+	res, err := rm.http.Get(rm.baseUrl + path)
+	if nil != err {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return nil, fmt.Errorf("read file %s of storage: status %d", path, res.StatusCode)
+	}
+	return res.Body, nil
+}
+
+// Storage service has no delete api
+func (rm *Remote) Delete(path string) error {
+	return ErrNotSupported
+}
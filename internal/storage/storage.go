@@ -0,0 +1,44 @@
+// Implementations of domain.IStorage: remote (storage service) and local
+// filesystem (public files are served by api at LocalPrefix, private files
+// are not served)
+package storage
+
+import (
+	"errors"
+
+	"github.com/Dcarbon/go-shared/libs/sclient"
+	"github.com/Dcarbon/iott-cloud/internal/domain"
+)
+
+const (
+	DriverRemote = "remote"
+	DriverLocal  = "local"
+)
+
+// Path prefix of local files
+const (
+	LocalPrefix   = "/static"
+	PrivatePrefix = "/private"
+)
+
+var (
+	ErrNotSupported = errors.New("storage doesn't support this operation")
+	ErrInvalidPath  = errors.New("invalid storage path")
+)
+
+// localDir: public files (local), temp files (remote). privateDir: private
+// files (local). baseUrl: files of storage service are read from
+func New(driver, host, token, localDir, privateDir, baseUrl string,
+) (domain.IStorage, error) {
+	switch driver {
+	case DriverLocal:
+		return NewLocal(localDir, privateDir)
+	case DriverRemote, "":
+		client, err := sclient.NewStorage(host, token)
+		if nil != err {
+			return nil, err
+		}
+		return NewRemote(client, localDir, baseUrl), nil
+	}
+	return nil, errors.New("unknown storage driver: " + driver)
+}